@@ -0,0 +1,258 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// ErrBatchFull is returned when adding more calls to a batch than a single
+	// extended transaction can carry.
+	ErrBatchFull = errors.New("too many calls in the batch")
+
+	// ErrBatchEmpty is returned when transacting a batch without any calls.
+	ErrBatchEmpty = errors.New("no calls in the batch")
+
+	// ErrBatchMismatch is returned when decoding the results of a batch from
+	// the receipt of a transaction other than the one it was sent as.
+	ErrBatchMismatch = errors.New("receipt does not match the batch")
+
+	// ErrBatchUnsupported is returned when transacting a batch of several calls
+	// on a chain not yet signing and executing one-to-many transfers.
+	ErrBatchUnsupported = errors.New("chain does not support batches of several calls yet")
+)
+
+// BatchTransactor is the backend a batch is estimated and sent through. The
+// head it reports picks the signer the batch is signed with.
+type BatchTransactor interface {
+	ContractTransactor
+
+	// HeaderByNumber returns the header of the given block, or of the latest
+	// block if number is nil.
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+// BatchCall is a contract call collected by a batch.
+type BatchCall struct {
+	Contract *BoundContract // Bound contract the call is addressed to
+	Method   string         // Name of the invoked contract method
+	Value    *big.Int       // Funds to transfer along with the call
+	Input    []byte         // Packed method input
+}
+
+// BatchResult is the outcome of one of the calls of a batch.
+type BatchResult struct {
+	*BatchCall
+
+	Status  uint64       // Receipt status of the call
+	GasUsed uint64       // Gas used by the call, its share of intrinsic gas included
+	Logs    []*types.Log // Logs emitted by the call
+}
+
+// Batch collects calls of generated contract bindings into a single extended
+// transaction: the first call is sent as the main call of the transaction, the
+// others as its one-to-many transfer legs, each executed on its own after it.
+// Chains only execute the legs from their one-to-many transfer fork on.
+type Batch struct {
+	transactor BatchTransactor     // Backend to estimate and send the transaction through
+	config     *params.ChainConfig // Chain the transaction is signed for
+
+	LockHeight uint64 // Lock height recorded in the extension, not enforced (only for more than one call)
+
+	calls []*BatchCall
+}
+
+// NewBatch creates an empty batch of contract calls, sent through the given
+// transactor and signed with the signer of the given chain at the next block.
+func NewBatch(transactor BatchTransactor, config *params.ChainConfig) *Batch {
+	return &Batch{
+		transactor: transactor,
+		config:     config,
+	}
+}
+
+// Add packs a call of the (paid) contract method with params as input values
+// and appends it to the batch.
+func (b *Batch) Add(contract *BoundContract, value *big.Int, method string, params ...interface{}) error {
+	input, err := contract.abi.Pack(method, params...)
+	if err != nil {
+		return err
+	}
+	return b.add(&BatchCall{Contract: contract, Method: method, Value: value, Input: input})
+}
+
+// AddTransfer appends a plain transfer of funds to the contract to the batch,
+// calling its default method if one is available.
+func (b *Batch) AddTransfer(contract *BoundContract, value *big.Int) error {
+	return b.add(&BatchCall{Contract: contract, Value: value})
+}
+
+// add appends a call to the batch, unless it is already full.
+func (b *Batch) add(call *BatchCall) error {
+	if uint64(len(b.calls)) >= params.TxCount {
+		return ErrBatchFull
+	}
+	if call.Value == nil {
+		call.Value = new(big.Int)
+	}
+	b.calls = append(b.calls, call)
+	return nil
+}
+
+// Calls returns the calls collected by the batch, in the order they execute.
+func (b *Batch) Calls() []*BatchCall {
+	return b.calls
+}
+
+// Transact signs the batch as a single extended transaction and schedules it
+// for execution. The value of opts is ignored in favour of those of the calls.
+// Without a gas limit, the estimates of the calls are summed, each of them
+// estimated on its own against the pending state. Batches of several calls
+// need the chain past both its PTC signer and one-to-many transfer forks.
+func (b *Batch) Transact(opts *TransactOpts) (*types.Transaction, error) {
+	if len(b.calls) == 0 {
+		return nil, ErrBatchEmpty
+	}
+	// Pick the signer of the next block, legs need both PTC forks
+	head, err := b.transactor.HeaderByNumber(ensureContext(opts.Context), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve chain head: %v", err)
+	}
+	next := new(big.Int).Add(head.Number, common.Big1)
+	if len(b.calls) > 1 && (!b.config.IsPTCSigner(next) || !b.config.IsExtraTo(next)) {
+		return nil, ErrBatchUnsupported
+	}
+	// Resolve the account nonce
+	var nonce uint64
+	if opts.Nonce == nil {
+		nonce, err = b.transactor.PendingNonceAt(ensureContext(opts.Context), opts.From)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve account nonce: %v", err)
+		}
+	} else {
+		nonce = opts.Nonce.Uint64()
+	}
+	// Figure out the gas allowance and gas price values
+	gasPrice := opts.GasPrice
+	if gasPrice == nil {
+		gasPrice, err = b.transactor.SuggestGasPrice(ensureContext(opts.Context))
+		if err != nil {
+			return nil, fmt.Errorf("failed to suggest gas price: %v", err)
+		}
+	}
+	gasLimit := opts.GasLimit
+	if gasLimit == 0 {
+		for _, call := range b.calls {
+			// Gas estimation cannot succeed without code for method invocations
+			if call.Input != nil {
+				if code, err := b.transactor.PendingCodeAt(ensureContext(opts.Context), call.Contract.address); err != nil {
+					return nil, err
+				} else if len(code) == 0 {
+					return nil, ErrNoCode
+				}
+			}
+			msg := ethereum.CallMsg{From: opts.From, To: &call.Contract.address, Value: call.Value, Data: call.Input}
+			gas, err := b.transactor.EstimateGas(ensureContext(opts.Context), msg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to estimate gas needed by %s: %v", call.Contract.address.Hex(), err)
+			}
+			gasLimit += gas
+		}
+	}
+	// Create the transaction, sign it and schedule it for execution
+	legs := make([]*types.ExtraTo_tr, 0, len(b.calls)-1)
+	for _, call := range b.calls[1:] {
+		to, input := call.Contract.address, hexutil.Bytes(call.Input)
+		legs = append(legs, &types.ExtraTo_tr{To_tr: &to, Value_tr: (*hexutil.Big)(call.Value), Input_tr: &input})
+	}
+	main := b.calls[0]
+	rawTx := types.NewTransactions(nonce, main.Contract.address, main.Value, gasLimit, gasPrice, main.Input, legs, b.LockHeight, 0)
+
+	if opts.Signer == nil {
+		return nil, errors.New("no signer to authorize the transaction with")
+	}
+	signedTx, err := opts.Signer(types.MakeSigner(b.config, next), opts.From, rawTx)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.transactor.SendTransaction(ensureContext(opts.Context), signedTx); err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
+
+// Results splits the receipt of the transaction the batch was sent as into the
+// outcomes of its calls. Each successful call of an extended transaction closes
+// its logs with a synthetic transfer log, which delimits the logs of the calls;
+// failed calls are reverted and leave no logs behind.
+func (b *Batch) Results(receipt *types.Receipt) ([]*BatchResult, error) {
+	if len(b.calls) == 0 {
+		return nil, ErrBatchEmpty
+	}
+	if len(receipt.Transfers) != len(b.calls)-1 {
+		return nil, ErrBatchMismatch
+	}
+	results := make([]*BatchResult, len(b.calls))
+	results[0] = &BatchResult{BatchCall: b.calls[0], Status: receipt.Status, GasUsed: receipt.GasUsed}
+	for i, transfer := range receipt.Transfers {
+		if transfer.To != b.calls[i+1].Contract.address {
+			return nil, ErrBatchMismatch
+		}
+		results[i+1] = &BatchResult{BatchCall: b.calls[i+1], Status: transfer.Status, GasUsed: transfer.GasUsed}
+		// Refunds are only credited to the transaction as a whole
+		if results[0].GasUsed > transfer.GasUsed {
+			results[0].GasUsed -= transfer.GasUsed
+		} else {
+			results[0].GasUsed = 0
+		}
+	}
+	// A plain transaction does not log its transfer, all logs are the call's
+	if len(results) == 1 {
+		results[0].Logs = receipt.Logs
+		return results, nil
+	}
+	logs := receipt.Logs
+	for _, result := range results {
+		if result.Status != types.ReceiptStatusSuccessful {
+			continue
+		}
+		for len(logs) > 0 {
+			log := logs[0]
+			logs = logs[1:]
+			if _, to, _, ok := types.ParseTransferLog(log); ok && to == result.Contract.address {
+				break
+			}
+			result.Logs = append(result.Logs, log)
+		}
+	}
+	return results, nil
+}
+
+// Address returns the deployment address of the bound contract.
+func (c *BoundContract) Address() common.Address {
+	return c.address
+}
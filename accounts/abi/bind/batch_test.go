@@ -0,0 +1,173 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bind_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const batchTestABI = `[{"constant":false,"inputs":[{"name":"value","type":"uint256"}],"name":"store","outputs":[],"payable":true,"type":"function"},{"anonymous":false,"inputs":[{"name":"value","type":"uint256"}],"name":"Stored","type":"event"}]`
+
+// batchTestCode logs the stored value as a Stored event: it copies the first
+// argument into memory and emits it under the event topic.
+var batchTestCode = append(append(common.FromHex("602060046000377f"), crypto.Keccak256([]byte("Stored(uint256)"))...), common.FromHex("60206000a100")...)
+
+// Tests that calls of several contracts are sent as a single extended
+// transaction signed for the chain's forks and that the results of the calls
+// are decoded from its receipt.
+func TestBatch(t *testing.T) {
+	var (
+		from      = crypto.PubkeyToAddress(testKey.PublicKey)
+		storerA   = common.Address{0x0a}
+		storerB   = common.Address{0x0b}
+		failing   = common.Address{0x0f} // Contract hitting an invalid opcode
+		ctx       = context.Background()
+		parsed, _ = abi.JSON(strings.NewReader(batchTestABI))
+		config    = *params.AllEthashProtocolChanges
+		alloc     = core.GenesisAlloc{
+			from:    {Balance: big.NewInt(10000000000)},
+			storerA: {Balance: new(big.Int), Code: batchTestCode},
+			storerB: {Balance: new(big.Int), Code: batchTestCode},
+			failing: {Balance: new(big.Int), Code: []byte{0xfe}},
+		}
+	)
+	// Sign and execute extended transactions from genesis
+	config.PTCSignerBlock = big.NewInt(0)
+	config.ExtraToBlock = big.NewInt(0)
+	backend := backends.NewSimulatedBackendWithConfig(&config, alloc)
+
+	contracts := make(map[common.Address]*bind.BoundContract)
+	for _, addr := range []common.Address{storerA, storerB, failing} {
+		contracts[addr] = bind.NewBoundContract(addr, parsed, backend, backend, backend)
+	}
+	auth := bind.NewKeyedTransactor(testKey)
+
+	// Send a batch with a failing call in the middle
+	batch := bind.NewBatch(backend, &config)
+	for i, call := range []struct {
+		contract common.Address
+		value    int64
+	}{{storerA, 0}, {failing, 0}, {storerB, 10}} {
+		if err := batch.Add(contracts[call.contract], big.NewInt(call.value), "store", big.NewInt(int64(i+1))); err != nil {
+			t.Fatalf("call %d: failed to add to the batch: %v", i, err)
+		}
+	}
+	if err := batch.Add(contracts[storerA], nil, "store", big.NewInt(4)); err != bind.ErrBatchFull {
+		t.Errorf("overflowing call error mismatch: have %v, want %v", err, bind.ErrBatchFull)
+	}
+	opts := *auth
+	opts.GasLimit = 200000
+
+	tx, err := batch.Transact(&opts)
+	if err != nil {
+		t.Fatalf("failed to send batch: %v", err)
+	}
+	if !tx.PTCSigned() {
+		t.Fatalf("batch not signed by the PTC signer")
+	}
+	backend.Commit()
+
+	receipt, err := backend.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		t.Fatalf("failed to retrieve receipt: %v", err)
+	}
+	results, err := batch.Results(receipt)
+	if err != nil {
+		t.Fatalf("failed to decode results: %v", err)
+	}
+	for i, want := range []struct {
+		status uint64
+		stored int64
+	}{{types.ReceiptStatusSuccessful, 1}, {types.ReceiptStatusFailed, 0}, {types.ReceiptStatusSuccessful, 3}} {
+		result := results[i]
+		if result.Status != want.status {
+			t.Errorf("result %d: status mismatch: have %d, want %d", i, result.Status, want.status)
+			continue
+		}
+		if want.status == types.ReceiptStatusFailed {
+			if len(result.Logs) != 0 {
+				t.Errorf("result %d: failed call logged: %v", i, result.Logs)
+			}
+			continue
+		}
+		if len(result.Logs) != 1 {
+			t.Errorf("result %d: log count mismatch: have %d, want 1", i, len(result.Logs))
+			continue
+		}
+		var event struct{ Value *big.Int }
+		if err := result.Contract.UnpackLog(&event, "Stored", *result.Logs[0]); err != nil {
+			t.Errorf("result %d: failed to unpack log: %v", i, err)
+		} else if event.Value.Int64() != want.stored {
+			t.Errorf("result %d: stored value mismatch: have %v, want %d", i, event.Value, want.stored)
+		}
+	}
+	if balance, _ := backend.BalanceAt(ctx, storerB, nil); balance.Int64() != 10 {
+		t.Errorf("paid call balance mismatch: have %v, want 10", balance)
+	}
+	// Send a batch with the gas allowance estimated
+	batch = bind.NewBatch(backend, &config)
+	batch.Add(contracts[storerA], nil, "store", big.NewInt(5))
+	batch.Add(contracts[storerB], nil, "store", big.NewInt(6))
+
+	if tx, err = batch.Transact(auth); err != nil {
+		t.Fatalf("failed to send estimated batch: %v", err)
+	}
+	backend.Commit()
+
+	receipt, _ = backend.TransactionReceipt(ctx, tx.Hash())
+	if results, err = batch.Results(receipt); err != nil {
+		t.Fatalf("failed to decode estimated results: %v", err)
+	}
+	for i, result := range results {
+		if result.Status != types.ReceiptStatusSuccessful || len(result.Logs) != 1 {
+			t.Errorf("estimated result %d: mismatch: status %d, %d logs", i, result.Status, len(result.Logs))
+		}
+	}
+	// Before the PTC forks only single calls can be sent
+	legacy := backends.NewSimulatedBackend(alloc)
+	contract := bind.NewBoundContract(storerA, parsed, legacy, legacy, legacy)
+
+	batch = bind.NewBatch(legacy, params.AllEthashProtocolChanges)
+	batch.Add(contract, nil, "store", big.NewInt(7))
+	batch.Add(contract, nil, "store", big.NewInt(8))
+
+	opts = *auth
+	opts.GasLimit = 200000
+	if _, err := batch.Transact(&opts); err != bind.ErrBatchUnsupported {
+		t.Errorf("legacy batch error mismatch: have %v, want %v", err, bind.ErrBatchUnsupported)
+	}
+	batch = bind.NewBatch(legacy, params.AllEthashProtocolChanges)
+	batch.Add(contract, nil, "store", big.NewInt(9))
+	if tx, err = batch.Transact(&opts); err != nil {
+		t.Fatalf("failed to send legacy single call: %v", err)
+	}
+	if len(tx.GetMatrix_EX()) != 0 {
+		t.Errorf("legacy single call sent as an extended transaction")
+	}
+}
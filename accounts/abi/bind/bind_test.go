@@ -809,6 +809,36 @@ var bindTests = []struct {
 			}
 		`,
 	},
+	// Tests that batch sessions append calls of methods whose names would clash
+	// with batch helpers of other methods
+	{
+		`Batcher`,
+		`
+			contract Batcher {
+				function store(uint value) {}
+				function batchStore(uint value) {}
+			}
+		`,
+		`606060405260068060106000396000f3606060405200`,
+		`[{"constant":false,"inputs":[{"name":"value","type":"uint256"}],"name":"store","outputs":[],"type":"function"},{"constant":false,"inputs":[{"name":"value","type":"uint256"}],"name":"batchStore","outputs":[],"type":"function"}]`,
+		`
+			transactor, err := NewBatcherTransactor(common.Address{}, nil)
+			if err != nil {
+				t.Fatalf("Failed to create transactor binding: %v", err)
+			}
+			session := &BatcherBatchSession{Contract: transactor, Batch: bind.NewBatch(nil, nil), Value: big.NewInt(1)}
+			if err := session.Store(big.NewInt(1)); err != nil {
+				t.Fatalf("Failed to batch store call: %v", err)
+			}
+			if err := session.BatchStore(big.NewInt(2)); err != nil {
+				t.Fatalf("Failed to batch batchStore call: %v", err)
+			}
+			calls := session.Batch.Calls()
+			if len(calls) != 2 || calls[0].Method != "store" || calls[1].Method != "batchStore" || calls[1].Value.Int64() != 1 {
+				t.Fatalf("Batched calls mismatch: %v", calls)
+			}
+		`,
+	},
 }
 
 // Tests that packages generated by the binder can be successfully compiled and
@@ -130,6 +130,14 @@ package {{.Package}}
 	  TransactOpts bind.TransactOpts    // Transaction auth options to use throughout this session
 	}
 
+	// {{.Type}}BatchSession is an auto generated write-only Go binding around an Ethereum contract,
+	// appending calls to a batch sent as a single transaction.
+	type {{.Type}}BatchSession struct {
+	  Contract *{{.Type}}Transactor // Generic contract transactor binding to set the session for
+	  Batch    *bind.Batch          // Batch to append the calls of this session to
+	  Value    *big.Int             // Funds to transfer along with the calls of this session (nil = 0)
+	}
+
 	// {{.Type}}Raw is an auto generated low-level Go binding around an Ethereum contract.
 	type {{.Type}}Raw struct {
 	  Contract *{{.Type}} // Generic contract binding to access the raw methods on
@@ -228,6 +236,12 @@ package {{.Package}}
 		return _{{$contract.Type}}.Contract.contract.Transact(opts, method, params...)
 	}
 
+	// Batch appends a call of the (paid) contract method with params as input values
+	// to a batch of calls sent as a single transaction.
+	func (_{{$contract.Type}} *{{$contract.Type}}TransactorRaw) Batch(batch *bind.Batch, value *big.Int, method string, params ...interface{}) error {
+		return batch.Add(_{{$contract.Type}}.Contract.contract, value, method, params...)
+	}
+
 	{{range .Calls}}
 		// {{.Normalized.Name}} is a free data retrieval call binding the contract method 0x{{printf "%x" .Original.Id}}.
 		//
@@ -284,6 +298,13 @@ package {{.Package}}
 		func (_{{$contract.Type}} *{{$contract.Type}}TransactorSession) {{.Normalized.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if ne $i 0}},{{end}} {{.Name}} {{bindtype .Type}} {{end}}) (*types.Transaction, error) {
 		  return _{{$contract.Type}}.Contract.{{.Normalized.Name}}(&_{{$contract.Type}}.TransactOpts {{range $i, $_ := .Normalized.Inputs}}, {{.Name}}{{end}})
 		}
+
+		// {{.Normalized.Name}} appends a call of the contract method 0x{{printf "%x" .Original.Id}} to the batch of the session.
+		//
+		// Solidity: {{.Original.String}}
+		func (_{{$contract.Type}} *{{$contract.Type}}BatchSession) {{.Normalized.Name}}({{range $i, $_ := .Normalized.Inputs}}{{if ne $i 0}},{{end}} {{.Name}} {{bindtype .Type}} {{end}}) error {
+		  return _{{$contract.Type}}.Batch.Add(_{{$contract.Type}}.Contract.contract, _{{$contract.Type}}.Value, "{{.Original.Name}}" {{range .Normalized.Inputs}}, {{.Name}}{{end}})
+		}
 	{{end}}
 
 	{{range .Events}}
@@ -1,23 +1,99 @@
 package boot
 
 import (
-	"fmt"
+	"math/big"
 	"testing"
-	"time"
 
-	"github.com/ethereum/go-ethereum/p2p"
-
-	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/eth"
+	"github.com/ethereum/go-ethereum/p2p/discover"
 )
 
-func TestNewBoot(t *testing.T) {
-	go p2p.Receiveudp()
-	go p2p.CustSend()
-	var bc *core.BlockChain
-	ans := New(bc, "asdddd")
-	ans.Run()
+func testHead(id byte, td int64) eth.PeerHead {
+	return eth.PeerHead{ID: discover.NodeID{id}, TD: big.NewInt(td)}
+}
+
+// Tests that the quorum defaults to a majority of the boot nodes, capped at the
+// number of remote boot nodes.
+func TestQuorumSize(t *testing.T) {
+	tests := []struct {
+		configured, total, remote int
+		want                      int
+	}{
+		{0, 3, 3, 2}, // Regular node, majority of three
+		{0, 3, 2, 2}, // Boot node itself, both others
+		{0, 1, 0, 0}, // Single boot node private network
+		{1, 3, 3, 1}, // Explicit quorum
+		{5, 3, 3, 3}, // Explicit quorum above the available boot nodes
+	}
+	for i, tt := range tests {
+		if have := quorumSize(tt.configured, tt.total, tt.remote); have != tt.want {
+			t.Errorf("test %d: quorum mismatch: have %d, want %d", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that the quorum head is the best head reached by enough boot nodes, so
+// a single node cannot inflate it.
+func TestQuorumHead(t *testing.T) {
+	heads := []eth.PeerHead{testHead(1, 100), testHead(2, 300), testHead(3, 200)}
+
+	if _, _, ok := quorumHead(heads[:1], 2); ok {
+		t.Fatalf("quorum met with a single head")
+	}
+	td, agreeing, ok := quorumHead(heads, 2)
+	if !ok {
+		t.Fatalf("quorum not met")
+	}
+	if td.Int64() != 200 {
+		t.Errorf("quorum td mismatch: have %v, want %d", td, 200)
+	}
+	if len(agreeing) != 2 || agreeing[0].ID != heads[1].ID || agreeing[1].ID != heads[2].ID {
+		t.Errorf("agreeing heads mismatch: have %v", agreeing)
+	}
+	if _, agreeing, ok := quorumHead(nil, 0); !ok || len(agreeing) != 0 {
+		t.Errorf("empty quorum mismatch: have %v/%v", agreeing, ok)
+	}
+}
+
+// Tests that the head hash is only taken if enough boot nodes announced it.
+func TestQuorumHeadHash(t *testing.T) {
+	heads := []eth.PeerHead{testHead(1, 200), testHead(2, 200), testHead(3, 200)}
+	heads[0].Head = common.HexToHash("0x01")
+	heads[1].Head = common.HexToHash("0x02")
+	heads[2].Head = common.HexToHash("0x02")
+
+	if _, ok := quorumHeadHash(heads[:2], 2); ok {
+		t.Fatalf("quorum met on different heads")
+	}
+	if hash, ok := quorumHeadHash(heads, 2); !ok || hash != heads[1].Head {
+		t.Errorf("quorum head mismatch: have %x/%v, want %x", hash, ok, heads[1].Head)
+	}
+}
 
-	time.Sleep(100 * time.Second)
+// Tests that the main node lists are only taken if enough boot nodes returned
+// the same ones, and that the nodes to sync from are the online main nodes.
+func TestQuorumTopology(t *testing.T) {
+	honest := &types.Topology{
+		MinerList:     []election.NodeInfo{{ID: "01", IP: "10.0.0.1"}},
+		CommitteeList: []election.NodeInfo{{ID: "02", IP: "10.0.0.2"}},
+		Both:          []election.NodeInfo{{ID: "01", IP: "10.0.0.1"}},
+		OfflineList:   []election.NodeInfo{{ID: "03", IP: "10.0.0.3"}},
+	}
+	forged := &types.Topology{MinerList: []election.NodeInfo{{ID: "ff", IP: "10.0.0.255"}}}
 
-	fmt.Println(ans)
+	if _, ok := quorumTopology([]*types.Topology{forged, honest}, 2); ok {
+		t.Fatalf("quorum met on different lists")
+	}
+	copied := *honest
+	topology, ok := quorumTopology([]*types.Topology{forged, honest, &copied}, 2)
+	if !ok {
+		t.Fatalf("quorum not met")
+	}
+	nodes := mainNodes(topology)
+	if len(nodes) != 2 || nodes[0].ID != "01" || nodes[1].ID != "02" {
+		t.Errorf("main nodes mismatch: have %v", nodes)
+	}
 }
@@ -0,0 +1,66 @@
+// Copyright 2018 The MATRIX Authors
+// This file is part of the MATRIX library.
+//
+// The MATRIX library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MATRIX library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MATRIX library. If not, see <http://www.gnu.org/licenses/>.
+
+package ca
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/mc"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// FeedTopology hands the role topology of every block processed by the
+// identity to the topology policy of the p2p server. It blocks until the
+// subscription to the identity fails.
+func FeedTopology(srv *p2p.Server) {
+	ch := make(chan mc.BlockToBucket)
+	sub, err := mc.SubscribeEvent(mc.BlockToBuckets, ch)
+	if err != nil {
+		log.Error("Failed to subscribe to block topologies", "err", err)
+		return
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case msg := <-ch:
+			srv.UpdateTopology(Ide.topologyUpdate(msg))
+		case <-sub.Err():
+			return
+		}
+	}
+}
+
+// topologyUpdate assembles the topology update of a block from the current
+// topology groups and the nodes put into the buckets.
+func (ide *Identity) topologyUpdate(msg mc.BlockToBucket) p2p.TopologyUpdate {
+	update := p2p.TopologyUpdate{
+		Number: msg.Height.Uint64(),
+		Role:   msg.Role,
+		Nodes:  make(map[common.RoleType][]*discover.Node),
+	}
+	for _, role := range []common.RoleType{common.RoleValidator, common.RoleMiner} {
+		for _, id := range ide.GetRolesByGroup(role) {
+			update.Nodes[role] = append(update.Nodes[role], &discover.Node{ID: id})
+		}
+	}
+	for _, id := range msg.Ms {
+		update.Nodes[common.RoleBucket] = append(update.Nodes[common.RoleBucket], &discover.Node{ID: id})
+	}
+	return update
+}
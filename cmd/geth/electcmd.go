@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	electAccountFlag = cli.StringFlag{
+		Name:  "account",
+		Usage: "Account sending the election transaction and its deposit",
+	}
+	electIPFlag = cli.StringFlag{
+		Name:  "ip",
+		Usage: "Public IP address the node is reachable on",
+	}
+	electTPSFlag = cli.UintFlag{
+		Name:  "tps",
+		Usage: "Transactions per second the node is able to process",
+	}
+	electDepositFlag = cli.StringFlag{
+		Name:  "deposit",
+		Usage: "Deposit in wei transferred along with the election (ignored on exit)",
+		Value: params.MinElectionDeposit.String(),
+	}
+	electNonceFlag = cli.Uint64Flag{
+		Name:  "nonce",
+		Usage: "Nonce of the sending account",
+	}
+	electGasFlag = cli.Uint64Flag{
+		Name:  "gas",
+		Usage: "Gas limit of the election transaction",
+		Value: 100000,
+	}
+	electGasPriceFlag = cli.StringFlag{
+		Name:  "gasprice",
+		Usage: "Gas price in wei of the election transaction",
+		Value: "1000000000",
+	}
+	electChainIDFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "Chain ID the transaction is signed for",
+		Value: params.MainnetChainConfig.ChainID.Uint64(),
+	}
+
+	electTypes = map[string]uint32{
+		"miner":     types.ElectMiner,
+		"committee": types.ElectCommittee,
+		"both":      types.ElectBoth,
+		"exit":      types.ElectExit,
+	}
+
+	electComptcd = cli.Comptcd{
+		Action:    utils.MigrateFlags(elect),
+		Name:      "elect",
+		Usage:     "Build and sign an election transaction",
+		ArgsUsage: "<miner|committee|both|exit>",
+		Category:  "ACCOUNT COMMANDS",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.KeyStoreDirFlag,
+			utils.PasswordFileFlag,
+			utils.LightKDFFlag,
+			utils.NodeKeyFileFlag,
+			utils.NodeKeyHexFlag,
+			electAccountFlag,
+			electIPFlag,
+			electTPSFlag,
+			electDepositFlag,
+			electNonceFlag,
+			electGasFlag,
+			electGasPriceFlag,
+			electChainIDFlag,
+		},
+		Description: `
+    geth elect --account <address> --ip <ip> --nonce <nonce> miner
+
+builds an election transaction standing the node for election as a miner, a
+committee member, both, or withdrawing it with exit. The payload is signed by
+the node key (--nodekey, or the key of the node in --datadir) and the
+transaction by the unlocked account, which transfers the deposit to the
+deposit account.
+
+The signed transaction is printed as hex and can be submitted with
+eth.sendRawTransaction.`,
+	}
+)
+
+// elect builds an election transaction, signs its payload with the node key and
+// the transaction with the sending account, and prints the raw transaction.
+func elect(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("The election type (miner, committee, both or exit) must be given as argument")
+	}
+	electType, ok := electTypes[ctx.Args().First()]
+	if !ok {
+		utils.Fatalf("Unknown election type %q", ctx.Args().First())
+	}
+	if !ctx.IsSet(electAccountFlag.Name) {
+		utils.Fatalf("The sending account must be given with --%s", electAccountFlag.Name)
+	}
+	stack, cfg := makeConfigNode(ctx)
+	ks := stack.AccountManager().Backends(keystore.KeyStoreType)[0].(*keystore.KeyStore)
+	account, _ := unlockAccount(ctx, ks, ctx.String(electAccountFlag.Name), 0, utils.MakePasswordList(ctx))
+
+	info := &types.ElectionTxPayLoadInfo{
+		TPS:       uint32(ctx.Uint(electTPSFlag.Name)),
+		IP:        ctx.String(electIPFlag.Name),
+		ElectType: electType,
+	}
+	deposit := new(big.Int)
+	if electType != types.ElectExit {
+		deposit = parseElectBig(ctx, electDepositFlag.Name)
+		info.Wealth = new(big.Int).Div(deposit, big.NewInt(params.Ether)).Uint64()
+	}
+	if err := info.Sign(account.Address, cfg.Node.NodeKey()); err != nil {
+		utils.Fatalf("Failed to sign the election payload: %v", err)
+	}
+	if err := info.Validate(account.Address); err != nil {
+		utils.Fatalf("Invalid election payload: %v", err)
+	}
+	data, err := types.EncodeElectionTxPayLoad(info)
+	if err != nil {
+		utils.Fatalf("Failed to encode the election payload: %v", err)
+	}
+	tx := types.NewTransaction(ctx.Uint64(electNonceFlag.Name), common.HexToAddress(params.HypothecatedAccount), deposit,
+		ctx.Uint64(electGasFlag.Name), parseElectBig(ctx, electGasPriceFlag.Name), data)
+
+	chainID := new(big.Int).SetUint64(ctx.Uint64(electChainIDFlag.Name))
+	signed, err := ks.SignTx(account, tx, chainID)
+	if err != nil {
+		utils.Fatalf("Failed to sign the election transaction: %v", err)
+	}
+	enc, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		utils.Fatalf("Failed to encode the election transaction: %v", err)
+	}
+	fmt.Printf("Node: %s\n", info.ID)
+	fmt.Printf("Transaction: %s\n", signed.Hash().Hex())
+	fmt.Println(hexutil.Encode(enc))
+	return nil
+}
+
+// parseElectBig parses a decimal or hex wei amount given in the named flag.
+func parseElectBig(ctx *cli.Context, name string) *big.Int {
+	value, ok := math.ParseBig256(ctx.String(name))
+	if !ok {
+		utils.Fatalf("Invalid --%s value %q", name, ctx.String(name))
+	}
+	return value
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/election"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var electionComptcd = cli.Comptcd{
+	Name:     "election",
+	Usage:    "Inspect the elections held by the local elector",
+	Category: "BLOCKCHAIN COMMANDS",
+	Description: `
+The elector records the inputs, engine version and outcome of every election
+it holds in the chain database, keyed by candidate period. Elections held again
+within a period are kept along with the earlier ones.`,
+	Subcomptcds: []cli.Comptcd{
+		{
+			Name:      "replay",
+			Usage:     "Hold a recorded election again and compare the outcome",
+			ArgsUsage: "<period>",
+			Action:    utils.MigrateFlags(replayElection),
+			Category:  "BLOCKCHAIN COMMANDS",
+			Flags: []cli.Flag{
+				utils.DataDirFlag,
+				utils.CacheFlag,
+				utils.LightModeFlag,
+			},
+			Description: `
+    geth election replay <period>
+
+recomputes every miner and validator election recorded for the candidate period
+from their recorded deposits, seed and parameters, and lists every weight and
+seat the replay (have) assigns differently from the record (want). It fails if
+any of the elections differs.`,
+		},
+	},
+}
+
+// replayElection holds the elections recorded for a candidate period again and
+// reports the differences to the recorded outcomes.
+func replayElection(ctx *cli.Context) error {
+	if len(ctx.Args()) != 1 {
+		utils.Fatalf("The candidate period must be given as argument")
+	}
+	period, err := strconv.ParseUint(ctx.Args().First(), 0, 64)
+	if err != nil {
+		utils.Fatalf("Invalid candidate period %q: %v", ctx.Args().First(), err)
+	}
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	var recorded, differing int
+	for _, role := range []string{election.RoleMiner, election.RoleValidator} {
+		records, err := election.DecodeRecords(rawdb.ReadElectionRecords(chainDb, period, role))
+		if err != nil {
+			utils.Fatalf("Invalid %s election records: %v", role, err)
+		}
+		if len(records) == 0 {
+			fmt.Printf("No %s election recorded for period %d\n", role, period)
+			continue
+		}
+		for _, rec := range records {
+			recorded++
+
+			if rec.Engine != election.EngineVersion {
+				fmt.Printf("Warning: %s election recorded by engine version %s, replaying with %s\n", role, rec.Engine, election.EngineVersion)
+			}
+			replayed, err := election.Replay(rec)
+			if err != nil {
+				utils.Fatalf("Failed to replay %s election: %v", role, err)
+			}
+			diffs := replayed.Diff(rec)
+			if len(diffs) == 0 {
+				fmt.Printf("Period %d %s election (block %d, seed %v, %d candidates): replay matches record\n", period, role, rec.Number, rec.Seed, len(rec.Deposits))
+				continue
+			}
+			differing++
+			fmt.Printf("Period %d %s election (block %d, seed %v, %d candidates): replay differs from record\n", period, role, rec.Number, rec.Seed, len(rec.Deposits))
+			for _, diff := range diffs {
+				fmt.Printf("  %s\n", diff)
+			}
+		}
+	}
+	if recorded == 0 {
+		utils.Fatalf("No election recorded for period %d", period)
+	}
+	if differing > 0 {
+		return fmt.Errorf("%d of %d elections differ from their record", differing, recorded)
+	}
+	return nil
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts"
 	"github.com/ethereum/go-ethereum/accounts/keystore"
 	"github.com/ethereum/go-ethereum/boot"
+	"github.com/ethereum/go-ethereum/ca"
 	"github.com/ethereum/go-ethereum/cmd/utils"
 	"github.com/ethereum/go-ethereum/console"
 	"github.com/ethereum/go-ethereum/eth"
@@ -46,7 +47,6 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"gopkg.in/urfave/cli.v1"
 	"github.com/ethereum/go-ethereum/election"
-	"github.com/ethereum/go-ethereum/params"
 )
 
 const (
@@ -88,6 +88,9 @@ var (
 		utils.TxPoolGlobalSlotsFlag,
 		utils.TxPoolAccountQueueFlag,
 		utils.TxPoolGlobalQueueFlag,
+		utils.TxPoolExtendedSlotsFlag,
+		utils.TxPoolElectionSlotsFlag,
+		utils.TxPoolDelegationSlotsFlag,
 		utils.TxPoolLifetimeFlag,
 		utils.FastSyncFlag,
 		utils.LightModeFlag,
@@ -173,6 +176,10 @@ func init() {
 		// See accountcmd.go:
 		accountComptcd,
 		walletComptcd,
+		// See electcmd.go:
+		electComptcd,
+		// See electioncmd.go:
+		electionComptcd,
 		// See consolecmd.go:
 		consoleComptcd,
 		attachComptcd,
@@ -319,12 +326,22 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 		utils.Fatalf("Ethereum service not running: %v", err)
 	}
 
-	ReNetSearch := boot.NetSearch(ethereum)
-	fmt.Println("ReNetSearch ::::", ReNetSearch)
+	search := boot.NewSearch(ethereum, stack.Server(), boot.DefaultConfig)
+	go func() {
+		stack.Wait()
+		search.Stop()
+	}()
+	ReNetSearch, err := search.Run()
+	if err != nil {
+		log.Warn("Boot search failed, not syncing from main nodes", "err", err)
+	}
 
 	//sync block
 	BootBlkSync(ReNetSearch, ethereum, stack)
 
+	// Keep the peer links in line with the role topology of the network
+	go ca.FeedTopology(stack.Server())
+
 	if err := ethereum.StartScheduler(stack.Server().Self(), stack.AccountManager(), stateReader); err != nil {
 		utils.Fatalf("Failed to start scheduler: %v", err)
 	}
@@ -376,13 +393,11 @@ func BootBlkSync(info boot.RE_boot, ethereum *eth.Ethereum, self *node.Node) {
 func beginSync(bootInfo boot.RE_boot, ethereum *eth.Ethereum, self *node.Node) error {
 	mList := make([]election.NodeInfo, 0)
 	mList = append(mList, bootInfo.Main_List...)
-	// main list is empty , add boot node to main list
-	if 0 == len(mList) {
-		for _, url := range params.MainnetBootnodes {
-			if bootNode, err := discover.ParseNode(url); err == nil {
-				mList = append(mList, election.NodeInfo{ID: bootNode.ID.String(), IP: bootNode.IP.String()})
-			}
-		}
+	// fresh network or no boot node to agree with, nothing to sync from
+	if 0 == bootInfo.Net_Flag || 0 == len(mList) {
+		log.Info("BOOT BLOCK SYNC: no node ahead, skip", "flag", bootInfo.Net_Flag)
+		ethereum.SkipBlkSync()
+		return nil
 	}
 
 	var (
@@ -122,6 +122,9 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TxPoolGlobalSlotsFlag,
 			utils.TxPoolAccountQueueFlag,
 			utils.TxPoolGlobalQueueFlag,
+			utils.TxPoolExtendedSlotsFlag,
+			utils.TxPoolElectionSlotsFlag,
+			utils.TxPoolDelegationSlotsFlag,
 			utils.TxPoolLifetimeFlag,
 		},
 	},
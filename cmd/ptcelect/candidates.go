@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// Values the elector substitutes for deposit details missing a deposit or an
+// online time, see Elector.Listen.
+var (
+	defaultDeposit = big.NewInt(50000)
+	defaultUptime  = big.NewInt(300)
+)
+
+// candidate is a node standing for election.
+type candidate struct {
+	ID      discover.NodeID
+	Deposit *big.Int
+	Uptime  *big.Int
+	TPS     uint64
+}
+
+// detail converts the candidate into the deposit details the elector weighs.
+func (c *candidate) detail() vm.DepositDetail {
+	return vm.DepositDetail{
+		NodeID:     c.ID,
+		Deposit:    c.Deposit,
+		WithdrawH:  new(big.Int),
+		OnlineTime: c.Uptime,
+	}
+}
+
+// newCandidate parses the fields of a candidate. The node ID is given in hex or
+// as an enode URL, the deposit and uptime as decimal or hex numbers; the latter
+// default to what the elector assumes for missing values if left empty.
+func newCandidate(id, deposit, uptime, tps string) (*candidate, error) {
+	c := &candidate{Deposit: defaultDeposit, Uptime: defaultUptime}
+
+	if strings.HasPrefix(id, "enode://") {
+		node, err := discover.ParseNode(id)
+		if err != nil {
+			return nil, err
+		}
+		c.ID = node.ID
+	} else {
+		nodeID, err := discover.HexID(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node ID %q: %v", id, err)
+		}
+		c.ID = nodeID
+	}
+	if deposit != "" {
+		value, ok := math.ParseBig256(deposit)
+		if !ok || value.Sign() < 0 {
+			return nil, fmt.Errorf("invalid deposit %q", deposit)
+		}
+		c.Deposit = value
+	}
+	if uptime != "" {
+		value, ok := math.ParseBig256(uptime)
+		if !ok || value.Sign() < 0 {
+			return nil, fmt.Errorf("invalid uptime %q", uptime)
+		}
+		c.Uptime = value
+	}
+	if tps != "" {
+		value, ok := math.ParseUint64(tps)
+		if !ok {
+			return nil, fmt.Errorf("invalid TPS %q", tps)
+		}
+		c.TPS = value
+	}
+	return c, nil
+}
+
+// loadCandidates reads a candidate list in the given format, or in the one
+// implied by the file extension if none is given.
+func loadCandidates(path, format string) ([]*candidate, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var candidates []*candidate
+	switch strings.ToLower(format) {
+	case "json":
+		candidates, err = parseJSON(f)
+	case "csv":
+		candidates, err = parseCSV(f)
+	default:
+		return nil, fmt.Errorf("unknown candidate list format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("empty candidate list")
+	}
+	// The elector tells nodes apart by ID only
+	seen := make(map[discover.NodeID]bool)
+	for _, c := range candidates {
+		if seen[c.ID] {
+			return nil, fmt.Errorf("duplicate candidate %s", c.ID.TerminalString())
+		}
+		seen[c.ID] = true
+	}
+	return candidates, nil
+}
+
+// quantity is a JSON number or a string holding a decimal or hex number.
+type quantity string
+
+func (q *quantity) UnmarshalJSON(input []byte) error {
+	*q = quantity(strings.Trim(string(input), `"`))
+	return nil
+}
+
+// parseJSON reads candidates from a JSON array of objects with the fields id,
+// deposit, uptime and tps.
+func parseJSON(r io.Reader) ([]*candidate, error) {
+	var list []struct {
+		ID      string   `json:"id"`
+		Deposit quantity `json:"deposit"`
+		Uptime  quantity `json:"uptime"`
+		TPS     quantity `json:"tps"`
+	}
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return nil, err
+	}
+	candidates := make([]*candidate, len(list))
+	for i, item := range list {
+		c, err := newCandidate(item.ID, string(item.Deposit), string(item.Uptime), string(item.TPS))
+		if err != nil {
+			return nil, fmt.Errorf("candidate %d: %v", i, err)
+		}
+		candidates[i] = c
+	}
+	return candidates, nil
+}
+
+// parseCSV reads candidates from CSV records. The first record names the
+// columns: id is mandatory, deposit, uptime and tps are optional.
+func parseCSV(r io.Reader) ([]*candidate, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["id"]; !ok {
+		return nil, errors.New("missing id column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	candidates := make([]*candidate, 0, len(records)-1)
+	for i, record := range records[1:] {
+		c, err := newCandidate(field(record, "id"), field(record, "deposit"), field(record, "uptime"), field(record, "tps"))
+		if err != nil {
+			return nil, fmt.Errorf("candidate %d: %v", i, err)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+const (
+	testID1 = "a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c"
+	testID2 = "3e4f8a3e47fb6e20d1bd0f18ba2f3d1dcbe16b87c0cf5a7efd8e0c89f8b1b7c27f5c1e92bd7aee0cb50ad0bf86bf6d01a64a4a85ef10e4cd6fe1ab9eac3c7cd4"
+)
+
+var testCandidateLists = map[string]string{
+	"candidates.json": `[
+		{"id": "` + testID1 + `", "deposit": "0x2540be400", "uptime": 512, "tps": 2000},
+		{"id": "enode://` + testID2 + `@127.0.0.1:30303", "deposit": 20000000000}
+	]`,
+	"candidates.csv": `# candidates of the next election
+id, deposit, uptime, tps
+` + testID1 + `, 10000000000, 0x200, 2000
+` + testID2 + `, 20000000000, ,
+`,
+}
+
+// Tests that the JSON and CSV formats carry the same candidates, filling in
+// the values the elector assumes for missing ones.
+func TestLoadCandidates(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ptcelect-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	want := []*candidate{
+		{ID: discover.MustHexID(testID1), Deposit: big.NewInt(10000000000), Uptime: big.NewInt(512), TPS: 2000},
+		{ID: discover.MustHexID(testID2), Deposit: big.NewInt(20000000000), Uptime: defaultUptime},
+	}
+	for name, content := range testCandidateLists {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		candidates, err := loadCandidates(path, "")
+		if err != nil {
+			t.Errorf("%s: failed to load candidates: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(candidates, want) {
+			t.Errorf("%s: candidates mismatch:\nhave %+v\nwant %+v", name, candidates, want)
+		}
+	}
+	// Candidates must be told apart by ID
+	path := filepath.Join(dir, "duplicates.csv")
+	if err := ioutil.WriteFile(path, []byte("id\n"+testID1+"\n"+testID1+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadCandidates(path, ""); err == nil {
+		t.Errorf("duplicate candidates accepted")
+	}
+	if _, err := loadCandidates(path, "xml"); err == nil {
+		t.Errorf("unknown format accepted")
+	}
+}
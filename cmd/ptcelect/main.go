@@ -0,0 +1,177 @@
+// ptcelect runs the committee election engine over a candidate list, so that
+// operators can see how deposits and uptime weigh before standing for election.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/cmd/utils"
+	"github.com/ethereum/go-ethereum/election"
+	"gopkg.in/urfave/cli.v1"
+)
+
+// Git SHA1 commit hash of the release (set via linker flags)
+var gitCommit = ""
+
+var (
+	formatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "candidate list format (json or csv), derived from the file extension by default",
+	}
+	roleFlag = cli.StringFlag{
+		Name:  "role",
+		Usage: "role to elect (validator or miner)",
+		Value: roleValidator,
+	}
+	seedFlag = cli.Int64Flag{
+		Name:  "seed",
+		Usage: "random seed of the (first) election",
+	}
+	runsFlag = cli.IntFlag{
+		Name:  "runs",
+		Usage: "number of elections to sample, with consecutive seeds",
+		Value: 1,
+	}
+	mastersFlag = cli.IntFlag{
+		Name:  "masters",
+		Usage: "number of master validators",
+		Value: 11,
+	}
+	backupsFlag = cli.IntFlag{
+		Name:  "backups",
+		Usage: "number of backup validators",
+		Value: 5,
+	}
+	minersFlag = cli.IntFlag{
+		Name:  "miners",
+		Usage: "number of master miners",
+		Value: 21,
+	}
+	maxSampleFlag = cli.IntFlag{
+		Name:  "maxsample",
+		Usage: "maximum number of samples drawn per election",
+		Value: 1000,
+	}
+	jsonFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "output JSON instead of a table",
+	}
+)
+
+var app = utils.NewApp(gitCommit, "the PTC election simulator")
+
+func init() {
+	app.ArgsUsage = "<candidates>"
+	app.Description = `
+Runs the elector over a list of candidates, given as a JSON array of objects or
+CSV records with the fields id, deposit, uptime and tps. Missing deposits and
+uptimes default to the values the elector assumes for them.
+
+The first election, held with --seed, is printed in full. With --runs, further
+elections are held with consecutive seeds and the share of them putting each
+candidate into each tier is reported.`
+	app.Flags = []cli.Flag{
+		formatFlag,
+		roleFlag,
+		seedFlag,
+		runsFlag,
+		mastersFlag,
+		backupsFlag,
+		minersFlag,
+		maxSampleFlag,
+		jsonFlag,
+	}
+	app.Action = simulate
+}
+
+func main() {
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func simulate(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		utils.Fatalf("Expected the candidate list as the only argument")
+	}
+	candidates, err := loadCandidates(ctx.Args().First(), ctx.String(formatFlag.Name))
+	if err != nil {
+		utils.Fatalf("Failed to load candidates: %v", err)
+	}
+	runs := ctx.Int(runsFlag.Name)
+	if runs < 1 {
+		utils.Fatalf("Invalid number of runs: %d", runs)
+	}
+	// The elector reads deposits as 64 bit integers and ranks every node at
+	// the same TPS, warn about inputs that do not weigh as one would expect.
+	for _, c := range candidates {
+		if !c.Deposit.IsUint64() {
+			fmt.Fprintf(os.Stderr, "Warning: deposit of %s exceeds 64 bits and is truncated by the elector\n", c.ID.TerminalString())
+		}
+		if c.TPS != 0 {
+			fmt.Fprintf(os.Stderr, "Warning: TPS of %s ignored, the elector weighs all nodes at 1000 TPS\n", c.ID.TerminalString())
+		}
+	}
+	elector := &election.Elector{
+		MaxSample: ctx.Int(maxSampleFlag.Name),
+		M:         ctx.Int(mastersFlag.Name),
+		P:         ctx.Int(backupsFlag.Name),
+		N:         ctx.Int(minersFlag.Name),
+	}
+	sim, err := newSimulator(elector, ctx.String(roleFlag.Name), candidates)
+	if err != nil {
+		utils.Fatalf("%v", err)
+	}
+	rep := sim.run(ctx.Int64(seedFlag.Name), runs)
+
+	if ctx.Bool(jsonFlag.Name) {
+		out, err := json.MarshalIndent(rep, "", "  ")
+		if err != nil {
+			utils.Fatalf("Failed to marshal report: %v", err)
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	printReport(rep)
+	return nil
+}
+
+// printReport writes the report as tables to stdout.
+func printReport(rep *report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+
+	fmt.Fprintf(w, "Election of %ss with seed %d\n\n", rep.Role, rep.Election.Seed)
+	fmt.Fprintln(w, "TIER\tPOSITION\tNODE\tSTOCK")
+	for _, tier := range []struct {
+		name  string
+		seats []seat
+	}{
+		{"principal", rep.Election.Principal},
+		{"backup", rep.Election.Backup},
+		{"candidate", rep.Election.Candidates},
+	} {
+		for i, elected := range tier.seats {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%d\n", tier.name, i, elected.ID.TerminalString(), elected.Stock)
+		}
+	}
+	if rep.Runs > 1 {
+		fmt.Fprintf(w, "\nSelection probabilities over %d elections\n\n", rep.Runs)
+	} else {
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "NODE\tDEPOSIT\tUPTIME\tWEIGHT\tPRINCIPAL\tBACKUP\tCANDIDATE")
+	for _, node := range rep.Nodes {
+		fmt.Fprintf(w, "%s\t%v\t%v\t%s\t%s\t%s\t%s\n", node.ID.TerminalString(), node.Deposit, node.Uptime,
+			percent(node.Weight), percent(node.Principal), percent(node.Backup), percent(node.Candidate))
+	}
+	w.Flush()
+}
+
+// percent formats a share as a percentage.
+func percent(share float64) string {
+	return fmt.Sprintf("%.1f%%", share*100)
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// Roles the elector selects nodes for.
+const (
+	roleValidator = "validator"
+	roleMiner     = "miner"
+)
+
+// seat is a node elected into a tier, with the stock the elector assigned it.
+type seat struct {
+	ID    discover.NodeID `json:"id"`
+	Stock int             `json:"stock"`
+}
+
+func newSeat(nodeid string, stock int) seat {
+	var id discover.NodeID
+	copy(id[:], nodeid)
+	return seat{ID: id, Stock: stock}
+}
+
+// assignment is the outcome of a single election.
+type assignment struct {
+	Seed       int64  `json:"seed"`
+	Principal  []seat `json:"principal"`
+	Backup     []seat `json:"backup"`
+	Candidates []seat `json:"candidates,omitempty"`
+}
+
+// nodeStats is the weight of a candidate and the share of elections that put it
+// into each tier.
+type nodeStats struct {
+	ID        discover.NodeID `json:"id"`
+	Deposit   *big.Int        `json:"deposit"`
+	Uptime    *big.Int        `json:"uptime"`
+	Weight    float64         `json:"weight"`
+	Principal float64         `json:"principal"`
+	Backup    float64         `json:"backup"`
+	Candidate float64         `json:"candidate"`
+}
+
+// report is the outcome of a simulation over a range of seeds.
+type report struct {
+	Role     string       `json:"role"`
+	Runs     int          `json:"runs"`
+	Election *assignment  `json:"election"`
+	Nodes    []*nodeStats `json:"nodes"`
+}
+
+// simulator runs elections over a fixed candidate list.
+type simulator struct {
+	elector    *election.Elector
+	role       string
+	candidates []*candidate
+	details    []vm.DepositDetail
+}
+
+func newSimulator(elector *election.Elector, role string, candidates []*candidate) (*simulator, error) {
+	if role != roleValidator && role != roleMiner {
+		return nil, fmt.Errorf("unknown role %q", role)
+	}
+	details := make([]vm.DepositDetail, len(candidates))
+	for i, c := range candidates {
+		details[i] = c.detail()
+	}
+	return &simulator{elector: elector, role: role, candidates: candidates, details: details}, nil
+}
+
+// elect runs a single election with the given seed, the same way the elector
+// does when asked for a re-election.
+func (s *simulator) elect(seed int64) *assignment {
+	result := &assignment{Seed: seed}
+
+	values := election.CalcAllValueFunction(s.details)
+	switch s.role {
+	case roleValidator:
+		principal, backup, candidates := s.elector.ValNodesSelected(values, seed)
+		for _, item := range principal {
+			result.Principal = append(result.Principal, newSeat(item.Nodeid, item.Value))
+		}
+		for _, item := range backup {
+			result.Backup = append(result.Backup, newSeat(item.Nodeid, item.Value))
+		}
+		for _, item := range candidates {
+			result.Candidates = append(result.Candidates, newSeat(item.Nodeid, item.Value))
+		}
+	case roleMiner:
+		principal, backup := s.elector.MinerNodesSelected(values, seed, s.elector.N)
+		for _, item := range principal {
+			result.Principal = append(result.Principal, newSeat(item.Nodeid, item.Value))
+		}
+		for _, item := range backup {
+			result.Backup = append(result.Backup, newSeat(item.Nodeid, item.Value))
+		}
+	}
+	return result
+}
+
+// run elects with the seeds start up to start+runs-1 and reports the first
+// election along with how often each candidate ended up in each tier.
+func (s *simulator) run(start int64, runs int) *report {
+	rep := &report{Role: s.role, Runs: runs}
+
+	index := make(map[discover.NodeID]*nodeStats)
+	for _, c := range s.candidates {
+		stats := &nodeStats{ID: c.ID, Deposit: c.Deposit, Uptime: c.Uptime}
+		rep.Nodes = append(rep.Nodes, stats)
+		index[c.ID] = stats
+	}
+	var total float64
+	values := election.CalcAllValueFunction(s.details)
+	for _, value := range values {
+		total += float64(value.Flot)
+	}
+	for i, value := range values {
+		if total > 0 {
+			rep.Nodes[i].Weight = float64(value.Flot) / total
+		}
+	}
+	share := 1 / float64(runs)
+	for i := 0; i < runs; i++ {
+		result := s.elect(start + int64(i))
+		if i == 0 {
+			rep.Election = result
+		}
+		for _, elected := range result.Principal {
+			index[elected.ID].Principal += share
+		}
+		for _, elected := range result.Backup {
+			index[elected.ID].Backup += share
+		}
+		for _, elected := range result.Candidates {
+			index[elected.ID].Candidate += share
+		}
+	}
+	return rep
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+func newTestElector() *election.Elector {
+	return &election.Elector{MaxSample: 1000, M: 11, P: 5, N: 21}
+}
+
+// Tests that elections among no more candidates than seats elect everyone,
+// independent of the seed.
+func TestSimulateAllElected(t *testing.T) {
+	candidates := []*candidate{
+		{ID: discover.MustHexID(testID1), Deposit: big.NewInt(10000000000), Uptime: big.NewInt(512)},
+		{ID: discover.MustHexID(testID2), Deposit: big.NewInt(40000000000), Uptime: big.NewInt(512)},
+	}
+	for _, role := range []string{roleValidator, roleMiner} {
+		sim, err := newSimulator(newTestElector(), role, candidates)
+		if err != nil {
+			t.Fatalf("%s: failed to create simulator: %v", role, err)
+		}
+		rep := sim.run(42, 10)
+		if len(rep.Election.Principal) != 2 || len(rep.Election.Backup) != 0 {
+			t.Errorf("%s: election mismatch: %+v", role, rep.Election)
+		}
+		for _, node := range rep.Nodes {
+			if node.Principal < 0.999 || node.Backup != 0 || node.Candidate != 0 {
+				t.Errorf("%s: node %s probabilities mismatch: %+v", role, node.ID.TerminalString(), node)
+			}
+		}
+		if rep.Nodes[0].Weight >= rep.Nodes[1].Weight {
+			t.Errorf("%s: larger deposit weighs less: %v >= %v", role, rep.Nodes[0].Weight, rep.Nodes[1].Weight)
+		}
+	}
+	if _, err := newSimulator(newTestElector(), "observer", candidates); err == nil {
+		t.Errorf("unknown role accepted")
+	}
+}
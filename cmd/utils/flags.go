@@ -35,6 +35,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/ptcpos"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/vm"
@@ -284,6 +285,21 @@ var (
 		Usage: "Maximum number of non-executable transaction slots for all accounts",
 		Value: eth.DefaultConfig.TxPool.GlobalQueue,
 	}
+	TxPoolExtendedSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.extendedslots",
+		Usage: "Maximum number of transaction slots for remote extended transactions (0 = unlimited)",
+		Value: eth.DefaultConfig.TxPool.ExtendedSlots,
+	}
+	TxPoolElectionSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.electionslots",
+		Usage: "Maximum number of transaction slots for remote election transactions (0 = unlimited)",
+		Value: eth.DefaultConfig.TxPool.ElectionSlots,
+	}
+	TxPoolDelegationSlotsFlag = cli.Uint64Flag{
+		Name:  "txpool.delegationslots",
+		Usage: "Maximum number of transaction slots for remote delegation transactions (0 = unlimited)",
+		Value: eth.DefaultConfig.TxPool.DelegationSlots,
+	}
 	TxPoolLifetimeFlag = cli.DurationFlag{
 		Name:  "txpool.lifetime",
 		Usage: "Maximum amount of time non-executable transaction are queued",
@@ -935,6 +951,15 @@ func setTxPool(ctx *cli.Context, cfg *core.TxPoolConfig) {
 	if ctx.GlobalIsSet(TxPoolGlobalQueueFlag.Name) {
 		cfg.GlobalQueue = ctx.GlobalUint64(TxPoolGlobalQueueFlag.Name)
 	}
+	if ctx.GlobalIsSet(TxPoolExtendedSlotsFlag.Name) {
+		cfg.ExtendedSlots = ctx.GlobalUint64(TxPoolExtendedSlotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolElectionSlotsFlag.Name) {
+		cfg.ElectionSlots = ctx.GlobalUint64(TxPoolElectionSlotsFlag.Name)
+	}
+	if ctx.GlobalIsSet(TxPoolDelegationSlotsFlag.Name) {
+		cfg.DelegationSlots = ctx.GlobalUint64(TxPoolDelegationSlotsFlag.Name)
+	}
 	if ctx.GlobalIsSet(TxPoolLifetimeFlag.Name) {
 		cfg.Lifetime = ctx.GlobalDuration(TxPoolLifetimeFlag.Name)
 	}
@@ -1223,6 +1248,8 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	var engine consensus.Engine
 	if config.Clique != nil {
 		engine = clique.New(config.Clique, chainDb)
+	} else if config.Ptcpos != nil {
+		engine = ptcpos.New(config.Ptcpos)
 	} else {
 		engine = ethash.NewFaker()
 		if !ctx.GlobalBool(FakePoWFlag.Name) {
@@ -1252,6 +1279,9 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 	if err != nil {
 		Fatalf("Can't create BlockChain: %v", err)
 	}
+	if engine, ok := engine.(*ptcpos.Ptcpos); ok {
+		engine.SetChain(chain)
+	}
 	return chain, chainDb
 }
 
@@ -105,3 +105,28 @@ type PoW interface {
 	// Hashrate returns the current mining hashrate of a PoW consensus engine.
 	Hashrate() float64
 }
+
+// Scheduled is a consensus engine whose sealers follow a schedule derived from
+// the chain state. The schedule of a block can only be resolved once its parent
+// is imported, so the check is left to block validation.
+type Scheduled interface {
+	Engine
+
+	// VerifySchedule checks whether the header was sealed by the account the
+	// schedule assigns its height to.
+	VerifySchedule(chain ChainReader, header *types.Header) error
+}
+
+// Certified is a consensus engine whose headers carry the votes of the verifier
+// committee approving the transactions of the block, so blocks can be checked
+// against the committee in charge of their height.
+type Certified interface {
+	Engine
+
+	// BatchVotes returns the committee votes the header carries, nil if none.
+	BatchVotes(header *types.Header) ([]types.BatchVote, error)
+
+	// Certify sets the committee votes approving the transactions of the block
+	// in a header being assembled. It has to be called after Prepare.
+	Certify(header *types.Header, votes []types.BatchVote) error
+}
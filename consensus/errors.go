@@ -34,4 +34,9 @@ var (
 	// ErrInvalidNumber is returned if a block's number doesn't equal it's parent's
 	// plus one.
 	ErrInvalidNumber = errors.New("invalid block number")
+
+	// ErrUnknownSchedule is returned when the seal of a header cannot be checked
+	// yet, as the sealers scheduled for it depend on ancestors that are not known
+	// yet. The check has to be repeated once they are.
+	ErrUnknownSchedule = errors.New("unknown seal schedule")
 )
@@ -515,7 +515,18 @@ func (ethash *Ethash) Prepare(chain consensus.ChainReader, header *types.Header)
 // setting the final state and assembling the block.
 func (ethash *Ethash) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
 	// Accumulate any block and uncle rewards and commit the final state root
-	accumulateRewards(chain.Config(), state, header, uncles)
+	ethash.lock.Lock()
+	distributor := ethash.reward
+	ethash.lock.Unlock()
+
+	if distributor != nil {
+		if _, err := distributor.Distribute(state, header, txs, receipts); err != nil {
+			return nil, err
+		}
+		accumulateUncleRewards(state, header, uncles, distributor.BlockReward())
+	} else {
+		accumulateRewards(chain.Config(), state, header, uncles)
+	}
 	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
 
 	// Header seems complete, assemble into a block and return
@@ -552,3 +563,17 @@ func accumulateRewards(config *params.ChainConfig, state *state.StateDB, header
 	}
 	state.AddBalance(header.Coinbase, reward)
 }
+
+// accumulateUncleRewards credits the coinbase of each included uncle with its
+// part of the given block reward, as accumulateRewards does. The nephew bonus
+// is left out, the author is paid by the reward distribution.
+func accumulateUncleRewards(state *state.StateDB, header *types.Header, uncles []*types.Header, blockReward *big.Int) {
+	r := new(big.Int)
+	for _, uncle := range uncles {
+		r.Add(uncle.Number, big8)
+		r.Sub(r, header.Number)
+		r.Mul(r, blockReward)
+		r.Div(r, big8)
+		state.AddBalance(uncle.Coinbase, r)
+	}
+}
@@ -34,6 +34,7 @@ import (
 
 	mmap "github.com/edsrzf/mmap-go"
 	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/reward"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/rpc"
@@ -403,6 +404,8 @@ type Ethash struct {
 	update   chan struct{} // Notification channel to update mining parameters
 	hashrate metrics.Meter // Meter tracking the average hashrate
 
+	reward *reward.Reward // PTC reward distribution, nil for coinbase-only rewards
+
 	// The fields below are hooks for testing
 	shared    *Ethash       // Shared PoW verifier to avoid cache regeneration
 	fakeFail  uint64        // Block number which fails PoW check even in fake mode
@@ -559,6 +562,15 @@ func (ethash *Ethash) SetThreads(threads int) {
 	}
 }
 
+// SetReward replaces the coinbase-only block rewards with the distribution of
+// the PTC hierarchy. Passing nil restores the plain ethash rewards.
+func (ethash *Ethash) SetReward(r *reward.Reward) {
+	ethash.lock.Lock()
+	defer ethash.lock.Unlock()
+
+	ethash.reward = r
+}
+
 // Hashrate implements PoW, returning the measured rate of the search invocations
 // per second over the last minute.
 func (ethash *Ethash) Hashrate() float64 {
@@ -0,0 +1,17 @@
+package ptcpos
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// API is a user facing RPC API to query the sealing schedule of the PTC
+// proof-of-stake scheme.
+type API struct {
+	ptcpos *Ptcpos
+}
+
+// GetSealer returns the account scheduled to seal the given height.
+func (api *API) GetSealer(number hexutil.Uint64) (common.Address, error) {
+	return api.ptcpos.Sealer(uint64(number))
+}
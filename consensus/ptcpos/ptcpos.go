@@ -0,0 +1,669 @@
+// Package ptcpos implements the proof-of-stake consensus engine of the PTC
+// hierarchy. Blocks are sealed by signature of the master miner the election
+// schedule assigns their height to, instead of by proof-of-work.
+package ptcpos
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/misc"
+	"github.com/ethereum/go-ethereum/consensus/reward"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/crypto/sha3"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	inmemorySignatures = 4096 // Number of recent block signatures to keep in memory
+
+	extraVanity = 32 // Fixed number of extra-data prefix bytes reserved for sealer vanity
+	extraSeal   = 65 // Fixed number of extra-data suffix bytes reserved for sealer seal
+)
+
+var (
+	uncleHash = types.CalcUncleHash(nil) // Always Keccak256(RLP([])) as uncles are meaningless outside of PoW.
+
+	sealDifficulty = big.NewInt(1) // Difficulty of every block, a single miner is scheduled per height
+)
+
+// Various error messages to mark blocks invalid. These should be private to
+// prevent engine specific errors from being referenced in the remainder of the
+// codebase, inherently breaking if the engine is swapped out. Please put common
+// error types into the consensus package.
+var (
+	// errUnknownBlock is returned when the sealer is requested for a block that
+	// is not part of the local blockchain.
+	errUnknownBlock = errors.New("unknown block")
+
+	// errMissingVanity is returned if a block's extra-data section is shorter than
+	// 32 bytes, which is required to store the sealer vanity.
+	errMissingVanity = errors.New("extra-data 32 byte vanity prefix missing")
+
+	// errMissingSignature is returned if a block's extra-data section doesn't seem
+	// to contain a 65 byte secp256k1 signature.
+	errMissingSignature = errors.New("extra-data 65 byte suffix signature missing")
+
+	// errExtraData is returned if a block's extra-data section holds anything
+	// besides the vanity, the committee votes and the signature.
+	errExtraData = errors.New("extra-data beyond vanity, votes and signature")
+
+	// errInvalidMixDigest is returned if a block's mix digest is non-zero.
+	errInvalidMixDigest = errors.New("non-zero mix digest")
+
+	// errInvalidUncleHash is returned if a block contains an non-empty uncle list.
+	errInvalidUncleHash = errors.New("non empty uncle hash")
+
+	// errInvalidDifficulty is returned if the difficulty of a block is not 1.
+	errInvalidDifficulty = errors.New("invalid difficulty")
+
+	// ErrInvalidTimestamp is returned if the timestamp of a block is lower than
+	// the previous block's timestamp + the minimum block period.
+	ErrInvalidTimestamp = errors.New("invalid timestamp")
+
+	// errUnauthorized is returned if a header is signed by another account than
+	// the master miner scheduled for its height.
+	errUnauthorized = errors.New("unauthorized")
+
+	// errNoChain is returned if the schedule is resolved before the engine is
+	// attached to a chain.
+	errNoChain = errors.New("no chain attached")
+
+	// errNoMasterMiner is returned if no master miner can seal a height.
+	errNoMasterMiner = errors.New("no master miner scheduled")
+
+	// errUncertifiedBlock is returned if a block is attempted to be sealed with
+	// transactions outside the batches the verifier committee approved.
+	errUncertifiedBlock = errors.New("transactions not approved by verifier committee")
+)
+
+// SignerFn is a signer callback function to request a hash to be signed by a
+// backing account.
+type SignerFn func(accounts.Account, []byte) ([]byte, error)
+
+// Chain provides the PTC hierarchy the engine seals and finalizes blocks by.
+type Chain interface {
+	// MasterMiners returns the master miners in charge of a height on the
+	// canonical chain and the seed of their sealing rotation.
+	MasterMiners(number uint64) ([]election.NodeInfo, common.Hash, error)
+
+	// MasterMinersAt returns the master miners in charge of a height on the chain
+	// through the given ancestor and the seed of their sealing rotation.
+	MasterMinersAt(ancestor *types.Header, number uint64) ([]election.NodeInfo, common.Hash, error)
+
+	// Topology returns the main node lists the given header has to carry.
+	Topology(header *types.Header) (*types.Topology, error)
+
+	// Unbondings returns the unbonded stake the given header has to pay back.
+	Unbondings(header *types.Header) ([]*types.Unbonding, error)
+}
+
+// sigHash returns the hash which is used as input for the sealer signature. It
+// is the hash of the entire header apart from the 65 byte signature contained
+// at the end of the extra data.
+//
+// Note, the method requires the extra data to be at least 65 bytes, otherwise it
+// panics. This is done to avoid accidentally using both forms (signature present
+// or not), which could be abused to produce different hashes for the same header.
+func sigHash(header *types.Header) (hash common.Hash) {
+	hasher := sha3.NewKeccak256()
+
+	fields := []interface{}{
+		header.ParentHash,
+		header.UncleHash,
+		header.Coinbase,
+		header.Root,
+		header.TxHash,
+		header.ReceiptHash,
+		header.Bloom,
+		header.Difficulty,
+		header.Number,
+		header.GasLimit,
+		header.GasUsed,
+		header.Time,
+		header.Extra[:len(header.Extra)-extraSeal], // Yes, this will panic if extra is too short
+	}
+	fields = append(fields, header.NodeListFields()...)
+	rlp.Encode(hasher, append(fields, header.MixDigest, header.Nonce))
+	hasher.Sum(hash[:0])
+	return hash
+}
+
+// headerVotes decodes the committee votes a header carries between the vanity
+// and the seal of its extra-data, nil if it carries none.
+func headerVotes(header *types.Header) ([]types.BatchVote, error) {
+	if len(header.Extra) <= extraVanity+extraSeal {
+		return nil, nil
+	}
+	var votes []types.BatchVote
+	if err := rlp.DecodeBytes(header.Extra[extraVanity:len(header.Extra)-extraSeal], &votes); err != nil || len(votes) == 0 {
+		return nil, errExtraData
+	}
+	return votes, nil
+}
+
+// ecrecover extracts the Ethereum account address from a signed header.
+func ecrecover(header *types.Header, sigcache *lru.ARCCache) (common.Address, error) {
+	// If the signature's already cached, return that
+	hash := header.Hash()
+	if address, known := sigcache.Get(hash); known {
+		return address.(common.Address), nil
+	}
+	// Retrieve the signature from the header extra-data
+	if len(header.Extra) < extraSeal {
+		return common.Address{}, errMissingSignature
+	}
+	signature := header.Extra[len(header.Extra)-extraSeal:]
+
+	// Recover the public key and the Ethereum address
+	pubkey, err := crypto.Ecrecover(sigHash(header).Bytes(), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	var signer common.Address
+	copy(signer[:], crypto.Keccak256(pubkey[1:])[12:])
+
+	sigcache.Add(hash, signer)
+	return signer, nil
+}
+
+// Ptcpos is the proof-of-stake consensus engine of the PTC hierarchy.
+type Ptcpos struct {
+	config     *params.PtcposConfig // Consensus engine configuration parameters
+	signatures *lru.ARCCache        // Signatures of recent blocks to speed up mining
+
+	chain   Chain            // PTC hierarchy the schedule is derived from
+	reward  *reward.Reward   // Block reward distribution, nil for no rewards
+	batches core.BatchReader // Transaction batches approved by the verifier committee
+
+	signer common.Address // Ethereum address of the signing key
+	signFn SignerFn       // Signer function to authorize hashes with
+	lock   sync.RWMutex   // Protects the fields above
+}
+
+// New creates a PTC proof-of-stake consensus engine. The engine has to be
+// attached to its chain with SetChain before verifying or sealing any block.
+func New(config *params.PtcposConfig) *Ptcpos {
+	signatures, _ := lru.NewARC(inmemorySignatures)
+
+	return &Ptcpos{
+		config:     config,
+		signatures: signatures,
+	}
+}
+
+// SetChain attaches the engine to the chain its schedule is derived from.
+func (p *Ptcpos) SetChain(chain Chain) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.chain = chain
+}
+
+// SetReward distributes the block rewards among the PTC topology.
+func (p *Ptcpos) SetReward(r *reward.Reward) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.reward = r
+}
+
+// SetBatches restricts the sealed blocks to the transaction batches approved by
+// the verifier committee.
+func (p *Ptcpos) SetBatches(batches core.BatchReader) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.batches = batches
+}
+
+// Authorize injects a private key into the consensus engine to mint new blocks
+// with.
+func (p *Ptcpos) Authorize(signer common.Address, signFn SignerFn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.signer = signer
+	p.signFn = signFn
+}
+
+// Sealer returns the account scheduled to seal the given height on the canonical
+// chain.
+func (p *Ptcpos) Sealer(number uint64) (common.Address, error) {
+	p.lock.RLock()
+	chain := p.chain
+	p.lock.RUnlock()
+
+	if chain == nil {
+		return common.Address{}, errNoChain
+	}
+	miners, seed, err := chain.MasterMiners(number)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return p.scheduled(miners, seed, number)
+}
+
+// sealerAt returns the account scheduled to seal the given height on the chain
+// through the given ancestor, so blocks of side chains are checked against their
+// own schedule.
+func (p *Ptcpos) sealerAt(ancestor *types.Header, number uint64) (common.Address, error) {
+	p.lock.RLock()
+	chain := p.chain
+	p.lock.RUnlock()
+
+	if chain == nil {
+		return common.Address{}, errNoChain
+	}
+	miners, seed, err := chain.MasterMinersAt(ancestor, number)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return p.scheduled(miners, seed, number)
+}
+
+// scheduled returns the master miner the leader rotation picks out of the miners
+// in charge of a height. Miners without an account can't seal, if none has one
+// the configured boot miners take over.
+func (p *Ptcpos) scheduled(miners []election.NodeInfo, seed common.Hash, number uint64) (common.Address, error) {
+	sealers := make([]election.NodeInfo, 0, len(miners))
+	for _, miner := range miners {
+		if miner.Account != (common.Address{}) {
+			sealers = append(sealers, miner)
+		}
+	}
+	if len(sealers) == 0 {
+		for _, miner := range p.config.Miners {
+			sealers = append(sealers, election.NodeInfo{ID: miner.Hex(), Account: miner})
+		}
+	}
+	sealer, err := core.LeaderOf(sealers, seed, number)
+	if err == core.ErrEmptyCommittee {
+		return common.Address{}, errNoMasterMiner
+	}
+	return sealer.Account, err
+}
+
+// Author implements consensus.Engine, returning the Ethereum address recovered
+// from the signature in the header's extra-data section.
+func (p *Ptcpos) Author(header *types.Header) (common.Address, error) {
+	return ecrecover(header, p.signatures)
+}
+
+// VerifyHeader checks whether a header conforms to the consensus rules.
+func (p *Ptcpos) VerifyHeader(chain consensus.ChainReader, header *types.Header, seal bool) error {
+	return p.verifyHeader(chain, header, nil)
+}
+
+// VerifyHeaders is similar to VerifyHeader, but verifies a batch of headers. The
+// method returns a quit channel to abort the operations and a results channel to
+// retrieve the async verifications (the order is that of the input slice).
+func (p *Ptcpos) VerifyHeaders(chain consensus.ChainReader, headers []*types.Header, seals []bool) (chan<- struct{}, <-chan error) {
+	abort := make(chan struct{})
+	results := make(chan error, len(headers))
+
+	go func() {
+		for i, header := range headers {
+			err := p.verifyHeader(chain, header, headers[:i])
+
+			select {
+			case <-abort:
+				return
+			case results <- err:
+			}
+		}
+	}()
+	return abort, results
+}
+
+// verifyHeader checks whether a header conforms to the consensus rules. The
+// caller may optionally pass in a batch of parents (ascending order) to avoid
+// looking those up from the database.
+func (p *Ptcpos) verifyHeader(chain consensus.ChainReader, header *types.Header, parents []*types.Header) error {
+	if header.Number == nil {
+		return errUnknownBlock
+	}
+	number := header.Number.Uint64()
+
+	// Don't waste time checking blocks from the future
+	if header.Time.Cmp(big.NewInt(time.Now().Unix())) > 0 {
+		return consensus.ErrFutureBlock
+	}
+	// Check that the extra-data contains the vanity, committee votes and signature only
+	if len(header.Extra) < extraVanity {
+		return errMissingVanity
+	}
+	if len(header.Extra) < extraVanity+extraSeal {
+		return errMissingSignature
+	}
+	if _, err := headerVotes(header); err != nil {
+		return err
+	}
+	// Ensure that the mix digest is zero as we don't have fork protection currently
+	if header.MixDigest != (common.Hash{}) {
+		return errInvalidMixDigest
+	}
+	// Ensure that the block doesn't contain any uncles which are meaningless in PoS
+	if header.UncleHash != uncleHash {
+		return errInvalidUncleHash
+	}
+	if number > 0 && (header.Difficulty == nil || header.Difficulty.Cmp(sealDifficulty) != 0) {
+		return errInvalidDifficulty
+	}
+	// If all checks passed, validate any special fields for hard forks
+	if err := misc.VerifyForkHashes(chain.Config(), header, false); err != nil {
+		return err
+	}
+	// The genesis block is the always valid dead-end
+	if number == 0 {
+		return nil
+	}
+	// Ensure that the block's timestamp isn't too close to it's parent
+	var parent *types.Header
+	if len(parents) > 0 {
+		parent = parents[len(parents)-1]
+	} else {
+		parent = chain.GetHeader(header.ParentHash, number-1)
+	}
+	if parent == nil || parent.Number.Uint64() != number-1 || parent.Hash() != header.ParentHash {
+		return consensus.ErrUnknownAncestor
+	}
+	if parent.Time.Uint64()+p.config.Period > header.Time.Uint64() {
+		return ErrInvalidTimestamp
+	}
+	// Resolve the schedule from the first block of the batch, the others may not
+	// be imported yet
+	ancestor := parent
+	if len(parents) > 0 {
+		ancestor = parents[0]
+	}
+	return p.verifySeal(ancestor, header)
+}
+
+// VerifyUncles implements consensus.Engine, always returning an error for any
+// uncles as this consensus mechanism doesn't permit uncles.
+func (p *Ptcpos) VerifyUncles(chain consensus.ChainReader, block *types.Block) error {
+	if len(block.Uncles()) > 0 {
+		return errors.New("uncles not allowed")
+	}
+	return nil
+}
+
+// VerifySeal implements consensus.Engine, checking whether the signature contained
+// in the header satisfies the consensus protocol requirements.
+func (p *Ptcpos) VerifySeal(chain consensus.ChainReader, header *types.Header) error {
+	if header.Number.Uint64() == 0 {
+		return errUnknownBlock
+	}
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	return p.verifySeal(parent, header)
+}
+
+// verifySeal checks that the header is signed and, if the schedule of its height
+// is known already on the chain through the given ancestor, that it is signed by
+// the scheduled master miner. Headers imported in a batch may be scheduled by a
+// broadcast block within the batch, those are checked by VerifySchedule once
+// their parent is imported.
+func (p *Ptcpos) verifySeal(ancestor *types.Header, header *types.Header) error {
+	// Verifying the genesis block is not supported
+	if header.Number.Uint64() == 0 {
+		return errUnknownBlock
+	}
+	signer, err := ecrecover(header, p.signatures)
+	if err != nil {
+		return err
+	}
+	sealer, err := p.sealerAt(ancestor, header.Number.Uint64())
+	if err == core.ErrUnknownCommittee {
+		return consensus.ErrUnknownSchedule
+	}
+	if err != nil {
+		return err
+	}
+	if signer != sealer {
+		return errUnauthorized
+	}
+	return nil
+}
+
+// VerifySchedule implements consensus.Scheduled, checking that the header was
+// sealed by the master miner scheduled for its height on the chain through its
+// parent.
+func (p *Ptcpos) VerifySchedule(chain consensus.ChainReader, header *types.Header) error {
+	if header.Number.Uint64() == 0 {
+		return errUnknownBlock
+	}
+	parent := chain.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	signer, err := ecrecover(header, p.signatures)
+	if err != nil {
+		return err
+	}
+	sealer, err := p.sealerAt(parent, header.Number.Uint64())
+	if err != nil {
+		return err
+	}
+	if signer != sealer {
+		return errUnauthorized
+	}
+	return nil
+}
+
+// BatchVotes implements consensus.Certified, returning the committee votes the
+// header carries between the vanity and the seal of its extra-data.
+func (p *Ptcpos) BatchVotes(header *types.Header) ([]types.BatchVote, error) {
+	if len(header.Extra) < extraVanity+extraSeal {
+		return nil, errMissingSignature
+	}
+	return headerVotes(header)
+}
+
+// Certify implements consensus.Certified, placing the committee votes approving
+// the transactions of the block between the vanity and the seal of the header
+// prepared for sealing.
+func (p *Ptcpos) Certify(header *types.Header, votes []types.BatchVote) error {
+	if len(header.Extra) < extraVanity+extraSeal {
+		return errMissingSignature
+	}
+	extra := append([]byte{}, header.Extra[:extraVanity]...)
+	if len(votes) > 0 {
+		enc, err := rlp.EncodeToBytes(votes)
+		if err != nil {
+			return err
+		}
+		extra = append(extra, enc...)
+	}
+	header.Extra = append(extra, make([]byte, extraSeal)...)
+	return nil
+}
+
+// Prepare implements consensus.Engine, preparing all the consensus fields of the
+// header for running the transactions on top.
+func (p *Ptcpos) Prepare(chain consensus.ChainReader, header *types.Header) error {
+	header.Nonce = types.BlockNonce{}
+	header.Difficulty = new(big.Int).Set(sealDifficulty)
+
+	// Ensure the extra data has all it's components
+	if len(header.Extra) < extraVanity {
+		header.Extra = append(header.Extra, bytes.Repeat([]byte{0x00}, extraVanity-len(header.Extra))...)
+	}
+	header.Extra = append(header.Extra[:extraVanity], make([]byte, extraSeal)...)
+
+	// Mix digest is reserved for now, set to empty
+	header.MixDigest = common.Hash{}
+
+	// Ensure the timestamp has the correct delay
+	number := header.Number.Uint64()
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	header.Time = new(big.Int).Add(parent.Time, new(big.Int).SetUint64(p.config.Period))
+	if header.Time.Int64() < time.Now().Unix() {
+		header.Time = big.NewInt(time.Now().Unix())
+	}
+	return nil
+}
+
+// Finalize implements consensus.Engine, applying the elections, deposits and
+// rewards of the block and returning the final block. Broadcast blocks commit to
+// the main node lists elected for the next period, refund the deposits of the
+// nodes exiting with them and pay back the delegated stake unbonded by then.
+func (p *Ptcpos) Finalize(chain consensus.ChainReader, header *types.Header, state *state.StateDB, txs []*types.Transaction, uncles []*types.Header, receipts []*types.Receipt) (*types.Block, error) {
+	p.lock.RLock()
+	hierarchy, distributor := p.chain, p.reward
+	p.lock.RUnlock()
+
+	if hierarchy == nil {
+		return nil, errNoChain
+	}
+	if params.IsBroadcastNumber(header.Number.Uint64()) {
+		topology, err := hierarchy.Topology(header)
+		if err != nil {
+			return nil, err
+		}
+		if chain.Config().IsNodeList(header.Number) {
+			header.TopologyRoot = topology.Hash()
+		}
+		refundDeposits(state, topology.OfflineList)
+
+		unbondings, err := hierarchy.Unbondings(header)
+		if err != nil {
+			return nil, err
+		}
+		releaseUnbondings(state, unbondings)
+	}
+	if distributor != nil {
+		if _, err := distributor.Distribute(state, header, txs, receipts); err != nil {
+			return nil, err
+		}
+	}
+	header.Root = state.IntermediateRoot(chain.Config().IsEIP158(header.Number))
+	header.UncleHash = types.CalcUncleHash(nil)
+
+	// Assemble and return the final block for sealing
+	return types.NewBlock(header, txs, nil, receipts), nil
+}
+
+// refundDeposits pays the deposits of the exiting nodes back out of the deposit
+// account. Candidate values hold the deposits in Shannon.
+func refundDeposits(state *state.StateDB, exits []election.NodeInfo) {
+	deposits := common.HexToAddress(params.HypothecatedAccount)
+	for _, node := range exits {
+		if node.Account == (common.Address{}) || node.Value == 0 {
+			continue
+		}
+		amount := new(big.Int).Mul(new(big.Int).SetUint64(node.Value), big.NewInt(params.Shannon))
+		if balance := state.GetBalance(deposits); balance.Cmp(amount) < 0 {
+			log.Warn("Deposit account short of refund", "node", node.ID, "refund", amount, "balance", balance)
+			amount = balance
+		}
+		state.SubBalance(deposits, amount)
+		state.AddBalance(node.Account, amount)
+	}
+}
+
+// releaseUnbondings pays unbonded stake back to its delegators out of the
+// deposit account, capped by its balance like the deposit refunds.
+func releaseUnbondings(state *state.StateDB, unbondings []*types.Unbonding) {
+	deposits := common.HexToAddress(params.HypothecatedAccount)
+	for _, unbonding := range unbondings {
+		amount := unbonding.Amount
+		if balance := state.GetBalance(deposits); balance.Cmp(amount) < 0 {
+			log.Warn("Deposit account short of unbonding", "delegator", unbonding.Delegator, "node", unbonding.NodeID, "amount", amount, "balance", balance)
+			amount = balance
+		}
+		state.SubBalance(deposits, amount)
+		state.AddBalance(unbonding.Delegator, amount)
+	}
+}
+
+// Seal implements consensus.Engine, attempting to create a sealed block using
+// the local signing credentials. Only the scheduled master miner seals, and only
+// blocks packing the batches the verifier committee approved for the height.
+func (p *Ptcpos) Seal(chain consensus.ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error) {
+	header := block.Header()
+
+	// Sealing the genesis block is not supported
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil, errUnknownBlock
+	}
+	// Don't hold the signer fields for the entire sealing procedure
+	p.lock.RLock()
+	signer, signFn, batches := p.signer, p.signFn, p.batches
+	p.lock.RUnlock()
+
+	parent := chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	sealer, err := p.sealerAt(parent, number)
+	if err != nil {
+		return nil, err
+	}
+	if sealer != signer {
+		log.Trace("Not scheduled to seal, waiting for others", "number", number, "sealer", sealer)
+		<-stop
+		return nil, nil
+	}
+	if batches != nil {
+		if approved, ok := batches.ApprovedTxs(number); ok {
+			for _, tx := range block.Transactions() {
+				if _, ok := approved[tx.Hash()]; !ok {
+					return nil, errUncertifiedBlock
+				}
+			}
+		}
+	}
+	// Sweet, the protocol permits us to sign the block, wait for our time
+	delay := time.Unix(header.Time.Int64(), 0).Sub(time.Now()) // nolint: gosimple
+	log.Trace("Waiting for slot to sign and propagate", "delay", common.PrettyDuration(delay))
+
+	select {
+	case <-stop:
+		return nil, nil
+	case <-time.After(delay):
+	}
+	// Sign all the things!
+	sighash, err := signFn(accounts.Account{Address: signer}, sigHash(header).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	copy(header.Extra[len(header.Extra)-extraSeal:], sighash)
+
+	return block.WithSeal(header), nil
+}
+
+// CalcDifficulty implements consensus.Engine, returning the fixed difficulty of
+// the blocks sealed by the scheduled master miner.
+func (p *Ptcpos) CalcDifficulty(chain consensus.ChainReader, time uint64, parent *types.Header) *big.Int {
+	return new(big.Int).Set(sealDifficulty)
+}
+
+// APIs implements consensus.Engine, returning the user facing RPC API to query
+// the sealing schedule.
+func (p *Ptcpos) APIs(chain consensus.ChainReader) []rpc.API {
+	return []rpc.API{{
+		Namespace: "ptcpos",
+		Version:   "1.0",
+		Service:   &API{ptcpos: p},
+		Public:    true,
+	}}
+}
@@ -0,0 +1,228 @@
+package ptcpos
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testChain schedules fixed master miners, or none if unknown is set, on top of
+// a single genesis header.
+type testChain struct {
+	genesis *types.Header
+	miners  []election.NodeInfo
+	seed    common.Hash
+	unknown bool
+}
+
+func (c *testChain) MasterMiners(number uint64) ([]election.NodeInfo, common.Hash, error) {
+	return c.MasterMinersAt(c.genesis, number)
+}
+
+func (c *testChain) MasterMinersAt(ancestor *types.Header, number uint64) ([]election.NodeInfo, common.Hash, error) {
+	if c.unknown {
+		return nil, common.Hash{}, core.ErrUnknownCommittee
+	}
+	return c.miners, c.seed, nil
+}
+
+func (c *testChain) Config() *params.ChainConfig               { return params.AllCliqueProtocolChanges }
+func (c *testChain) CurrentHeader() *types.Header              { return c.genesis }
+func (c *testChain) GetBlock(common.Hash, uint64) *types.Block { return nil }
+
+func (c *testChain) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if hash != c.genesis.Hash() || number != 0 {
+		return nil
+	}
+	return c.genesis
+}
+
+func (c *testChain) GetHeaderByNumber(number uint64) *types.Header {
+	return c.GetHeader(c.genesis.Hash(), number)
+}
+
+func (c *testChain) GetHeaderByHash(hash common.Hash) *types.Header {
+	return c.GetHeader(hash, 0)
+}
+
+func (c *testChain) Topology(header *types.Header) (*types.Topology, error) {
+	return new(types.Topology), nil
+}
+
+func (c *testChain) Unbondings(header *types.Header) ([]*types.Unbonding, error) {
+	return nil, nil
+}
+
+// signerFn signs hashes with the given key.
+func signerFn(key *ecdsa.PrivateKey) SignerFn {
+	return func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, key)
+	}
+}
+
+// Tests that only the master miner scheduled for a height seals it and that
+// headers sealed by anyone else fail verification.
+func TestSealSchedule(t *testing.T) {
+	var (
+		key1, _ = crypto.GenerateKey()
+		key2, _ = crypto.GenerateKey()
+		keys    = map[common.Address]*ecdsa.PrivateKey{
+			crypto.PubkeyToAddress(key1.PublicKey): key1,
+			crypto.PubkeyToAddress(key2.PublicKey): key2,
+		}
+		chain  = &testChain{genesis: &types.Header{Number: big.NewInt(0)}, seed: common.Hash{0x01}}
+		engine = New(&params.PtcposConfig{Miners: []common.Address{
+			crypto.PubkeyToAddress(key1.PublicKey),
+			crypto.PubkeyToAddress(key2.PublicKey),
+		}})
+	)
+	if _, err := engine.Sealer(1); err != errNoChain {
+		t.Fatalf("detached engine: error mismatch: have %v, want %v", err, errNoChain)
+	}
+	engine.SetChain(chain)
+
+	sealer, err := engine.Sealer(1)
+	if err != nil {
+		t.Fatalf("failed to schedule sealer: %v", err)
+	}
+	if _, ok := keys[sealer]; !ok {
+		t.Fatalf("sealer %x not among the boot miners", sealer)
+	}
+	var other common.Address
+	for addr := range keys {
+		if addr != sealer {
+			other = addr
+		}
+	}
+	seal := func(signer common.Address) *types.Header {
+		header := &types.Header{
+			ParentHash: chain.genesis.Hash(),
+			Number:     big.NewInt(1),
+			Difficulty: big.NewInt(1),
+			Time:       big.NewInt(1),
+			UncleHash:  uncleHash,
+			Extra:      make([]byte, extraVanity+extraSeal),
+		}
+		sig, err := signerFn(keys[signer])(accounts.Account{Address: signer}, sigHash(header).Bytes())
+		if err != nil {
+			t.Fatalf("failed to sign header: %v", err)
+		}
+		copy(header.Extra[len(header.Extra)-extraSeal:], sig)
+		return header
+	}
+	// The scheduled sealer seals through the engine
+	engine.Authorize(sealer, signerFn(keys[sealer]))
+	block, err := engine.Seal(chain, types.NewBlockWithHeader(seal(sealer)), make(chan struct{}))
+	if err != nil {
+		t.Fatalf("failed to seal block: %v", err)
+	}
+	if author, _ := engine.Author(block.Header()); author != sealer {
+		t.Errorf("author mismatch: have %x, want %x", author, sealer)
+	}
+	if err := engine.VerifySchedule(chain, block.Header()); err != nil {
+		t.Errorf("scheduled seal rejected: %v", err)
+	}
+	// Anyone else is rejected, unless the schedule is not known yet
+	header := seal(other)
+	if err := engine.VerifySchedule(chain, header); err != errUnauthorized {
+		t.Errorf("unscheduled seal: error mismatch: have %v, want %v", err, errUnauthorized)
+	}
+	chain.unknown = true
+	if err := engine.verifySeal(chain.genesis, header); err != consensus.ErrUnknownSchedule {
+		t.Errorf("unknown schedule: error mismatch: have %v, want %v", err, consensus.ErrUnknownSchedule)
+	}
+	if err := engine.VerifySchedule(chain, header); err != core.ErrUnknownCommittee {
+		t.Errorf("unknown schedule: error mismatch: have %v, want %v", err, core.ErrUnknownCommittee)
+	}
+	chain.unknown = false
+
+	// Elected master miners with accounts take over from the boot miners
+	chain.miners = []election.NodeInfo{{ID: "01"}, {ID: "02", Account: other}}
+	if sealer, err := engine.Sealer(1); err != nil || sealer != other {
+		t.Errorf("elected sealer mismatch: have %x (%v), want %x", sealer, err, other)
+	}
+}
+
+// Tests that the committee votes set in a prepared header are carried between
+// the vanity and the seal, and that anything else there is refused.
+func TestCertify(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	vote := types.BatchVote{Result: true}
+	if err := vote.Sign(1, common.Hash{0x01}, key); err != nil {
+		t.Fatalf("failed to sign vote: %v", err)
+	}
+	engine := New(&params.PtcposConfig{})
+	header := &types.Header{Number: big.NewInt(1), Extra: make([]byte, extraVanity+extraSeal)}
+	if votes, err := engine.BatchVotes(header); err != nil || votes != nil {
+		t.Fatalf("uncertified header: have votes %v (%v)", votes, err)
+	}
+	if err := engine.Certify(header, []types.BatchVote{vote}); err != nil {
+		t.Fatalf("failed to certify header: %v", err)
+	}
+	votes, err := engine.BatchVotes(header)
+	if err != nil || len(votes) != 1 || votes[0].Validate(1, common.Hash{0x01}) != nil {
+		t.Fatalf("carried votes mismatch: have %v (%v)", votes, err)
+	}
+	header.Extra = append(make([]byte, extraVanity), append([]byte{0x01, 0x02}, make([]byte, extraSeal)...)...)
+	if _, err := engine.BatchVotes(header); err != errExtraData {
+		t.Errorf("garbage extra-data: error mismatch: have %v, want %v", err, errExtraData)
+	}
+}
+
+// Tests that the deposits of exiting nodes are refunded out of the deposit
+// account, capped by its balance.
+func TestRefundDeposits(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+
+	deposits := common.HexToAddress(params.HypothecatedAccount)
+	statedb.AddBalance(deposits, big.NewInt(3*params.Shannon))
+
+	refundDeposits(statedb, []election.NodeInfo{
+		{ID: "01", Account: common.Address{0x01}, Value: 2},
+		{ID: "02", Value: 5},
+		{ID: "03", Account: common.Address{0x03}, Value: 2},
+	})
+	if balance := statedb.GetBalance(common.Address{0x01}); balance.Cmp(big.NewInt(2*params.Shannon)) != 0 {
+		t.Errorf("full refund mismatch: have %v, want %v", balance, 2*params.Shannon)
+	}
+	if balance := statedb.GetBalance(common.Address{0x03}); balance.Cmp(big.NewInt(params.Shannon)) != 0 {
+		t.Errorf("capped refund mismatch: have %v, want %v", balance, params.Shannon)
+	}
+	if balance := statedb.GetBalance(deposits); balance.Sign() != 0 {
+		t.Errorf("deposit account not drained: %v", balance)
+	}
+}
+
+// Tests that unbonded stake is paid back to its delegators out of the deposit
+// account, capped by its balance.
+func TestReleaseUnbondings(t *testing.T) {
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+
+	deposits := common.HexToAddress(params.HypothecatedAccount)
+	statedb.AddBalance(deposits, big.NewInt(3000))
+
+	releaseUnbondings(statedb, []*types.Unbonding{
+		{Delegator: common.Address{0x01}, NodeID: "01", Amount: big.NewInt(2000), Release: 30},
+		{Delegator: common.Address{0x02}, NodeID: "01", Amount: big.NewInt(2000), Release: 30},
+	})
+	if balance := statedb.GetBalance(common.Address{0x01}); balance.Cmp(big.NewInt(2000)) != 0 {
+		t.Errorf("full release mismatch: have %v, want 2000", balance)
+	}
+	if balance := statedb.GetBalance(common.Address{0x02}); balance.Cmp(big.NewInt(1000)) != 0 {
+		t.Errorf("capped release mismatch: have %v, want 1000", balance)
+	}
+	if balance := statedb.GetBalance(deposits); balance.Sign() != 0 {
+		t.Errorf("deposit account not drained: %v", balance)
+	}
+}
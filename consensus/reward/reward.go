@@ -0,0 +1,276 @@
+// Package reward implements the block reward distribution of the PTC
+// hierarchy. Every block mints a static reward which, together with the fees
+// of the included transactions, is split between the master miners, master
+// validators, backups and candidates of the topology in charge according to
+// the configured rates and the deposits the nodes hold.
+package reward
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// errInvalidRates is returned if the configured group rates exceed the
+	// whole payout of a block.
+	errInvalidRates = errors.New("reward rates exceed the rate base")
+
+	// errInvalidBlockReward is returned if the configured static block reward
+	// is missing or negative.
+	errInvalidBlockReward = errors.New("invalid static block reward")
+
+	// errNoTopology is returned if no topology reader is given.
+	errNoTopology = errors.New("no reward topology")
+
+	// errReceiptMismatch is returned if the number of receipts does not match
+	// the number of transactions the fees should be collected from.
+	errReceiptMismatch = errors.New("transaction and receipt count mismatch")
+
+	rateBase = big.NewInt(params.RewardRateBase)
+)
+
+// Role identifies why an account was credited in a block.
+type Role string
+
+const (
+	RoleAuthor          Role = "author"          // Block author, receives the undistributed remainder
+	RoleMasterMiner     Role = "masterMiner"     // Elected master miner
+	RoleMasterValidator Role = "masterValidator" // Elected master validator
+	RoleBackup          Role = "backup"          // Backup miner or validator
+	RoleCandidate       Role = "candidate"       // Candidate node waiting for substitution
+	RoleDelegator       Role = "delegator"       // Deposit holder backing one of the nodes above
+)
+
+// Topology is the set of nodes taking part in the reward of a block. The nodes
+// are weighted by the deposits they hold, their Value in Shannon.
+type Topology struct {
+	MasterMiners     []election.NodeInfo
+	MasterValidators []election.NodeInfo
+	Backups          []election.NodeInfo
+	Candidates       []election.NodeInfo
+}
+
+// TopologyReader retrieves the topology in charge of a block. It has to be
+// derived from the chain the block extends, so every node pays the same rewards.
+type TopologyReader interface {
+	Topology(header *types.Header) (*Topology, error)
+}
+
+// Delegation is a stake delegated by a deposit holder to a topology node.
+type Delegation struct {
+	Delegator common.Address
+	Amount    *big.Int
+}
+
+// DelegationReader retrieves the stake backing a topology node, used to pass
+// a node's reward through to its deposit holders. Like the topology, it has to
+// be derived from the chain the block extends.
+type DelegationReader interface {
+	// Delegations returns the node's own stake and the stakes delegated to it
+	// as of the parent of the given block.
+	Delegations(header *types.Header, node common.Address) (*big.Int, []Delegation, error)
+}
+
+// Entry is a single credit paid out while finalizing a block.
+type Entry struct {
+	Account common.Address // Account receiving the credit
+	Node    common.Address // Topology node the credit is attributed to
+	Role    Role           // Reason of the credit
+	Amount  *big.Int       // Credited amount in wei
+}
+
+// Reward distributes the block payout among the active topology.
+type Reward struct {
+	config      *params.RewardConfig
+	topology    TopologyReader
+	delegations DelegationReader // Optional, nil disables the delegator pass-through
+}
+
+// New creates a reward distributor with the given rates. The delegation reader
+// may be nil, in which case nodes keep their whole share.
+func New(config *params.RewardConfig, topology TopologyReader, delegations DelegationReader) (*Reward, error) {
+	if config.BlockReward == nil || config.BlockReward.Sign() < 0 {
+		return nil, errInvalidBlockReward
+	}
+	if topology == nil {
+		return nil, errNoTopology
+	}
+	sum := config.MinerRate + config.ValidatorRate + config.BackupRate + config.CandidateRate
+	if sum > params.RewardRateBase || config.CommissionRate > params.RewardRateBase {
+		return nil, errInvalidRates
+	}
+	return &Reward{
+		config:      config,
+		topology:    topology,
+		delegations: delegations,
+	}, nil
+}
+
+// Fees sums up the transaction fees paid in a block. The fees have already
+// been credited to the coinbase while applying the transactions.
+func Fees(txs []*types.Transaction, receipts []*types.Receipt) (*big.Int, error) {
+	if len(txs) != len(receipts) {
+		return nil, errReceiptMismatch
+	}
+	fees := new(big.Int)
+	for i, tx := range txs {
+		fee := new(big.Int).SetUint64(receipts[i].GasUsed)
+		fees.Add(fees, fee.Mul(fee, tx.GasPrice()))
+	}
+	return fees, nil
+}
+
+// BlockReward returns the static reward minted in every block.
+func (r *Reward) BlockReward() *big.Int {
+	return new(big.Int).Set(r.config.BlockReward)
+}
+
+// Calculate computes the credits of a block without touching any state. The
+// result is deterministic for a given header, transaction set and topology,
+// which allows the reward history to be recomputed from the chain.
+func (r *Reward) Calculate(header *types.Header, txs []*types.Transaction, receipts []*types.Receipt) ([]*Entry, error) {
+	fees, err := Fees(txs, receipts)
+	if err != nil {
+		return nil, err
+	}
+	total := new(big.Int).Add(r.config.BlockReward, fees)
+
+	topology, err := r.topology.Topology(header)
+	if err != nil {
+		return nil, err
+	}
+	groups := []struct {
+		role  Role
+		rate  uint64
+		nodes []election.NodeInfo
+	}{
+		{RoleMasterMiner, r.config.MinerRate, topology.MasterMiners},
+		{RoleMasterValidator, r.config.ValidatorRate, topology.MasterValidators},
+		{RoleBackup, r.config.BackupRate, topology.Backups},
+		{RoleCandidate, r.config.CandidateRate, topology.Candidates},
+	}
+	var (
+		entries []*Entry
+		paid    = new(big.Int)
+	)
+	for _, group := range groups {
+		share := new(big.Int).SetUint64(group.rate)
+		share.Mul(share, total)
+		share.Div(share, rateBase)
+
+		split, err := r.split(group.role, share, group.nodes, header)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range split {
+			paid.Add(paid, entry.Amount)
+			entries = append(entries, entry)
+		}
+	}
+	// Rounding dust, empty groups and the unassigned rate go to the author
+	if rest := new(big.Int).Sub(total, paid); rest.Sign() > 0 {
+		entries = append(entries, &Entry{Account: header.Coinbase, Node: header.Coinbase, Role: RoleAuthor, Amount: rest})
+	}
+	return entries, nil
+}
+
+// Distribute moves the fees out of the coinbase, mints the block reward and
+// credits every entry of the block. It returns the applied entries.
+func (r *Reward) Distribute(state *state.StateDB, header *types.Header, txs []*types.Transaction, receipts []*types.Receipt) ([]*Entry, error) {
+	entries, err := r.Calculate(header, txs, receipts)
+	if err != nil {
+		return nil, err
+	}
+	fees, _ := Fees(txs, receipts)
+	state.SubBalance(header.Coinbase, fees)
+
+	for _, entry := range entries {
+		state.AddBalance(entry.Account, entry.Amount)
+	}
+	return entries, nil
+}
+
+// split divides a group share among its nodes proportionally to their deposits,
+// falling back to an even split if no node holds any. Nodes without an account
+// can't be paid, their part goes to the author.
+func (r *Reward) split(role Role, share *big.Int, nodes []election.NodeInfo, header *types.Header) ([]*Entry, error) {
+	if len(nodes) == 0 || share.Sign() == 0 {
+		return nil, nil
+	}
+	weights := new(big.Int)
+	for _, node := range nodes {
+		weights.Add(weights, new(big.Int).SetUint64(node.Value))
+	}
+	var entries []*Entry
+	for _, node := range nodes {
+		if node.Account == (common.Address{}) {
+			continue
+		}
+		amount := new(big.Int)
+		if weights.Sign() == 0 {
+			amount.Div(share, big.NewInt(int64(len(nodes))))
+		} else {
+			amount.Mul(share, new(big.Int).SetUint64(node.Value))
+			amount.Div(amount, weights)
+		}
+		if amount.Sign() == 0 {
+			continue
+		}
+		paid, err := r.passThrough(role, node.Account, amount, header)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, paid...)
+	}
+	return entries, nil
+}
+
+// passThrough pays a node's amount, sharing everything above the commission
+// with the deposit holders backing the node, pro rata to their stake.
+func (r *Reward) passThrough(role Role, node common.Address, amount *big.Int, header *types.Header) ([]*Entry, error) {
+	if r.delegations == nil {
+		return []*Entry{{Account: node, Node: node, Role: role, Amount: amount}}, nil
+	}
+	own, delegations, err := r.delegations.Delegations(header, node)
+	if err != nil {
+		return nil, err
+	}
+	if len(delegations) == 0 {
+		return []*Entry{{Account: node, Node: node, Role: role, Amount: amount}}, nil
+	}
+	stake := new(big.Int)
+	if own != nil {
+		stake.Set(own)
+	}
+	for _, d := range delegations {
+		stake.Add(stake, d.Amount)
+	}
+	if stake.Sign() == 0 {
+		return []*Entry{{Account: node, Node: node, Role: role, Amount: amount}}, nil
+	}
+	commission := new(big.Int).SetUint64(r.config.CommissionRate)
+	commission.Mul(commission, amount)
+	commission.Div(commission, rateBase)
+
+	shared := new(big.Int).Sub(amount, commission)
+	kept := new(big.Int).Set(amount)
+
+	var entries []*Entry
+	for _, d := range delegations {
+		part := new(big.Int).Mul(shared, d.Amount)
+		part.Div(part, stake)
+		if part.Sign() == 0 {
+			continue
+		}
+		kept.Sub(kept, part)
+		entries = append(entries, &Entry{Account: d.Delegator, Node: node, Role: RoleDelegator, Amount: part})
+	}
+	// The node keeps its commission, its own stake's part and the rounding dust
+	return append([]*Entry{{Account: node, Node: node, Role: role, Amount: kept}}, entries...), nil
+}
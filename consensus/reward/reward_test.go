@@ -0,0 +1,172 @@
+package reward
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+type staticTopology struct{ topology *Topology }
+
+func (s staticTopology) Topology(header *types.Header) (*Topology, error) { return s.topology, nil }
+
+type staticDelegations map[common.Address][]Delegation
+
+func (s staticDelegations) Delegations(header *types.Header, node common.Address) (*big.Int, []Delegation, error) {
+	return big.NewInt(100), s[node], nil
+}
+
+var errDelegations = errors.New("delegations not available")
+
+type failingDelegations struct{}
+
+func (failingDelegations) Delegations(header *types.Header, node common.Address) (*big.Int, []Delegation, error) {
+	return nil, nil, errDelegations
+}
+
+var (
+	author     = common.HexToAddress("0x01")
+	miner1     = common.HexToAddress("0x11")
+	miner2     = common.HexToAddress("0x12")
+	validator1 = common.HexToAddress("0x21")
+	backup1    = common.HexToAddress("0x31")
+	delegator1 = common.HexToAddress("0x41")
+
+	testConfig = &params.RewardConfig{
+		BlockReward:    big.NewInt(1000000),
+		MinerRate:      400,
+		ValidatorRate:  300,
+		BackupRate:     100,
+		CandidateRate:  100,
+		CommissionRate: 200,
+	}
+	testTopology = &Topology{
+		MasterMiners: []election.NodeInfo{
+			{Account: miner1, Value: 3},
+			{Account: miner2, Value: 1},
+		},
+		MasterValidators: []election.NodeInfo{{Account: validator1, Value: 1}},
+		Backups:          []election.NodeInfo{{Account: backup1}, {ID: "boot"}},
+	}
+)
+
+func sumEntries(entries []*Entry) map[common.Address]*big.Int {
+	sums := make(map[common.Address]*big.Int)
+	for _, entry := range entries {
+		if sums[entry.Account] == nil {
+			sums[entry.Account] = new(big.Int)
+		}
+		sums[entry.Account].Add(sums[entry.Account], entry.Amount)
+	}
+	return sums
+}
+
+// Tests that the block payout is split by group rate and deposit, and that the
+// unassigned rate of empty groups and the part of nodes without an account are
+// credited to the author.
+func TestCalculateSplit(t *testing.T) {
+	r, err := New(testConfig, staticTopology{testTopology}, nil)
+	if err != nil {
+		t.Fatalf("failed to create reward: %v", err)
+	}
+	header := &types.Header{Number: big.NewInt(1), Coinbase: author}
+	entries, err := r.Calculate(header, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to calculate rewards: %v", err)
+	}
+	want := map[common.Address]int64{
+		miner1:     300000,
+		miner2:     100000,
+		validator1: 300000,
+		backup1:    50000,
+		author:     250000, // 100 unassigned + 100 empty candidate rate + 50 boot backup
+	}
+	sums := sumEntries(entries)
+	for addr, amount := range want {
+		if sums[addr] == nil || sums[addr].Int64() != amount {
+			t.Errorf("reward of %x mismatch: have %v, want %d", addr, sums[addr], amount)
+		}
+	}
+}
+
+// Tests that transaction fees are taken back from the coinbase and distributed
+// along with the block reward.
+func TestDistributeFees(t *testing.T) {
+	r, _ := New(testConfig, staticTopology{testTopology}, nil)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	statedb.AddBalance(author, big.NewInt(21000)) // fee credited while applying the tx
+
+	header := &types.Header{Number: big.NewInt(1), Coinbase: author}
+	txs := []*types.Transaction{types.NewTransaction(0, miner1, big.NewInt(0), 21000, big.NewInt(1), nil)}
+	receipts := []*types.Receipt{{GasUsed: 21000}}
+
+	if _, err := r.Distribute(statedb, header, txs, receipts); err != nil {
+		t.Fatalf("failed to distribute rewards: %v", err)
+	}
+	total := new(big.Int)
+	for _, addr := range []common.Address{author, miner1, miner2, validator1, backup1} {
+		total.Add(total, statedb.GetBalance(addr))
+	}
+	if want := big.NewInt(1021000); total.Cmp(want) != 0 {
+		t.Errorf("total payout mismatch: have %v, want %v", total, want)
+	}
+}
+
+// Tests that a node's share above the commission is passed through to its
+// delegators pro rata to their stake.
+func TestDelegatorPassThrough(t *testing.T) {
+	delegations := staticDelegations{validator1: {{Delegator: delegator1, Amount: big.NewInt(300)}}}
+	r, _ := New(testConfig, staticTopology{testTopology}, delegations)
+
+	header := &types.Header{Number: big.NewInt(1), Coinbase: author}
+	entries, err := r.Calculate(header, nil, nil)
+	if err != nil {
+		t.Fatalf("failed to calculate rewards: %v", err)
+	}
+	sums := sumEntries(entries)
+
+	// 300000 share, 60000 commission, 240000 shared 100:300 with the delegator
+	if have := sums[delegator1]; have == nil || have.Int64() != 180000 {
+		t.Errorf("delegator reward mismatch: have %v, want %d", have, 180000)
+	}
+	if have := sums[validator1]; have == nil || have.Int64() != 120000 {
+		t.Errorf("validator reward mismatch: have %v, want %d", have, 120000)
+	}
+}
+
+// Tests that failing to retrieve the delegations of a node fails the reward
+// calculation instead of paying everything to the node.
+func TestDelegationFailure(t *testing.T) {
+	r, _ := New(testConfig, staticTopology{testTopology}, failingDelegations{})
+
+	header := &types.Header{Number: big.NewInt(1), Coinbase: author}
+	if _, err := r.Calculate(header, nil, nil); err != errDelegations {
+		t.Errorf("error mismatch: have %v, want %v", err, errDelegations)
+	}
+}
+
+// Tests that rates exceeding the whole payout and missing block rewards are
+// rejected.
+func TestInvalidConfig(t *testing.T) {
+	config := *testConfig
+	config.CandidateRate = 500
+	if _, err := New(&config, staticTopology{testTopology}, nil); err != errInvalidRates {
+		t.Errorf("rates: error mismatch: have %v, want %v", err, errInvalidRates)
+	}
+	config = *testConfig
+	config.BlockReward = nil
+	if _, err := New(&config, staticTopology{testTopology}, nil); err != errInvalidBlockReward {
+		t.Errorf("block reward: error mismatch: have %v, want %v", err, errInvalidBlockReward)
+	}
+	if _, err := New(testConfig, nil, nil); err != errNoTopology {
+		t.Errorf("topology: error mismatch: have %v, want %v", err, errNoTopology)
+	}
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var (
+	// ErrUnapprovedTx is returned if a block contains transactions the verifier
+	// committee in charge of its height did not approve.
+	ErrUnapprovedTx = errors.New("transaction outside approved batches")
+
+	// ErrBatchVotesNotEmpty is returned if a block carries committee votes it
+	// does not need, broadcast blocks and blocks without transactions.
+	ErrBatchVotesNotEmpty = errors.New("batch votes in block without batch")
+)
+
+// BatchReader provides the transaction batches approved by the verifier
+// committee, as collected by the miner from the verifier messages. The miner
+// only admits batches approved by the leader scheduled for their height.
+type BatchReader interface {
+	// ApprovedTxs returns the hashes of the transactions approved for a block
+	// height and whether any batch was approved for it at all.
+	ApprovedTxs(number uint64) (map[common.Hash]struct{}, bool)
+}
+
+// BatchQuorum reports whether the votes approve a batch under the rule of the
+// verifier session: more than half of the committee members voting approve it,
+// holding more than three quarters of their wealth. If the voters declare no
+// wealth at all, the count decides alone. Votes of nodes outside the committee
+// and repeated votes are not counted.
+func BatchQuorum(committee []election.NodeInfo, votes []types.BatchVote) bool {
+	members := make(map[string]election.NodeInfo, len(committee))
+	for _, node := range committee {
+		members[rejectionNodeID(node.ID)] = node
+	}
+	var (
+		seen                    = make(map[string]bool, len(votes))
+		valid, passed           int
+		wealthTotal, wealthPass uint64
+	)
+	for _, vote := range votes {
+		id := rejectionNodeID(vote.NodeId)
+		node, ok := members[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		valid++
+		wealthTotal += node.Wealth
+		if vote.Result {
+			passed++
+			wealthPass += node.Wealth
+		}
+	}
+	if passed*2 <= valid {
+		return false
+	}
+	return wealthTotal == 0 || wealthPass*4 > 3*wealthTotal
+}
+
+// VerifyBatchVotes checks that the votes are signed by the nodes casting them
+// for the batch with the given root at the given height, and approve it by the
+// quorum of the committee.
+func VerifyBatchVotes(committee []election.NodeInfo, number uint64, root common.Hash, votes []types.BatchVote) error {
+	for i := range votes {
+		if err := votes[i].Validate(number, root); err != nil {
+			return err
+		}
+	}
+	if !BatchQuorum(committee, votes) {
+		return ErrUnapprovedTx
+	}
+	return nil
+}
+
+// ValidateBatches checks that the transactions of a block were approved by the
+// verifier committee in charge of its height. On engines whose headers carry
+// the committee votes, a block packing transactions has to carry votes for its
+// transaction root passing the quorum of the committee. Broadcast blocks pack
+// the special transactions and are not restricted.
+func (bc *BlockChain) ValidateBatches(block *types.Block) error {
+	engine, ok := bc.engine.(consensus.Certified)
+	if !ok {
+		return nil
+	}
+	votes, err := engine.BatchVotes(block.Header())
+	if err != nil {
+		return err
+	}
+	number := block.NumberU64()
+	if params.IsBroadcastNumber(number) || len(block.Transactions()) == 0 {
+		if len(votes) > 0 {
+			return ErrBatchVotesNotEmpty
+		}
+		return nil
+	}
+	if len(votes) == 0 {
+		return ErrUnapprovedTx
+	}
+	parent := bc.GetHeader(block.ParentHash(), number-1)
+	if parent == nil {
+		return consensus.ErrUnknownAncestor
+	}
+	committee, _, err := bc.CommitteeAt(parent, number)
+	if err != nil {
+		return err
+	}
+	return VerifyBatchVotes(committee, number, block.TxHash(), votes)
+}
@@ -0,0 +1,101 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// certifiedEngine carries the committee votes in the extra-data of the headers.
+type certifiedEngine struct {
+	consensus.Engine
+}
+
+func (e certifiedEngine) BatchVotes(header *types.Header) ([]types.BatchVote, error) {
+	if len(header.Extra) == 0 {
+		return nil, nil
+	}
+	var votes []types.BatchVote
+	err := rlp.DecodeBytes(header.Extra, &votes)
+	return votes, err
+}
+
+func (e certifiedEngine) Certify(header *types.Header, votes []types.BatchVote) error {
+	enc, err := rlp.EncodeToBytes(votes)
+	header.Extra = enc
+	return err
+}
+
+// Tests that blocks packing transactions are only accepted carrying the votes
+// of the committee in charge of their height approving their transactions.
+func TestValidateBatches(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		member   = func() *ecdsa.PrivateKey { k, _ := crypto.GenerateKey(); return k }()
+		outsider = func() *ecdsa.PrivateKey { k, _ := crypto.GenerateKey(); return k }()
+		config   = *params.TestChainConfig
+	)
+	config.Ptcpos = &params.PtcposConfig{Bootnodes: []string{"enode://" + hex.EncodeToString(crypto.FromECDSAPub(&member.PublicKey)[1:]) + "@127.0.0.1:30303"}}
+	gspec := &Genesis{
+		Config: &config,
+		Alloc:  GenesisAlloc{address: {Balance: big.NewInt(1000000000)}},
+	}
+	tx, err := types.SignTx(types.NewTransaction(0, common.Address{0x01}, big.NewInt(1000), params.TxGas, nil, nil), types.NewEIP155Signer(config.ChainID), key)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	root := types.DeriveSha(types.Transactions{tx})
+	vote := func(prv *ecdsa.PrivateKey, root common.Hash, result bool) types.BatchVote {
+		vote := types.BatchVote{Result: result}
+		if err := vote.Sign(1, root, prv); err != nil {
+			t.Fatalf("failed to sign vote: %v", err)
+		}
+		return vote
+	}
+	tests := []struct {
+		name   string
+		engine consensus.Engine
+		txs    types.Transactions
+		votes  []types.BatchVote
+		err    error
+	}{
+		{"uncertified engine", ethash.NewFullFaker(), types.Transactions{tx}, nil, nil},
+		{"empty block", certifiedEngine{ethash.NewFullFaker()}, nil, nil, nil},
+		{"approved batch", certifiedEngine{ethash.NewFullFaker()}, types.Transactions{tx}, []types.BatchVote{vote(member, root, true)}, nil},
+		{"missing votes", certifiedEngine{ethash.NewFullFaker()}, types.Transactions{tx}, nil, ErrUnapprovedTx},
+		{"rejected batch", certifiedEngine{ethash.NewFullFaker()}, types.Transactions{tx}, []types.BatchVote{vote(member, root, false)}, ErrUnapprovedTx},
+		{"outsider votes", certifiedEngine{ethash.NewFullFaker()}, types.Transactions{tx}, []types.BatchVote{vote(outsider, root, true)}, ErrUnapprovedTx},
+		{"votes on other batch", certifiedEngine{ethash.NewFullFaker()}, types.Transactions{tx}, []types.BatchVote{vote(member, common.Hash{0x01}, true)}, types.ErrBatchVoteNodeSig},
+		{"votes in empty block", certifiedEngine{ethash.NewFullFaker()}, nil, []types.BatchVote{vote(member, types.EmptyRootHash, true)}, ErrBatchVotesNotEmpty},
+	}
+	for _, tt := range tests {
+		db := ethdb.NewMemDatabase()
+		genesis := gspec.MustCommit(db)
+		blocks, _ := GenerateChain(gspec.Config, genesis, tt.engine, db, 1, func(i int, gen *BlockGen) {
+			for _, tx := range tt.txs {
+				gen.AddTx(tx)
+			}
+			if tt.votes != nil {
+				enc, _ := rlp.EncodeToBytes(tt.votes)
+				gen.SetExtra(enc)
+			}
+		})
+		chain, _ := NewBlockChain(db, nil, gspec.Config, tt.engine, vm.Config{})
+		if _, err := chain.InsertChain(blocks); err != tt.err {
+			t.Errorf("%s: error mismatch: have %v, want %v", tt.name, err, tt.err)
+		}
+		chain.Stop()
+	}
+}
@@ -64,12 +64,23 @@ func (v *BlockValidator) ValidateBody(block *types.Block) error {
 	if err := v.engine.VerifyUncles(v.bc, block); err != nil {
 		return err
 	}
+	if engine, ok := v.engine.(consensus.Scheduled); ok {
+		if err := engine.VerifySchedule(v.bc, header); err != nil {
+			return err
+		}
+	}
 	if hash := types.CalcUncleHash(block.Uncles()); hash != header.UncleHash {
 		return fmt.Errorf("uncle root hash mismatch: have %x, want %x", hash, header.UncleHash)
 	}
 	if hash := types.DeriveSha(block.Transactions()); hash != header.TxHash {
 		return fmt.Errorf("transaction root hash mismatch: have %x, want %x", hash, header.TxHash)
 	}
+	if err := v.bc.ValidateNodeLists(header); err != nil {
+		return err
+	}
+	if err := v.bc.ValidateBatches(block); err != nil {
+		return err
+	}
 	return nil
 }
 
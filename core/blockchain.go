@@ -133,6 +133,9 @@ type BlockChain struct {
 	InserBlockNotify func()
 
 	HACache  map[string][]*types.Transaction			// Hypothecated Account Cache
+
+	candidates  *CandidateIndex  // Election candidate set maintained along the canonical chain
+	delegations *DelegationIndex // Stake delegated to candidate nodes along the canonical chain
 }
 
 // NewBlockChain returns a fully initialised block chain using information
@@ -167,6 +170,8 @@ func NewBlockChain(db ethdb.Database, cacheConfig *CacheConfig, chainConfig *par
 		badBlocks:    badBlocks,
 		HACache:	  make(map[string][]*types.Transaction),
 	}
+	bc.candidates = newCandidateIndex(bc, db)
+	bc.delegations = newDelegationIndex(bc, db)
 	bc.SetValidator(NewBlockValidator(chainConfig, bc, engine))
 	bc.SetProcessor(NewStateProcessor(chainConfig, bc, engine))
 
@@ -958,6 +963,11 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 	}
 	rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
 
+	// Store the node lists published by broadcast blocks next to the receipts
+	if topology := bc.publishedTopology(block.Header()); topology != nil {
+		rawdb.WriteTopology(batch, block.Hash(), block.NumberU64(), topology)
+	}
+
 	// If the total difficulty is higher than our known, add it to the canonical chain
 	// Second clause in the if statement reduces the vulnerability to selfish mining.
 	// Please refer to http://www.cs.cornell.edu/~ie53/publications/btcProcFC.pdf
@@ -991,6 +1001,8 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		bc.insert(block)
 		bc.InserBlockNotify()
 		bc.UpdateHACache(block)
+		bc.candidates.Update(block)
+		bc.delegations.Update(block)
 	}
 	bc.futureBlocks.Remove(block.Hash())
 	return status, nil
@@ -1073,7 +1085,8 @@ func (bc *BlockChain) insertChain(chain types.Blocks) (int, []interface{}, []*ty
 		bstart := time.Now()
 
 		err := <-results
-		if err == nil {
+		if err == nil || err == consensus.ErrUnknownSchedule {
+			// Seals the engine could not schedule yet are checked along the body
 			err = bc.Validator().ValidateBody(block)
 		}
 		switch {
@@ -1572,10 +1585,18 @@ func (bc *BlockChain) InserBlockNotify2Schedeuler(f func()){
 	bc.InserBlockNotify = f
 }
 
+// Candidates returns the index of the election candidate set.
+func (bc *BlockChain) Candidates() *CandidateIndex {
+	return bc.candidates
+}
+
+// Delegations returns the index of the stake delegated to candidate nodes.
+func (bc *BlockChain) Delegations() *DelegationIndex {
+	return bc.delegations
+}
+
 func (bc *BlockChain) UpdateHACache(block *types.Block) {
-	config := bc.Config()
-	height := bc.CurrentBlock().Header().Number
-	sender := types.MakeSigner(config, height)
+	sender := types.MakeSigner(bc.Config(), block.Number())
 	txList := block.Transactions()
 	for _, tx := range txList{
 		if isElect, _ := tx.GetElectType(); isElect {
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"errors"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// candidateLeadBlocks is the number of blocks ahead of a broadcast block its
+// main node list is generated at. The candidate period fed into a broadcast
+// block closes at that height.
+const candidateLeadBlocks = 2
+
+var errMissingCandidateBlock = errors.New("block of the candidate period not available")
+
+// CandidateIndex maintains the election candidate set incrementally as blocks
+// are inserted. Candidate period p is fed into broadcast block p*interval and
+// closes candidateLeadBlocks blocks before it; the set as of every closing
+// block is indexed in the database, so any block's set can be rebuilt from the
+// closest indexed ancestor. Reorgs are handled by rewinding to that ancestor.
+type CandidateIndex struct {
+	chain    *BlockChain
+	db       ethdb.Database
+	interval uint64
+
+	head  *types.Header                           // Last block applied to nodes
+	nodes map[string]*types.ElectionTxPayLoadInfo // Candidate set as of head, keyed by node ID
+	lock  sync.Mutex
+}
+
+// newCandidateIndex creates a candidate index over the given chain.
+func newCandidateIndex(chain *BlockChain, db ethdb.Database) *CandidateIndex {
+	return &CandidateIndex{
+		chain:    chain,
+		db:       db,
+		interval: params.BroadcastInterval,
+	}
+}
+
+// Period returns the candidate period the given block belongs to.
+func (ci *CandidateIndex) Period(number uint64) uint64 {
+	return (number + candidateLeadBlocks + ci.interval - 1) / ci.interval
+}
+
+// PeriodEnd returns the number of the block closing the given period.
+func (ci *CandidateIndex) PeriodEnd(period uint64) uint64 {
+	return period*ci.interval - candidateLeadBlocks
+}
+
+// closes reports whether the block closes its candidate period.
+func (ci *CandidateIndex) closes(number uint64) bool {
+	return (number+candidateLeadBlocks)%ci.interval == 0
+}
+
+// Update applies a block that became the canonical head. If it does not extend
+// the previously applied head, the set is rewound to the closest indexed
+// ancestor and rebuilt up to the block.
+func (ci *CandidateIndex) Update(block *types.Block) {
+	ci.lock.Lock()
+	defer ci.lock.Unlock()
+
+	if ci.head != nil && block.ParentHash() == ci.head.Hash() {
+		ci.apply(ci.nodes, block)
+		ci.head = block.Header()
+		return
+	}
+	nodes, err := ci.rebuild(block.Header())
+	if err != nil {
+		log.Warn("Failed to rebuild candidate set", "number", block.Number(), "hash", block.Hash(), "err", err)
+		ci.head, ci.nodes = nil, nil
+		return
+	}
+	if ci.head != nil {
+		log.Debug("Rewound candidate set", "from", ci.head.Number, "to", block.Number())
+	}
+	ci.head, ci.nodes = block.Header(), nodes
+}
+
+// Candidates returns the candidate set as of the given block, sorted by node ID.
+// The returned entries are shared with the index and must not be modified.
+func (ci *CandidateIndex) Candidates(hash common.Hash, number uint64) ([]*types.ElectionTxPayLoadInfo, error) {
+	ci.lock.Lock()
+	defer ci.lock.Unlock()
+
+	if ci.head != nil && ci.head.Hash() == hash {
+		return sortCandidates(ci.nodes), nil
+	}
+	if nodes, ok := rawdb.ReadCandidates(ci.db, hash, number); ok {
+		return nodes, nil
+	}
+	header := ci.chain.GetHeader(hash, number)
+	if header == nil {
+		return nil, errMissingCandidateBlock
+	}
+	nodes, err := ci.rebuild(header)
+	if err != nil {
+		return nil, err
+	}
+	return sortCandidates(nodes), nil
+}
+
+// rebuild reconstructs the candidate set as of the given block, replaying the
+// blocks since the closest ancestor whose set is indexed.
+func (ci *CandidateIndex) rebuild(header *types.Header) (map[string]*types.ElectionTxPayLoadInfo, error) {
+	var (
+		blocks []*types.Block
+		nodes  = make(map[string]*types.ElectionTxPayLoadInfo)
+	)
+	for {
+		number := header.Number.Uint64()
+		if ci.closes(number) {
+			if indexed, ok := rawdb.ReadCandidates(ci.db, header.Hash(), number); ok {
+				for _, node := range indexed {
+					nodes[node.ID] = node
+				}
+				break
+			}
+		}
+		block := ci.chain.GetBlock(header.Hash(), number)
+		if block == nil {
+			return nil, errMissingCandidateBlock
+		}
+		blocks = append(blocks, block)
+		if number == 0 {
+			break
+		}
+		if header = ci.chain.GetHeader(header.ParentHash, number-1); header == nil {
+			return nil, errMissingCandidateBlock
+		}
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		ci.apply(nodes, blocks[i])
+	}
+	return nodes, nil
+}
+
+// apply folds the election transactions of a block into the candidate set. Only
+// successful transactions to the deposit account whose payload is signed by the
+// node key count, and for a listed node only those of the account that first
+// registered it. Exits only survive the period they were sent in, the first
+// block of a new period drops them, except for boot nodes which would otherwise
+// be listed again. The Value of a candidate is the deposit it holds. The set is
+// indexed if the block closes its period.
+func (ci *CandidateIndex) apply(nodes map[string]*types.ElectionTxPayLoadInfo, block *types.Block) {
+	number := block.NumberU64()
+	if number > 0 && ci.closes(number-1) {
+		boot := bootNodeIDs(ci.chain.Config().BootNodes())
+		for id, node := range nodes {
+			if node.ElectType != types.ElectExit {
+				continue
+			}
+			if boot[id] {
+				// The deposit was refunded with the broadcast block. Entries may
+				// be shared with lists handed out before, replace rather than
+				// modify them.
+				refunded := *node
+				refunded.Value = 0
+				nodes[id] = &refunded
+				continue
+			}
+			delete(nodes, id)
+		}
+	}
+	receipts := rawdb.ReadReceipts(ci.db, block.Hash(), number)
+	if len(receipts) != len(block.Transactions()) {
+		log.Warn("Missing receipts of election transactions", "number", number, "hash", block.Hash())
+		receipts = nil
+	}
+	deposits := common.HexToAddress(params.HypothecatedAccount)
+
+	// Senders are derived with the signer of the block, not of the chain head
+	signer := types.MakeSigner(ci.chain.Config(), block.Number())
+	for i, tx := range block.Transactions() {
+		info := tx.ParseElectionTxPayLoad()
+		if info == nil {
+			continue
+		}
+		if to := tx.To(); to == nil || *to != deposits {
+			continue
+		}
+		if receipts == nil || failedReceipt(receipts[i]) {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			log.Warn("Failed to derive election sender", "number", number, "tx", tx.Hash(), "err", err)
+			continue
+		}
+		if err := info.Validate(from); err != nil {
+			log.Debug("Skipping invalid election transaction", "number", number, "tx", tx.Hash(), "err", err)
+			continue
+		}
+		info.ID = strings.ToLower(strings.TrimPrefix(info.ID, "0x"))
+		info.Account = from
+
+		// Track the deposit held for the node in Shannon, an exit keeps it until
+		// refunded. The deposit belongs to the account that registered the node,
+		// only that one may top it up or exit.
+		if prev := nodes[info.ID]; prev != nil {
+			if prev.Account != (common.Address{}) && prev.Account != from {
+				log.Debug("Skipping election transaction of foreign account", "number", number, "tx", tx.Hash(), "node", info.ID, "account", prev.Account, "sender", from)
+				continue
+			}
+			info.Value = prev.Value
+		}
+		if info.ElectType != types.ElectExit {
+			info.Value += new(big.Int).Div(tx.Value(), big.NewInt(params.Shannon)).Uint64()
+		}
+		nodes[info.ID] = info
+	}
+	if ci.closes(number) {
+		rawdb.WriteCandidates(ci.db, block.Hash(), number, sortCandidates(nodes))
+	}
+}
+
+// failedReceipt reports whether a receipt records a failed transaction. Receipts
+// of blocks before Byzantium carry a state root instead of a status.
+func failedReceipt(receipt *types.Receipt) bool {
+	return len(receipt.PostState) == 0 && receipt.Status == types.ReceiptStatusFailed
+}
+
+// sortCandidates flattens a candidate set into a list sorted by node ID.
+func sortCandidates(nodes map[string]*types.ElectionTxPayLoadInfo) []*types.ElectionTxPayLoadInfo {
+	list := make([]*types.ElectionTxPayLoadInfo, 0, len(nodes))
+	for _, node := range nodes {
+		list = append(list, node)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
@@ -0,0 +1,179 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// electTx creates an election transaction of the given node signed by key.
+func electTx(t *testing.T, gen *BlockGen, key, node *ecdsa.PrivateKey, electType uint32) *types.Transaction {
+	return electTxTo(t, gen, key, node, electType, common.HexToAddress(params.HypothecatedAccount))
+}
+
+// electTxTo creates an election transaction of the given node signed by key and
+// sent to the given recipient.
+func electTxTo(t *testing.T, gen *BlockGen, key, node *ecdsa.PrivateKey, electType uint32, to common.Address) *types.Transaction {
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	info := &types.ElectionTxPayLoadInfo{IP: "192.168.3.95", ElectType: electType}
+	if err := info.Sign(from, node); err != nil {
+		t.Fatalf("failed to sign payload: %v", err)
+	}
+	data, err := types.EncodeElectionTxPayLoad(info)
+	if err != nil {
+		t.Fatalf("failed to encode payload: %v", err)
+	}
+	tx := types.NewTransaction(gen.TxNonce(from), to, big.NewInt(0), 100000, big.NewInt(1), data)
+	tx, _ = types.SignTx(tx, types.NewEIP155Signer(params.TestChainConfig.ChainID), key)
+	return tx
+}
+
+// candidateTypes returns the election types of a candidate set keyed by node ID.
+func candidateTypes(t *testing.T, index *CandidateIndex, block *types.Block) map[string]uint32 {
+	nodes, err := index.Candidates(block.Hash(), block.NumberU64())
+	if err != nil {
+		t.Fatalf("block #%d: failed to retrieve candidates: %v", block.NumberU64(), err)
+	}
+	result := make(map[string]uint32)
+	for _, node := range nodes {
+		result[node.ID] = node.ElectType
+	}
+	return result
+}
+
+// Tests that the candidate set is maintained across periods, only counts signed
+// elections sent to the deposit account, ignores elections of a listed node from
+// another account than the one that registered it, drops exits of past periods
+// but those of boot nodes and is rewound on reorgs.
+func TestCandidateIndex(t *testing.T) {
+	var (
+		db       = ethdb.NewMemDatabase()
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		other, _ = crypto.HexToECDSA("8a1f9a8f95be41cd7ccb6168179afb4504aefe388d1e14474d32c45c72ce7b7a")
+		nodeA    = func() *ecdsa.PrivateKey { k, _ := crypto.GenerateKey(); return k }()
+		nodeB    = func() *ecdsa.PrivateKey { k, _ := crypto.GenerateKey(); return k }()
+		nodeC    = func() *ecdsa.PrivateKey { k, _ := crypto.GenerateKey(); return k }()
+		bootNode = func() *ecdsa.PrivateKey { k, _ := crypto.GenerateKey(); return k }()
+		idBoot   = hex.EncodeToString(crypto.FromECDSAPub(&bootNode.PublicKey)[1:])
+		funds    = big.NewInt(1000000000)
+		config   = *params.TestChainConfig
+	)
+	config.Ptcpos = &params.PtcposConfig{Bootnodes: []string{"enode://" + idBoot + "@127.0.0.1:30303"}}
+	var (
+		alloc   = GenesisAlloc{crypto.PubkeyToAddress(key.PublicKey): {Balance: funds}, crypto.PubkeyToAddress(other.PublicKey): {Balance: funds}}
+		gspec   = &Genesis{Config: &config, Alloc: alloc}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, receipts := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 20, func(i int, gen *BlockGen) {
+		switch gen.Number().Uint64() {
+		case 3:
+			gen.AddTx(electTx(t, gen, key, nodeA, types.ElectMiner))
+			gen.AddTx(electTxTo(t, gen, key, nodeC, types.ElectMiner, common.Address{0x01}))
+		case 5:
+			gen.AddTx(electTx(t, gen, key, nodeB, types.ElectCommittee))
+		case 12:
+			gen.AddTx(electTx(t, gen, key, nodeA, types.ElectExit))
+			gen.AddTx(electTx(t, gen, key, bootNode, types.ElectExit))
+			gen.AddTx(electTx(t, gen, other, nodeB, types.ElectExit))
+		}
+	})
+	forks, forkReceipts := GenerateChain(gspec.Config, blocks[9], ethash.NewFaker(), db, 10, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{2})
+	})
+	for i, block := range append(blocks, forks...) {
+		rawdb.WriteBlock(db, block)
+		rawdb.WriteReceipts(db, block.Hash(), block.NumberU64(), append(receipts, forkReceipts...)[i])
+	}
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	index := chain.Candidates()
+	for _, block := range blocks {
+		index.Update(block)
+	}
+	idA := hex.EncodeToString(crypto.FromECDSAPub(&nodeA.PublicKey)[1:])
+	idB := hex.EncodeToString(crypto.FromECDSAPub(&nodeB.PublicKey)[1:])
+
+	tests := []struct {
+		block *types.Block
+		want  map[string]uint32
+	}{
+		{blocks[1], map[string]uint32{}},
+		{blocks[7], map[string]uint32{idA: types.ElectMiner, idB: types.ElectCommittee}},
+		{blocks[17], map[string]uint32{idA: types.ElectExit, idB: types.ElectCommittee, idBoot: types.ElectExit}},
+		{blocks[18], map[string]uint32{idB: types.ElectCommittee, idBoot: types.ElectExit}},
+	}
+	for _, tt := range tests {
+		have := candidateTypes(t, index, tt.block)
+		if len(have) != len(tt.want) {
+			t.Errorf("block #%d: candidate count mismatch: have %d, want %d", tt.block.NumberU64(), len(have), len(tt.want))
+		}
+		for id, electType := range tt.want {
+			if have[id] != electType {
+				t.Errorf("block #%d: node %s type mismatch: have %x, want %x", tt.block.NumberU64(), id[:8], have[id], electType)
+			}
+		}
+	}
+	// The deposit of a node stays with the account that registered it
+	nodes, _ := index.Candidates(blocks[17].Hash(), blocks[17].NumberU64())
+	for _, node := range nodes {
+		if node.ID == idB && node.Account != crypto.PubkeyToAddress(key.PublicKey) {
+			t.Errorf("deposit account mismatch: have %x, want %x", node.Account, crypto.PubkeyToAddress(key.PublicKey))
+		}
+	}
+	// The boot node stays out of the main node lists after its exit period
+	nodes, _ = index.Candidates(blocks[18].Hash(), blocks[18].NumberU64())
+	if lists := MainNodeList(config.BootNodes(), nodes); len(lists.MinerList)+len(lists.CommitteeList) != 1 {
+		t.Errorf("exited boot node listed again: %+v", lists)
+	}
+	if end := index.PeriodEnd(2); !index.closes(end) || index.Period(end) != 2 || index.Period(end+1) != 3 {
+		t.Errorf("period boundaries mismatch around block #%d", end)
+	}
+	// Reorg onto a fork without the exit and ensure the index rewinds
+	for _, block := range forks {
+		index.Update(block)
+	}
+	have := candidateTypes(t, index, forks[len(forks)-1])
+	if have[idA] != types.ElectMiner || have[idB] != types.ElectCommittee {
+		t.Errorf("reorged candidate set mismatch: have %v", have)
+	}
+}
+
+// Tests that refunding the deposit of an exited boot node at the start of a new
+// period leaves the entries handed out for the previous period untouched.
+func TestCandidateIndexRefundCopies(t *testing.T) {
+	var (
+		db       = ethdb.NewMemDatabase()
+		bootNode = func() *ecdsa.PrivateKey { k, _ := crypto.GenerateKey(); return k }()
+		idBoot   = hex.EncodeToString(crypto.FromECDSAPub(&bootNode.PublicKey)[1:])
+		config   = *params.TestChainConfig
+	)
+	config.Ptcpos = &params.PtcposConfig{Bootnodes: []string{"enode://" + idBoot + "@127.0.0.1:30303"}}
+	gspec := &Genesis{Config: &config}
+	gspec.MustCommit(db)
+
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	index := chain.Candidates()
+	exit := &types.ElectionTxPayLoadInfo{ID: idBoot, ElectType: types.ElectExit, Value: 100}
+	nodes := map[string]*types.ElectionTxPayLoadInfo{idBoot: exit}
+
+	index.apply(nodes, types.NewBlockWithHeader(&types.Header{Number: new(big.Int).SetUint64(index.PeriodEnd(1) + 1)}))
+	if nodes[idBoot] == nil || nodes[idBoot].Value != 0 {
+		t.Errorf("boot node deposit not refunded: %+v", nodes[idBoot])
+	}
+	if exit.Value != 100 {
+		t.Errorf("handed out entry modified: have value %d, want 100", exit.Value)
+	}
+}
@@ -0,0 +1,275 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+var errMissingDelegationBlock = errors.New("block of the delegations not available")
+
+// delegating reports whether the chain bonds delegated stake. Only the ptcpos
+// engine pays unbonded stake back, so delegation transactions on other chains
+// are plain transfers to the deposit account.
+func delegating(config *params.ChainConfig) bool {
+	return config.Ptcpos != nil
+}
+
+// bondKey identifies the stake a delegator has bonded to a node.
+type bondKey struct {
+	node      string
+	delegator common.Address
+}
+
+// delegationSet is the delegated stake as of a block. Amounts are replaced, not
+// modified, so they can be shared with the lists handed out.
+type delegationSet struct {
+	bonds      map[bondKey]*big.Int
+	unbondings []*types.Unbonding
+}
+
+func newDelegationSet() *delegationSet {
+	return &delegationSet{bonds: make(map[bondKey]*big.Int)}
+}
+
+// flatten converts the set into its sorted list form.
+func (set *delegationSet) flatten() *types.Delegations {
+	delegations := &types.Delegations{
+		Bonds:      make([]*types.Bond, 0, len(set.bonds)),
+		Unbondings: append([]*types.Unbonding{}, set.unbondings...),
+	}
+	for key, amount := range set.bonds {
+		delegations.Bonds = append(delegations.Bonds, &types.Bond{Delegator: key.delegator, NodeID: key.node, Amount: amount})
+	}
+	sort.Slice(delegations.Bonds, func(i, j int) bool {
+		a, b := delegations.Bonds[i], delegations.Bonds[j]
+		if a.NodeID != b.NodeID {
+			return a.NodeID < b.NodeID
+		}
+		return bytes.Compare(a.Delegator[:], b.Delegator[:]) < 0
+	})
+	sort.SliceStable(delegations.Unbondings, func(i, j int) bool {
+		a, b := delegations.Unbondings[i], delegations.Unbondings[j]
+		if a.Release != b.Release {
+			return a.Release < b.Release
+		}
+		if a.NodeID != b.NodeID {
+			return a.NodeID < b.NodeID
+		}
+		return bytes.Compare(a.Delegator[:], b.Delegator[:]) < 0
+	})
+	return delegations
+}
+
+// DelegationIndex maintains the stake delegated to candidate nodes as blocks
+// are inserted, the same way the candidate index maintains the candidate set:
+// the stake as of every block closing a candidate period is indexed in the
+// database and any other block's stake is rebuilt from the closest indexed
+// ancestor.
+//
+// Delegations bond the value of their transaction to a node. Undelegations
+// unbond up to the bonded stake, which stays locked in the deposit account
+// until the broadcast block params.UnbondingPeriods periods later pays it back.
+// Chains without the ptcpos engine index no stake.
+type DelegationIndex struct {
+	chain    *BlockChain
+	db       ethdb.Database
+	interval uint64
+
+	head *types.Header  // Last block applied to set
+	set  *delegationSet // Delegated stake as of head
+	lock sync.Mutex
+}
+
+// newDelegationIndex creates a delegation index over the given chain.
+func newDelegationIndex(chain *BlockChain, db ethdb.Database) *DelegationIndex {
+	return &DelegationIndex{
+		chain:    chain,
+		db:       db,
+		interval: params.BroadcastInterval,
+	}
+}
+
+// closes reports whether the block closes its candidate period.
+func (di *DelegationIndex) closes(number uint64) bool {
+	return (number+candidateLeadBlocks)%di.interval == 0
+}
+
+// Update applies a block that became the canonical head. If it does not extend
+// the previously applied head, the stake is rewound to the closest indexed
+// ancestor and rebuilt up to the block.
+func (di *DelegationIndex) Update(block *types.Block) {
+	if !delegating(di.chain.Config()) {
+		return
+	}
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	if di.head != nil && block.ParentHash() == di.head.Hash() {
+		di.apply(di.set, block)
+		di.head = block.Header()
+		return
+	}
+	set, err := di.rebuild(block.Header())
+	if err != nil {
+		log.Warn("Failed to rebuild delegations", "number", block.Number(), "hash", block.Hash(), "err", err)
+		di.head, di.set = nil, nil
+		return
+	}
+	if di.head != nil {
+		log.Debug("Rewound delegations", "from", di.head.Number, "to", block.Number())
+	}
+	di.head, di.set = block.Header(), set
+}
+
+// Delegations returns the delegated stake as of the given block.
+func (di *DelegationIndex) Delegations(hash common.Hash, number uint64) (*types.Delegations, error) {
+	if !delegating(di.chain.Config()) {
+		return new(types.Delegations), nil
+	}
+	di.lock.Lock()
+	defer di.lock.Unlock()
+
+	if di.head != nil && di.head.Hash() == hash {
+		return di.set.flatten(), nil
+	}
+	if delegations := rawdb.ReadDelegations(di.db, hash, number); delegations != nil {
+		return delegations, nil
+	}
+	header := di.chain.GetHeader(hash, number)
+	if header == nil {
+		return nil, errMissingDelegationBlock
+	}
+	set, err := di.rebuild(header)
+	if err != nil {
+		return nil, err
+	}
+	return set.flatten(), nil
+}
+
+// rebuild reconstructs the delegated stake as of the given block, replaying the
+// blocks since the closest ancestor whose stake is indexed.
+func (di *DelegationIndex) rebuild(header *types.Header) (*delegationSet, error) {
+	var (
+		blocks []*types.Block
+		set    = newDelegationSet()
+	)
+	for {
+		number := header.Number.Uint64()
+		if di.closes(number) {
+			if indexed := rawdb.ReadDelegations(di.db, header.Hash(), number); indexed != nil {
+				for _, bond := range indexed.Bonds {
+					set.bonds[bondKey{bond.NodeID, bond.Delegator}] = bond.Amount
+				}
+				set.unbondings = indexed.Unbondings
+				break
+			}
+		}
+		block := di.chain.GetBlock(header.Hash(), number)
+		if block == nil {
+			return nil, errMissingDelegationBlock
+		}
+		blocks = append(blocks, block)
+		if number == 0 {
+			break
+		}
+		if header = di.chain.GetHeader(header.ParentHash, number-1); header == nil {
+			return nil, errMissingDelegationBlock
+		}
+	}
+	for i := len(blocks) - 1; i >= 0; i-- {
+		di.apply(set, blocks[i])
+	}
+	return set, nil
+}
+
+// apply folds the delegation transactions of a block into the delegated stake.
+// Unbondings released by the block are dropped first, as its finalization pays
+// them back. The stake is indexed if the block closes its candidate period.
+func (di *DelegationIndex) apply(set *delegationSet, block *types.Block) {
+	number := block.NumberU64()
+
+	unbondings := set.unbondings[:0:0]
+	for _, unbonding := range set.unbondings {
+		if unbonding.Release > number {
+			unbondings = append(unbondings, unbonding)
+		}
+	}
+	set.unbondings = unbondings
+
+	// Senders are derived with the signer of the block, not of the chain head
+	signer := types.MakeSigner(di.chain.Config(), block.Number())
+	for _, tx := range block.Transactions() {
+		info := tx.ParseDelegationTxPayLoad()
+		if info == nil || tx.To() == nil || *tx.To() != common.HexToAddress(params.HypothecatedAccount) {
+			continue
+		}
+		from, err := types.Sender(signer, tx)
+		if err != nil {
+			log.Warn("Failed to derive delegation sender", "number", number, "tx", tx.Hash(), "err", err)
+			continue
+		}
+		key := bondKey{info.NodeID, from}
+		bonded := set.bonds[key]
+		if bonded == nil {
+			bonded = new(big.Int)
+		}
+		switch info.Type {
+		case types.DelegateStake:
+			set.bonds[key] = new(big.Int).Add(bonded, tx.Value())
+
+		case types.UndelegateStake:
+			// Only bonded stake can be unbonded, excess requests are capped
+			amount := info.Amount
+			if amount.Cmp(bonded) > 0 {
+				amount = bonded
+			}
+			if amount.Sign() <= 0 {
+				continue
+			}
+			if remaining := new(big.Int).Sub(bonded, amount); remaining.Sign() > 0 {
+				set.bonds[key] = remaining
+			} else {
+				delete(set.bonds, key)
+			}
+			set.unbondings = append(set.unbondings, &types.Unbonding{
+				Delegator: from,
+				NodeID:    info.NodeID,
+				Amount:    new(big.Int).Set(amount),
+				Release:   params.UnbondingRelease(number),
+			})
+		}
+	}
+	if di.closes(number) {
+		rawdb.WriteDelegations(di.db, block.Hash(), number, set.flatten())
+	}
+}
+
+// Unbondings returns the unbonded stake the given header has to pay back, as of
+// its parent.
+func (bc *BlockChain) Unbondings(header *types.Header) ([]*types.Unbonding, error) {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil, nil
+	}
+	delegations, err := bc.delegations.Delegations(header.ParentHash, number-1)
+	if err != nil {
+		return nil, err
+	}
+	var released []*types.Unbonding
+	for _, unbonding := range delegations.Unbondings {
+		if unbonding.Release <= number {
+			released = append(released, unbonding)
+		}
+	}
+	return released, nil
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const delegatedNode = "a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c"
+
+// delegationTx creates a delegation transaction to delegatedNode signed by key.
+func delegationTx(gen *BlockGen, key []byte, delegationType uint32, amount int64) *types.Transaction {
+	priv, _ := crypto.ToECDSA(key)
+	from := crypto.PubkeyToAddress(priv.PublicKey)
+
+	tx, _ := types.NewDelegationTransaction(gen.TxNonce(from), common.HexToAddress(params.HypothecatedAccount), delegationType, delegatedNode, big.NewInt(amount), 100000, big.NewInt(1))
+	tx, _ = types.SignTx(tx, types.NewEIP155Signer(params.TestChainConfig.ChainID), priv)
+	return tx
+}
+
+// Tests that bonds and unbondings are maintained across periods, unbondings are
+// released by their broadcast block and the stake is rewound on reorgs. Chains
+// without the ptcpos engine must neither accept nor index delegations.
+func TestDelegationIndex(t *testing.T) {
+	config := *params.TestChainConfig
+	config.Ptcpos = &params.PtcposConfig{}
+
+	var (
+		db      = ethdb.NewMemDatabase()
+		key     = common.FromHex("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		priv, _ = crypto.ToECDSA(key)
+		from    = crypto.PubkeyToAddress(priv.PublicKey)
+		gspec   = &Genesis{Config: &config, Alloc: GenesisAlloc{from: {Balance: big.NewInt(1000000000)}}}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 45, func(i int, gen *BlockGen) {
+		switch gen.Number().Uint64() {
+		case 3:
+			gen.AddTx(delegationTx(gen, key, types.DelegateStake, 5000))
+		case 5:
+			gen.AddTx(delegationTx(gen, key, types.UndelegateStake, 2000))
+		case 7:
+			gen.AddTx(delegationTx(gen, key, types.UndelegateStake, 10000))
+		}
+	})
+	forks, _ := GenerateChain(gspec.Config, blocks[5], ethash.NewFaker(), db, 10, func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{2})
+	})
+	for _, block := range append(blocks, forks...) {
+		rawdb.WriteBlock(db, block)
+	}
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	index := chain.Delegations()
+	for _, block := range blocks {
+		index.Update(block)
+	}
+	release := params.UnbondingRelease(5)
+
+	tests := []struct {
+		block      *types.Block
+		bonded     int64
+		unbondings []int64
+	}{
+		{blocks[1], 0, nil},
+		{blocks[3], 5000, nil},
+		{blocks[5], 3000, []int64{2000}},
+		{blocks[7], 0, []int64{2000, 3000}},
+		{blocks[release-2], 0, []int64{2000, 3000}},
+		{blocks[release-1], 0, nil},
+	}
+	for _, tt := range tests {
+		delegations, err := index.Delegations(tt.block.Hash(), tt.block.NumberU64())
+		if err != nil {
+			t.Fatalf("block #%d: failed to retrieve delegations: %v", tt.block.NumberU64(), err)
+		}
+		var bonded int64
+		for _, bond := range delegations.Bonds {
+			if bond.NodeID != delegatedNode || bond.Delegator != from {
+				t.Errorf("block #%d: unexpected bond %+v", tt.block.NumberU64(), bond)
+			}
+			bonded += bond.Amount.Int64()
+		}
+		if bonded != tt.bonded {
+			t.Errorf("block #%d: bonded stake mismatch: have %d, want %d", tt.block.NumberU64(), bonded, tt.bonded)
+		}
+		if len(delegations.Unbondings) != len(tt.unbondings) {
+			t.Errorf("block #%d: unbonding count mismatch: have %d, want %d", tt.block.NumberU64(), len(delegations.Unbondings), len(tt.unbondings))
+			continue
+		}
+		for i, amount := range tt.unbondings {
+			if unbonding := delegations.Unbondings[i]; unbonding.Amount.Int64() != amount || unbonding.Release != release {
+				t.Errorf("block #%d: unbonding %d mismatch: have %+v, want %d at #%d", tt.block.NumberU64(), i, unbonding, amount, release)
+			}
+		}
+	}
+	// The broadcast block releasing the unbondings has to pay them back
+	released, err := chain.Unbondings(blocks[release-1].Header())
+	if err != nil || len(released) != 2 {
+		t.Fatalf("released unbondings mismatch: have %d/%v, want 2", len(released), err)
+	}
+	// Reorg onto a fork without the full undelegation and ensure the index rewinds
+	for _, block := range forks {
+		index.Update(block)
+	}
+	head := forks[len(forks)-1]
+	delegations, err := index.Delegations(head.Hash(), head.NumberU64())
+	if err != nil {
+		t.Fatalf("failed to retrieve reorged delegations: %v", err)
+	}
+	if len(delegations.Bonds) != 1 || delegations.Bonds[0].Amount.Int64() != 3000 || len(delegations.Unbondings) != 1 {
+		t.Errorf("reorged delegations mismatch: %+v", delegations)
+	}
+	// Ensure chains unable to pay unbondings back ignore delegations
+	tx := blocks[2].Transactions()[0]
+	if err := validateDelegationTx(tx, &config); err != nil {
+		t.Errorf("delegation rejected on delegating chain: %v", err)
+	}
+	if err := validateDelegationTx(tx, params.TestChainConfig); err != ErrDelegationDisabled {
+		t.Errorf("delegation error mismatch: have %v, want %v", err, ErrDelegationDisabled)
+	}
+	plaindb := ethdb.NewMemDatabase()
+	(&Genesis{Config: params.TestChainConfig, Alloc: gspec.Alloc}).MustCommit(plaindb)
+	for _, block := range blocks {
+		rawdb.WriteBlock(plaindb, block)
+	}
+	plain, _ := NewBlockChain(plaindb, nil, params.TestChainConfig, ethash.NewFaker(), vm.Config{})
+	defer plain.Stop()
+
+	for _, block := range blocks {
+		plain.Delegations().Update(block)
+	}
+	if delegations, err := plain.Delegations().Delegations(blocks[5].Hash(), 5); err != nil || len(delegations.Bonds) != 0 {
+		t.Errorf("plain chain delegations mismatch: have %+v (%v), want none", delegations, err)
+	}
+}
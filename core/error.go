@@ -32,4 +32,17 @@ var (
 	// ErrNonceTooHigh is returned if the nonce of a transaction is higher than the
 	// next one expected based on the local chain.
 	ErrNonceTooHigh = errors.New("nonce too high")
+
+	// ErrInvalidNonceLane is returned if the nonce of a transaction selects a lane
+	// beyond the parallel nonce lanes of an account.
+	ErrInvalidNonceLane = errors.New("invalid nonce lane")
+
+	// ErrNonceLaneClosed is returned if an account sends a transaction on a lane
+	// beyond the first before ever sending one on the first. Its lane nonces
+	// would otherwise be lost if the account got cleared from the state as empty.
+	ErrNonceLaneClosed = errors.New("nonce lanes closed before first transaction")
+
+	// ErrLaneContractCreation is returned if a contract creation is sent on a lane
+	// beyond the first, as contract addresses derive from the account nonce.
+	ErrLaneContractCreation = errors.New("contract creation on nonce lane")
 )
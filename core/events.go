@@ -24,6 +24,15 @@ import (
 // NewTxsEvent is posted when a batch of transactions enter the transaction pool.
 type NewTxsEvent struct{ Txs []*types.Transaction }
 
+// TxRejectedEvent is posted when the committee rejects a transaction.
+type TxRejectedEvent struct{ Status *TxRejectionStatus }
+
+// TxStatusEvent is posted when a transaction reaches a stage of its lifecycle.
+type TxStatusEvent struct {
+	Hash common.Hash `json:"transactionHash"`
+	TxStageRecord
+}
+
 // PendingLogsEvent is posted pre mining and notifies of pending logs.
 type PendingLogsEvent struct {
 	Logs []*types.Log
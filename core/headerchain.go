@@ -239,8 +239,9 @@ func (hc *HeaderChain) ValidateHeaderChain(chain []*types.Header, checkFreq int)
 		if BadHashes[header.Hash()] {
 			return i, ErrBlacklistedHash
 		}
-		// Otherwise wait for headers checks and ensure they pass
-		if err := <-results; err != nil {
+		// Otherwise wait for headers checks and ensure they pass, seals the
+		// engine could not schedule yet are checked on insertion
+		if err := <-results; err != nil && err != consensus.ErrUnknownSchedule {
 			return i, err
 		}
 	}
@@ -271,6 +272,12 @@ func (hc *HeaderChain) InsertHeaderChain(chain []*types.Header, writeHeader WhCa
 			stats.ignored++
 			continue
 		}
+		// Check the sealer schedule now that the ancestors are written
+		if engine, ok := hc.engine.(consensus.Scheduled); ok {
+			if err := engine.VerifySchedule(hc, header); err != nil {
+				return i, err
+			}
+		}
 		if err := writeHeader(header); err != nil {
 			return i, err
 		}
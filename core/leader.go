@@ -0,0 +1,197 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// CommitteeEffectDelay is the number of blocks after a broadcast block until
+// the committee it lists takes over verifying.
+const CommitteeEffectDelay = 6
+
+var (
+	// ErrUnknownCommittee is returned if the broadcast block listing the
+	// committee of a height, or the node lists it commits to, are not known yet.
+	ErrUnknownCommittee = errors.New("committee not known yet")
+
+	// ErrEmptyCommittee is returned if a leader is picked from an empty committee.
+	ErrEmptyCommittee = errors.New("empty committee")
+)
+
+// LeaderRotation orders a committee for its leader rotation. The members are
+// sorted by the hash of the seed and their node ID, so the order only depends
+// on the committee and the seed, not on the order the members are listed in.
+func LeaderRotation(committee []election.NodeInfo, seed common.Hash) []election.NodeInfo {
+	keys := make(map[string]common.Hash, len(committee))
+	for _, node := range committee {
+		keys[node.ID] = crypto.Keccak256Hash(seed[:], []byte(node.ID))
+	}
+	rotation := make([]election.NodeInfo, len(committee))
+	copy(rotation, committee)
+	sort.SliceStable(rotation, func(i, j int) bool {
+		if cmp := bytes.Compare(keys[rotation[i].ID][:], keys[rotation[j].ID][:]); cmp != 0 {
+			return cmp < 0
+		}
+		return rotation[i].ID < rotation[j].ID
+	})
+	return rotation
+}
+
+// LeaderOf returns the leader of a committee for the given height. The members
+// take turns in the order of their rotation, so every member leads once within
+// as many consecutive heights as the committee has members.
+func LeaderOf(committee []election.NodeInfo, seed common.Hash, number uint64) (election.NodeInfo, error) {
+	if len(committee) == 0 {
+		return election.NodeInfo{}, ErrEmptyCommittee
+	}
+	rotation := LeaderRotation(committee, seed)
+	return rotation[number%uint64(len(rotation))], nil
+}
+
+// CommitteeBlock returns the number of the broadcast block listing the committee
+// in charge of the given height, zero while the boot committee is.
+func CommitteeBlock(number uint64) uint64 {
+	if number <= CommitteeEffectDelay {
+		return 0
+	}
+	return (number - CommitteeEffectDelay - 1) / params.BroadcastInterval * params.BroadcastInterval
+}
+
+// nodeListsInCharge returns the main node lists in charge of the given height on
+// the chain through the given ancestor, and the seed of their rotations, the hash
+// of the broadcast block listing them. The ancestor is any block below the height
+// and not below that broadcast block, usually the parent of the height. Before
+// the first broadcast block and after ones listing no node the boot nodes are in
+// charge. If the broadcast block commits to lists that cannot be derived yet,
+// ErrUnknownCommittee is returned so the caller can retry later.
+func (bc *BlockChain) nodeListsInCharge(ancestor *types.Header, number uint64) (election.NodeList, common.Hash, error) {
+	block := CommitteeBlock(number)
+
+	header := ancestor
+	for header != nil && header.Number.Uint64() > block {
+		header = bc.GetHeader(header.ParentHash, header.Number.Uint64()-1)
+	}
+	if header == nil || header.Number.Uint64() != block || (number > 0 && ancestor.Number.Uint64() >= number) {
+		return election.NodeList{}, common.Hash{}, ErrUnknownCommittee
+	}
+	if block > 0 {
+		topology := bc.GetTopology(header.Hash(), block)
+		if topology == nil && header.HasNodeLists() {
+			return election.NodeList{}, common.Hash{}, ErrUnknownCommittee
+		}
+		if !topology.Empty() {
+			lists := election.NodeList{
+				MinerList:     topology.MinerList,
+				CommitteeList: topology.CommitteeList,
+				Both:          topology.Both,
+				OfflineList:   topology.OfflineList,
+			}
+			return lists, header.Hash(), nil
+		}
+	}
+	return MainNodeList(bc.chainConfig.BootNodes(), nil), header.Hash(), nil
+}
+
+// NodeListsAt returns the main node lists in charge of the given height on the
+// chain through the given ancestor and the seed of their rotations.
+func (bc *BlockChain) NodeListsAt(ancestor *types.Header, number uint64) (election.NodeList, common.Hash, error) {
+	return bc.nodeListsInCharge(ancestor, number)
+}
+
+// canonicalAncestor returns the canonical block closest below the given height,
+// its parent once the chain reached it.
+func (bc *BlockChain) canonicalAncestor(number uint64) *types.Header {
+	if number == 0 {
+		return bc.Genesis().Header()
+	}
+	if header := bc.GetHeaderByNumber(number - 1); header != nil {
+		return header
+	}
+	return bc.CurrentHeader()
+}
+
+// Committee returns the verifier committee in charge of the given height on the
+// canonical chain and the seed of its leader rotation.
+func (bc *BlockChain) Committee(number uint64) ([]election.NodeInfo, common.Hash, error) {
+	return bc.CommitteeAt(bc.canonicalAncestor(number), number)
+}
+
+// CommitteeAt returns the verifier committee in charge of the given height on
+// the chain through the given ancestor, usually the parent of the height, and
+// the seed of its leader rotation. The committee serves both the committee and
+// the dual role nodes.
+func (bc *BlockChain) CommitteeAt(ancestor *types.Header, number uint64) ([]election.NodeInfo, common.Hash, error) {
+	lists, seed, err := bc.nodeListsInCharge(ancestor, number)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	committee := make([]election.NodeInfo, 0, len(lists.CommitteeList)+len(lists.Both))
+	committee = append(committee, lists.CommitteeList...)
+	committee = append(committee, lists.Both...)
+	return committee, seed, nil
+}
+
+// MasterMiners returns the master miners in charge of the given height on the
+// canonical chain and the seed of their sealing rotation.
+func (bc *BlockChain) MasterMiners(number uint64) ([]election.NodeInfo, common.Hash, error) {
+	return bc.MasterMinersAt(bc.canonicalAncestor(number), number)
+}
+
+// MasterMinersAt returns the master miners in charge of the given height on the
+// chain through the given ancestor, usually the parent of the height, and the
+// seed of their sealing rotation. The miners serve both the miner and the dual
+// role nodes.
+func (bc *BlockChain) MasterMinersAt(ancestor *types.Header, number uint64) ([]election.NodeInfo, common.Hash, error) {
+	lists, seed, err := bc.nodeListsInCharge(ancestor, number)
+	if err != nil {
+		return nil, common.Hash{}, err
+	}
+	miners := make([]election.NodeInfo, 0, len(lists.MinerList)+len(lists.Both))
+	miners = append(miners, lists.MinerList...)
+	miners = append(miners, lists.Both...)
+	return miners, seed, nil
+}
+
+// Leader returns the verifier committee leader of the given height.
+func (bc *BlockChain) Leader(number uint64) (election.NodeInfo, error) {
+	committee, seed, err := bc.Committee(number)
+	if err != nil {
+		return election.NodeInfo{}, err
+	}
+	return LeaderOf(committee, seed, number)
+}
+
+// LeaderSchedule returns the verifier committee leaders of count heights
+// starting at from. The schedule ends early at the first height whose committee
+// is not known yet.
+func (bc *BlockChain) LeaderSchedule(from, count uint64) ([]election.NodeInfo, error) {
+	var (
+		schedule = make([]election.NodeInfo, 0, count)
+		rotation []election.NodeInfo
+		block    uint64
+	)
+	for number := from; number < from+count; number++ {
+		if rotation == nil || CommitteeBlock(number) != block {
+			committee, seed, err := bc.Committee(number)
+			if err == ErrUnknownCommittee {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(committee) == 0 {
+				return nil, ErrEmptyCommittee
+			}
+			rotation, block = LeaderRotation(committee, seed), CommitteeBlock(number)
+		}
+		schedule = append(schedule, rotation[number%uint64(len(rotation))])
+	}
+	return schedule, nil
+}
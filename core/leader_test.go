@@ -0,0 +1,144 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the leader rotation only depends on the committee and the seed and
+// that every member leads once per round.
+func TestLeaderRotation(t *testing.T) {
+	committee := []election.NodeInfo{{ID: "01"}, {ID: "02"}, {ID: "03"}, {ID: "04"}}
+	reversed := []election.NodeInfo{committee[3], committee[2], committee[1], committee[0]}
+	seed := common.Hash{0x01}
+
+	led := make(map[string]int)
+	for number := uint64(100); number < 100+uint64(len(committee)); number++ {
+		leader, err := LeaderOf(committee, seed, number)
+		if err != nil {
+			t.Fatalf("height %d: failed to pick leader: %v", number, err)
+		}
+		if other, _ := LeaderOf(reversed, seed, number); other.ID != leader.ID {
+			t.Errorf("height %d: leader depends on member order: %s != %s", number, leader.ID, other.ID)
+		}
+		led[leader.ID]++
+	}
+	if len(led) != len(committee) {
+		t.Errorf("round leaders mismatch: have %v, want every member once", led)
+	}
+	if _, err := LeaderOf(nil, seed, 1); err != ErrEmptyCommittee {
+		t.Errorf("empty committee: error mismatch: have %v, want %v", err, ErrEmptyCommittee)
+	}
+}
+
+// Tests that the leader schedule follows the committee in charge of each height
+// and ends at the first height whose committee is not known yet.
+func TestLeaderSchedule(t *testing.T) {
+	if block := CommitteeBlock(CommitteeEffectDelay); block != 0 {
+		t.Errorf("boot committee block mismatch: have %d, want 0", block)
+	}
+	if block := CommitteeBlock(params.BroadcastInterval + CommitteeEffectDelay + 1); block != params.BroadcastInterval {
+		t.Errorf("committee block mismatch: have %d, want %d", block, params.BroadcastInterval)
+	}
+	var (
+		db      = ethdb.NewMemDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 5, nil)
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	committee, seed, err := chain.Committee(1)
+	if err != nil {
+		t.Fatalf("failed to retrieve boot committee: %v", err)
+	}
+	if seed != genesis.Hash() || len(committee) != len(MainNodeList(params.MainnetBootnodes, nil).CommitteeList) {
+		t.Fatalf("boot committee mismatch: seed %x, %d members", seed, len(committee))
+	}
+	schedule, err := chain.LeaderSchedule(1, 2*params.BroadcastInterval)
+	if err != nil {
+		t.Fatalf("failed to retrieve schedule: %v", err)
+	}
+	// The first broadcast block is not mined yet, so the schedule stops there
+	if want := params.BroadcastInterval + CommitteeEffectDelay; uint64(len(schedule)) != want {
+		t.Fatalf("schedule length mismatch: have %d, want %d", len(schedule), want)
+	}
+	for i, leader := range schedule {
+		if want, _ := chain.Leader(uint64(i) + 1); want.ID != leader.ID {
+			t.Errorf("height %d: scheduled leader mismatch: have %s, want %s", i+1, leader.ID, want.ID)
+		}
+	}
+}
+
+// Tests that the committee of a height is resolved on the chain through the
+// given ancestor, so side chains are checked against their own broadcast blocks.
+func TestCommitteeAtSideChain(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+		number  = params.BroadcastInterval + CommitteeEffectDelay + 1
+	)
+	canonical, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, int(number-1), nil)
+	side, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, int(number-1), func(i int, gen *BlockGen) {
+		gen.SetCoinbase(common.Address{0x01})
+	})
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(canonical); err != nil {
+		t.Fatalf("failed to insert canonical chain: %v", err)
+	}
+	if _, err := chain.InsertChain(side); err != nil {
+		t.Fatalf("failed to insert side chain: %v", err)
+	}
+	broadcast := params.BroadcastInterval - 1
+	if _, seed, err := chain.Committee(number); err != nil || seed != canonical[broadcast].Hash() {
+		t.Errorf("canonical committee seed mismatch: have %x (%v), want %x", seed, err, canonical[broadcast].Hash())
+	}
+	if _, seed, err := chain.CommitteeAt(side[len(side)-1].Header(), number); err != nil || seed != side[broadcast].Hash() {
+		t.Errorf("side chain committee seed mismatch: have %x (%v), want %x", seed, err, side[broadcast].Hash())
+	}
+	if _, _, err := chain.CommitteeAt(side[len(side)-1].Header(), number-1); err != ErrUnknownCommittee {
+		t.Errorf("ancestor at height: error mismatch: have %v, want %v", err, ErrUnknownCommittee)
+	}
+}
+
+// Tests that a broadcast block committing to node lists that cannot be derived
+// yet leaves its committee unknown instead of handing the boot nodes charge.
+func TestCommitteeAtUnknownLists(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+	)
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	// A broadcast header whose ancestors, and so its candidates, are unknown
+	broadcast := &types.Header{
+		ParentHash:   genesis.Hash(),
+		Number:       new(big.Int).SetUint64(params.BroadcastInterval),
+		Difficulty:   big.NewInt(1),
+		Time:         big.NewInt(0),
+		TopologyRoot: common.Hash{0x01},
+	}
+	rawdb.WriteHeader(db, broadcast)
+
+	if _, _, err := chain.CommitteeAt(broadcast, params.BroadcastInterval+CommitteeEffectDelay+1); err != ErrUnknownCommittee {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrUnknownCommittee)
+	}
+}
@@ -0,0 +1,213 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// bootNodeWealth is the wealth the boot nodes are listed with in the main node
+// lists, they are masternodes since the genesis block without a deposit.
+const bootNodeWealth = 10000
+
+var (
+	// ErrNodeListsNotEmpty is returned if a block other than a broadcast block
+	// carries main node lists.
+	ErrNodeListsNotEmpty = errors.New("node lists in non-broadcast block")
+
+	// ErrNodeListsMismatch is returned if the main node lists of a broadcast
+	// block differ from the ones derived from its candidate set.
+	ErrNodeListsMismatch = errors.New("node lists mismatch")
+
+	// ErrTopologyRootUnscheduled is returned if a block from before the node
+	// list fork commits to a topology root.
+	ErrTopologyRootUnscheduled = errors.New("topology root before node list block")
+
+	// ErrLegacyNodeLists is returned if a block from the node list fork on lists
+	// the main nodes inline instead of committing to them.
+	ErrLegacyNodeLists = errors.New("inline node lists after node list block")
+)
+
+// bootNodeIDs returns the node IDs of the given boot node URLs.
+func bootNodeIDs(bootnodes []string) map[string]bool {
+	ids := make(map[string]bool, len(bootnodes))
+	for _, url := range bootnodes {
+		if boot, err := discover.ParseNode(url); err == nil {
+			ids[boot.ID.String()] = true
+		}
+	}
+	return ids
+}
+
+// MainNodeList derives the main node lists from a candidate set. The given boot
+// nodes are listed unless the candidates hold an election or exit of them, and
+// every list is sorted by node ID.
+func MainNodeList(bootnodes []string, candidates []*types.ElectionTxPayLoadInfo) election.NodeList {
+	nodes := make(map[string]*types.ElectionTxPayLoadInfo, len(candidates)+len(bootnodes))
+	for _, candidate := range candidates {
+		nodes[candidate.ID] = candidate
+	}
+	for i, url := range bootnodes {
+		boot, err := discover.ParseNode(url)
+		if err != nil {
+			continue
+		}
+		id := boot.ID.String()
+		if _, ok := nodes[id]; ok {
+			continue
+		}
+		// The first boot node sits in the committee, the others mine
+		electType := types.ElectMiner
+		if i == 0 {
+			electType = types.ElectCommittee
+		}
+		nodes[id] = &types.ElectionTxPayLoadInfo{ID: id, IP: boot.IP.String(), Wealth: bootNodeWealth, ElectType: electType}
+	}
+	var lists election.NodeList
+	for _, node := range sortCandidates(nodes) {
+		info := election.NodeInfo{
+			TPS:        node.TPS,
+			IP:         node.IP,
+			ID:         node.ID,
+			Wealth:     node.Wealth,
+			OnlineTime: node.OnlineTime,
+			TxHash:     node.TxHash,
+			Value:      node.Value,
+			Account:    node.Account,
+		}
+		switch node.ElectType {
+		case types.ElectExit:
+			lists.OfflineList = append(lists.OfflineList, info)
+		case types.ElectMiner:
+			lists.MinerList = append(lists.MinerList, info)
+		case types.ElectCommittee:
+			lists.CommitteeList = append(lists.CommitteeList, info)
+		case types.ElectBoth:
+			lists.Both = append(lists.Both, info)
+		}
+	}
+	return lists
+}
+
+// NodeLists returns the main node lists the given header has to carry. They
+// are empty unless the header is a broadcast block, which lists the candidate
+// set as of the block closing its candidate period.
+func (bc *BlockChain) NodeLists(header *types.Header) (election.NodeList, error) {
+	number := header.Number.Uint64()
+	if !params.IsBroadcastNumber(number) {
+		return election.NodeList{}, nil
+	}
+	hash, ancestor := header.ParentHash, number-1
+	for ; ancestor > number-candidateLeadBlocks; ancestor-- {
+		parent := bc.GetHeader(hash, ancestor)
+		if parent == nil {
+			return election.NodeList{}, errMissingCandidateBlock
+		}
+		hash = parent.ParentHash
+	}
+	candidates, err := bc.candidates.Candidates(hash, ancestor)
+	if err != nil {
+		return election.NodeList{}, err
+	}
+	return MainNodeList(bc.chainConfig.BootNodes(), candidates), nil
+}
+
+// Topology returns the main node lists of a header as a topology object. It is
+// empty unless the header is a broadcast block.
+func (bc *BlockChain) Topology(header *types.Header) (*types.Topology, error) {
+	lists, err := bc.NodeLists(header)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Topology{
+		MinerList:     lists.MinerList,
+		CommitteeList: lists.CommitteeList,
+		Both:          lists.Both,
+		OfflineList:   lists.OfflineList,
+	}, nil
+}
+
+// SetNodeLists commits a header being assembled to its main node lists. Headers
+// from before the node list fork are left without.
+func (bc *BlockChain) SetNodeLists(header *types.Header) error {
+	if !bc.chainConfig.IsNodeList(header.Number) {
+		return nil
+	}
+	topology, err := bc.Topology(header)
+	if err != nil {
+		return err
+	}
+	header.TopologyRoot = topology.Hash()
+	return nil
+}
+
+// ValidateNodeLists checks the topology root of a header against the main node
+// lists derived from the chain. Non-broadcast blocks have to leave it empty and
+// only headers from before the node list fork may list the nodes inline.
+func (bc *BlockChain) ValidateNodeLists(header *types.Header) error {
+	if !bc.chainConfig.IsNodeList(header.Number) {
+		if header.TopologyRoot != (common.Hash{}) {
+			return ErrTopologyRootUnscheduled
+		}
+		return nil
+	}
+	if header.LegacyNodeLists() != nil {
+		return ErrLegacyNodeLists
+	}
+	if !params.IsBroadcastNumber(header.Number.Uint64()) {
+		if header.HasNodeLists() {
+			return ErrNodeListsNotEmpty
+		}
+		return nil
+	}
+	want, err := bc.Topology(header)
+	if err != nil {
+		return err
+	}
+	if root := want.Hash(); header.TopologyRoot != root {
+		return fmt.Errorf("%v: have root %x, want %x", ErrNodeListsMismatch, header.TopologyRoot, root)
+	}
+	return nil
+}
+
+// publishedTopology returns the main node lists a header publishes: the ones it
+// lists inline if it is from before the node list fork, otherwise the lists
+// derived from the chain if they match its topology root. It returns nil if the
+// header publishes none or they cannot be derived.
+func (bc *BlockChain) publishedTopology(header *types.Header) *types.Topology {
+	if legacy := header.LegacyNodeLists(); legacy != nil {
+		return legacy
+	}
+	if header.TopologyRoot == (common.Hash{}) {
+		return nil
+	}
+	topology, err := bc.Topology(header)
+	if err != nil || topology.Hash() != header.TopologyRoot {
+		return nil
+	}
+	return topology
+}
+
+// GetTopology retrieves the main node lists published by a block, deriving and
+// storing them if the block was imported without.
+func (bc *BlockChain) GetTopology(hash common.Hash, number uint64) *types.Topology {
+	if topology := rawdb.ReadTopology(bc.db, hash, number); topology != nil {
+		return topology
+	}
+	header := bc.GetHeader(hash, number)
+	if header == nil {
+		return nil
+	}
+	topology := bc.publishedTopology(header)
+	if topology == nil {
+		return nil
+	}
+	rawdb.WriteTopology(bc.db, hash, number, topology)
+	return topology
+}
@@ -0,0 +1,143 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that the main node lists are derived deterministically and that the
+// boot nodes are dropped from them once they exit.
+func TestMainNodeList(t *testing.T) {
+	boot := MainNodeList(params.MainnetBootnodes, nil)
+	if len(boot.CommitteeList) != 1 || len(boot.MinerList) != len(params.MainnetBootnodes)-1 {
+		t.Fatalf("boot node lists mismatch: committee %d, miner %d", len(boot.CommitteeList), len(boot.MinerList))
+	}
+	for i := 1; i < len(boot.MinerList); i++ {
+		if boot.MinerList[i-1].ID >= boot.MinerList[i].ID {
+			t.Fatalf("miner list not sorted at %d", i)
+		}
+	}
+	exit := &types.ElectionTxPayLoadInfo{ID: boot.MinerList[0].ID, ElectType: types.ElectExit}
+	lists := MainNodeList(params.MainnetBootnodes, []*types.ElectionTxPayLoadInfo{exit})
+	if len(lists.MinerList) != len(boot.MinerList)-1 || len(lists.OfflineList) != 1 || lists.OfflineList[0].ID != exit.ID {
+		t.Fatalf("exited boot node still listed: miner %d, offline %d", len(lists.MinerList), len(lists.OfflineList))
+	}
+}
+
+// Tests that broadcast blocks are only accepted committing to the derived node
+// lists, that any other block has to leave its topology root empty and that the
+// lists of accepted broadcast blocks are stored.
+func TestValidateNodeLists(t *testing.T) {
+	config := *params.TestChainConfig
+	config.NodeListBlock = big.NewInt(0)
+
+	lists := MainNodeList(params.MainnetBootnodes, nil)
+	topology := &types.Topology{MinerList: lists.MinerList, CommitteeList: lists.CommitteeList}
+	tests := []struct {
+		name  string
+		fill  func(number uint64) bool
+		valid bool
+	}{
+		{"derived lists", func(number uint64) bool { return number == 10 }, true},
+		{"missing lists", func(number uint64) bool { return false }, false},
+		{"lists in ordinary block", func(number uint64) bool { return number == 10 || number == 5 }, false},
+	}
+	for _, tt := range tests {
+		var (
+			db      = ethdb.NewMemDatabase()
+			gspec   = &Genesis{Config: &config}
+			genesis = gspec.MustCommit(db)
+		)
+		blocks, _ := GenerateChain(&config, genesis, ethash.NewFaker(), db, 12, func(i int, gen *BlockGen) {
+			if tt.fill(gen.Number().Uint64()) {
+				gen.header.TopologyRoot = topology.Hash()
+			}
+		})
+		chain, _ := NewBlockChain(db, nil, &config, ethash.NewFaker(), vm.Config{})
+
+		_, err := chain.InsertChain(blocks)
+		if tt.valid && err != nil {
+			t.Errorf("%s: failed to insert chain: %v", tt.name, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("%s: invalid chain accepted", tt.name)
+		}
+		if tt.valid {
+			if have := chain.GetTopology(blocks[9].Hash(), 10); have.Hash() != topology.Hash() {
+				t.Errorf("%s: stored topology mismatch: have %x, want %x", tt.name, have.Hash(), topology.Hash())
+			}
+		}
+		chain.Stop()
+	}
+}
+
+// Tests that blocks from before the node list fork may not commit to a topology
+// root.
+func TestValidateNodeListsBeforeFork(t *testing.T) {
+	config := *params.TestChainConfig
+	config.NodeListBlock = big.NewInt(100)
+
+	var (
+		db       = ethdb.NewMemDatabase()
+		gspec    = &Genesis{Config: &config}
+		genesis  = gspec.MustCommit(db)
+		topology = &types.Topology{MinerList: MainNodeList(params.MainnetBootnodes, nil).MinerList}
+	)
+	blocks, _ := GenerateChain(&config, genesis, ethash.NewFaker(), db, 12, func(i int, gen *BlockGen) {
+		if gen.Number().Uint64() == 10 {
+			gen.header.TopologyRoot = topology.Hash()
+		}
+	})
+	chain, _ := NewBlockChain(db, nil, &config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks); err != ErrTopologyRootUnscheduled {
+		t.Fatalf("insert error mismatch: have %v, want %v", err, ErrTopologyRootUnscheduled)
+	}
+}
+
+// Tests that the main node lists a broadcast block from before the node list
+// fork lists inline are served as its topology.
+func TestGetTopologyLegacy(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		gspec   = &Genesis{Config: params.TestChainConfig}
+		genesis = gspec.MustCommit(db)
+		lists   = MainNodeList(params.MainnetBootnodes, nil)
+	)
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	// Assemble a broadcast header in the layout from before the fork
+	enc, err := rlp.EncodeToBytes([]interface{}{
+		genesis.Hash(), types.EmptyUncleHash, common.Address{}, genesis.Root(), types.EmptyRootHash, types.EmptyRootHash,
+		types.Bloom{}, big.NewInt(131072), big.NewInt(10), uint64(4712388), uint64(0), big.NewInt(1426516743), []byte{},
+		lists.MinerList, lists.CommitteeList, lists.Both, lists.OfflineList,
+		common.Hash{}, types.BlockNonce{},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode legacy header: %v", err)
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(enc, header); err != nil {
+		t.Fatalf("failed to decode legacy header: %v", err)
+	}
+	rawdb.WriteHeader(db, header)
+
+	want := &types.Topology{MinerList: lists.MinerList, CommitteeList: lists.CommitteeList}
+	if have := chain.GetTopology(header.Hash(), 10); have == nil || have.Hash() != want.Hash() {
+		t.Fatalf("legacy topology mismatch: have %v, want %v", have, want)
+	}
+	if stored := rawdb.ReadTopology(db, header.Hash(), 10); stored == nil || stored.Hash() != want.Hash() {
+		t.Errorf("legacy topology not stored: %v", stored)
+	}
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that transactions on different nonce lanes of an account are executed
+// independently of each other, each lane keeping its own sequence.
+func TestNonceLanes(t *testing.T) {
+	var (
+		db     = ethdb.NewMemDatabase()
+		key, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		from   = crypto.PubkeyToAddress(key.PublicKey)
+		config = *params.TestChainConfig
+		signer = types.NewEIP155Signer(config.ChainID)
+	)
+	config.NonceLaneBlock = big.NewInt(0)
+
+	gspec := &Genesis{Config: &config, Alloc: GenesisAlloc{from: {Balance: big.NewInt(1000000000)}}}
+	genesis := gspec.MustCommit(db)
+
+	// Interleave two lanes with the first one, which has to be opened beforehand
+	nonces := []uint64{0, types.LaneNonce(2, 0), types.LaneNonce(1, 0), 1, types.LaneNonce(2, 1)}
+	blocks, _ := GenerateChain(&config, genesis, ethash.NewFaker(), db, 1, func(i int, gen *BlockGen) {
+		for _, nonce := range nonces {
+			tx, _ := types.SignTx(types.NewTransaction(nonce, common.Address{0x01}, big.NewInt(1), params.TxGas, big.NewInt(1), nil), signer, key)
+			gen.AddTx(tx)
+		}
+	})
+	statedb, _ := state.New(blocks[0].Root(), state.NewDatabase(db))
+	for lane, want := range []uint64{2, types.LaneNonce(1, 1), types.LaneNonce(2, 2), types.LaneNonce(3, 0)} {
+		if have := statedb.GetLaneNonce(from, uint64(lane)); have != want {
+			t.Errorf("lane %d: nonce mismatch: have %#x, want %#x", lane, have, want)
+		}
+	}
+	if have := statedb.GetBalance(common.Address{0x01}); have.Int64() != int64(len(nonces)) {
+		t.Errorf("recipient balance mismatch: have %v, want %d", have, len(nonces))
+	}
+}
+
+// Tests the rules transactions on nonce lanes beyond the first have to obey.
+func TestValidateNonceLane(t *testing.T) {
+	tests := []struct {
+		lane, nonce uint64
+		create      bool
+		err         error
+	}{
+		{0, 0, false, nil},
+		{0, 0, true, nil},
+		{1, 1, false, nil},
+		{params.NonceLanes - 1, 1, false, nil},
+		{params.NonceLanes, 1, false, ErrInvalidNonceLane},
+		{1, 1, true, ErrLaneContractCreation},
+		{1, 0, false, ErrNonceLaneClosed},
+	}
+	for i, tt := range tests {
+		if err := validateNonceLane(tt.lane, tt.nonce, tt.create); err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that the transaction pool promotes, replaces and invalidates the
+// transactions of each nonce lane without stalling the others.
+func TestTransactionNonceLanes(t *testing.T) {
+	t.Parallel()
+
+	config := *params.TestChainConfig
+	config.NonceLaneBlock = big.NewInt(0)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	pool := NewTxPool(testTxPoolConfig, &config, blockchain)
+	defer pool.Stop()
+
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	pool.currentState.SetNonce(addr, 1)
+	pool.currentState.AddBalance(addr, big.NewInt(1000000))
+	pool.lockedReset(nil, nil)
+
+	// A gap on the first lane must not hold back the second one
+	for _, nonce := range []uint64{2, types.LaneNonce(1, 0), types.LaneNonce(1, 1)} {
+		if err := pool.AddRemote(transaction(nonce, 100000, key)); err != nil {
+			t.Fatalf("failed to add transaction %#x: %v", nonce, err)
+		}
+	}
+	if pending, queued := pool.Stats(); pending != 2 || queued != 1 {
+		t.Fatalf("pool stats mismatch: have %d pending %d queued, want 2 pending 1 queued", pending, queued)
+	}
+	if nonce := pool.State().GetLaneNonce(addr, 1); nonce != types.LaneNonce(1, 2) {
+		t.Errorf("pending lane nonce mismatch: have %#x, want %#x", nonce, types.LaneNonce(1, 2))
+	}
+	if nonce := pool.State().GetLaneNonce(addr, 0); nonce != 1 {
+		t.Errorf("pending account nonce mismatch: have %d, want 1", nonce)
+	}
+	// Replacements are matched on the lane and its sequence
+	if err := pool.AddRemote(pricedTransaction(types.LaneNonce(1, 0), 100000, big.NewInt(1), key)); err != ErrReplaceUnderpriced {
+		t.Errorf("underpriced replacement error mismatch: have %v, want %v", err, ErrReplaceUnderpriced)
+	}
+	if err := pool.AddRemote(pricedTransaction(types.LaneNonce(1, 0), 100000, big.NewInt(2), key)); err != nil {
+		t.Errorf("failed to replace lane transaction: %v", err)
+	}
+	// Lanes beyond the configured count are rejected
+	if err := pool.AddRemote(transaction(types.LaneNonce(params.NonceLanes, 0), 100000, key)); err != ErrInvalidNonceLane {
+		t.Errorf("invalid lane error mismatch: have %v, want %v", err, ErrInvalidNonceLane)
+	}
+	// Filling the gap of the first lane promotes it, leaving the second alone
+	if err := pool.AddRemote(transaction(1, 100000, key)); err != nil {
+		t.Fatalf("failed to add gap filling transaction: %v", err)
+	}
+	if pending, queued := pool.Stats(); pending != 4 || queued != 0 {
+		t.Fatalf("pool stats mismatch: have %d pending %d queued, want 4 pending 0 queued", pending, queued)
+	}
+	// Executing the start of the second lane drops it from the pool only
+	pool.currentState.SetLaneNonce(addr, types.LaneNonce(1, 1))
+	pool.lockedReset(nil, nil)
+
+	if pending, queued := pool.Stats(); pending != 3 || queued != 0 {
+		t.Fatalf("pool stats mismatch after execution: have %d pending %d queued, want 3 pending 0 queued", pending, queued)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReadCandidates retrieves the election candidate set as of the given block,
+// along with whether a set was stored for the block at all.
+func ReadCandidates(db DatabaseReader, hash common.Hash, number uint64) ([]*types.ElectionTxPayLoadInfo, bool) {
+	data, _ := db.Get(candidatesKey(number, hash))
+	if len(data) == 0 {
+		return nil, false
+	}
+	var nodes []*types.ElectionTxPayLoadInfo
+	if err := rlp.DecodeBytes(data, &nodes); err != nil {
+		log.Error("Invalid candidate set RLP", "hash", hash, "err", err)
+		return nil, false
+	}
+	return nodes, true
+}
+
+// WriteCandidates stores the election candidate set as of the given block.
+func WriteCandidates(db DatabaseWriter, hash common.Hash, number uint64, nodes []*types.ElectionTxPayLoadInfo) {
+	data, err := rlp.EncodeToBytes(nodes)
+	if err != nil {
+		log.Crit("Failed to encode candidate set", "err", err)
+	}
+	if err := db.Put(candidatesKey(number, hash), data); err != nil {
+		log.Crit("Failed to store candidate set", "err", err)
+	}
+}
+
+// DeleteCandidates removes the election candidate set stored for a block.
+func DeleteCandidates(db DatabaseDeleter, hash common.Hash, number uint64) {
+	if err := db.Delete(candidatesKey(number, hash)); err != nil {
+		log.Crit("Failed to delete candidate set", "err", err)
+	}
+}
+
+// ReadDelegations retrieves the delegated stake as of the given block.
+func ReadDelegations(db DatabaseReader, hash common.Hash, number uint64) *types.Delegations {
+	data, _ := db.Get(delegationsKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	delegations := new(types.Delegations)
+	if err := rlp.DecodeBytes(data, delegations); err != nil {
+		log.Error("Invalid delegations RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return delegations
+}
+
+// WriteDelegations stores the delegated stake as of the given block.
+func WriteDelegations(db DatabaseWriter, hash common.Hash, number uint64, delegations *types.Delegations) {
+	data, err := rlp.EncodeToBytes(delegations)
+	if err != nil {
+		log.Crit("Failed to encode delegations", "err", err)
+	}
+	if err := db.Put(delegationsKey(number, hash), data); err != nil {
+		log.Crit("Failed to store delegations", "err", err)
+	}
+}
+
+// DeleteDelegations removes the delegated stake stored for a block.
+func DeleteDelegations(db DatabaseDeleter, hash common.Hash, number uint64) {
+	if err := db.Delete(delegationsKey(number, hash)); err != nil {
+		log.Crit("Failed to delete delegations", "err", err)
+	}
+}
+
+// ReadTopology retrieves the main node lists published by a broadcast block.
+func ReadTopology(db DatabaseReader, hash common.Hash, number uint64) *types.Topology {
+	data, _ := db.Get(topologyKey(number, hash))
+	if len(data) == 0 {
+		return nil
+	}
+	topology := new(types.Topology)
+	if err := rlp.DecodeBytes(data, topology); err != nil {
+		log.Error("Invalid topology RLP", "hash", hash, "err", err)
+		return nil
+	}
+	return topology
+}
+
+// WriteTopology stores the main node lists published by a broadcast block.
+func WriteTopology(db DatabaseWriter, hash common.Hash, number uint64, topology *types.Topology) {
+	data, err := rlp.EncodeToBytes(topology)
+	if err != nil {
+		log.Crit("Failed to encode topology", "err", err)
+	}
+	if err := db.Put(topologyKey(number, hash), data); err != nil {
+		log.Crit("Failed to store topology", "err", err)
+	}
+}
+
+// DeleteTopology removes the main node lists stored for a block.
+func DeleteTopology(db DatabaseDeleter, hash common.Hash, number uint64) {
+	if err := db.Delete(topologyKey(number, hash)); err != nil {
+		log.Crit("Failed to delete topology", "err", err)
+	}
+}
+
+// ReadElectionRecords retrieves the records of the elections of the given role
+// held for a candidate period, in the order they were held. The records are
+// opaque to the database, they are encoded by the elector.
+func ReadElectionRecords(db DatabaseReader, period uint64, role string) [][]byte {
+	data, _ := db.Get(electionRecordKey(period, role))
+	if len(data) == 0 {
+		return nil
+	}
+	var records [][]byte
+	if err := rlp.DecodeBytes(data, &records); err != nil {
+		log.Error("Invalid election records RLP", "period", period, "role", role, "err", err)
+		return nil
+	}
+	return records
+}
+
+// WriteElectionRecords stores the records of the elections of the given role
+// held for a candidate period. An election is held again within the period if
+// the first one failed, so every record held is kept.
+func WriteElectionRecords(db DatabaseWriter, period uint64, role string, records [][]byte) {
+	data, err := rlp.EncodeToBytes(records)
+	if err != nil {
+		log.Crit("Failed to encode election records", "err", err)
+	}
+	if err := db.Put(electionRecordKey(period, role), data); err != nil {
+		log.Crit("Failed to store election records", "err", err)
+	}
+}
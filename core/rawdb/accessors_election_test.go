@@ -0,0 +1,114 @@
+package rawdb
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Tests candidate set storage and retrieval operations.
+func TestCandidatesStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	hash := common.HexToHash("0x0102")
+
+	if _, ok := ReadCandidates(db, hash, 8); ok {
+		t.Fatalf("non existent candidate set returned")
+	}
+	// An empty set has to be distinguishable from a missing one
+	WriteCandidates(db, hash, 8, nil)
+	if nodes, ok := ReadCandidates(db, hash, 8); !ok || len(nodes) != 0 {
+		t.Fatalf("empty candidate set mismatch: have %v/%v", nodes, ok)
+	}
+	nodes := []*types.ElectionTxPayLoadInfo{
+		{ID: "01", IP: "192.168.3.95", TPS: 2000, ElectType: types.ElectMiner, Account: common.HexToAddress("0x11")},
+		{ID: "02", IP: "192.168.3.96", ElectType: types.ElectExit, Version: types.ElectionPayLoadV1, Signature: []byte{1, 2, 3}},
+	}
+	WriteCandidates(db, hash, 8, nodes)
+	have, ok := ReadCandidates(db, hash, 8)
+	if !ok || len(have) != len(nodes) {
+		t.Fatalf("candidate set mismatch: have %d/%v, want %d", len(have), ok, len(nodes))
+	}
+	for i := range nodes {
+		if have[i].ID != nodes[i].ID || have[i].ElectType != nodes[i].ElectType || have[i].Account != nodes[i].Account {
+			t.Errorf("candidate %d mismatch: have %+v, want %+v", i, have[i], nodes[i])
+		}
+	}
+	DeleteCandidates(db, hash, 8)
+	if _, ok := ReadCandidates(db, hash, 8); ok {
+		t.Fatalf("deleted candidate set returned")
+	}
+}
+
+// Tests delegated stake storage and retrieval operations.
+func TestDelegationsStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	hash := common.HexToHash("0x0102")
+
+	if delegations := ReadDelegations(db, hash, 8); delegations != nil {
+		t.Fatalf("non existent delegations returned: %v", delegations)
+	}
+	delegations := &types.Delegations{
+		Bonds:      []*types.Bond{{Delegator: common.HexToAddress("0x11"), NodeID: "01", Amount: big.NewInt(5000)}},
+		Unbondings: []*types.Unbonding{{Delegator: common.HexToAddress("0x12"), NodeID: "01", Amount: big.NewInt(3000), Release: 40}},
+	}
+	WriteDelegations(db, hash, 8, delegations)
+	if have := ReadDelegations(db, hash, 8); !reflect.DeepEqual(have, delegations) {
+		t.Fatalf("delegations mismatch: have %+v, want %+v", have, delegations)
+	}
+	DeleteDelegations(db, hash, 8)
+	if delegations := ReadDelegations(db, hash, 8); delegations != nil {
+		t.Fatalf("deleted delegations returned: %v", delegations)
+	}
+}
+
+// Tests topology storage and retrieval operations.
+func TestTopologyStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	hash := common.HexToHash("0x0102")
+
+	if topology := ReadTopology(db, hash, 10); topology != nil {
+		t.Fatalf("non existent topology returned: %v", topology)
+	}
+	topology := &types.Topology{
+		MinerList:     []election.NodeInfo{{ID: "01", IP: "192.168.3.95", Wealth: 10000}},
+		CommitteeList: []election.NodeInfo{{ID: "02", IP: "192.168.3.96", Wealth: 10000}},
+	}
+	WriteTopology(db, hash, 10, topology)
+	have := ReadTopology(db, hash, 10)
+	if have == nil {
+		t.Fatalf("stored topology not found")
+	}
+	if have.Hash() != topology.Hash() {
+		t.Fatalf("topology root mismatch: have %x, want %x", have.Hash(), topology.Hash())
+	}
+	DeleteBlock(db, hash, 10)
+	if topology := ReadTopology(db, hash, 10); topology != nil {
+		t.Fatalf("deleted topology returned: %v", topology)
+	}
+}
+
+// Tests election record storage and retrieval operations.
+func TestElectionRecordStorage(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+
+	if records := ReadElectionRecords(db, 1, "miner"); records != nil {
+		t.Fatalf("non existent election records returned: %v", records)
+	}
+	records := [][]byte{[]byte(`{"number":8}`), []byte(`{"number":9}`)}
+	WriteElectionRecords(db, 1, "miner", records)
+	if have := ReadElectionRecords(db, 1, "miner"); !reflect.DeepEqual(have, records) {
+		t.Fatalf("election records mismatch: have %q, want %q", have, records)
+	}
+	// Records are kept per period and role
+	if records := ReadElectionRecords(db, 1, "validator"); records != nil {
+		t.Fatalf("validator records returned for miner elections: %q", records)
+	}
+	if records := ReadElectionRecords(db, 2, "miner"); records != nil {
+		t.Fatalf("records returned for other period: %q", records)
+	}
+}
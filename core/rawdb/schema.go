@@ -53,6 +53,12 @@ var (
 	txLookupPrefix  = []byte("l") // txLookupPrefix + hash -> transaction/receipt lookup metadata
 	bloomBitsPrefix = []byte("B") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> bloom bits
 
+	candidatesPrefix  = []byte("c") // candidatesPrefix + num (uint64 big endian) + hash -> election candidate set
+	delegationsPrefix = []byte("d") // delegationsPrefix + num (uint64 big endian) + hash -> delegated stake
+	topologyPrefix    = []byte("o") // topologyPrefix + num (uint64 big endian) + hash -> main node lists
+
+	electionRecordPrefix = []byte("E") // electionRecordPrefix + period (uint64 big endian) + role -> election records
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
@@ -108,6 +114,26 @@ func blockReceiptsKey(number uint64, hash common.Hash) []byte {
 	return append(append(blockReceiptsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
 }
 
+// candidatesKey = candidatesPrefix + num (uint64 big endian) + hash
+func candidatesKey(number uint64, hash common.Hash) []byte {
+	return append(append(candidatesPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// delegationsKey = delegationsPrefix + num (uint64 big endian) + hash
+func delegationsKey(number uint64, hash common.Hash) []byte {
+	return append(append(delegationsPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// topologyKey = topologyPrefix + num (uint64 big endian) + hash
+func topologyKey(number uint64, hash common.Hash) []byte {
+	return append(append(topologyPrefix, encodeBlockNumber(number)...), hash.Bytes()...)
+}
+
+// electionRecordKey = electionRecordPrefix + period (uint64 big endian) + role
+func electionRecordKey(period uint64, role string) []byte {
+	return append(append(electionRecordPrefix, encodeBlockNumber(period)...), role...)
+}
+
 // txLookupKey = txLookupPrefix + hash
 func txLookupKey(hash common.Hash) []byte {
 	return append(txLookupPrefix, hash.Bytes()...)
@@ -34,6 +34,7 @@ type ManagedState struct {
 	mu sync.RWMutex
 
 	accounts map[common.Address]*account
+	lanes    map[common.Address]map[uint64]uint64 // Pending nonces of the lanes beyond the first
 }
 
 // ManagedState returns a new ptcaged state with the statedb as it's backing layer
@@ -41,6 +42,7 @@ func ManageState(statedb *StateDB) *ManagedState {
 	return &ManagedState{
 		StateDB:  statedb.Copy(),
 		accounts: make(map[common.Address]*account),
+		lanes:    make(map[common.Address]map[uint64]uint64),
 	}
 }
 
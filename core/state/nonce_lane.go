@@ -0,0 +1,70 @@
+package state
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// laneNonceKey returns the storage slot of an account holding the sequence
+// number of the next transaction on one of its nonce lanes beyond the first.
+// Only accounts without code send transactions, so the slot cannot collide
+// with contract storage.
+func laneNonceKey(lane uint64) common.Hash {
+	return crypto.Keccak256Hash([]byte("nonce lane"), common.BigToHash(new(big.Int).SetUint64(lane)).Bytes())
+}
+
+// GetLaneNonce returns the next nonce of the given lane of an account. The first
+// lane is the account nonce itself.
+func (self *StateDB) GetLaneNonce(addr common.Address, lane uint64) uint64 {
+	if lane == 0 {
+		return self.GetNonce(addr)
+	}
+	return types.LaneNonce(lane, self.GetState(addr, laneNonceKey(lane)).Big().Uint64())
+}
+
+// SetLaneNonce sets the next nonce of the lane the given nonce belongs to.
+func (self *StateDB) SetLaneNonce(addr common.Address, nonce uint64) {
+	lane := types.NonceLane(nonce)
+	if lane == 0 {
+		self.SetNonce(addr, nonce)
+		return
+	}
+	self.SetState(addr, laneNonceKey(lane), common.BigToHash(new(big.Int).SetUint64(types.NonceSeq(nonce))))
+}
+
+// GetLaneNonce returns the canonical nonce of the given lane for the ptcaged or
+// unptcaged account.
+func (ms *ManagedState) GetLaneNonce(addr common.Address, lane uint64) uint64 {
+	if lane == 0 {
+		return ms.GetNonce(addr)
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	// Always make sure the state lane nonce isn't actually higher than the tracked one
+	nonce := ms.StateDB.GetLaneNonce(addr, lane)
+	if tracked, ok := ms.lanes[addr][lane]; ok && tracked > nonce {
+		return tracked
+	}
+	return nonce
+}
+
+// SetLaneNonce sets the new canonical nonce of the lane the given nonce belongs
+// to for the ptcaged state.
+func (ms *ManagedState) SetLaneNonce(addr common.Address, nonce uint64) {
+	lane := types.NonceLane(nonce)
+	if lane == 0 {
+		ms.SetNonce(addr, nonce)
+		return
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.lanes[addr] == nil {
+		ms.lanes[addr] = make(map[uint64]uint64)
+	}
+	ms.lanes[addr][lane] = nonce
+}
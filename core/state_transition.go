@@ -22,6 +22,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/params"
@@ -58,6 +59,7 @@ type StateTransition struct {
 	data       []byte
 	state      vm.StateDB
 	evm        *vm.EVM
+	transfers  []*types.TransferReceipt
 }
 
 // Message represents a message sent to a contract.
@@ -75,6 +77,12 @@ type Message interface {
 	Data() []byte
 }
 
+// extendedMessage is implemented by messages carrying the extension of their
+// transaction, whose one-to-many transfers are executed after the main call.
+type extendedMessage interface {
+	Extra() types.Matrix_Extra
+}
+
 // IntrinsicGas computes the 'intrinsic gas' for a message with the given data.
 func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error) {
 	// Set the starting gas for the raw transaction
@@ -108,6 +116,25 @@ func IntrinsicGas(data []byte, contractCreation, homestead bool) (uint64, error)
 	return gas, nil
 }
 
+// ExtraIntrinsicGas computes the intrinsic gas of the one-to-many legs of a
+// transaction extension, charged on top of the gas of the transaction itself.
+func ExtraIntrinsicGas(extra []types.Matrix_Extra, homestead bool) (uint64, error) {
+	var gas uint64
+	for _, ex := range extra {
+		for _, leg := range ex.ExtraTo {
+			legGas, err := IntrinsicGas(leg.Payload, leg.Recipient == nil, homestead)
+			if err != nil {
+				return 0, err
+			}
+			if gas+legGas < gas {
+				return 0, vm.ErrOutOfGas
+			}
+			gas += legGas
+		}
+	}
+	return gas, nil
+}
+
 // NewStateTransition initialises and returns a new state transition object.
 func NewStateTransition(evm *vm.EVM, msg Message, gp *GasPool) *StateTransition {
 	return &StateTransition{
@@ -168,6 +195,12 @@ func (st *StateTransition) preCheck() error {
 	// Make sure this transaction's nonce is correct.
 	if st.msg.CheckNonce() {
 		nonce := st.state.GetNonce(st.msg.From())
+		if lane := types.NonceLane(st.msg.Nonce()); lane > 0 && st.evm.ChainConfig().IsNonceLane(st.evm.BlockNumber) {
+			if err := validateNonceLane(lane, nonce, st.msg.To() == nil); err != nil {
+				return err
+			}
+			nonce = st.state.GetLaneNonce(st.msg.From(), lane)
+		}
 		if nonce < st.msg.Nonce() {
 			return ErrNonceTooHigh
 		} else if nonce > st.msg.Nonce() {
@@ -177,6 +210,20 @@ func (st *StateTransition) preCheck() error {
 	return st.buyGas()
 }
 
+// validateNonceLane checks whether an account, at the given nonce on its first
+// lane, may send a transaction on the given lane.
+func validateNonceLane(lane, nonce uint64, contractCreation bool) error {
+	switch {
+	case lane >= params.NonceLanes:
+		return ErrInvalidNonceLane
+	case lane > 0 && contractCreation:
+		return ErrLaneContractCreation
+	case lane > 0 && nonce == 0:
+		return ErrNonceLaneClosed
+	}
+	return nil
+}
+
 // TransitionDb will transition the state by applying the current message and
 // returning the result including the the used gas. It returns an error if it
 // failed. An error indicates a consensus issue.
@@ -189,12 +236,24 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	homestead := st.evm.ChainConfig().IsHomestead(st.evm.BlockNumber)
 	contractCreation := msg.To() == nil
 
-	// Pay intrinsic gas
+	// Pay intrinsic gas, the one of the one-to-many transfers included once they
+	// are executed
 	gas, err := IntrinsicGas(st.data, contractCreation, homestead)
 	if err != nil {
 		return nil, 0, false, err
 	}
-	if err = st.useGas(gas); err != nil {
+	var extra types.Matrix_Extra
+	if ext, ok := msg.(extendedMessage); ok && !contractCreation && st.evm.ChainConfig().IsExtraTo(st.evm.BlockNumber) {
+		extra = ext.Extra()
+	}
+	extraGas, err := ExtraIntrinsicGas([]types.Matrix_Extra{extra}, homestead)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	if gas+extraGas < gas {
+		return nil, 0, false, vm.ErrOutOfGas
+	}
+	if err = st.useGas(gas + extraGas); err != nil {
 		return nil, 0, false, err
 	}
 
@@ -208,8 +267,12 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 	if contractCreation {
 		ret, _, st.gas, vmerr = evm.Create(sender, st.data, st.gas, st.value)
 	} else {
-		// Increment the nonce for the next transaction
-		st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		// Increment the nonce of the lane for the next transaction
+		if lane := types.NonceLane(msg.Nonce()); lane > 0 && st.evm.ChainConfig().IsNonceLane(st.evm.BlockNumber) {
+			st.state.SetLaneNonce(msg.From(), st.state.GetLaneNonce(sender.Address(), lane)+1)
+		} else {
+			st.state.SetNonce(msg.From(), st.state.GetNonce(sender.Address())+1)
+		}
 		ret, st.gas, vmerr = evm.Call(sender, st.to(), st.data, st.gas, st.value)
 	}
 	if vmerr != nil {
@@ -221,12 +284,58 @@ func (st *StateTransition) TransitionDb() (ret []byte, usedGas uint64, failed bo
 			return nil, 0, false, vmerr
 		}
 	}
+	if len(extra.ExtraTo) > 0 {
+		if vmerr == nil {
+			st.state.AddLog(types.NewTransferLog(msg.From(), st.to(), st.value, st.evm.BlockNumber.Uint64()))
+		}
+		st.transfers = st.transfer(sender, extra.ExtraTo, homestead)
+	}
 	st.refundGas()
 	st.state.AddBalance(st.evm.Coinbase, new(big.Int).Mul(new(big.Int).SetUint64(st.gasUsed()), st.gasPrice))
 
 	return ret, st.gasUsed(), vmerr != nil, err
 }
 
+// transfer executes the one-to-many transfers of an extended transaction, each
+// on its own: a failing transfer is reverted without affecting the others or
+// the main call. Successful transfers are recorded by a synthetic log so their
+// recipients get indexed by the bloom filters.
+func (st *StateTransition) transfer(sender vm.AccountRef, legs []types.Tx_to, homestead bool) []*types.TransferReceipt {
+	receipts := make([]*types.TransferReceipt, len(legs))
+	for i, leg := range legs {
+		// Intrinsic gas was paid up front, account it to the transfer
+		gas, _ := IntrinsicGas(leg.Payload, leg.Recipient == nil, homestead)
+
+		receipt := &types.TransferReceipt{Value: new(big.Int), Status: types.ReceiptStatusFailed}
+		if leg.Amount != nil {
+			receipt.Value.Set(leg.Amount)
+		}
+		receipts[i] = receipt
+
+		// Contract creations would shift the sender nonce, they always fail
+		if leg.Recipient == nil {
+			receipt.GasUsed = gas
+			continue
+		}
+		receipt.To = *leg.Recipient
+
+		var (
+			remaining = st.gas
+			err       error
+		)
+		_, st.gas, err = st.evm.Call(sender, receipt.To, leg.Payload, st.gas, receipt.Value)
+		receipt.GasUsed = gas + remaining - st.gas
+
+		if err != nil {
+			log.Debug("Transfer failed", "to", receipt.To, "err", err)
+			continue
+		}
+		receipt.Status = types.ReceiptStatusSuccessful
+		st.state.AddLog(types.NewTransferLog(sender.Address(), receipt.To, receipt.Value, st.evm.BlockNumber.Uint64()))
+	}
+	return receipts
+}
+
 func (st *StateTransition) refundGas() {
 	// Apply refund counter, capped to half of the used gas.
 	refund := st.gasUsed() / 2
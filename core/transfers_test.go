@@ -0,0 +1,92 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the one-to-many transfers of extended transactions are executed
+// one by one from their fork on, reported by the receipt and logged for the
+// bloom filters.
+func TestExtendedTransfers(t *testing.T) {
+	var (
+		db      = ethdb.NewMemDatabase()
+		key, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		from    = crypto.PubkeyToAddress(key.PublicKey)
+		main    = common.Address{0x01}
+		payee   = common.Address{0x02}
+		failing = common.Address{0x03} // Contract hitting an invalid opcode
+		config  = *params.TestChainConfig
+	)
+	config.PTCSignerBlock = big.NewInt(0)
+	config.ExtraToBlock = big.NewInt(2)
+
+	gspec := &Genesis{Config: &config, Alloc: GenesisAlloc{
+		from:    {Balance: big.NewInt(1000000000)},
+		failing: {Balance: new(big.Int), Code: []byte{0xfe}},
+	}}
+	genesis := gspec.MustCommit(db)
+
+	blocks, receipts := GenerateChain(&config, genesis, ethash.NewFaker(), db, 2, func(i int, gen *BlockGen) {
+		legs := []*types.ExtraTo_tr{
+			{To_tr: &payee, Value_tr: (*hexutil.Big)(big.NewInt(100))},
+			{To_tr: &failing, Value_tr: (*hexutil.Big)(big.NewInt(300))},
+		}
+		tx := types.NewTransactions(gen.TxNonce(from), main, big.NewInt(50), 100000, big.NewInt(1), nil, legs, 0, 0)
+		tx, _ = types.SignTx(tx, types.NewPTCSigner(config.ChainID), key)
+		gen.AddTx(tx)
+	})
+	// Before the fork only the main call is executed and paid for
+	statedb, _ := state.New(blocks[0].Root(), state.NewDatabase(db))
+	if have := statedb.GetBalance(payee); have.Sign() != 0 {
+		t.Errorf("pre-fork payee balance mismatch: have %v, want 0", have)
+	}
+	if receipt := receipts[0][0]; len(receipt.Transfers) != 0 || len(receipt.Logs) != 0 || receipt.GasUsed != params.TxGas {
+		t.Errorf("pre-fork receipt mismatch: transfers %d, logs %d, gas %d", len(receipt.Transfers), len(receipt.Logs), receipt.GasUsed)
+	}
+	statedb, _ = state.New(blocks[1].Root(), state.NewDatabase(db))
+	if have := statedb.GetBalance(main); have.Int64() != 100 {
+		t.Errorf("main recipient balance mismatch: have %v, want 100", have)
+	}
+	if have := statedb.GetBalance(payee); have.Int64() != 100 {
+		t.Errorf("payee balance mismatch: have %v, want 100", have)
+	}
+	if have := statedb.GetBalance(failing); have.Sign() != 0 {
+		t.Errorf("failed transfer not reverted: balance %v", have)
+	}
+	receipt := receipts[1][0]
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		t.Errorf("main call failed")
+	}
+	if len(receipt.Transfers) != 2 {
+		t.Fatalf("transfer receipt count mismatch: have %d, want 2", len(receipt.Transfers))
+	}
+	if tr := receipt.Transfers[0]; tr.To != payee || tr.Value.Int64() != 100 || tr.Status != types.ReceiptStatusSuccessful || tr.GasUsed != params.TxGas {
+		t.Errorf("payee transfer mismatch: %+v", tr)
+	}
+	if tr := receipt.Transfers[1]; tr.To != failing || tr.Status != types.ReceiptStatusFailed || tr.GasUsed <= params.TxGas {
+		t.Errorf("failing transfer mismatch: %+v", tr)
+	}
+	// Both successful transfers are logged and indexed by the bloom
+	if len(receipt.Logs) != 2 {
+		t.Fatalf("transfer log count mismatch: have %d, want 2", len(receipt.Logs))
+	}
+	for i, want := range []common.Address{main, payee} {
+		sender, recipient, _, ok := types.ParseTransferLog(receipt.Logs[i])
+		if !ok || sender != from || recipient != want {
+			t.Errorf("log %d: transfer mismatch: have %x -> %x, want %x -> %x", i, sender, recipient, from, want)
+		}
+	}
+	if !types.BloomLookup(blocks[1].Bloom(), payee.Hash()) {
+		t.Errorf("payee missing from the block bloom")
+	}
+}
@@ -18,7 +18,6 @@ package core
 
 import (
 	"container/heap"
-	"math"
 	"math/big"
 	"sort"
 
@@ -27,6 +26,26 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 )
 
+// txSlotSize is the byte size covered by a single pool slot. A transaction takes
+// up a slot per started txSlotSize of its encoding and at least one slot per
+// transfer it makes, its one-to-many legs included, so that heavy extended
+// transactions pay for the pool capacity they use.
+const txSlotSize = 32 * 1024
+
+// numSlots returns the number of pool slots the transaction takes up.
+func numSlots(tx *types.Transaction) int {
+	slots := (int(tx.Size()) + txSlotSize - 1) / txSlotSize
+
+	transfers := 1
+	for _, extra := range tx.GetMatrix_EX() {
+		transfers += len(extra.ExtraTo)
+	}
+	if transfers > slots {
+		slots = transfers
+	}
+	return slots
+}
+
 // nonceHeap is a heap.Interface implementation over 64bit unsigned integers for
 // retrieving sorted transactions from the possibly gapped future queue.
 type nonceHeap []uint64
@@ -53,6 +72,7 @@ type txSortedMap struct {
 	items map[uint64]*types.Transaction // Hash map storing the transaction data
 	index *nonceHeap                    // Heap of nonces of all the stored transactions (non-strict mode)
 	cache types.Transactions            // Cache of the transactions already sorted
+	slots int                           // Number of pool slots taken by the transactions
 }
 
 // newTxSortedMap creates a new nonce-sorted transaction map.
@@ -72,22 +92,31 @@ func (m *txSortedMap) Get(nonce uint64) *types.Transaction {
 // index. If a transaction already exists with the same nonce, it's overwritten.
 func (m *txSortedMap) Put(tx *types.Transaction) {
 	nonce := tx.Nonce()
-	if m.items[nonce] == nil {
+	if old := m.items[nonce]; old == nil {
 		heap.Push(m.index, nonce)
+	} else {
+		m.slots -= numSlots(old)
 	}
 	m.items[nonce], m.cache = tx, nil
+	m.slots += numSlots(tx)
 }
 
 // Forward removes all transactions from the map with a nonce lower than the
-// provided threshold. Every removed transaction is returned for any post-removal
-// maintenance.
+// provided threshold on its nonce lane, leaving other lanes alone. Every removed
+// transaction is returned for any post-removal maintenance.
 func (m *txSortedMap) Forward(threshold uint64) types.Transactions {
+	// Lanes beyond the first are preceded by others, filter them out of the middle
+	if lane := types.NonceLane(threshold); lane > 0 {
+		start := types.LaneNonce(lane, 0)
+		return m.Filter(func(tx *types.Transaction) bool { return tx.Nonce() >= start && tx.Nonce() < threshold })
+	}
 	var removed types.Transactions
 
 	// Pop off heap items until the threshold is reached
 	for m.index.Len() > 0 && (*m.index)[0] < threshold {
 		nonce := heap.Pop(m.index).(uint64)
 		removed = append(removed, m.items[nonce])
+		m.slots -= numSlots(m.items[nonce])
 		delete(m.items, nonce)
 	}
 	// If we had a cached order, shift the front
@@ -106,6 +135,7 @@ func (m *txSortedMap) Filter(filter func(*types.Transaction) bool) types.Transac
 	for nonce, tx := range m.items {
 		if filter(tx) {
 			removed = append(removed, tx)
+			m.slots -= numSlots(tx)
 			delete(m.items, nonce)
 		}
 	}
@@ -135,6 +165,7 @@ func (m *txSortedMap) Cap(threshold int) types.Transactions {
 	sort.Sort(*m.index)
 	for size := len(m.items); size > threshold; size-- {
 		drops = append(drops, m.items[(*m.index)[size-1]])
+		m.slots -= numSlots(m.items[(*m.index)[size-1]])
 		delete(m.items, (*m.index)[size-1])
 	}
 	*m.index = (*m.index)[:threshold]
@@ -147,11 +178,39 @@ func (m *txSortedMap) Cap(threshold int) types.Transactions {
 	return drops
 }
 
+// CapSlots places a hard limit on the pool slots taken by the items, returning
+// the highest nonce'd transactions dropped to get under that limit.
+func (m *txSortedMap) CapSlots(threshold int) types.Transactions {
+	// Short circuit if the items take up less slots than the limit
+	if m.slots <= threshold {
+		return nil
+	}
+	// Otherwise gather and drop the highest nonce'd transactions
+	var drops types.Transactions
+
+	sort.Sort(*m.index)
+	size := len(m.items)
+	for ; m.slots > threshold; size-- {
+		tx := m.items[(*m.index)[size-1]]
+		drops = append(drops, tx)
+		m.slots -= numSlots(tx)
+		delete(m.items, tx.Nonce())
+	}
+	*m.index = (*m.index)[:size]
+	heap.Init(m.index)
+
+	// If we had a cache, shift the back
+	if m.cache != nil {
+		m.cache = m.cache[:len(m.cache)-len(drops)]
+	}
+	return drops
+}
+
 // Remove deletes a transaction from the maintained map, returning whether the
 // transaction was found.
 func (m *txSortedMap) Remove(nonce uint64) bool {
 	// Short circuit if no transaction is present
-	_, ok := m.items[nonce]
+	tx, ok := m.items[nonce]
 	if !ok {
 		return false
 	}
@@ -162,6 +221,7 @@ func (m *txSortedMap) Remove(nonce uint64) bool {
 			break
 		}
 	}
+	m.slots -= numSlots(tx)
 	delete(m.items, nonce)
 	m.cache = nil
 
@@ -174,8 +234,20 @@ func (m *txSortedMap) Remove(nonce uint64) bool {
 //
 // Note, all transactions with nonces lower than start will also be returned to
 // prevent getting into and invalid state. This is not something that should ever
-// happen but better to be self correcting than failing!
+// happen but better to be self correcting than failing! On lanes beyond the first
+// the sequence strictly starts at the provided nonce, lower lanes being left alone.
 func (m *txSortedMap) Ready(start uint64) types.Transactions {
+	if types.NonceLane(start) > 0 {
+		var ready types.Transactions
+		for next := start; m.items[next] != nil; next++ {
+			ready = append(ready, m.items[next])
+		}
+		if len(ready) > 0 {
+			end := start + uint64(len(ready))
+			m.Filter(func(tx *types.Transaction) bool { return tx.Nonce() >= start && tx.Nonce() < end })
+		}
+		return ready
+	}
 	// Short circuit if no transactions are available
 	if m.index.Len() == 0 || (*m.index)[0] > start {
 		return nil
@@ -184,6 +256,7 @@ func (m *txSortedMap) Ready(start uint64) types.Transactions {
 	var ready types.Transactions
 	for next := (*m.index)[0]; m.index.Len() > 0 && (*m.index)[0] == next; next++ {
 		ready = append(ready, m.items[next])
+		m.slots -= numSlots(m.items[next])
 		delete(m.items, next)
 		heap.Pop(m.index)
 	}
@@ -197,6 +270,11 @@ func (m *txSortedMap) Len() int {
 	return len(m.items)
 }
 
+// Slots returns the number of pool slots taken by the transactions of the map.
+func (m *txSortedMap) Slots() int {
+	return m.slots
+}
+
 // Flatten creates a nonce-sorted slice of transactions based on the loosely
 // sorted internal representation. The result of the sorting is cached in case
 // it's requested again before any modifications are made to the contents.
@@ -298,17 +376,20 @@ func (l *txList) Filter(costLimit *big.Int, gasLimit uint64) (types.Transactions
 	// Filter out all the transactions above the account's funds
 	removed := l.txs.Filter(func(tx *types.Transaction) bool { return tx.Cost().Cmp(costLimit) > 0 || tx.Gas() > gasLimit })
 
-	// If the list was strict, filter anything above the lowest nonce
+	// If the list was strict, filter anything above the lowest nonce of each lane
 	var invalids types.Transactions
 
 	if l.strict && len(removed) > 0 {
-		lowest := uint64(math.MaxUint64)
+		lowest := make(map[uint64]uint64)
 		for _, tx := range removed {
-			if nonce := tx.Nonce(); lowest > nonce {
-				lowest = nonce
+			if low, ok := lowest[tx.Lane()]; !ok || low > tx.Nonce() {
+				lowest[tx.Lane()] = tx.Nonce()
 			}
 		}
-		invalids = l.txs.Filter(func(tx *types.Transaction) bool { return tx.Nonce() > lowest })
+		invalids = l.txs.Filter(func(tx *types.Transaction) bool {
+			low, ok := lowest[tx.Lane()]
+			return ok && tx.Nonce() > low
+		})
 	}
 	return removed, invalids
 }
@@ -319,9 +400,15 @@ func (l *txList) Cap(threshold int) types.Transactions {
 	return l.txs.Cap(threshold)
 }
 
+// CapSlots places a hard limit on the pool slots taken by the items, returning
+// all transactions exceeding that limit.
+func (l *txList) CapSlots(threshold int) types.Transactions {
+	return l.txs.CapSlots(threshold)
+}
+
 // Remove deletes a transaction from the maintained list, returning whether the
-// transaction was found, and also returning any transaction invalidated due to
-// the deletion (strict mode only).
+// transaction was found, and also returning any transaction of the same nonce
+// lane invalidated due to the deletion (strict mode only).
 func (l *txList) Remove(tx *types.Transaction) (bool, types.Transactions) {
 	// Remove the transaction from the set
 	nonce := tx.Nonce()
@@ -330,7 +417,8 @@ func (l *txList) Remove(tx *types.Transaction) (bool, types.Transactions) {
 	}
 	// In strict mode, filter out non-executable transactions
 	if l.strict {
-		return true, l.txs.Filter(func(tx *types.Transaction) bool { return tx.Nonce() > nonce })
+		lane := tx.Lane()
+		return true, l.txs.Filter(func(tx *types.Transaction) bool { return tx.Lane() == lane && tx.Nonce() > nonce })
 	}
 	return true, nil
 }
@@ -346,11 +434,28 @@ func (l *txList) Ready(start uint64) types.Transactions {
 	return l.txs.Ready(start)
 }
 
+// Lanes returns the nonce lanes the transactions of the list are sent on, in
+// ascending order.
+func (l *txList) Lanes() []uint64 {
+	var lanes []uint64
+	for _, tx := range l.Flatten() {
+		if lane := tx.Lane(); len(lanes) == 0 || lanes[len(lanes)-1] != lane {
+			lanes = append(lanes, lane)
+		}
+	}
+	return lanes
+}
+
 // Len returns the length of the transaction list.
 func (l *txList) Len() int {
 	return l.txs.Len()
 }
 
+// Slots returns the number of pool slots taken by the transactions of the list.
+func (l *txList) Slots() int {
+	return l.txs.Slots()
+}
+
 // Empty returns whether the list of transactions is empty or not.
 func (l *txList) Empty() bool {
 	return l.Len() == 0
@@ -492,13 +597,14 @@ func (l *txPricedList) Underpriced(tx *types.Transaction, local *accountSet) boo
 	return cheapest.GasPrice().Cmp(tx.GasPrice()) >= 0
 }
 
-// Discard finds a number of most underpriced transactions, removes them from the
-// priced list and returns them for further removal from the entire pool.
-func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions {
-	drop := make(types.Transactions, 0, count) // Remote underpriced transactions to drop
+// Discard finds the most underpriced transactions taking up the given number of
+// pool slots, removes them from the priced list and returns them for further
+// removal from the entire pool.
+func (l *txPricedList) Discard(slots int, local *accountSet) types.Transactions {
+	drop := make(types.Transactions, 0, slots) // Remote underpriced transactions to drop
 	save := make(types.Transactions, 0, 64)    // Local underpriced transactions to keep
 
-	for len(*l.items) > 0 && count > 0 {
+	for len(*l.items) > 0 && slots > 0 {
 		// Discard stale transactions if found during cleanup
 		tx := heap.Pop(l.items).(*types.Transaction)
 		if l.all.Get(tx.Hash()) == nil {
@@ -510,7 +616,7 @@ func (l *txPricedList) Discard(count int, local *accountSet) types.Transactions
 			save = append(save, tx)
 		} else {
 			drop = append(drop, tx)
-			count--
+			slots -= numSlots(tx)
 		}
 	}
 	for _, tx := range save {
@@ -18,6 +18,7 @@ package core
 
 import (
 	"container/list"
+	"crypto/ecdsa"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,6 +32,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/ca"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
@@ -104,6 +106,26 @@ var (
 	ErrTXCountOverflow = errors.New("Transaction quantity spillover")
 	ErrTxToRepeat      = errors.New("Contains duplicate transfer accounts")
 	ErrTXWrongful      = errors.New("transaction is unlawful")
+
+	// ErrElectionDeposit is returned if an election transaction carries less
+	// than the minimum deposit.
+	ErrElectionDeposit = errors.New("election deposit below minimum")
+
+	// ErrDelegationRecipient is returned if a delegation transaction is not
+	// sent to the deposit account.
+	ErrDelegationRecipient = errors.New("delegation transaction not sent to the deposit account")
+
+	// ErrDelegationStake is returned if a delegation bonds less than the
+	// minimum stake or an undelegation does not unbond a positive amount.
+	ErrDelegationStake = errors.New("invalid delegation stake")
+
+	// ErrDelegationDisabled is returned if a delegation transaction is sent on
+	// a chain whose engine does not pay unbonded stake back.
+	ErrDelegationDisabled = errors.New("delegations not supported by the chain")
+
+	// ErrTxTypeCap is returned if a remote transaction would take up more pool
+	// slots than configured for its type.
+	ErrTxTypeCap = errors.New("transaction type pool slots exceeded")
 )
 
 var (
@@ -127,6 +149,17 @@ var (
 	// General tx metrics
 	invalidTxCounter     = metrics.NewRegisteredCounter("txpool/invalid", nil)
 	underpricedTxCounter = metrics.NewRegisteredCounter("txpool/underpriced", nil)
+	typeCapTxCounter     = metrics.NewRegisteredCounter("txpool/typecap", nil) // Dropped due to the type caps
+	rejectedTxCounter    = metrics.NewRegisteredCounter("txpool/rejected", nil) // Dropped due to committee rejections
+
+	// Metrics for the pool slots taken up, in total and per transaction type
+	slotsGauge      = metrics.NewRegisteredGauge("txpool/slots", nil)
+	classSlotsGauge = [numTxClasses]metrics.Gauge{
+		txClassPlain:      metrics.NewRegisteredGauge("txpool/slots/plain", nil),
+		txClassExtended:   metrics.NewRegisteredGauge("txpool/slots/extended", nil),
+		txClassElection:   metrics.NewRegisteredGauge("txpool/slots/election", nil),
+		txClassDelegation: metrics.NewRegisteredGauge("txpool/slots/delegation", nil),
+	}
 )
 
 // TxStatus is the current status of a transaction as seen by the pool.
@@ -145,9 +178,6 @@ type TxStatus uint
 
 
 var mapNS = make(map[uint32]*big.Int)                  //YY
-var mapErrtxs = make(map[common.Hash][]common.Address)    //YY  used to store improper transactions（map[S,addr]）
-var mapLossErrtxs = make(map[*big.Int][]common.Address)//YY  used to store improper transactions that don't exist locally (judge whenever new transactions come in)
-var mapErrtxsTiming = make(map[common.Hash]uint64)     //YY  regular deletion on improper transactions (20 blocks)
 var mapTxsTiming = make(map[common.Hash]uint64)        //YY  regular deletion on the remaining transactions in pending after block packing
 //YY
 
@@ -232,6 +262,10 @@ type TxPoolConfig struct {
 	AccountQueue uint64 // Maximum number of non-executable transaction slots permitted per account
 	GlobalQueue  uint64 // Maximum number of non-executable transaction slots for all accounts
 
+	ExtendedSlots   uint64 // Maximum number of slots taken by remote extended transactions (0 = unlimited)
+	ElectionSlots   uint64 // Maximum number of slots taken by remote election transactions (0 = unlimited)
+	DelegationSlots uint64 // Maximum number of slots taken by remote delegation transactions (0 = unlimited)
+
 	Lifetime time.Duration // Maximum amount of time non-executable transaction are queued
 }
 
@@ -249,6 +283,10 @@ var DefaultTxPoolConfig = TxPoolConfig{
 	AccountQueue: 64,
 	GlobalQueue:  1024,
 
+	ExtendedSlots:   1024,
+	ElectionSlots:   128,
+	DelegationSlots: 256,
+
 	Lifetime: 3 * time.Hour,
 }
 
@@ -271,6 +309,20 @@ func (config *TxPoolConfig) sanitize() TxPoolConfig {
 	return conf
 }
 
+// classSlots returns the maximum number of pool slots remote transactions of
+// the given type may take up, or 0 if unlimited.
+func (config *TxPoolConfig) classSlots(class txClass) uint64 {
+	switch class {
+	case txClassExtended:
+		return config.ExtendedSlots
+	case txClassElection:
+		return config.ElectionSlots
+	case txClassDelegation:
+		return config.DelegationSlots
+	}
+	return 0
+}
+
 // TxPool contains all currently known transactions. Transactions
 // enter the pool when they are received from the network or submitted
 // locally. They exit the pool when they are included in the blockchain.
@@ -302,15 +354,22 @@ type TxPool struct {
 	beats   map[common.Address]time.Time // Last heartbeat from each known account
 	all     *txLookup                    // All transactions to allow lookups
 	//=================by hezi==================//
-	SContainer map[*big.Int]*types.Transaction
+	SContainer map[common.Hash]*types.Transaction // Flooded transactions keyed by their S value, see sKey
 	NContainer map[uint32]*types.Transaction
 	Special    map[common.Hash]*types.Transaction // All special transactions
 	//=================================================//
 	priced *txPricedList // All transactions sorted by price
 
+	rejections    *txRejections     // Evidence of the transactions rejected by the committee
+	rejectionKey  *ecdsa.PrivateKey // Node key to sign rejections of flooded transactions with
+	rejectionFeed event.Feed        // Feed of the transactions rejected by the committee
+
+	tracker *TxTracker // Lifecycle of the transactions passing through the pool
+
 	wg sync.WaitGroup // for shutdown sync
 
-	homestead bool
+	homestead  bool
+	nonceLanes bool // Whether transactions may be sent on nonce lanes beyond the first
 }
 
 // NewTxPool creates a new transaction pool to gather, sort and filter inbound
@@ -328,14 +387,17 @@ func NewTxPool(config TxPoolConfig, chainconfig *params.ChainConfig, chain block
 		pending:     make(map[common.Address]*txList),
 		queue:       make(map[common.Address]*txList),
 		beats:       make(map[common.Address]time.Time),
-		SContainer:  make(map[*big.Int]*types.Transaction),    //by hezi
+		SContainer:  make(map[common.Hash]*types.Transaction), //by hezi
 		NContainer:  make(map[uint32]*types.Transaction),      //by hezi
 		Special:     make(map[common.Hash]*types.Transaction), //by hezi
 		all:         newTxLookup(),
+		rejections:  newTxRejections(),
+		tracker:     NewTxTracker(),
 		chainHeadCh: make(chan ChainHeadEvent, chainHeadChanSize),
 		gasPrice:    new(big.Int).SetUint64(config.PriceLimit),
 	}
 	pool.locals = newAccountSet(pool.signer)
+	pool.all.tracker = pool.tracker
 	pool.priced = newTxPricedList(pool.all)
 	pool.reset(nil, chain.CurrentBlock().Header())
 
@@ -401,9 +463,11 @@ func (pool *TxPool) loop() {
 				if pool.chainconfig.IsHomestead(ev.Block.Number()) {
 					pool.homestead = true
 				}
+				// Record the inclusions before the reset drops the included transactions
+				pool.recordInclusions(head, ev.Block)
 				pool.reset(head.Header(), ev.Block.Header())
 				head = ev.Block
-				pool.BlockTiming() //YY
+				pool.rejections.prune(head.NumberU64() + 1)
 				//write the broadcasting block to db  hezi
 				if head.Number().Uint64()%100 == 0 {
 					tmpdt := make(map[string][]byte)
@@ -469,16 +533,19 @@ func (pool *TxPool) loop() {
 	}
 }
 
+// sKey returns the key of an S value in the S container. The S values arriving
+// from the network are fresh pointers, so they have to be keyed by value.
+func sKey(s *big.Int) common.Hash {
+	return common.BigToHash(s)
+}
+
 //sTxmap->tx的编号N是否为nil ;hezi
 func (pool *TxPool) sTxValIsNil(s *big.Int) bool {
 	pool.mu.Lock()
-	tmpN := pool.SContainer[s].N
+	tx := pool.SContainer[sKey(s)]
 	pool.mu.Unlock()
 
-	if len(tmpN) == 0 {
-		return true
-	}
-	return false
+	return tx != nil && len(tx.N) == 0
 }
 
 //给tx设置num ;hezi
@@ -490,13 +557,13 @@ func (pool *TxPool) setTxNum(tx *types.Transaction, num uint32) {
 
 //设置map[s]tx ;hezi
 func (pool *TxPool) setsTx(s *big.Int, tx *types.Transaction) {
-	pool.SContainer[s] = tx
+	pool.SContainer[sKey(s)] = tx
 }
 
 //根据s获取tx ;hezi
 func (pool *TxPool) getTxbyS(s *big.Int) (tx *types.Transaction) {
 	pool.mu.Lock()
-	tx = pool.SContainer[s]
+	tx = pool.SContainer[sKey(s)]
 	pool.mu.Unlock()
 	return tx
 }
@@ -523,7 +590,7 @@ func (pool *TxPool) deletnTx(num uint32) {
 
 //hezi
 func (pool *TxPool) deletsTx(s *big.Int) {
-	delete(pool.SContainer, s)
+	delete(pool.SContainer, sKey(s))
 }
 
 func (pool *TxPool) packageSNList() {
@@ -562,6 +629,7 @@ func (pool *TxPool) packageSNList() {
 					pool.setTxNum(tx, num)
 					tmpsnlst[s] = num
 					pool.setnTx(num, tx)
+					pool.tracker.Record(tx.Hash(), TxStageRecord{Stage: TxStageFlooded})
 				}
 			}
 			//=================================
@@ -597,9 +665,12 @@ func (pool *TxPool) ProcessMsg(m NetworkMsgData) {
 		recv := msgdata.data.(map[uint32]*types.Floodtxdata)
 		nodeid := m.NodeId
 		pool.RecvFloodTx(recv,nodeid)
-	case RecvErrTx: //YY//TODO Attach sender address upon receipt of error transactions
-		//addr common.Address,listS []*big.Int
-		//pool.AddErrorTx()
+	case RecvErrTx: //YY
+		for _, err := range pool.AddRejections(msgdata.data.([]*types.TxRejection)) {
+			if err != nil {
+				log.Debug("Discarding transaction rejection", "node", m.NodeId, "err", err)
+			}
+		}
 	}
 }
 
@@ -617,8 +688,8 @@ func (pool *TxPool) sendMsg(data msgstruct) {
 	case RecvTxbyN://YY
 		//TODO respond to fixed nodes about request of Tx based on N
 	case RecvErrTx://YY
-		p2p.SendToSingle(data.NodeId,common.NetworkMsg,data.data)
-		//TODO send error transactions to all validators for consensus
+		// Rejections are counted by every verifier against the committee stake
+		p2p.SendToGroup(common.RoleValidator, common.NetworkMsg, data)
 	}
 }
 
@@ -668,6 +739,34 @@ func (pool *TxPool) lockedReset(oldHead, newHead *types.Header) {
 	pool.reset(oldHead, newHead)
 }
 
+// recordInclusions records the inclusion of the transactions of every block the
+// new head adds to the chain of the old one, up to the same depth reset reinjects
+// transactions from, as a head event may cover several imported blocks.
+func (pool *TxPool) recordInclusions(oldHead, newHead *types.Block) {
+	var (
+		added    []*types.Block
+		rem, add = oldHead, newHead
+	)
+	for rem != nil && rem.NumberU64() > add.NumberU64() {
+		rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1)
+	}
+	for add != nil && len(added) < 64 && (rem == nil || add.Hash() != rem.Hash()) {
+		added = append(added, add)
+		if rem != nil && rem.NumberU64() == add.NumberU64() {
+			rem = pool.chain.GetBlock(rem.ParentHash(), rem.NumberU64()-1)
+		}
+		if add.NumberU64() == 0 {
+			break
+		}
+		add = pool.chain.GetBlock(add.ParentHash(), add.NumberU64()-1)
+	}
+	for i := len(added) - 1; i >= 0; i-- {
+		for _, tx := range added[i].Transactions() {
+			pool.tracker.Record(tx.Hash(), TxStageRecord{Stage: TxStageIncluded, Number: hexutil.Uint64(added[i].NumberU64())})
+		}
+	}
+}
+
 // reset retrieves the current state of the blockchain and ensures the content
 // of the transaction pool is valid with regard to the chain state.
 func (pool *TxPool) reset(oldHead, newHead *types.Header) {
@@ -731,6 +830,11 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	pool.pendingState = state.ManageState(statedb)
 	pool.currentMaxGas = newHead.GasLimit
 
+	// Switch to the PTC signer and enable nonce lanes once the next block requires it
+	next := new(big.Int).Add(newHead.Number, big.NewInt(1))
+	pool.switchSigner(next)
+	pool.nonceLanes = pool.chainconfig.IsNonceLane(next)
+
 	// Inject any transactions discarded due to reorgs
 	log.Debug("Reinjecting stale transactions", "count", len(reinject))
 	pool.addTxsLocked(reinject, false)
@@ -741,10 +845,14 @@ func (pool *TxPool) reset(oldHead, newHead *types.Header) {
 	// higher gas price)
 	pool.demoteUnexecutables()
 
-	// Update all accounts to the latest known pending nonce
+	// Update all accounts to the latest known pending nonce of each lane
 	for addr, list := range pool.pending {
 		txs := list.Flatten() // Heavy but will be cached and is needed by the miner anyway
-		pool.pendingState.SetNonce(addr, txs[len(txs)-1].Nonce()+1)
+		for i, tx := range txs {
+			if i == len(txs)-1 || txs[i+1].Lane() != tx.Lane() {
+				pool.pendingState.SetLaneNonce(addr, tx.Nonce()+1)
+			}
+		}
 	}
 	// Check the queue and move transactions over to the pending if possible
 	// or remove those that have become invalid
@@ -759,6 +867,7 @@ func (pool *TxPool) Stop() {
 	// Unsubscribe subscriptions registered from blockchain
 	pool.chainHeadSub.Unsubscribe()
 	pool.wg.Wait()
+	pool.tracker.Stop()
 
 	if pool.journal != nil {
 		pool.journal.close()
@@ -772,6 +881,18 @@ func (pool *TxPool) SubscribeNewTxsEvent(ch chan<- NewTxsEvent) event.Subscripti
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
 }
 
+// SubscribeTxStatusEvent registers a subscription of TxStatusEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeTxStatusEvent(ch chan<- TxStatusEvent) event.Subscription {
+	return pool.scope.Track(pool.tracker.Subscribe(ch))
+}
+
+// Tracker returns the tracker of the lifecycle of the transactions passing
+// through the pool.
+func (pool *TxPool) Tracker() *TxTracker {
+	return pool.tracker
+}
+
 //by hezi
 func (pool *TxPool) SubscribeNewSNEvent(ch chan<- NewSNEvent) event.Subscription {
 	return pool.scope.Track(pool.txFeed.Subscribe(ch))
@@ -991,8 +1112,7 @@ func (pool *TxPool) GetTxByN(listN []uint32,nid discover.NodeID) {
 //YY 接收洪泛的交易（根据N请求到的交易）
 func (pool *TxPool) RecvFloodTx(mapNtx map[uint32]*types.Floodtxdata,nid discover.NodeID){
 	pool.mu.RLock()
-	defer pool.mu.RUnlock()
-	errorTxs := make([]*big.Int, 0)
+	txs := make([]*types.Transaction, 0, len(mapNtx))
 	for n, ftx := range mapNtx {
 		s := mapNS[n]
 		if s == nil || n == 0 { //如果S或者N 不合法则直接跳过
@@ -1010,21 +1130,33 @@ func (pool *TxPool) RecvFloodTx(mapNtx map[uint32]*types.Floodtxdata,nid discove
 			tx.N = append(tx.N, n)
 		}
 		tx.SetTxS(s)
-		err := pool.addTx(tx, false)
-		if err != nil {
-			errorTxs = append(errorTxs, s)
-		}
+		txs = append(txs, tx)
 	}
-	if len(errorTxs) > 0 {
-		//TODO 发送错误交易用来做共识，发送时需要签名（接收到错误交易后需要添加账户，然后调用AddErrorTx（）标明本节点认为是错误交易，同时也需要记一票）
-		//TODO 在这如何进行签名？？如何获得账户信息
-		pool.sendMsg(msgstruct{RecvErrTx,nid,errorTxs})
+	pool.mu.RUnlock()
+
+	if rejections := pool.addFloodTxs(txs); len(rejections) > 0 {
+		pool.sendMsg(msgstruct{RecvErrTx,nid,rejections})
 	}
 }
-//YY 接收错误交易
-//问题：如果leader作弊其交易池中有一笔错误交易，其他验证者没有该笔交易，这时候洪泛给其他节点肯定会跟leader要该笔交易，这时候如果广播错误交易的S给其他节点，其他节点可能都没有这个记录
-func (pool *TxPool)RecvErrTx(){
 
+// addFloodTxs adds flooded transactions to the pool and signs a rejection of
+// each one invalid for every node. The rejections are counted like those of the
+// other verifiers once the pool lock is released, and returned to be sent to
+// them.
+func (pool *TxPool) addFloodTxs(txs []*types.Transaction) []*types.TxRejection {
+	pool.mu.Lock()
+	var rejections []*types.TxRejection
+	for i, err := range pool.addTxsLocked(txs, false) {
+		if rejection := pool.signRejection(txs[i].Hash(), err); rejection != nil {
+			rejections = append(rejections, rejection)
+		}
+	}
+	pool.mu.Unlock()
+
+	if len(rejections) > 0 {
+		pool.AddRejections(rejections)
+	}
+	return rejections
 }
 //YY 删除交易池中所有的交易（all,queue,pending） 废弃
 func (pool *TxPool) DeletAllTxs() {
@@ -1078,74 +1210,98 @@ func (pool *TxPool) delNestingMap(tx *types.Transaction) {
 //	return Errtxs
 //}
 
-//YY 添加并记录错误的交易
-func (pool *TxPool) AddErrorTx(addr common.Address, listS []*big.Int) {
-	for _, s := range listS {
-		tx := pool.getTxbyS(s)
-		//tx:=pool.SContainer[s]
-		if tx == nil { //如果本地没有没有S对应的交易就需要暂存，因为有可能是先收到错误交易共识后收到错误交易
-			mapLossErrtxs[s] = append(mapLossErrtxs[s], addr)
-			return
-		}
-		hash := tx.Hash()
-		for _, acc := range mapErrtxs[hash] {
-			if addr == acc { //判断当前错误交易，同一个节点是否发送过
-				return
-			}
+// local retrieves all currently known local transactions, groupped by origin
+// account and sorted by nonce. The returned transaction set is a copy and can be
+// freely modified by calling code.
+func (pool *TxPool) local() map[common.Address]types.Transactions {
+	txs := make(map[common.Address]types.Transactions)
+	for addr := range pool.locals.accounts {
+		if pending := pool.pending[addr]; pending != nil {
+			txs[addr] = append(txs[addr], pending.Flatten()...)
 		}
-		mapErrtxs[hash] = append(mapErrtxs[hash], addr)
-		if uint64(len(mapErrtxs[hash])) >= params.ErrTxConsensus {
-			pool.mu.Lock()
-			pool.removeTx(hash, true)
-			pool.mu.Unlock()
-		} else {
-			pool.addBlockTiming(hash)
+		if queued := pool.queue[addr]; queued != nil {
+			txs[addr] = append(txs[addr], queued.Flatten()...)
 		}
 	}
+	return txs
 }
 
-//YY 添加区块定时  //TODO 应该是每次打包完后将pending中的内容删除，pending中余下的交易放入区块定时中(这里需要重新创建一个全局的map)。
-func (pool *TxPool) addBlockTiming(hash common.Hash) {
-	pool.mu.RLock()
-	defer pool.mu.RUnlock()
-	if _, ok := mapErrtxsTiming[hash]; ok {
-		return
+// validateElectionTx checks the payload of a transaction sent to the deposit
+// account: it has to be signed by the key of the node it registers on behalf of
+// the sender and, unless exiting, carry at least the minimum deposit. Other
+// transactions pass unchecked, whatever their data looks like.
+func validateElectionTx(tx *types.Transaction, from common.Address) error {
+	if tx.To() == nil || *tx.To() != common.HexToAddress(params.HypothecatedAccount) {
+		return nil
+	}
+	info, err := types.DecodeElectionTxPayLoad(tx.Data())
+	if err != nil {
+		if err == types.ErrElectionVersion {
+			return err
+		}
+		return nil
+	}
+	if err := info.Validate(from); err != nil {
+		return err
 	}
-	mapErrtxsTiming[hash] = pool.chain.CurrentBlock().Number().Uint64()
+	if info.ElectType != types.ElectExit && tx.Value().Cmp(params.MinElectionDeposit) < 0 {
+		return ErrElectionDeposit
+	}
+	return nil
 }
 
-//YY 20个区块定时删除(每次收到新区快头广播时触发)
-func (pool *TxPool) BlockTiming() {
-	blockNum := pool.chain.CurrentBlock().Number()
-	listHash := make([]common.Hash, 0)
-	for hash, num := range mapErrtxsTiming {
-		if n := new(big.Int).Sub(blockNum, new(big.Int).SetUint64(num)); n.Uint64() >= params.SubBlockNum {
-			listHash = append(listHash, hash)
-		}
+// switchSigner moves the pool over to the PTC signer if the given block is past
+// its fork. Extended transactions admitted before are dropped, as their legacy
+// signatures do not commit to the extension and are invalid from then on.
+func (pool *TxPool) switchSigner(number *big.Int) {
+	if !pool.chainconfig.IsPTCSigner(number) {
+		return
+	}
+	if _, ok := pool.signer.(types.PTCSigner); ok {
+		return
 	}
-	if len(listHash) > 0 {
-		for _, hash := range listHash {
-			delete(mapErrtxsTiming, hash)
-			pool.removeTx(hash, true)
-			delete(mapErrtxs, hash)
+	var legacy []common.Hash
+	pool.all.Range(func(hash common.Hash, tx *types.Transaction) bool {
+		if len(tx.GetMatrix_EX()) > 0 && !tx.PTCSigned() {
+			legacy = append(legacy, hash)
 		}
+		return true
+	})
+	for _, hash := range legacy {
+		pool.removeTx(hash, true)
 	}
+	pool.signer = types.NewPTCSigner(pool.chainconfig.ChainId)
+	pool.locals.signer = pool.signer
+
+	log.Info("Switched transaction pool to the PTC signer", "number", number, "dropped", len(legacy))
 }
 
-// local retrieves all currently known local transactions, groupped by origin
-// account and sorted by nonce. The returned transaction set is a copy and can be
-// freely modified by calling code.
-func (pool *TxPool) local() map[common.Address]types.Transactions {
-	txs := make(map[common.Address]types.Transactions)
-	for addr := range pool.locals.accounts {
-		if pending := pool.pending[addr]; pending != nil {
-			txs[addr] = append(txs[addr], pending.Flatten()...)
+// validateDelegationTx checks the payload of a delegation transaction: it has
+// to be sent to the deposit account of a chain supporting delegations and
+// either bond at least the minimum stake or unbond a positive amount without
+// transferring any value. Other transactions pass unchecked.
+func validateDelegationTx(tx *types.Transaction, config *params.ChainConfig) error {
+	info := tx.ParseDelegationTxPayLoad()
+	if info == nil {
+		return nil
+	}
+	if !delegating(config) {
+		return ErrDelegationDisabled
+	}
+	if tx.To() == nil || *tx.To() != common.HexToAddress(params.HypothecatedAccount) {
+		return ErrDelegationRecipient
+	}
+	switch info.Type {
+	case types.DelegateStake:
+		if tx.Value().Cmp(params.MinDelegation) < 0 {
+			return ErrDelegationStake
 		}
-		if queued := pool.queue[addr]; queued != nil {
-			txs[addr] = append(txs[addr], queued.Flatten()...)
+	case types.UndelegateStake:
+		if tx.Value().Sign() != 0 || info.Amount.Sign() <= 0 {
+			return ErrDelegationStake
 		}
 	}
-	return txs
+	return nil
 }
 
 // validateTx checks whether a transaction is valid according to the consensus
@@ -1178,17 +1334,16 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 				}
 			}
 		}
-		// Heuristic limit, reject transactions over 32KB to prevent DOS attacks
-		var txsize uint64 = 32 * 1024
-		if uint64(tx.Size()) > txsize*txcount {
+		// Heuristic limit, reject transactions over 32KB per transfer to prevent DOS attacks
+		if uint64(tx.Size()) > txSlotSize*txcount {
 			return ErrOversizedData
 		}
-		if txcount > params.TxCount { //验证一对多交易最多支持1000条
+		if txcount > params.TxCount { //验证一对多交易最多支持的转账数
 			return ErrTXCountOverflow
 		}
 	} else {
 		// Heuristic limit, reject transactions over 32KB to prevent DOS attacks
-		if tx.Size() > 32*1024 {
+		if tx.Size() > txSlotSize {
 			return ErrOversizedData
 		}
 	}
@@ -1205,6 +1360,9 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 
 	// Make sure the transaction is signed properly
 	from, err := types.Sender(pool.signer, tx)
+	if err == types.ErrLegacyExtendedSig {
+		return err
+	}
 	if err != nil {
 		return ErrInvalidSender
 	}
@@ -1213,13 +1371,32 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 		return ErrTXWrongful
 	}
 
+	// Election transactions must carry a valid payload signed by the node key
+	if err := validateElectionTx(tx, from); err != nil {
+		return err
+	}
+	// Delegation transactions must bond to or unbond from the deposit account
+	if err := validateDelegationTx(tx, pool.chainconfig); err != nil {
+		return err
+	}
+
 	// Drop non-local transactions under our own minimal accepted gas price
 	local = local || pool.locals.contains(from) // account may be local even if the transaction arrived from the network
 	if !local && pool.gasPrice.Cmp(tx.GasPrice()) > 0 {
 		return ErrUnderpriced
 	}
-	// Ensure the transaction adheres to nonce ordering
-	if pool.currentState.GetNonce(from) > tx.Nonce() {
+	// Ensure the transaction adheres to nonce ordering on its lane
+	nonce := pool.currentState.GetNonce(from)
+	if lane := tx.Lane(); lane > 0 {
+		if !pool.nonceLanes {
+			return ErrInvalidNonceLane
+		}
+		if err := validateNonceLane(lane, nonce, tx.To() == nil); err != nil {
+			return err
+		}
+		nonce = pool.currentState.GetLaneNonce(from, lane)
+	}
+	if nonce > tx.Nonce() {
 		return ErrNonceTooLow
 	}
 	//YY add if
@@ -1240,15 +1417,11 @@ func (pool *TxPool) validateTx(tx *types.Transaction, local bool) error {
 	}
 	//YY add if
 	if len(txEx) > 0 && len(txEx[0].ExtraTo) > 0 {
-		for _, tx_list := range txEx {
-			for _, txs := range tx_list.ExtraTo {
-				tmpintrGas, tmperr := IntrinsicGas(txs.Payload, txs.Recipient == nil, pool.homestead)
-				if tmperr != nil {
-					return tmperr
-				}
-				intrGas += tmpintrGas
-			}
+		extraGas, err := ExtraIntrinsicGas(txEx, pool.homestead)
+		if err != nil {
+			return err
 		}
+		intrGas += extraGas
 	}
 	if tx.Gas() < intrGas {
 		return ErrIntrinsicGas
@@ -1302,12 +1475,33 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 		return false, fmt.Errorf("known transaction: %x", hash)
 	}
 
+	// If the transaction was rejected by the committee, discard it
+	if pool.rejections.rejected(hash) {
+		log.Trace("Discarding rejected transaction", "hash", hash)
+		rejectedTxCounter.Inc(1)
+		return false, ErrTxRejected
+	}
 	// If the transaction fails basic validation, discard it
 	if err := pool.validateTx(tx, local); err != nil {
 		log.Trace("Discarding invalid transaction", "hash", hash, "err", err)
 		invalidTxCounter.Inc(1)
 		return false, err
 	}
+	// If the transaction type is over its slot cap, discard remote ones
+	if sender, _ := types.Sender(pool.signer, tx); !local && !pool.locals.contains(sender) {
+		class := classifyTx(tx)
+		slots := pool.all.ClassSlots(class) + numSlots(tx)
+
+		// A replacement frees the slots of the transaction it replaces
+		if old := pool.sameNonce(sender, tx.Nonce()); old != nil && classifyTx(old) == class {
+			slots -= numSlots(old)
+		}
+		if limit := pool.config.classSlots(class); limit > 0 && uint64(slots) > limit {
+			log.Trace("Discarding transaction over its type cap", "hash", hash, "class", class)
+			typeCapTxCounter.Inc(1)
+			return false, ErrTxTypeCap
+		}
+	}
 
 	////======================by hezi============================//
 	//if tx.GetMatrix_EX() == nil ||  tx.GetMatrix_EX()[0].TxType == 0 {
@@ -1336,7 +1530,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	//}
 
 	// If the transaction pool is full, discard underpriced transactions
-	if uint64(pool.all.Count()) >= pool.config.GlobalSlots+pool.config.GlobalQueue {
+	if uint64(pool.all.Slots()+numSlots(tx)) > pool.config.GlobalSlots+pool.config.GlobalQueue {
 		// If the new transaction is underpriced, don't accept it
 		if !local && pool.priced.Underpriced(tx, pool.locals) {
 			log.Trace("Discarding underpriced transaction", "hash", hash, "price", tx.GasPrice())
@@ -1344,7 +1538,7 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 			return false, ErrUnderpriced
 		}
 		// New transaction is better than our worse ones, make room for it
-		drop := pool.priced.Discard(pool.all.Count()-int(pool.config.GlobalSlots+pool.config.GlobalQueue-1), pool.locals)
+		drop := pool.priced.Discard(pool.all.Slots()+numSlots(tx)-int(pool.config.GlobalSlots+pool.config.GlobalQueue), pool.locals)
 		for _, tx := range drop {
 			log.Trace("Discarding freshly underpriced transaction", "hash", tx.Hash(), "price", tx.GasPrice())
 			underpricedTxCounter.Inc(1)
@@ -1401,6 +1595,20 @@ func (pool *TxPool) add(tx *types.Transaction, local bool) (bool, error) {
 	return replace, nil
 }
 
+// sameNonce returns the pending or queued transaction of an account a new one
+// with the given nonce would replace, if any.
+func (pool *TxPool) sameNonce(addr common.Address, nonce uint64) *types.Transaction {
+	for _, list := range []*txList{pool.pending[addr], pool.queue[addr]} {
+		if list == nil {
+			continue
+		}
+		if tx := list.txs.Get(nonce); tx != nil {
+			return tx
+		}
+	}
+	return nil
+}
+
 // enqueueTx inserts a new transaction into the non-executable transaction queue.
 //
 // Note, this method assumes the pool lock is held!
@@ -1475,7 +1683,7 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx *types.T
 	}
 	// Set the potentially new pending nonce and notify any subsystems of the new tx
 	pool.beats[addr] = time.Now()
-	pool.pendingState.SetNonce(addr, tx.Nonce()+1)
+	pool.pendingState.SetLaneNonce(addr, tx.Nonce()+1)
 
 	return true
 }
@@ -1614,9 +1822,9 @@ func (pool *TxPool) removeTx(hash common.Hash, outofbound bool) {
 			for _, tx := range invalids {
 				pool.enqueueTx(tx.Hash(), tx)
 			}
-			// Update the account nonce if needed
-			if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr) > nonce {
-				pool.pendingState.SetNonce(addr, nonce)
+			// Update the lane nonce if needed
+			if nonce := tx.Nonce(); pool.pendingState.GetLaneNonce(addr, tx.Lane()) > nonce {
+				pool.pendingState.SetLaneNonce(addr, nonce)
 			}
 			return
 		}
@@ -1655,11 +1863,13 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			continue // Just in case someone calls with a non existing account
 		}
 		// Drop all transactions that are deemed too old (low nonce)
-		for _, tx := range list.Forward(pool.currentState.GetNonce(addr)) {
-			hash := tx.Hash()
-			log.Trace("Removed old queued transaction", "hash", hash)
-			pool.all.Remove(hash)
-			pool.priced.Removed()
+		for _, lane := range list.Lanes() {
+			for _, tx := range list.Forward(pool.currentState.GetLaneNonce(addr, lane)) {
+				hash := tx.Hash()
+				log.Trace("Removed old queued transaction", "hash", hash)
+				pool.all.Remove(hash)
+				pool.priced.Removed()
+			}
 		}
 		// Drop all transactions that are too costly (low balance or out of gas)
 		drops, _ := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -1670,17 +1880,19 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			pool.priced.Removed()
 			queuedNofundsCounter.Inc(1)
 		}
-		// Gather all executable transactions and promote them
-		for _, tx := range list.Ready(pool.pendingState.GetNonce(addr)) {
-			hash := tx.Hash()
-			if pool.promoteTx(addr, hash, tx) {
-				log.Trace("Promoting queued transaction", "hash", hash)
-				promoted = append(promoted, tx)
+		// Gather all executable transactions of each lane and promote them
+		for _, lane := range list.Lanes() {
+			for _, tx := range list.Ready(pool.pendingState.GetLaneNonce(addr, lane)) {
+				hash := tx.Hash()
+				if pool.promoteTx(addr, hash, tx) {
+					log.Trace("Promoting queued transaction", "hash", hash)
+					promoted = append(promoted, tx)
+				}
 			}
 		}
 		// Drop all transactions over the allowed limit
 		if !pool.locals.contains(addr) {
-			for _, tx := range list.Cap(int(pool.config.AccountQueue)) {
+			for _, tx := range list.CapSlots(int(pool.config.AccountQueue)) {
 				hash := tx.Hash()
 				pool.all.Remove(hash)
 				pool.priced.Removed()
@@ -1710,16 +1922,15 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 	// If the pending limit is overflown, start equalizing allowances
 	pending := uint64(0)
 	for _, list := range pool.pending {
-		pending += uint64(list.Len())
+		pending += uint64(list.Slots())
 	}
 	if pending > pool.config.GlobalSlots {
-		pendingBeforeCap := pending
 		// Assemble a spam order to penalize large transactors first
 		spammers := prque.New()
 		for addr, list := range pool.pending {
 			// Only evict transactions from high rollers
-			if !pool.locals.contains(addr) && uint64(list.Len()) > pool.config.AccountSlots {
-				spammers.Push(addr, float32(list.Len()))
+			if !pool.locals.contains(addr) && uint64(list.Slots()) > pool.config.AccountSlots {
+				spammers.Push(addr, float32(list.Slots()))
 			}
 		}
 		// Gradually drop transactions from offenders
@@ -1732,12 +1943,15 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 			// Equalize balances until all the same or below threshold
 			if len(offenders) > 1 {
 				// Calculate the equalization threshold for all current offenders
-				threshold := pool.pending[offender.(common.Address)].Len()
+				threshold := pool.pending[offender.(common.Address)].Slots()
 
 				// Iteratively reduce all offenders until below limit or threshold reached
-				for pending > pool.config.GlobalSlots && pool.pending[offenders[len(offenders)-2]].Len() > threshold {
+				for pending > pool.config.GlobalSlots && pool.pending[offenders[len(offenders)-2]].Slots() > threshold {
 					for i := 0; i < len(offenders)-1; i++ {
 						list := pool.pending[offenders[i]]
+						if list.Len() == 0 {
+							continue
+						}
 						for _, tx := range list.Cap(list.Len() - 1) {
 							// Drop the transaction from the global pools too
 							hash := tx.Hash()
@@ -1745,21 +1959,25 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 							pool.priced.Removed()
 
 							// Update the account nonce to the dropped transaction
-							if nonce := tx.Nonce(); pool.pendingState.GetNonce(offenders[i]) > nonce {
-								pool.pendingState.SetNonce(offenders[i], nonce)
+							if nonce := tx.Nonce(); pool.pendingState.GetLaneNonce(offenders[i], tx.Lane()) > nonce {
+								pool.pendingState.SetLaneNonce(offenders[i], nonce)
 							}
 							log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+							pending -= uint64(numSlots(tx))
+							pendingRateLimitCounter.Inc(1)
 						}
-						pending--
 					}
 				}
 			}
 		}
 		// If still above threshold, reduce to limit or min allowance
 		if pending > pool.config.GlobalSlots && len(offenders) > 0 {
-			for pending > pool.config.GlobalSlots && uint64(pool.pending[offenders[len(offenders)-1]].Len()) > pool.config.AccountSlots {
+			for pending > pool.config.GlobalSlots && uint64(pool.pending[offenders[len(offenders)-1]].Slots()) > pool.config.AccountSlots {
 				for _, addr := range offenders {
 					list := pool.pending[addr]
+					if list.Len() == 0 {
+						continue
+					}
 					for _, tx := range list.Cap(list.Len() - 1) {
 						// Drop the transaction from the global pools too
 						hash := tx.Hash()
@@ -1767,21 +1985,21 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 						pool.priced.Removed()
 
 						// Update the account nonce to the dropped transaction
-						if nonce := tx.Nonce(); pool.pendingState.GetNonce(addr) > nonce {
-							pool.pendingState.SetNonce(addr, nonce)
+						if nonce := tx.Nonce(); pool.pendingState.GetLaneNonce(addr, tx.Lane()) > nonce {
+							pool.pendingState.SetLaneNonce(addr, nonce)
 						}
 						log.Trace("Removed fairness-exceeding pending transaction", "hash", hash)
+						pending -= uint64(numSlots(tx))
+						pendingRateLimitCounter.Inc(1)
 					}
-					pending--
 				}
 			}
 		}
-		pendingRateLimitCounter.Inc(int64(pendingBeforeCap - pending))
 	}
 	// If we've queued more transactions than the hard limit, drop oldest ones
 	queued := uint64(0)
 	for _, list := range pool.queue {
-		queued += uint64(list.Len())
+		queued += uint64(list.Slots())
 	}
 	if queued > pool.config.GlobalQueue {
 		// Sort all accounts with queued transactions by heartbeat
@@ -1800,20 +2018,25 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 
 			addresses = addresses[:len(addresses)-1]
 
-			// Drop all transactions if they are less than the overflow
-			if size := uint64(list.Len()); size <= drop {
-				for _, tx := range list.Flatten() {
+			// Drop all transactions if they take up less slots than the overflow
+			if size := uint64(list.Slots()); size <= drop {
+				txs := list.Flatten()
+				for _, tx := range txs {
 					pool.removeTx(tx.Hash(), true)
 				}
 				drop -= size
-				queuedRateLimitCounter.Inc(int64(size))
+				queuedRateLimitCounter.Inc(int64(len(txs)))
 				continue
 			}
 			// Otherwise drop only last few transactions
 			txs := list.Flatten()
 			for i := len(txs) - 1; i >= 0 && drop > 0; i-- {
 				pool.removeTx(txs[i].Hash(), true)
-				drop--
+				if slots := uint64(numSlots(txs[i])); slots < drop {
+					drop -= slots
+				} else {
+					drop = 0
+				}
 				queuedRateLimitCounter.Inc(1)
 			}
 		}
@@ -1826,14 +2049,14 @@ func (pool *TxPool) promoteExecutables(accounts []common.Address) {
 func (pool *TxPool) demoteUnexecutables() {
 	// Iterate over all accounts and demote any non-executable transactions
 	for addr, list := range pool.pending {
-		nonce := pool.currentState.GetNonce(addr)
-
 		// Drop all transactions that are deemed too old (low nonce)
-		for _, tx := range list.Forward(nonce) {
-			hash := tx.Hash()
-			log.Trace("Removed old pending transaction", "hash", hash)
-			pool.all.Remove(hash)
-			pool.priced.Removed()
+		for _, lane := range list.Lanes() {
+			for _, tx := range list.Forward(pool.currentState.GetLaneNonce(addr, lane)) {
+				hash := tx.Hash()
+				log.Trace("Removed old pending transaction", "hash", hash)
+				pool.all.Remove(hash)
+				pool.priced.Removed()
+			}
 		}
 		// Drop all transactions that are too costly (low balance or out of gas), and queue any invalids back for later
 		drops, invalids := list.Filter(pool.currentState.GetBalance(addr), pool.currentMaxGas)
@@ -1849,12 +2072,14 @@ func (pool *TxPool) demoteUnexecutables() {
 			log.Trace("Demoting pending transaction", "hash", hash)
 			pool.enqueueTx(hash, tx)
 		}
-		// If there's a gap in front, warn (should never happen) and postpone all transactions
-		if list.Len() > 0 && list.txs.Get(nonce) == nil {
-			for _, tx := range list.Cap(0) {
-				hash := tx.Hash()
-				log.Error("Demoting invalidated transaction", "hash", hash)
-				pool.enqueueTx(hash, tx)
+		// If there's a gap in front of a lane, warn (should never happen) and postpone all its transactions
+		for _, lane := range list.Lanes() {
+			if list.txs.Get(pool.currentState.GetLaneNonce(addr, lane)) == nil {
+				for _, tx := range list.txs.Filter(func(tx *types.Transaction) bool { return tx.Lane() == lane }) {
+					hash := tx.Hash()
+					log.Error("Demoting invalidated transaction", "hash", hash)
+					pool.enqueueTx(hash, tx)
+				}
 			}
 		}
 		// Delete the entire queue entry if it became empty.
@@ -1923,8 +2148,10 @@ func (as *accountSet) add(addr common.Address) {
 // peeking into the pool in TxPool.Get without having to acquire the widely scoped
 // TxPool.mu mutex.
 type txLookup struct {
-	all  map[common.Hash]*types.Transaction
-	lock sync.RWMutex
+	all     map[common.Hash]*types.Transaction
+	slots   [numTxClasses]int // Pool slots taken up by each transaction type
+	tracker *TxTracker        // Tracker of the transactions entering and leaving the pool, if any
+	lock    sync.RWMutex
 }
 
 // newTxLookup returns a new txLookup structure.
@@ -1962,20 +2189,119 @@ func (t *txLookup) Count() int {
 	return len(t.all)
 }
 
+// Slots returns the current number of pool slots taken up by the items in the
+// lookup.
+func (t *txLookup) Slots() int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.total()
+}
+
+// ClassSlots returns the current number of pool slots taken up by the items of
+// the given transaction type in the lookup.
+func (t *txLookup) ClassSlots(class txClass) int {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.slots[class]
+}
+
 // Add adds a transaction to the lookup.
 func (t *txLookup) Add(tx *types.Transaction) {
+	hash := tx.Hash()
+
 	t.lock.Lock()
-	defer t.lock.Unlock()
+	if _, ok := t.all[hash]; ok {
+		t.lock.Unlock()
+		return
+	}
+	t.all[hash] = tx
+	t.account(tx, numSlots(tx))
+	t.lock.Unlock()
 
-	t.all[tx.Hash()] = tx
+	if t.tracker != nil {
+		t.tracker.Record(hash, TxStageRecord{Stage: TxStageAdmitted})
+	}
 }
 
 // Remove removes a transaction from the lookup.
 func (t *txLookup) Remove(hash common.Hash) {
 	t.lock.Lock()
-	defer t.lock.Unlock()
-
+	tx, ok := t.all[hash]
+	if !ok {
+		t.lock.Unlock()
+		return
+	}
 	delete(t.all, hash)
+	t.account(tx, -numSlots(tx))
+	t.lock.Unlock()
+
+	if t.tracker != nil {
+		t.tracker.Record(hash, TxStageRecord{Stage: TxStageEvicted})
+	}
+}
+
+// account adjusts the slots taken up by the type of the transaction and reports
+// them to the metrics system.
+func (t *txLookup) account(tx *types.Transaction, slots int) {
+	class := classifyTx(tx)
+	t.slots[class] += slots
+
+	classSlotsGauge[class].Update(int64(t.slots[class]))
+	slotsGauge.Update(int64(t.total()))
+}
+
+// total returns the number of pool slots taken up by all transaction types.
+func (t *txLookup) total() int {
+	var total int
+	for _, slots := range t.slots {
+		total += slots
+	}
+	return total
+}
+
+// txClass is the type of a transaction the pool caps the slots of.
+type txClass int
+
+const (
+	txClassPlain      txClass = iota // Plain value transfers and contract calls
+	txClassExtended                  // Transactions with one-to-many transfer legs
+	txClassElection                  // Election transactions to the deposit account
+	txClassDelegation                // Delegation transactions to the deposit account
+	numTxClasses
+)
+
+// String implements fmt.Stringer.
+func (c txClass) String() string {
+	switch c {
+	case txClassExtended:
+		return "extended"
+	case txClassElection:
+		return "election"
+	case txClassDelegation:
+		return "delegation"
+	}
+	return "plain"
+}
+
+// classifyTx returns the type of the transaction the pool caps the slots of.
+func classifyTx(tx *types.Transaction) txClass {
+	for _, extra := range tx.GetMatrix_EX() {
+		if len(extra.ExtraTo) > 0 {
+			return txClassExtended
+		}
+	}
+	if tx.To() == nil || *tx.To() != common.HexToAddress(params.HypothecatedAccount) {
+		return txClassPlain
+	}
+	if tx.ParseElectionTxPayLoad() != nil {
+		return txClassElection
+	}
+	if tx.ParseDelegationTxPayLoad() != nil {
+		return txClassDelegation
+	}
+	return txClassPlain
 }
 
 //
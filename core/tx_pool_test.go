@@ -100,17 +100,30 @@ func validateTxPoolInternals(pool *TxPool) error {
 	if priced := pool.priced.items.Len() - pool.priced.stales; priced != pending+queued {
 		return fmt.Errorf("total priced transaction count %d != %d pending + %d queued", priced, pending, queued)
 	}
-	// Ensure the next nonce to assign is the correct one
+	// Ensure the slots taken up are consistent with the pending and queued lists
+	slots := 0
+	for _, list := range pool.pending {
+		slots += list.Slots()
+	}
+	for _, list := range pool.queue {
+		slots += list.Slots()
+	}
+	if total := pool.all.Slots(); total != slots {
+		return fmt.Errorf("total transaction slots %d != %d pending and queued", total, slots)
+	}
+	// Ensure the next nonce to assign on each lane is the correct one
 	for addr, txs := range pool.pending {
-		// Find the last transaction
-		var last uint64
+		// Find the last transaction of each lane
+		last := make(map[uint64]uint64)
 		for nonce := range txs.txs.items {
-			if last < nonce {
-				last = nonce
+			if lane := types.NonceLane(nonce); last[lane] < nonce {
+				last[lane] = nonce
 			}
 		}
-		if nonce := pool.pendingState.GetNonce(addr); nonce != last+1 {
-			return fmt.Errorf("pending nonce mismatch: have %v, want %v", nonce, last+1)
+		for lane, nonce := range last {
+			if have := pool.pendingState.GetLaneNonce(addr, lane); have != nonce+1 {
+				return fmt.Errorf("pending nonce mismatch on lane %d: have %v, want %v", lane, have, nonce+1)
+			}
 		}
 	}
 	return nil
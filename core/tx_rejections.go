@@ -0,0 +1,292 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// maxTrackedRejections is the maximum number of transactions the pool keeps the
+// rejection evidence of.
+const maxTrackedRejections = 4096
+
+var (
+	// ErrTxRejected is returned if a transaction was rejected by the committee.
+	ErrTxRejected = errors.New("transaction rejected by the committee")
+
+	// ErrRejectionRange is returned if a rejection was signed for a height too
+	// far from the current one to be counted.
+	ErrRejectionRange = errors.New("transaction rejection height out of range")
+
+	// ErrRejectionNotMember is returned if a rejection is not signed by a member
+	// of the committee in charge.
+	ErrRejectionNotMember = errors.New("transaction rejection not signed by a committee member")
+
+	// ErrRejectionsFull is returned if the evidence of too many transactions is
+	// already kept.
+	ErrRejectionsFull = errors.New("too many rejected transactions tracked")
+)
+
+// rejectableErrors are the validation errors that make a transaction invalid for
+// every node, as opposed to the local policies of the pool.
+var rejectableErrors = map[error]bool{
+	ErrInvalidSender:       true,
+	ErrNonceTooLow:         true,
+	ErrInsufficientFunds:   true,
+	ErrIntrinsicGas:        true,
+	ErrGasLimit:            true,
+	ErrNegativeValue:       true,
+	ErrTXCountOverflow:     true,
+	ErrTxToRepeat:          true,
+	ErrTXWrongful:          true,
+	ErrElectionDeposit:     true,
+	ErrDelegationRecipient: true,
+	ErrDelegationStake:     true,
+	ErrDelegationDisabled:  true,
+	ErrInvalidNonceLane:    true,
+	ErrNonceLaneClosed:     true,
+}
+
+// committeeReader is implemented by chains able to tell the verifier committee
+// in charge of a height, such as BlockChain.
+type committeeReader interface {
+	Committee(number uint64) ([]election.NodeInfo, common.Hash, error)
+}
+
+// TxRejectionStatus is the outcome of the rejections of a transaction, tallied
+// against the stake of the committee in charge. The stake of a member is the
+// deposit it holds in Shannon, or one per member if the committee holds none,
+// as committees of boot nodes do.
+type TxRejectionStatus struct {
+	TxHash     common.Hash
+	Number     uint64               // Height of the committee the rejections were tallied against
+	Rejected   bool                 // Whether the rejecting stake exceeds the quorum
+	Stake      *big.Int             // Stake of the rejecting committee members
+	Total      *big.Int             // Stake of the whole committee
+	Rejections []*types.TxRejection // Rejections of the committee members, in committee order
+}
+
+// txRejections is the evidence pool of the transaction rejections signed by the
+// verifiers, keyed by transaction hash so rejections of transactions unknown to
+// the local pool are kept too.
+type txRejections struct {
+	votes  map[common.Hash]map[string]*types.TxRejection // Latest rejection of each node, by transaction
+	status map[common.Hash]*TxRejectionStatus            // Last tally of each transaction
+}
+
+// newTxRejections creates an empty rejection evidence pool.
+func newTxRejections() *txRejections {
+	return &txRejections{
+		votes:  make(map[common.Hash]map[string]*types.TxRejection),
+		status: make(map[common.Hash]*TxRejectionStatus),
+	}
+}
+
+// add stores a rejection, replacing any older one of the same node, and returns
+// whether the evidence changed.
+func (r *txRejections) add(rejection *types.TxRejection) (bool, error) {
+	votes := r.votes[rejection.TxHash]
+	if votes == nil {
+		if len(r.votes) >= maxTrackedRejections {
+			return false, ErrRejectionsFull
+		}
+		votes = make(map[string]*types.TxRejection)
+		r.votes[rejection.TxHash] = votes
+	}
+	id := rejectionNodeID(rejection.NodeID)
+	if old := votes[id]; old != nil && old.Number >= rejection.Number {
+		return false, nil
+	}
+	votes[id] = rejection
+	return true, nil
+}
+
+// tally counts the stake of the committee members rejecting the transaction.
+// Members are weighed by the deposit they hold, not by their declared wealth.
+func (r *txRejections) tally(hash common.Hash, number uint64, committee []election.NodeInfo) *TxRejectionStatus {
+	status := &TxRejectionStatus{TxHash: hash, Number: number, Stake: new(big.Int), Total: new(big.Int)}
+
+	deposits := false
+	for _, node := range committee {
+		deposits = deposits || node.Value > 0
+	}
+	votes := r.votes[hash]
+	for _, node := range committee {
+		weight := new(big.Int).SetUint64(node.Value)
+		if !deposits {
+			weight.SetUint64(1)
+		}
+		status.Total.Add(status.Total, weight)
+		if rejection := votes[rejectionNodeID(node.ID)]; rejection != nil {
+			status.Stake.Add(status.Stake, weight)
+			status.Rejections = append(status.Rejections, rejection)
+		}
+	}
+	quorum := new(big.Int).Mul(status.Total, new(big.Int).SetUint64(params.TxRejectionQuorum))
+	status.Rejected = status.Total.Sign() > 0 && new(big.Int).Mul(status.Stake, big.NewInt(100)).Cmp(quorum) > 0
+	r.status[hash] = status
+	return status
+}
+
+// rejected returns whether the transaction was rejected by the committee.
+func (r *txRejections) rejected(hash common.Hash) bool {
+	status := r.status[hash]
+	return status != nil && status.Rejected
+}
+
+// prune drops the rejections that went stale at the given height, along with
+// the evidence of the transactions left without any.
+func (r *txRejections) prune(number uint64) {
+	for hash, votes := range r.votes {
+		for id, rejection := range votes {
+			if rejection.Number+params.SubBlockNum <= number {
+				delete(votes, id)
+			}
+		}
+		if len(votes) == 0 {
+			delete(r.votes, hash)
+			delete(r.status, hash)
+		}
+	}
+}
+
+// rejectionNodeID normalizes a hex encoded node ID.
+func rejectionNodeID(id string) string {
+	return strings.ToLower(strings.TrimPrefix(id, "0x"))
+}
+
+// SetRejectionKey sets the node key the pool signs its rejections of flooded
+// transactions with.
+func (pool *TxPool) SetRejectionKey(key *ecdsa.PrivateKey) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	pool.rejectionKey = key
+}
+
+// signRejection signs a rejection of the transaction for the given validation
+// error. It returns nil if the error is a local policy of the pool or no node
+// key is set.
+func (pool *TxPool) signRejection(hash common.Hash, err error) *types.TxRejection {
+	if pool.rejectionKey == nil || !rejectableErrors[err] {
+		return nil
+	}
+	rejection := &types.TxRejection{
+		TxHash: hash,
+		Number: pool.chain.CurrentBlock().NumberU64() + 1,
+		Reason: err.Error(),
+	}
+	if err := rejection.Sign(pool.rejectionKey); err != nil {
+		log.Warn("Failed to sign transaction rejection", "hash", hash, "err", err)
+		return nil
+	}
+	return rejection
+}
+
+// AddRejections validates rejections signed by verifiers and counts them against
+// the stake of the committee in charge of the next block. Transactions rejected
+// by more than the quorum of the stake are dropped from the pool, refused from
+// then on and reported to the subscribers of TxRejectedEvent.
+func (pool *TxPool) AddRejections(rejections []*types.TxRejection) []error {
+	events, errs := pool.addRejections(rejections)
+	for _, ev := range events {
+		pool.rejectionFeed.Send(ev)
+	}
+	return errs
+}
+
+// addRejections validates and counts the rejections under the pool lock,
+// returning the events to report for the transactions newly rejected.
+func (pool *TxPool) addRejections(rejections []*types.TxRejection) ([]TxRejectedEvent, []error) {
+	errs := make([]error, len(rejections))
+
+	chain, ok := pool.chain.(committeeReader)
+	if !ok {
+		for i := range errs {
+			errs[i] = ErrUnknownCommittee
+		}
+		return nil, errs
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	number := pool.chain.CurrentBlock().NumberU64() + 1
+	committee, _, err := chain.Committee(number)
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return nil, errs
+	}
+	members := make(map[string]bool, len(committee))
+	for _, node := range committee {
+		members[rejectionNodeID(node.ID)] = true
+	}
+	// Store the rejections of committee members within the counted heights
+	changed := make(map[common.Hash]bool)
+	for i, rejection := range rejections {
+		if rejection.Number > number+1 || rejection.Number+params.SubBlockNum <= number {
+			errs[i] = ErrRejectionRange
+			continue
+		}
+		if err := rejection.Validate(); err != nil {
+			errs[i] = err
+			continue
+		}
+		if !members[rejectionNodeID(rejection.NodeID)] {
+			errs[i] = ErrRejectionNotMember
+			continue
+		}
+		added, err := pool.rejections.add(rejection)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if added {
+			changed[rejection.TxHash] = true
+		}
+	}
+	// Tally the transactions with new evidence and drop the rejected ones
+	var events []TxRejectedEvent
+	for hash := range changed {
+		if pool.rejections.rejected(hash) {
+			continue
+		}
+		status := pool.rejections.tally(hash, number, committee)
+		if !status.Rejected {
+			continue
+		}
+		pool.tracker.Record(hash, TxStageRecord{Stage: TxStageEvicted, Reason: ErrTxRejected.Error()})
+		if pool.all.Get(hash) != nil {
+			pool.removeTx(hash, true)
+		}
+		log.Debug("Dropped transaction rejected by the committee", "hash", hash, "stake", status.Stake, "total", status.Total)
+		rejectedTxCounter.Inc(1)
+
+		events = append(events, TxRejectedEvent{Status: status})
+	}
+	return events, errs
+}
+
+// TxRejection returns the last tally of the rejections of a transaction, or nil
+// if no committee member rejected it.
+func (pool *TxPool) TxRejection(hash common.Hash) *TxRejectionStatus {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.rejections.status[hash]
+}
+
+// SubscribeTxRejectedEvent registers a subscription of TxRejectedEvent and
+// starts sending event to the given channel.
+func (pool *TxPool) SubscribeTxRejectedEvent(ch chan<- TxRejectedEvent) event.Subscription {
+	return pool.scope.Track(pool.rejectionFeed.Subscribe(ch))
+}
@@ -0,0 +1,208 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// testCommitteeChain is a test chain with a fixed verifier committee.
+type testCommitteeChain struct {
+	*testBlockChain
+	committee []election.NodeInfo
+}
+
+func (bc *testCommitteeChain) Committee(number uint64) ([]election.NodeInfo, common.Hash, error) {
+	return bc.committee, common.Hash{}, nil
+}
+
+// signedRejection creates a rejection of the transaction signed by the key.
+func signedRejection(hash common.Hash, number uint64, key *ecdsa.PrivateKey) *types.TxRejection {
+	rejection := &types.TxRejection{TxHash: hash, Number: number, Reason: ErrInsufficientFunds.Error()}
+	rejection.Sign(key)
+	return rejection
+}
+
+// Tests that transactions are only dropped once the committee members rejecting
+// them hold more than the quorum of the committee deposits, regardless of the
+// wealth they declare.
+func TestTransactionRejections(t *testing.T) {
+	t.Parallel()
+
+	// Create a committee of three members with differing stakes
+	keys := make([]*ecdsa.PrivateKey, 4)
+	chain := &testCommitteeChain{}
+	for i, deposit := range []uint64{50, 30, 20, 0} {
+		keys[i], _ = crypto.GenerateKey()
+		if deposit > 0 {
+			id := hex.EncodeToString(crypto.FromECDSAPub(&keys[i].PublicKey)[1:])
+			chain.committee = append(chain.committee, election.NodeInfo{ID: id, Value: deposit, Wealth: 1000 - deposit})
+		}
+	}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	chain.testBlockChain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, chain)
+	defer pool.Stop()
+
+	rejected := make(chan TxRejectedEvent, 1)
+	sub := pool.SubscribeTxRejectedEvent(rejected)
+	defer sub.Unsubscribe()
+
+	key, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	tx := transaction(0, 100000, key)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	// Rejections of outsiders, for other heights or forged ones are not counted
+	forged := signedRejection(tx.Hash(), 1, keys[3])
+	forged.NodeID = chain.committee[0].ID
+
+	for i, tt := range []struct {
+		rejection *types.TxRejection
+		err       error
+	}{
+		{signedRejection(tx.Hash(), 1, keys[3]), ErrRejectionNotMember},
+		{signedRejection(tx.Hash(), 3, keys[0]), ErrRejectionRange},
+		{forged, types.ErrRejectionNodeSig},
+		{signedRejection(tx.Hash(), 1, keys[2]), nil},
+		{signedRejection(tx.Hash(), 1, keys[1]), nil},
+	} {
+		if errs := pool.AddRejections([]*types.TxRejection{tt.rejection}); errs[0] != tt.err {
+			t.Errorf("rejection %d: error mismatch: have %v, want %v", i, errs[0], tt.err)
+		}
+	}
+	// Half of the stake does not exceed the quorum
+	status := pool.TxRejection(tx.Hash())
+	if status == nil || status.Rejected || status.Stake.Int64() != 50 || status.Total.Int64() != 100 {
+		t.Fatalf("tally mismatch below quorum: have %+v", status)
+	}
+	if pool.Get(tx.Hash()) == nil {
+		t.Fatalf("transaction dropped below quorum")
+	}
+	// The member with the largest stake tips the balance
+	if errs := pool.AddRejections([]*types.TxRejection{signedRejection(tx.Hash(), 1, keys[0])}); errs[0] != nil {
+		t.Fatalf("failed to add rejection: %v", errs[0])
+	}
+	select {
+	case ev := <-rejected:
+		if ev.Status.TxHash != tx.Hash() || len(ev.Status.Rejections) != 3 {
+			t.Errorf("rejection event mismatch: have %+v", ev.Status)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("rejection event not fired")
+	}
+	if pool.Get(tx.Hash()) != nil {
+		t.Errorf("rejected transaction not dropped")
+	}
+	if err := pool.AddRemote(tx); err != ErrTxRejected {
+		t.Errorf("rejected transaction error mismatch: have %v, want %v", err, ErrTxRejected)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+	// Stale evidence is forgotten
+	pool.rejections.prune(1 + params.SubBlockNum)
+	if status := pool.TxRejection(tx.Hash()); status != nil {
+		t.Errorf("stale evidence kept: %+v", status)
+	}
+}
+
+// Tests that flooded transactions invalid for every node are rejected with the
+// node key and that the own rejection is counted without deadlocking the pool.
+func TestFloodRejection(t *testing.T) {
+	t.Parallel()
+
+	nodeKey, _ := crypto.GenerateKey()
+	chain := &testCommitteeChain{committee: []election.NodeInfo{{
+		ID:    hex.EncodeToString(crypto.FromECDSAPub(&nodeKey.PublicKey)[1:]),
+		Value: 100,
+	}}}
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	chain.testBlockChain = &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	pool := NewTxPool(testTxPoolConfig, params.TestChainConfig, chain)
+	defer pool.Stop()
+	pool.SetRejectionKey(nodeKey)
+
+	rejected := make(chan TxRejectedEvent, 1)
+	sub := pool.SubscribeTxRejectedEvent(rejected)
+	defer sub.Unsubscribe()
+
+	key, _ := crypto.GenerateKey()
+	poor, _ := crypto.GenerateKey()
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	valid, unfunded := transaction(0, 100000, key), transaction(0, 100000, poor)
+	done := make(chan []*types.TxRejection)
+	go func() { done <- pool.addFloodTxs([]*types.Transaction{valid, unfunded}) }()
+
+	select {
+	case rejections := <-done:
+		if len(rejections) != 1 || rejections[0].TxHash != unfunded.Hash() {
+			t.Fatalf("rejections mismatch: have %+v", rejections)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("flooded transactions not added")
+	}
+	select {
+	case ev := <-rejected:
+		if ev.Status.TxHash != unfunded.Hash() {
+			t.Errorf("rejection event mismatch: have %+v", ev.Status)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("rejection event not fired")
+	}
+	if pool.Get(valid.Hash()) == nil {
+		t.Errorf("valid flooded transaction not added")
+	}
+	if err := pool.AddRemote(unfunded); err != ErrTxRejected {
+		t.Errorf("rejected transaction error mismatch: have %v, want %v", err, ErrTxRejected)
+	}
+}
+
+// Tests that tallies do not overflow on large deposits and that committees of
+// boot nodes without deposits weigh their members equally.
+func TestRejectionTally(t *testing.T) {
+	hash := common.Hash{0x01}
+	tests := []struct {
+		deposits []uint64
+		rejected int // Number of leading members rejecting
+		stake    *big.Int
+		want     bool
+	}{
+		{[]uint64{math.MaxUint64, math.MaxUint64}, 1, new(big.Int).SetUint64(math.MaxUint64), false},
+		{[]uint64{math.MaxUint64, math.MaxUint64, 1}, 2, new(big.Int).Mul(new(big.Int).SetUint64(math.MaxUint64), big.NewInt(2)), true},
+		{[]uint64{0, 0, 0}, 2, big.NewInt(2), true},
+		{[]uint64{0, 0, 0}, 1, big.NewInt(1), false},
+	}
+	for i, tt := range tests {
+		rejections := newTxRejections()
+		committee := make([]election.NodeInfo, len(tt.deposits))
+		for j, deposit := range tt.deposits {
+			committee[j] = election.NodeInfo{ID: fmt.Sprintf("%02x", j), Value: deposit}
+			if j < tt.rejected {
+				rejections.add(&types.TxRejection{TxHash: hash, Number: 1, NodeID: committee[j].ID})
+			}
+		}
+		status := rejections.tally(hash, 1, committee)
+		if status.Stake.Cmp(tt.stake) != 0 || status.Rejected != tt.want {
+			t.Errorf("test %d: tally mismatch: have stake %v rejected %v, want stake %v rejected %v", i, status.Stake, status.Rejected, tt.stake, tt.want)
+		}
+	}
+}
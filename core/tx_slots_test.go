@@ -0,0 +1,193 @@
+package core
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// extendedTransaction creates an extended transaction paying a distinct
+// recipient on each of the given number of one-to-many legs.
+func extendedTransaction(nonce uint64, gasprice *big.Int, legs int, signer types.Signer, key *ecdsa.PrivateKey) *types.Transaction {
+	extra := make([]*types.ExtraTo_tr, legs)
+	for i := range extra {
+		to := common.Address{byte(i + 1)}
+		extra[i] = &types.ExtraTo_tr{To_tr: &to, Value_tr: (*hexutil.Big)(big.NewInt(1))}
+	}
+	tx, _ := types.SignTx(types.NewTransactions(nonce, common.Address{}, big.NewInt(100), 100000, gasprice, nil, extra, 0, 0), signer, key)
+	return tx
+}
+
+// setupSlotsTxPool creates a transaction pool past the PTC signer fork, with the
+// given configuration and a number of funded accounts.
+func setupSlotsTxPool(config TxPoolConfig, accounts int) (*TxPool, []*ecdsa.PrivateKey) {
+	chainconfig := *params.TestChainConfig
+	chainconfig.PTCSignerBlock = big.NewInt(0)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	blockchain := &testBlockChain{statedb, 1000000, new(event.Feed)}
+
+	pool := NewTxPool(config, &chainconfig, blockchain)
+
+	keys := make([]*ecdsa.PrivateKey, accounts)
+	for i := range keys {
+		keys[i], _ = crypto.GenerateKey()
+		pool.currentState.AddBalance(crypto.PubkeyToAddress(keys[i].PublicKey), big.NewInt(10000000))
+	}
+	return pool, keys
+}
+
+// Tests that transactions take up pool slots by their transfers and size.
+func TestTransactionSlots(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	signer := types.NewPTCSigner(params.TestChainConfig.ChainID)
+
+	if slots := numSlots(transaction(0, 100000, key)); slots != 1 {
+		t.Errorf("plain transaction slots mismatch: have %d, want 1", slots)
+	}
+	if slots := numSlots(extendedTransaction(0, big.NewInt(1), 2, signer, key)); slots != 3 {
+		t.Errorf("extended transaction slots mismatch: have %d, want 3", slots)
+	}
+	data := make([]byte, txSlotSize+1)
+	tx, _ := types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(0), 100000, big.NewInt(1), data), types.HomesteadSigner{}, key)
+	if slots := numSlots(tx); slots != 2 {
+		t.Errorf("oversized transaction slots mismatch: have %d, want 2", slots)
+	}
+	// The lookup accounts the slots of each transaction type once
+	lookup := newTxLookup()
+	plain, extended := transaction(0, 100000, key), extendedTransaction(1, big.NewInt(1), 2, signer, key)
+	lookup.Add(plain)
+	lookup.Add(extended)
+	lookup.Add(extended)
+
+	if slots := lookup.Slots(); slots != 4 {
+		t.Errorf("lookup slots mismatch: have %d, want 4", slots)
+	}
+	if slots := lookup.ClassSlots(txClassExtended); slots != 3 {
+		t.Errorf("lookup extended slots mismatch: have %d, want 3", slots)
+	}
+	lookup.Remove(extended.Hash())
+	lookup.Remove(extended.Hash())
+	if slots := lookup.Slots(); slots != 1 {
+		t.Errorf("lookup slots mismatch after removal: have %d, want 1", slots)
+	}
+}
+
+// Tests that remote transactions of a type are capped at the slots configured
+// for it, replacements included, while local ones are not.
+func TestTransactionTypeCap(t *testing.T) {
+	t.Parallel()
+
+	config := testTxPoolConfig
+	config.ExtendedSlots = 4
+
+	pool, keys := setupSlotsTxPool(config, 3)
+	defer pool.Stop()
+
+	if err := pool.AddRemote(extendedTransaction(0, big.NewInt(1), 2, pool.signer, keys[0])); err != nil {
+		t.Fatalf("failed to add extended transaction: %v", err)
+	}
+	if err := pool.AddRemote(extendedTransaction(0, big.NewInt(1), 2, pool.signer, keys[1])); err != ErrTxTypeCap {
+		t.Errorf("capped extended transaction error mismatch: have %v, want %v", err, ErrTxTypeCap)
+	}
+	// Replacements only take up the slots beyond those of the replaced one
+	if err := pool.AddRemote(extendedTransaction(0, big.NewInt(2), 2, pool.signer, keys[0])); err != nil {
+		t.Errorf("failed to replace capped extended transaction: %v", err)
+	}
+	// Other transaction types and local transactions are unaffected
+	if err := pool.AddRemote(transaction(0, 100000, keys[1])); err != nil {
+		t.Errorf("failed to add plain transaction: %v", err)
+	}
+	if err := pool.AddLocal(extendedTransaction(0, big.NewInt(1), 2, pool.signer, keys[2])); err != nil {
+		t.Errorf("failed to add local extended transaction: %v", err)
+	}
+	if slots := pool.all.ClassSlots(txClassExtended); slots != 6 {
+		t.Errorf("extended slots mismatch: have %d, want 6", slots)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that a full pool makes room for a transaction by the slots it takes up,
+// not by the number of transactions.
+func TestTransactionSlotEviction(t *testing.T) {
+	t.Parallel()
+
+	config := testTxPoolConfig
+	config.GlobalSlots = 2
+	config.GlobalQueue = 2
+
+	pool, keys := setupSlotsTxPool(config, 5)
+	defer pool.Stop()
+
+	// Fill the pool with cheap plain transactions of distinct accounts
+	for i := 0; i < 4; i++ {
+		if err := pool.AddRemote(pricedTransaction(0, 100000, big.NewInt(int64(i+1)), keys[i])); err != nil {
+			t.Fatalf("failed to add transaction %d: %v", i, err)
+		}
+	}
+	// An extended transaction with two legs has to evict the three cheapest
+	if err := pool.AddRemote(extendedTransaction(0, big.NewInt(10), 2, pool.signer, keys[4])); err != nil {
+		t.Fatalf("failed to add extended transaction: %v", err)
+	}
+	if slots := pool.all.Slots(); slots != 4 {
+		t.Errorf("pool slots mismatch: have %d, want 4", slots)
+	}
+	for i := 0; i < 3; i++ {
+		if pool.pending[crypto.PubkeyToAddress(keys[i].PublicKey)] != nil {
+			t.Errorf("transaction %d not evicted", i)
+		}
+	}
+	if pool.pending[crypto.PubkeyToAddress(keys[3].PublicKey)] == nil {
+		t.Errorf("best priced transaction evicted")
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
+
+// Tests that the queue limits of accounts and of the pool are enforced on the
+// slots their transactions take up, not on their number.
+func TestTransactionQueueSlots(t *testing.T) {
+	t.Parallel()
+
+	config := testTxPoolConfig
+	config.AccountQueue = 4
+	config.GlobalQueue = 5
+
+	pool, keys := setupSlotsTxPool(config, 3)
+	defer pool.Stop()
+
+	// Two queued extended transactions exceed the slots of the account
+	pool.AddRemote(extendedTransaction(1, big.NewInt(1), 2, pool.signer, keys[0]))
+	pool.AddRemote(extendedTransaction(2, big.NewInt(1), 2, pool.signer, keys[0]))
+
+	list := pool.queue[crypto.PubkeyToAddress(keys[0].PublicKey)]
+	if list == nil || list.Len() != 1 || list.Slots() != 3 {
+		t.Fatalf("account queue mismatch: have %v, want 1 transaction in 3 slots", list)
+	}
+	// Queued transactions of other accounts exceed the slots of the pool
+	pool.AddRemote(transaction(1, 100000, keys[1]))
+	pool.AddRemote(extendedTransaction(1, big.NewInt(1), 2, pool.signer, keys[2]))
+
+	queued := 0
+	for _, list := range pool.queue {
+		queued += list.Slots()
+	}
+	if queued > int(config.GlobalQueue) {
+		t.Errorf("queued slots mismatch: have %d, want at most %d", queued, config.GlobalQueue)
+	}
+	if err := validateTxPoolInternals(pool); err != nil {
+		t.Fatalf("pool internal state corrupted: %v", err)
+	}
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// maxTrackedTxs is the maximum number of transactions the tracker keeps the
+	// lifecycle of, the ones tracked the longest are forgotten first.
+	maxTrackedTxs = 16384
+
+	// txStatusQueueSize is the number of status events queued for delivery to
+	// the subscribers. Events recorded while the queue is full are dropped.
+	txStatusQueueSize = 1024
+)
+
+// droppedTxStatusCounter counts the status events dropped as the subscribers
+// fell behind.
+var droppedTxStatusCounter = metrics.NewRegisteredCounter("txpool/status/dropped", nil)
+
+// TxStage is a stage of the lifecycle of a transaction, from its admission into
+// the local pool to its inclusion into a block or its eviction.
+type TxStage string
+
+const (
+	TxStageAdmitted   TxStage = "admitted"   // Admitted into the local pool
+	TxStageFlooded    TxStage = "flooded"    // Flooded to the verifiers under its S/N numbers
+	TxStageVotePassed TxStage = "votePassed" // Passed the vote of the verifier committee
+	TxStageVoteFailed TxStage = "voteFailed" // Failed the vote of the verifier committee
+	TxStageHandedOff  TxStage = "handedOff"  // Handed off to the miners to be sealed
+	TxStageIncluded   TxStage = "included"   // Included into a block
+	TxStageEvicted    TxStage = "evicted"    // Dropped from the local pool without inclusion
+)
+
+// TxStageRecord is a stage a transaction reached.
+type TxStageRecord struct {
+	Stage  TxStage        `json:"stage"`
+	Time   time.Time      `json:"time"`
+	Number hexutil.Uint64 `json:"blockNumber,omitempty"` // Including block, only for TxStageIncluded
+	Reason string         `json:"reason,omitempty"`      // Reason of a failed vote or an eviction, if known
+}
+
+// TxLifecycle is the stages a transaction reached, in the order it did.
+type TxLifecycle struct {
+	Hash   common.Hash     `json:"transactionHash"`
+	Stage  TxStage         `json:"stage"` // Last stage reached
+	Stages []TxStageRecord `json:"stages"`
+}
+
+// TxTracker records the stages of the lifecycle of transactions as they pass
+// through the local pool, the flood, the committee vote and the miners. Stages
+// are recorded under the lock of the pool, so the subscribers are notified from
+// a bounded queue instead, which never blocks recording.
+type TxTracker struct {
+	txs   map[common.Hash]*TxLifecycle
+	order []common.Hash // Tracked transactions, in the order they were first seen
+	lock  sync.RWMutex
+
+	feed   event.Feed
+	events chan TxStatusEvent // Status events waiting to be delivered, in recording order
+	quit   chan struct{}
+}
+
+// NewTxTracker creates a tracker without any transaction and starts delivering
+// its status events.
+func NewTxTracker() *TxTracker {
+	t := &TxTracker{
+		txs:    make(map[common.Hash]*TxLifecycle),
+		events: make(chan TxStatusEvent, txStatusQueueSize),
+		quit:   make(chan struct{}),
+	}
+	go t.loop()
+	return t
+}
+
+// Stop terminates the delivery of the status events.
+func (t *TxTracker) Stop() {
+	close(t.quit)
+}
+
+// loop delivers the queued status events to the subscribers.
+func (t *TxTracker) loop() {
+	for {
+		select {
+		case ev := <-t.events:
+			t.feed.Send(ev)
+		case <-t.quit:
+			return
+		}
+	}
+}
+
+// Record appends a stage to the lifecycle of the transaction and queues it for
+// the subscribers of TxStatusEvent, dropping it if they fell behind. Inclusions
+// are only recorded for tracked transactions, evictions once in a row and not
+// after an inclusion, as the pool drops included transactions too.
+func (t *TxTracker) Record(hash common.Hash, record TxStageRecord) {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	t.lock.Lock()
+	lifecycle := t.txs[hash]
+	if lifecycle == nil {
+		if record.Stage == TxStageIncluded {
+			t.lock.Unlock()
+			return
+		}
+		if len(t.order) >= maxTrackedTxs {
+			delete(t.txs, t.order[0])
+			t.order = t.order[1:]
+		}
+		lifecycle = &TxLifecycle{Hash: hash}
+		t.txs[hash] = lifecycle
+		t.order = append(t.order, hash)
+	}
+	if record.Stage == TxStageEvicted && (lifecycle.Stage == TxStageEvicted || lifecycle.Stage == TxStageIncluded) {
+		t.lock.Unlock()
+		return
+	}
+	lifecycle.Stage = record.Stage
+	lifecycle.Stages = append(lifecycle.Stages, record)
+
+	// Queue under the lock to keep the events in recording order
+	select {
+	case t.events <- TxStatusEvent{Hash: hash, TxStageRecord: record}:
+	default:
+		droppedTxStatusCounter.Inc(1)
+	}
+	t.lock.Unlock()
+}
+
+// Lifecycle returns a copy of the lifecycle of the transaction, or nil if it is
+// not tracked.
+func (t *TxTracker) Lifecycle(hash common.Hash) *TxLifecycle {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	lifecycle := t.txs[hash]
+	if lifecycle == nil {
+		return nil
+	}
+	cpy := *lifecycle
+	cpy.Stages = append([]TxStageRecord(nil), lifecycle.Stages...)
+	return &cpy
+}
+
+// Subscribe registers a subscription of TxStatusEvent.
+func (t *TxTracker) Subscribe(ch chan<- TxStatusEvent) event.Subscription {
+	return t.feed.Subscribe(ch)
+}
@@ -0,0 +1,194 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that the tracker records the stages in order, ignores inclusions of
+// untracked transactions and evictions of included ones.
+func TestTxTrackerRecord(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTxTracker()
+	defer tracker.Stop()
+
+	untracked := common.HexToHash("0x01")
+	tracker.Record(untracked, TxStageRecord{Stage: TxStageIncluded, Number: 1})
+	if lifecycle := tracker.Lifecycle(untracked); lifecycle != nil {
+		t.Fatalf("untracked inclusion recorded: %+v", lifecycle)
+	}
+	hash := common.HexToHash("0x02")
+	for _, stage := range []TxStage{TxStageAdmitted, TxStageFlooded, TxStageVotePassed, TxStageHandedOff, TxStageIncluded, TxStageEvicted} {
+		tracker.Record(hash, TxStageRecord{Stage: stage})
+	}
+	lifecycle := tracker.Lifecycle(hash)
+	if lifecycle == nil {
+		t.Fatalf("transaction not tracked")
+	}
+	if lifecycle.Stage != TxStageIncluded || len(lifecycle.Stages) != 5 {
+		t.Fatalf("lifecycle mismatch: have stage %s with %d records, want %s with 5", lifecycle.Stage, len(lifecycle.Stages), TxStageIncluded)
+	}
+	for i, record := range lifecycle.Stages {
+		if record.Time.IsZero() {
+			t.Errorf("record %d: time not set", i)
+		}
+	}
+	// Returned lifecycles are copies
+	lifecycle.Stages[0].Stage = TxStageEvicted
+	if tracker.Lifecycle(hash).Stages[0].Stage != TxStageAdmitted {
+		t.Errorf("lifecycle not copied")
+	}
+}
+
+// Tests that the tracker forgets the transactions tracked the longest once it
+// tracks too many.
+func TestTxTrackerCap(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTxTracker()
+	defer tracker.Stop()
+	for i := 0; i <= maxTrackedTxs; i++ {
+		tracker.Record(common.BigToHash(big.NewInt(int64(i))), TxStageRecord{Stage: TxStageAdmitted})
+	}
+	if len(tracker.txs) != maxTrackedTxs || len(tracker.order) != maxTrackedTxs {
+		t.Fatalf("tracked transaction count mismatch: have %d/%d, want %d", len(tracker.txs), len(tracker.order), maxTrackedTxs)
+	}
+	if tracker.Lifecycle(common.BigToHash(big.NewInt(0))) != nil {
+		t.Errorf("oldest transaction not forgotten")
+	}
+	if tracker.Lifecycle(common.BigToHash(big.NewInt(maxTrackedTxs))) == nil {
+		t.Errorf("newest transaction not tracked")
+	}
+}
+
+// Tests that recording does not block on subscribers falling behind, which get
+// the queued events in recording order.
+func TestTxTrackerSlowSubscriber(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewTxTracker()
+	defer tracker.Stop()
+
+	statuses := make(chan TxStatusEvent)
+	sub := tracker.Subscribe(statuses)
+	defer sub.Unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*txStatusQueueSize; i++ {
+			tracker.Record(common.BigToHash(big.NewInt(int64(i))), TxStageRecord{Stage: TxStageAdmitted})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("recording blocked on a slow subscriber")
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case ev := <-statuses:
+			if want := common.BigToHash(big.NewInt(int64(i))); ev.Hash != want {
+				t.Errorf("event %d: hash mismatch: have %x, want %x", i, ev.Hash, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("event %d not delivered", i)
+		}
+	}
+}
+
+// Tests that the pool reports the admission and eviction of transactions.
+func TestTransactionLifecycle(t *testing.T) {
+	t.Parallel()
+
+	pool, key := setupTxPool()
+	defer pool.Stop()
+
+	statuses := make(chan TxStatusEvent, 4)
+	sub := pool.SubscribeTxStatusEvent(statuses)
+	defer sub.Unsubscribe()
+
+	pool.currentState.AddBalance(crypto.PubkeyToAddress(key.PublicKey), big.NewInt(1000000))
+
+	tx := transaction(0, 100000, key)
+	if err := pool.AddRemote(tx); err != nil {
+		t.Fatalf("failed to add transaction: %v", err)
+	}
+	pool.mu.Lock()
+	pool.removeTx(tx.Hash(), true)
+	pool.mu.Unlock()
+
+	for _, want := range []TxStage{TxStageAdmitted, TxStageEvicted} {
+		select {
+		case ev := <-statuses:
+			if ev.Hash != tx.Hash() || ev.Stage != want {
+				t.Errorf("status event mismatch: have %x %s, want %x %s", ev.Hash, ev.Stage, tx.Hash(), want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s status event not fired", want)
+		}
+	}
+	lifecycle := pool.Tracker().Lifecycle(tx.Hash())
+	if lifecycle == nil || lifecycle.Stage != TxStageEvicted || len(lifecycle.Stages) != 2 {
+		t.Fatalf("lifecycle mismatch: have %+v", lifecycle)
+	}
+}
+
+// Tests that the pool records the inclusion of the transactions of every block
+// imported at once, not only of the new head.
+func TestTransactionInclusions(t *testing.T) {
+	t.Parallel()
+
+	var (
+		db      = ethdb.NewMemDatabase()
+		key, _  = crypto.GenerateKey()
+		gspec   = &Genesis{Config: params.TestChainConfig, Alloc: GenesisAlloc{crypto.PubkeyToAddress(key.PublicKey): {Balance: big.NewInt(1000000000)}}}
+		genesis = gspec.MustCommit(db)
+		txs     = []*types.Transaction{transaction(0, 100000, key), transaction(1, 100000, key), transaction(2, 100000, key)}
+	)
+	blocks, _ := GenerateChain(gspec.Config, genesis, ethash.NewFaker(), ethdb.NewMemDatabase(), len(txs), func(i int, gen *BlockGen) {
+		gen.AddTx(txs[i])
+	})
+	chain, _ := NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{})
+	defer chain.Stop()
+
+	pool := NewTxPool(testTxPoolConfig, gspec.Config, chain)
+	defer pool.Stop()
+
+	statuses := make(chan TxStatusEvent, 2*len(txs))
+	sub := pool.SubscribeTxStatusEvent(statuses)
+	defer sub.Unsubscribe()
+
+	if errs := pool.AddRemotes(txs); errs[0] != nil || errs[1] != nil || errs[2] != nil {
+		t.Fatalf("failed to add transactions: %v", errs)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to import blocks: %v", err)
+	}
+	included := make(map[common.Hash]uint64)
+	for len(included) < len(txs) {
+		select {
+		case ev := <-statuses:
+			if ev.Stage == TxStageIncluded {
+				included[ev.Hash] = uint64(ev.Number)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("inclusions not recorded: have %d, want %d", len(included), len(txs))
+		}
+	}
+	for i, tx := range txs {
+		if number := included[tx.Hash()]; number != uint64(i+1) {
+			t.Errorf("transaction %d: inclusion number mismatch: have %d, want %d", i, number, i+1)
+		}
+	}
+}
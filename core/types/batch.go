@@ -0,0 +1,91 @@
+package types
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	ErrBatchVoteUnsigned = errors.New("batch vote not signed")
+	ErrBatchVoteNodeSig  = errors.New("batch vote signature does not match node ID")
+)
+
+// BatchVote is the vote of a verifier committee member on a transaction batch,
+// signed by the node key over the height and the root of the batch so it can
+// certify the batch to the chain.
+type BatchVote struct {
+	Result bool
+	NodeId string // Hex encoded node ID, the uncompressed node public key
+
+	Signature []byte // Node key signature over SigHash
+}
+
+// SigHash returns the hash the node key signs for a vote on the batch with the
+// given root approved for the block at number.
+func (v *BatchVote) SigHash(number uint64, root common.Hash) common.Hash {
+	return rlpHash([]interface{}{
+		number,
+		root,
+		v.Result,
+		strings.ToLower(v.NodeId),
+	})
+}
+
+// Sign sets the node ID to the public key of prv and signs the vote on the batch
+// with the given root approved for the block at number.
+func (v *BatchVote) Sign(number uint64, root common.Hash, prv *ecdsa.PrivateKey) error {
+	v.NodeId = hex.EncodeToString(crypto.FromECDSAPub(&prv.PublicKey)[1:])
+
+	sig, err := crypto.Sign(v.SigHash(number, root).Bytes(), prv)
+	if err != nil {
+		return err
+	}
+	v.Signature = sig
+	return nil
+}
+
+// Validate checks that the vote on the batch with the given root approved for
+// the block at number is signed by the key of the node it claims.
+func (v *BatchVote) Validate(number uint64, root common.Hash) error {
+	if len(v.Signature) == 0 {
+		return ErrBatchVoteUnsigned
+	}
+	pub, err := crypto.Ecrecover(v.SigHash(number, root).Bytes(), v.Signature)
+	if err != nil {
+		return ErrBatchVoteNodeSig
+	}
+	if hex.EncodeToString(pub[1:]) != strings.ToLower(strings.TrimPrefix(v.NodeId, "0x")) {
+		return ErrBatchVoteNodeSig
+	}
+	return nil
+}
+
+// VerifiedBatch is a transaction list the verifier committee approved for the
+// block at Number, together with the votes certifying the approval.
+type VerifiedBatch struct {
+	Number uint64
+	Txs    Transactions
+	Votes  []BatchVote
+}
+
+// ApprovedBy reports whether the given committee member voted for the batch.
+func (b *VerifiedBatch) ApprovedBy(id string) bool {
+	for _, vote := range b.Votes {
+		if vote.NodeId == id && vote.Result {
+			return true
+		}
+	}
+	return false
+}
+
+// Hash returns the root of the batch transactions, identifying the batch among
+// the ones approved for the same height. A block packing exactly the batch has
+// it as its transaction root, which the votes sign.
+func (b *VerifiedBatch) Hash() common.Hash {
+	return DeriveSha(b.Txs)
+}
@@ -30,7 +30,6 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto/sha3"
 	"github.com/ethereum/go-ethereum/rlp"
-	"github.com/ethereum/go-ethereum/election"
 )
 
 var (
@@ -69,25 +68,25 @@ func (n *BlockNonce) UnmarshalText(input []byte) error {
 
 // Header represents a block header in the Ethereum blockchain.
 type Header struct {
-	ParentHash  common.Hash    `json:"parentHash"       gencodec:"required"`
-	UncleHash   common.Hash    `json:"sha3Uncles"       gencodec:"required"`
-	Coinbase    common.Address `json:"miner"            gencodec:"required"`
-	Root        common.Hash    `json:"stateRoot"        gencodec:"required"`
-	TxHash      common.Hash    `json:"transactionsRoot" gencodec:"required"`
-	ReceiptHash common.Hash    `json:"receiptsRoot"     gencodec:"required"`
-	Bloom       Bloom          `json:"logsBloom"        gencodec:"required"`
-	Difficulty  *big.Int       `json:"difficulty"       gencodec:"required"`
-	Number      *big.Int       `json:"number"           gencodec:"required"`
-	GasLimit    uint64         `json:"gasLimit"         gencodec:"required"`
-	GasUsed     uint64         `json:"gasUsed"          gencodec:"required"`
-	Time        *big.Int       `json:"timestamp"        gencodec:"required"`
-	Extra       []byte         `json:"extraData"        gencodec:"required"`
-	MinerList   []election.NodeInfo   `json:"MinerList"        gencodec:"required"`
-	CommitteeList []election.NodeInfo `json:"CommitteeList"        gencodec:"required"`
-	Both          []election.NodeInfo  `json:"Both"        gencodec:"required"`
-	OfflineList   []election.NodeInfo  `json:"OfflineList"        gencodec:"required"`
-	MixDigest   common.Hash    `json:"mixHash"          gencodec:"required"`
-	Nonce       BlockNonce     `json:"nonce"            gencodec:"required"`
+	ParentHash   common.Hash    `json:"parentHash"       gencodec:"required"`
+	UncleHash    common.Hash    `json:"sha3Uncles"       gencodec:"required"`
+	Coinbase     common.Address `json:"miner"            gencodec:"required"`
+	Root         common.Hash    `json:"stateRoot"        gencodec:"required"`
+	TxHash       common.Hash    `json:"transactionsRoot" gencodec:"required"`
+	ReceiptHash  common.Hash    `json:"receiptsRoot"     gencodec:"required"`
+	Bloom        Bloom          `json:"logsBloom"        gencodec:"required"`
+	Difficulty   *big.Int       `json:"difficulty"       gencodec:"required"`
+	Number       *big.Int       `json:"number"           gencodec:"required"`
+	GasLimit     uint64         `json:"gasLimit"         gencodec:"required"`
+	GasUsed      uint64         `json:"gasUsed"          gencodec:"required"`
+	Time         *big.Int       `json:"timestamp"        gencodec:"required"`
+	Extra        []byte         `json:"extraData"        gencodec:"required"`
+	TopologyRoot common.Hash    `json:"topologyRoot"`
+	MixDigest    common.Hash    `json:"mixHash"          gencodec:"required"`
+	Nonce        BlockNonce     `json:"nonce"            gencodec:"required"`
+
+	// Main node lists listed inline by headers from before the node list fork
+	legacyLists *Topology
 }
 
 // field type overrides for gencodec
@@ -109,31 +108,18 @@ func (h *Header) Hash() common.Hash {
 
 // HashNoNonce returns the hash which is used as input for the proof-of-work search.
 func (h *Header) HashNoNonce() common.Hash {
-	return rlpHash([]interface{}{
-		h.ParentHash,
-		h.UncleHash,
-		h.Coinbase,
-		h.Root,
-		h.TxHash,
-		h.ReceiptHash,
-		h.Bloom,
-		h.Difficulty,
-		h.Number,
-		h.GasLimit,
-		h.GasUsed,
-		h.Time,
-		h.Extra,
-		h.MinerList,
-		h.CommitteeList,
-		h.Both,
-		h.OfflineList,
-	})
+	return rlpHash(h.rlpFields(false))
+}
+
+// HasNodeLists reports whether the header commits to any main node list.
+func (h *Header) HasNodeLists() bool {
+	return h.TopologyRoot != (common.Hash{}) || !h.legacyLists.Empty()
 }
 
 // Size returns the approximate memory used by all internal contents. It is used
 // to approximate and limit the memory consumption of various caches.
 func (h *Header) Size() common.StorageSize {
-	return common.StorageSize(unsafe.Sizeof(*h)) + common.StorageSize(len(h.MinerList)+len(h.CommitteeList)+len(h.Both)+len(h.OfflineList)+len(h.Extra)+(h.Difficulty.BitLen()+h.Number.BitLen()+h.Time.BitLen())/8)
+	return common.StorageSize(unsafe.Sizeof(*h)) + common.StorageSize(len(h.Extra)+(h.Difficulty.BitLen()+h.Number.BitLen()+h.Time.BitLen())/8)
 }
 
 func rlpHash(x interface{}) (h common.Hash) {
@@ -262,22 +248,6 @@ func CopyHeader(h *Header) *Header {
 		cpy.Extra = make([]byte, len(h.Extra))
 		copy(cpy.Extra, h.Extra)
 	}
-	if len(h.MinerList) > 0 {
-		cpy.MinerList = make([]election.NodeInfo , len(h.MinerList))
-		copy(cpy.MinerList, h.MinerList)
-	}
-	if len(h.Both) > 0 {
-		cpy.Both = make([]election.NodeInfo, len(h.Both))
-		copy(cpy.Both, h.Both)
-	}
-	if len(h.CommitteeList) > 0 {
-		cpy.CommitteeList = make([]election.NodeInfo, len(h.CommitteeList))
-		copy(cpy.CommitteeList, h.CommitteeList)
-	}
-	if len(h.OfflineList) > 0 {
-		cpy.OfflineList = make([]election.NodeInfo, len(h.OfflineList))
-		copy(cpy.OfflineList, h.OfflineList)
-	}
 	return &cpy
 }
 
@@ -0,0 +1,143 @@
+package types
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Delegation transaction types. Like the election types, the type doubles as
+// the marker byte opening the payload of a delegation transaction.
+const (
+	DelegateStake   uint32 = 0xcc // Bond the transferred value to a candidate node
+	UndelegateStake uint32 = 0xbb // Unbond stake from a candidate node
+)
+
+// DelegationPayLoadV1 is the version byte following the type marker of the
+// payloads produced by EncodeDelegationTxPayLoad.
+const DelegationPayLoadV1 uint8 = 1
+
+var (
+	ErrDelegationPayLoad = errors.New("malformed delegation payload")
+	ErrDelegationNode    = errors.New("invalid delegation node ID")
+)
+
+// DelegationTxPayLoadInfo is the payload of a delegation transaction. A
+// delegation bonds the value of its transaction to the node, an undelegation
+// starts unbonding the given amount from it.
+type DelegationTxPayLoadInfo struct {
+	Type   uint32
+	NodeID string   // Hex encoded node ID of the candidate
+	Amount *big.Int // Stake to unbond, unused by delegations
+}
+
+// delegationPayLoadV1 is the RLP encoding of a version 1 payload.
+type delegationPayLoadV1 struct {
+	NodeID string
+	Amount *big.Int
+}
+
+// isDelegationType reports whether the given marker denotes a delegation transaction.
+func isDelegationType(marker uint32) bool {
+	return marker == DelegateStake || marker == UndelegateStake
+}
+
+// normalizeNodeID converts a hex node ID into the form candidates are keyed by,
+// lower case without 0x prefix.
+func normalizeNodeID(id string) (string, error) {
+	id = strings.ToLower(strings.TrimPrefix(id, "0x"))
+	if b, err := hex.DecodeString(id); err != nil || len(b) != 64 {
+		return "", ErrDelegationNode
+	}
+	return id, nil
+}
+
+// EncodeDelegationTxPayLoad encodes a delegation into the transaction data of
+// a delegation transaction.
+func EncodeDelegationTxPayLoad(info *DelegationTxPayLoadInfo) ([]byte, error) {
+	if !isDelegationType(info.Type) {
+		return nil, ErrDelegationPayLoad
+	}
+	id, err := normalizeNodeID(info.NodeID)
+	if err != nil {
+		return nil, err
+	}
+	amount := new(big.Int)
+	if info.Type == UndelegateStake && info.Amount != nil {
+		amount.Set(info.Amount)
+	}
+	enc, err := rlp.EncodeToBytes(&delegationPayLoadV1{NodeID: id, Amount: amount})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(info.Type), DelegationPayLoadV1}, enc...), nil
+}
+
+// DecodeDelegationTxPayLoad decodes the transaction data of a delegation
+// transaction.
+func DecodeDelegationTxPayLoad(data []byte) (*DelegationTxPayLoadInfo, error) {
+	if len(data) < 2 || !isDelegationType(uint32(data[0])) || data[1] != DelegationPayLoadV1 {
+		return nil, ErrDelegationPayLoad
+	}
+	var payload delegationPayLoadV1
+	if err := rlp.DecodeBytes(data[2:], &payload); err != nil {
+		return nil, ErrDelegationPayLoad
+	}
+	id, err := normalizeNodeID(payload.NodeID)
+	if err != nil || id != payload.NodeID {
+		return nil, ErrDelegationNode
+	}
+	return &DelegationTxPayLoadInfo{Type: uint32(data[0]), NodeID: id, Amount: payload.Amount}, nil
+}
+
+// ParseDelegationTxPayLoad decodes the delegation payload of the transaction,
+// or returns nil if the transaction is not a delegation transaction.
+func (tx *Transaction) ParseDelegationTxPayLoad() *DelegationTxPayLoadInfo {
+	info, err := DecodeDelegationTxPayLoad(tx.data.Payload)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// NewDelegationTransaction creates a delegation transaction sent to the given
+// deposit account. Delegations bond the amount by transferring it, while
+// undelegations carry it in the payload and transfer nothing.
+func NewDelegationTransaction(nonce uint64, deposits common.Address, delegationType uint32, nodeID string, amount *big.Int, gasLimit uint64, gasPrice *big.Int) (*Transaction, error) {
+	info := &DelegationTxPayLoadInfo{Type: delegationType, NodeID: nodeID, Amount: amount}
+	data, err := EncodeDelegationTxPayLoad(info)
+	if err != nil {
+		return nil, err
+	}
+	value := new(big.Int)
+	if delegationType == DelegateStake {
+		value.Set(amount)
+	}
+	return NewTransaction(nonce, deposits, value, gasLimit, gasPrice, data), nil
+}
+
+// Bond is stake a delegator has bonded to a candidate node.
+type Bond struct {
+	Delegator common.Address
+	NodeID    string
+	Amount    *big.Int
+}
+
+// Unbonding is stake leaving a candidate node. It is paid back to the
+// delegator by the broadcast block at Release.
+type Unbonding struct {
+	Delegator common.Address
+	NodeID    string
+	Amount    *big.Int
+	Release   uint64
+}
+
+// Delegations is the delegated stake as of a block.
+type Delegations struct {
+	Bonds      []*Bond      // Sorted by node ID, then delegator
+	Unbondings []*Unbonding // Sorted by release, then node ID and delegator
+}
@@ -0,0 +1,60 @@
+package types
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const delegateNode = "a979fb575495b8d6db44f750317d0f4622bf4c2aa3365d6af7c284339968eef29b69ad0dce72a4d8db5ebb4968de0e3bec910127f134779fbcb0cb6d3331163c"
+
+var delegateDeposits = common.HexToAddress("0x0000000000000000000000000000000000000044")
+
+// Tests that delegation transactions survive an encode/decode round trip.
+func TestDelegationPayLoadRoundTrip(t *testing.T) {
+	tests := []struct {
+		delegationType uint32
+		amount         *big.Int
+		value, unbond  int64
+	}{
+		{DelegateStake, big.NewInt(5000), 5000, 0},
+		{UndelegateStake, big.NewInt(3000), 0, 3000},
+	}
+	for _, tt := range tests {
+		tx, err := NewDelegationTransaction(1, delegateDeposits, tt.delegationType, "0x"+strings.ToUpper(delegateNode), tt.amount, 100000, big.NewInt(1))
+		if err != nil {
+			t.Fatalf("type %x: failed to create transaction: %v", tt.delegationType, err)
+		}
+		if tx.Value().Int64() != tt.value {
+			t.Errorf("type %x: value mismatch: have %v, want %d", tt.delegationType, tx.Value(), tt.value)
+		}
+		info := tx.ParseDelegationTxPayLoad()
+		if info == nil {
+			t.Fatalf("type %x: failed to parse payload", tt.delegationType)
+		}
+		if info.Type != tt.delegationType || info.NodeID != delegateNode || info.Amount.Int64() != tt.unbond {
+			t.Errorf("type %x: payload mismatch: %+v", tt.delegationType, info)
+		}
+		if isElect, _ := tx.GetElectType(); isElect {
+			t.Errorf("type %x: delegation parsed as election", tt.delegationType)
+		}
+	}
+}
+
+// Tests that invalid payloads are rejected.
+func TestDelegationPayLoadInvalid(t *testing.T) {
+	if _, err := EncodeDelegationTxPayLoad(&DelegationTxPayLoadInfo{Type: DelegateStake, NodeID: "27044ec5"}); err != ErrDelegationNode {
+		t.Errorf("short node ID: error mismatch: have %v, want %v", err, ErrDelegationNode)
+	}
+	if _, err := EncodeDelegationTxPayLoad(&DelegationTxPayLoadInfo{Type: ElectMiner, NodeID: delegateNode}); err != ErrDelegationPayLoad {
+		t.Errorf("election type: error mismatch: have %v, want %v", err, ErrDelegationPayLoad)
+	}
+	if _, err := DecodeDelegationTxPayLoad([]byte{byte(DelegateStake), 9, 0xc0}); err != ErrDelegationPayLoad {
+		t.Errorf("unknown version: error mismatch: have %v, want %v", err, ErrDelegationPayLoad)
+	}
+	if tx := NewTransaction(0, delegateDeposits, big.NewInt(1), 21000, big.NewInt(1), nil); tx.ParseDelegationTxPayLoad() != nil {
+		t.Errorf("plain transfer parsed as delegation")
+	}
+}
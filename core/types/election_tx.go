@@ -0,0 +1,204 @@
+package types
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Election transaction types. The type doubles as the marker byte opening the
+// payload of an election transaction.
+const (
+	ElectMiner     uint32 = 0xff // Stand for election as a miner
+	ElectCommittee uint32 = 0xee // Stand for election as a validator
+	ElectBoth      uint32 = 0xdd // Stand for election as both miner and validator
+	ElectExit      uint32 = 0xaa // Withdraw from the election and release the deposit
+)
+
+// Election payload versions. The version byte follows the type marker; the
+// legacy format carries a JSON object right after the marker instead.
+const (
+	ElectionPayLoadLegacy uint8 = 0 // Unsigned JSON payload, decoded for chain history only
+	ElectionPayLoadV1     uint8 = 1 // RLP payload signed by the node key
+
+	ElectionPayLoadVersion = ElectionPayLoadV1 // Version produced by EncodeElectionTxPayLoad
+)
+
+var (
+	ErrElectionPayLoad  = errors.New("malformed election payload")
+	ErrElectionVersion  = errors.New("unsupported election payload version")
+	ErrElectionUnsigned = errors.New("election payload not signed by the node key")
+	ErrElectionNodeSig  = errors.New("election payload signature does not match node ID")
+	ErrElectionIP       = errors.New("invalid election node IP")
+)
+
+// ElectionTxPayLoadInfo is the node information carried by an election
+// transaction. TxHash, Value and Account are not part of the payload, they are
+// filled in by the consumers from the transaction itself.
+type ElectionTxPayLoadInfo struct {
+	TPS        uint32
+	IP         string
+	ID         string // Hex encoded node ID, the uncompressed node public key
+	Wealth     uint64
+	OnlineTime uint64
+	TxHash     uint64
+	Value      uint64
+	ElectType  uint32
+	Account    common.Address
+
+	Version   uint8  `json:"-"`
+	Signature []byte `json:"-"` // Node key signature over SigHash, empty for legacy payloads
+}
+
+// electionPayLoadV1 is the RLP encoding of a version 1 payload.
+type electionPayLoadV1 struct {
+	TPS        uint32
+	IP         string
+	ID         string
+	Wealth     uint64
+	OnlineTime uint64
+	Signature  []byte
+}
+
+// isElectType reports whether the given marker denotes an election transaction.
+func isElectType(marker uint32) bool {
+	switch marker {
+	case ElectMiner, ElectCommittee, ElectBoth, ElectExit:
+		return true
+	}
+	return false
+}
+
+// SigHash returns the hash the node key signs. It commits to the sending
+// account so a payload cannot be replayed by another account.
+func (info *ElectionTxPayLoadInfo) SigHash(from common.Address) common.Hash {
+	return rlpHash([]interface{}{
+		info.ElectType,
+		ElectionPayLoadV1,
+		from,
+		info.TPS,
+		info.IP,
+		strings.ToLower(info.ID),
+		info.Wealth,
+		info.OnlineTime,
+	})
+}
+
+// Sign sets the node ID to the public key of prv and signs the payload on
+// behalf of the sending account.
+func (info *ElectionTxPayLoadInfo) Sign(from common.Address, prv *ecdsa.PrivateKey) error {
+	info.ID = hex.EncodeToString(crypto.FromECDSAPub(&prv.PublicKey)[1:])
+	info.Version = ElectionPayLoadV1
+
+	sig, err := crypto.Sign(info.SigHash(from).Bytes(), prv)
+	if err != nil {
+		return err
+	}
+	info.Signature = sig
+	return nil
+}
+
+// Validate checks that the node IP is usable and that the payload is signed by
+// the key of the node it claims, on behalf of the given sending account.
+func (info *ElectionTxPayLoadInfo) Validate(from common.Address) error {
+	ip := net.ParseIP(info.IP)
+	if ip == nil || ip.IsUnspecified() || ip.IsMulticast() || ip.Equal(net.IPv4bcast) {
+		return ErrElectionIP
+	}
+	if info.Version == ElectionPayLoadLegacy || len(info.Signature) == 0 {
+		return ErrElectionUnsigned
+	}
+	pub, err := crypto.Ecrecover(info.SigHash(from).Bytes(), info.Signature)
+	if err != nil {
+		return ErrElectionNodeSig
+	}
+	if hex.EncodeToString(pub[1:]) != strings.ToLower(strings.TrimPrefix(info.ID, "0x")) {
+		return ErrElectionNodeSig
+	}
+	return nil
+}
+
+// EncodeElectionTxPayLoad encodes the node information into the transaction
+// data of an election transaction of the current payload version.
+func EncodeElectionTxPayLoad(info *ElectionTxPayLoadInfo) ([]byte, error) {
+	if !isElectType(info.ElectType) {
+		return nil, ErrElectionPayLoad
+	}
+	enc, err := rlp.EncodeToBytes(&electionPayLoadV1{
+		TPS:        info.TPS,
+		IP:         info.IP,
+		ID:         info.ID,
+		Wealth:     info.Wealth,
+		OnlineTime: info.OnlineTime,
+		Signature:  info.Signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(info.ElectType), ElectionPayLoadV1}, enc...), nil
+}
+
+// DecodeElectionTxPayLoad decodes the transaction data of an election
+// transaction, accepting both the legacy JSON and the versioned RLP format.
+func DecodeElectionTxPayLoad(data []byte) (*ElectionTxPayLoadInfo, error) {
+	if len(data) < 2 || !isElectType(uint32(data[0])) {
+		return nil, ErrElectionPayLoad
+	}
+	electType := uint32(data[0])
+
+	// Legacy payloads carry a JSON object right after the marker
+	if data[1] == '{' {
+		info := new(ElectionTxPayLoadInfo)
+		if err := json.Unmarshal(data[1:], info); err != nil {
+			return nil, ErrElectionPayLoad
+		}
+		info.ElectType, info.Version = electType, ElectionPayLoadLegacy
+		return info, nil
+	}
+	switch data[1] {
+	case ElectionPayLoadV1:
+		var payload electionPayLoadV1
+		if err := rlp.DecodeBytes(data[2:], &payload); err != nil {
+			return nil, ErrElectionPayLoad
+		}
+		return &ElectionTxPayLoadInfo{
+			TPS:        payload.TPS,
+			IP:         payload.IP,
+			ID:         payload.ID,
+			Wealth:     payload.Wealth,
+			OnlineTime: payload.OnlineTime,
+			ElectType:  electType,
+			Version:    ElectionPayLoadV1,
+			Signature:  payload.Signature,
+		}, nil
+	default:
+		return nil, ErrElectionVersion
+	}
+}
+
+// ParseElectionTxPayLoad decodes the election payload of the transaction, or
+// returns nil if the transaction is not an election transaction.
+func (tx *Transaction) ParseElectionTxPayLoad() *ElectionTxPayLoadInfo {
+	info, err := DecodeElectionTxPayLoad(tx.data.Payload)
+	if err != nil {
+		return nil
+	}
+	return info
+}
+
+// GetElectType reports whether the transaction is an election transaction and
+// if so, its election type.
+func (tx *Transaction) GetElectType() (bool, uint32) {
+	info := tx.ParseElectionTxPayLoad()
+	if info == nil {
+		return false, 0
+	}
+	return true, info.ElectType
+}
@@ -0,0 +1,92 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var electFrom = common.HexToAddress("0x2c8b487d9c75d009d9e9cb8291952d715c36324c")
+
+func signedElectPayLoad(t *testing.T, electType uint32) *ElectionTxPayLoadInfo {
+	key, _ := crypto.GenerateKey()
+	info := &ElectionTxPayLoadInfo{TPS: 2000, IP: "192.168.3.95", Wealth: 20000, OnlineTime: 1000, ElectType: electType}
+	if err := info.Sign(electFrom, key); err != nil {
+		t.Fatalf("failed to sign payload: %v", err)
+	}
+	return info
+}
+
+// Tests that signed payloads survive an encode/decode round trip and validate.
+func TestElectionPayLoadRoundTrip(t *testing.T) {
+	for _, electType := range []uint32{ElectMiner, ElectCommittee, ElectBoth, ElectExit} {
+		info := signedElectPayLoad(t, electType)
+		data, err := EncodeElectionTxPayLoad(info)
+		if err != nil {
+			t.Fatalf("type %x: failed to encode payload: %v", electType, err)
+		}
+		tx := NewTransaction(0, electFrom, big.NewInt(0), 21000, big.NewInt(1), data)
+
+		isElect, have := tx.GetElectType()
+		if !isElect || have != electType {
+			t.Errorf("type %x: elect type mismatch: have %v/%x", electType, isElect, have)
+		}
+		dec := tx.ParseElectionTxPayLoad()
+		if dec == nil {
+			t.Fatalf("type %x: failed to parse payload", electType)
+		}
+		if dec.ID != info.ID || dec.IP != info.IP || dec.TPS != info.TPS || dec.Version != ElectionPayLoadV1 {
+			t.Errorf("type %x: payload mismatch: have %+v, want %+v", electType, dec, info)
+		}
+		if err := dec.Validate(electFrom); err != nil {
+			t.Errorf("type %x: failed to validate payload: %v", electType, err)
+		}
+	}
+}
+
+// Tests that legacy JSON payloads still decode but do not validate.
+func TestElectionPayLoadLegacy(t *testing.T) {
+	enc, _ := json.Marshal(ElectionTxPayLoadInfo{TPS: 2000, IP: "192.168.3.95", ID: "27044ec5"})
+	data := append([]byte{byte(ElectCommittee)}, enc...)
+
+	info, err := DecodeElectionTxPayLoad(data)
+	if err != nil {
+		t.Fatalf("failed to decode legacy payload: %v", err)
+	}
+	if info.ElectType != ElectCommittee || info.Version != ElectionPayLoadLegacy || info.ID != "27044ec5" {
+		t.Errorf("legacy payload mismatch: %+v", info)
+	}
+	if err := info.Validate(electFrom); err != ErrElectionUnsigned {
+		t.Errorf("error mismatch: have %v, want %v", err, ErrElectionUnsigned)
+	}
+}
+
+// Tests that invalid payloads are rejected.
+func TestElectionPayLoadInvalid(t *testing.T) {
+	if _, err := DecodeElectionTxPayLoad([]byte{byte(ElectMiner), 9, 0xc0}); err != ErrElectionVersion {
+		t.Errorf("unknown version: error mismatch: have %v, want %v", err, ErrElectionVersion)
+	}
+	if _, err := DecodeElectionTxPayLoad([]byte{0x01, ElectionPayLoadV1}); err != ErrElectionPayLoad {
+		t.Errorf("unknown type: error mismatch: have %v, want %v", err, ErrElectionPayLoad)
+	}
+	// A payload replayed by another account must not validate
+	info := signedElectPayLoad(t, ElectMiner)
+	if err := info.Validate(common.HexToAddress("0x01")); err != ErrElectionNodeSig {
+		t.Errorf("replayed payload: error mismatch: have %v, want %v", err, ErrElectionNodeSig)
+	}
+	// A node ID other than the signing key must not validate
+	info = signedElectPayLoad(t, ElectMiner)
+	other := signedElectPayLoad(t, ElectMiner)
+	info.ID = other.ID
+	if err := info.Validate(electFrom); err != ErrElectionNodeSig {
+		t.Errorf("foreign node ID: error mismatch: have %v, want %v", err, ErrElectionNodeSig)
+	}
+	info = signedElectPayLoad(t, ElectMiner)
+	info.IP = "0.0.0.0"
+	if err := info.Validate(electFrom); err != ErrElectionIP {
+		t.Errorf("unspecified IP: error mismatch: have %v, want %v", err, ErrElectionIP)
+	}
+}
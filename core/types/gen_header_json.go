@@ -9,33 +9,29 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
-	"github.com/ethereum/go-ethereum/election"
 )
 
 var _ = (*headerMarshaling)(nil)
 
 func (h Header) MarshalJSON() ([]byte, error) {
 	type Header struct {
-		ParentHash  common.Hash    `json:"parentHash"       gencodec:"required"`
-		UncleHash   common.Hash    `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase    common.Address `json:"miner"            gencodec:"required"`
-		Root        common.Hash    `json:"stateRoot"        gencodec:"required"`
-		TxHash      common.Hash    `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash common.Hash    `json:"receiptsRoot"     gencodec:"required"`
-		Bloom       Bloom          `json:"logsBloom"        gencodec:"required"`
-		Difficulty  *hexutil.Big   `json:"difficulty"       gencodec:"required"`
-		Number      *hexutil.Big   `json:"number"           gencodec:"required"`
-		GasLimit    hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
-		GasUsed     hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
-		Time        *hexutil.Big   `json:"timestamp"        gencodec:"required"`
-		Extra       hexutil.Bytes  `json:"extraData"        gencodec:"required"`
-		MixDigest   common.Hash    `json:"mixHash"          gencodec:"required"`
-		Nonce       BlockNonce     `json:"nonce"            gencodec:"required"`
-		Hash        common.Hash    `json:"hash"`
-		MinerList   []election.NodeInfo   `json:"MinerList"        gencodec:"required"`
-		CommitteeList []election.NodeInfo `json:"CommitteeList"        gencodec:"required"`
-		Both          []election.NodeInfo  `json:"Both"        gencodec:"required"`
-		OfflineList   []election.NodeInfo  `json:"OfflineList"        gencodec:"required"`
+		ParentHash   common.Hash    `json:"parentHash"       gencodec:"required"`
+		UncleHash    common.Hash    `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase     common.Address `json:"miner"            gencodec:"required"`
+		Root         common.Hash    `json:"stateRoot"        gencodec:"required"`
+		TxHash       common.Hash    `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash  common.Hash    `json:"receiptsRoot"     gencodec:"required"`
+		Bloom        Bloom          `json:"logsBloom"        gencodec:"required"`
+		Difficulty   *hexutil.Big   `json:"difficulty"       gencodec:"required"`
+		Number       *hexutil.Big   `json:"number"           gencodec:"required"`
+		GasLimit     hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
+		GasUsed      hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
+		Time         *hexutil.Big   `json:"timestamp"        gencodec:"required"`
+		Extra        hexutil.Bytes  `json:"extraData"        gencodec:"required"`
+		TopologyRoot common.Hash    `json:"topologyRoot"`
+		MixDigest    common.Hash    `json:"mixHash"          gencodec:"required"`
+		Nonce        BlockNonce     `json:"nonce"            gencodec:"required"`
+		Hash         common.Hash    `json:"hash"`
 	}
 	var enc Header
 	enc.ParentHash = h.ParentHash
@@ -51,37 +47,31 @@ func (h Header) MarshalJSON() ([]byte, error) {
 	enc.GasUsed = hexutil.Uint64(h.GasUsed)
 	enc.Time = (*hexutil.Big)(h.Time)
 	enc.Extra = h.Extra
+	enc.TopologyRoot = h.TopologyRoot
 	enc.MixDigest = h.MixDigest
 	enc.Nonce = h.Nonce
 	enc.Hash = h.Hash()
-	enc.MinerList = h.MinerList
-	enc.CommitteeList = h.CommitteeList
-	enc.Both = h.Both
-	enc.OfflineList = h.OfflineList
 	return json.Marshal(&enc)
 }
 
 func (h *Header) UnmarshalJSON(input []byte) error {
 	type Header struct {
-		ParentHash  *common.Hash    `json:"parentHash"       gencodec:"required"`
-		UncleHash   *common.Hash    `json:"sha3Uncles"       gencodec:"required"`
-		Coinbase    *common.Address `json:"miner"            gencodec:"required"`
-		Root        *common.Hash    `json:"stateRoot"        gencodec:"required"`
-		TxHash      *common.Hash    `json:"transactionsRoot" gencodec:"required"`
-		ReceiptHash *common.Hash    `json:"receiptsRoot"     gencodec:"required"`
-		Bloom       *Bloom          `json:"logsBloom"        gencodec:"required"`
-		Difficulty  *hexutil.Big    `json:"difficulty"       gencodec:"required"`
-		Number      *hexutil.Big    `json:"number"           gencodec:"required"`
-		GasLimit    *hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
-		GasUsed     *hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
-		Time        *hexutil.Big    `json:"timestamp"        gencodec:"required"`
-		Extra       *hexutil.Bytes  `json:"extraData"        gencodec:"required"`
-		MixDigest   *common.Hash    `json:"mixHash"          gencodec:"required"`
-		Nonce       *BlockNonce     `json:"nonce"            gencodec:"required"`
-		MinerList   *[]election.NodeInfo   `json:"MinerList"        gencodec:"required"`
-		CommitteeList *[]election.NodeInfo `json:"CommitteeList"        gencodec:"required"`
-		Both          *[]election.NodeInfo  `json:"Both"        gencodec:"required"`
-		OfflineList   *[]election.NodeInfo  `json:"OfflineList"        gencodec:"required"`
+		ParentHash   *common.Hash    `json:"parentHash"       gencodec:"required"`
+		UncleHash    *common.Hash    `json:"sha3Uncles"       gencodec:"required"`
+		Coinbase     *common.Address `json:"miner"            gencodec:"required"`
+		Root         *common.Hash    `json:"stateRoot"        gencodec:"required"`
+		TxHash       *common.Hash    `json:"transactionsRoot" gencodec:"required"`
+		ReceiptHash  *common.Hash    `json:"receiptsRoot"     gencodec:"required"`
+		Bloom        *Bloom          `json:"logsBloom"        gencodec:"required"`
+		Difficulty   *hexutil.Big    `json:"difficulty"       gencodec:"required"`
+		Number       *hexutil.Big    `json:"number"           gencodec:"required"`
+		GasLimit     *hexutil.Uint64 `json:"gasLimit"         gencodec:"required"`
+		GasUsed      *hexutil.Uint64 `json:"gasUsed"          gencodec:"required"`
+		Time         *hexutil.Big    `json:"timestamp"        gencodec:"required"`
+		Extra        *hexutil.Bytes  `json:"extraData"        gencodec:"required"`
+		TopologyRoot *common.Hash    `json:"topologyRoot"`
+		MixDigest    *common.Hash    `json:"mixHash"          gencodec:"required"`
+		Nonce        *BlockNonce     `json:"nonce"            gencodec:"required"`
 	}
 	var dec Header
 	if err := json.Unmarshal(input, &dec); err != nil {
@@ -139,10 +129,9 @@ func (h *Header) UnmarshalJSON(input []byte) error {
 		return errors.New("missing required field 'extraData' for Header")
 	}
 	h.Extra = *dec.Extra
-	h.MinerList = *dec.MinerList
-	h.CommitteeList = *dec.CommitteeList
-	h.Both = *dec.Both
-	h.OfflineList = *dec.OfflineList
+	if dec.TopologyRoot != nil {
+		h.TopologyRoot = *dec.TopologyRoot
+	}
 	if dec.MixDigest == nil {
 		return errors.New("missing required field 'mixHash' for Header")
 	}
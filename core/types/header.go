@@ -0,0 +1,152 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// errZeroTopologyRoot is returned when decoding a header committing to an empty
+// topology root, which has to be encoded in the layout from before the node
+// list fork instead.
+var errZeroTopologyRoot = errors.New("rlp: zero topology root")
+
+// NodeListFields returns the header fields covering the main node lists. From
+// the node list fork on, headers listing nodes commit to them through their
+// topology root. All other headers keep the layout from before the fork, the
+// four lists inline, so their encoding and hash are unchanged.
+func (h *Header) NodeListFields() []interface{} {
+	if h.TopologyRoot != (common.Hash{}) {
+		return []interface{}{h.TopologyRoot}
+	}
+	lists := h.legacyLists
+	if lists == nil {
+		lists = new(Topology)
+	}
+	return []interface{}{lists.MinerList, lists.CommitteeList, lists.Both, lists.OfflineList}
+}
+
+// LegacyNodeLists returns the main node lists the header lists inline, nil if it
+// does not. Only headers from before the node list fork may.
+func (h *Header) LegacyNodeLists() *Topology {
+	return h.legacyLists
+}
+
+// rlpFields returns the fields of the header encoding, with or without the seal.
+func (h *Header) rlpFields(seal bool) []interface{} {
+	fields := []interface{}{
+		h.ParentHash,
+		h.UncleHash,
+		h.Coinbase,
+		h.Root,
+		h.TxHash,
+		h.ReceiptHash,
+		h.Bloom,
+		h.Difficulty,
+		h.Number,
+		h.GasLimit,
+		h.GasUsed,
+		h.Time,
+		h.Extra,
+	}
+	fields = append(fields, h.NodeListFields()...)
+	if seal {
+		fields = append(fields, h.MixDigest, h.Nonce)
+	}
+	return fields
+}
+
+// EncodeRLP implements rlp.Encoder.
+func (h *Header) EncodeRLP(w io.Writer) error {
+	return rlp.Encode(w, h.rlpFields(true))
+}
+
+// DecodeRLP implements rlp.Decoder, accepting both header layouts.
+func (h *Header) DecodeRLP(s *rlp.Stream) error {
+	if _, err := s.List(); err != nil {
+		return err
+	}
+	var dec Header
+	for _, field := range []interface{}{
+		&dec.ParentHash,
+		&dec.UncleHash,
+		&dec.Coinbase,
+		&dec.Root,
+		&dec.TxHash,
+		&dec.ReceiptHash,
+		&dec.Bloom,
+		&dec.Difficulty,
+		&dec.Number,
+		&dec.GasLimit,
+		&dec.GasUsed,
+		&dec.Time,
+		&dec.Extra,
+	} {
+		if err := s.Decode(field); err != nil {
+			return err
+		}
+	}
+	kind, _, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind == rlp.List {
+		lists := new(Topology)
+		for _, list := range []*[]election.NodeInfo{&lists.MinerList, &lists.CommitteeList, &lists.Both, &lists.OfflineList} {
+			if err := s.Decode(list); err != nil {
+				return err
+			}
+		}
+		if !lists.Empty() {
+			dec.legacyLists = lists
+		}
+	} else {
+		if err := s.Decode(&dec.TopologyRoot); err != nil {
+			return err
+		}
+		if dec.TopologyRoot == (common.Hash{}) {
+			return errZeroTopologyRoot
+		}
+	}
+	if err := s.Decode(&dec.MixDigest); err != nil {
+		return err
+	}
+	if err := s.Decode(&dec.Nonce); err != nil {
+		return err
+	}
+	if err := s.ListEnd(); err != nil {
+		return err
+	}
+	*h = dec
+	return nil
+}
+
+// HeaderJSON wraps a header for JSON decoding. On top of the generated decoder
+// it keeps the main node lists headers from before the node list fork list
+// inline, so the decoded header hashes like the one served.
+type HeaderJSON struct {
+	*Header
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (h *HeaderJSON) UnmarshalJSON(input []byte) error {
+	header := new(Header)
+	if err := json.Unmarshal(input, header); err != nil {
+		return err
+	}
+	if header.TopologyRoot == (common.Hash{}) {
+		lists := new(Topology)
+		if err := json.Unmarshal(input, lists); err != nil {
+			return err
+		}
+		if !lists.Empty() {
+			header.legacyLists = lists
+		}
+	}
+	h.Header = header
+	return nil
+}
@@ -0,0 +1,89 @@
+package types
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// preForkHeader is the header layout from before the node list fork.
+type preForkHeader struct {
+	ParentHash    common.Hash
+	UncleHash     common.Hash
+	Coinbase      common.Address
+	Root          common.Hash
+	TxHash        common.Hash
+	ReceiptHash   common.Hash
+	Bloom         Bloom
+	Difficulty    *big.Int
+	Number        *big.Int
+	GasLimit      uint64
+	GasUsed       uint64
+	Time          *big.Int
+	Extra         []byte
+	MinerList     []election.NodeInfo
+	CommitteeList []election.NodeInfo
+	Both          []election.NodeInfo
+	OfflineList   []election.NodeInfo
+	MixDigest     common.Hash
+	Nonce         BlockNonce
+}
+
+// Tests that headers without a topology root keep the layout from before the
+// node list fork and that both layouts round trip.
+func TestHeaderRLPLayout(t *testing.T) {
+	lists := &Topology{MinerList: []election.NodeInfo{{ID: "01", IP: "192.168.3.95"}}}
+	old := &preForkHeader{
+		Difficulty: big.NewInt(131072),
+		Number:     big.NewInt(10),
+		Time:       big.NewInt(1426516743),
+		Extra:      []byte("extra"),
+		MinerList:  lists.MinerList,
+		Nonce:      EncodeNonce(1),
+	}
+	want, err := rlp.EncodeToBytes(old)
+	if err != nil {
+		t.Fatalf("failed to encode pre-fork header: %v", err)
+	}
+	var legacy Header
+	if err := rlp.DecodeBytes(want, &legacy); err != nil {
+		t.Fatalf("failed to decode pre-fork header: %v", err)
+	}
+	if legacy.LegacyNodeLists().Hash() != lists.Hash() {
+		t.Errorf("inline node lists not kept")
+	}
+	if have, _ := rlp.EncodeToBytes(&legacy); !bytes.Equal(have, want) {
+		t.Errorf("pre-fork header encoding mismatch:\\nhave %x\\nwant %x", have, want)
+	}
+	// A header committing to a root uses the fork layout and round trips
+	header := CopyHeader(&legacy)
+	header.legacyLists, header.TopologyRoot = nil, lists.Hash()
+
+	enc, err := rlp.EncodeToBytes(header)
+	if err != nil {
+		t.Fatalf("failed to encode header: %v", err)
+	}
+	var dec Header
+	if err := rlp.DecodeBytes(enc, &dec); err != nil {
+		t.Fatalf("failed to decode header: %v", err)
+	}
+	if dec.Hash() != header.Hash() || dec.TopologyRoot != header.TopologyRoot || dec.LegacyNodeLists() != nil {
+		t.Errorf("header round trip mismatch: have %x, want %x", dec.Hash(), header.Hash())
+	}
+	if dec.Hash() == legacy.Hash() || dec.HashNoNonce() == legacy.HashNoNonce() {
+		t.Errorf("header layouts hash alike")
+	}
+	// A zero root has to be encoded in the pre-fork layout
+	fields := header.rlpFields(true)
+	fields[13] = common.Hash{}
+	if enc, err = rlp.EncodeToBytes(fields); err != nil {
+		t.Fatalf("failed to encode header: %v", err)
+	}
+	if err := rlp.DecodeBytes(enc, &dec); err != errZeroTopologyRoot {
+		t.Errorf("zero root error mismatch: have %v, want %v", err, errZeroTopologyRoot)
+	}
+}
@@ -0,0 +1,26 @@
+package types
+
+import "github.com/ethereum/go-ethereum/params"
+
+// nonceSeqBits is the number of low bits of a nonce holding its sequence number
+// within its lane.
+const nonceSeqBits = 64 - params.NonceLaneBits
+
+// NonceLane returns the parallel nonce lane a nonce belongs to, selected by its
+// top bits. Lane 0 is the plain account nonce.
+func NonceLane(nonce uint64) uint64 {
+	return nonce >> nonceSeqBits
+}
+
+// NonceSeq returns the sequence number of a nonce within its lane.
+func NonceSeq(nonce uint64) uint64 {
+	return nonce & (1<<nonceSeqBits - 1)
+}
+
+// LaneNonce returns the nonce with the given sequence number on the given lane.
+func LaneNonce(lane, seq uint64) uint64 {
+	return lane<<nonceSeqBits | NonceSeq(seq)
+}
+
+// Lane returns the parallel nonce lane the transaction is sent on.
+func (tx *Transaction) Lane() uint64 { return NonceLane(tx.data.AccountNonce) }
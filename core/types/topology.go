@@ -0,0 +1,30 @@
+package types
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/election"
+)
+
+// Topology holds the main node lists published by a broadcast block. The lists
+// are stored next to the block like its receipts, the header only commits to
+// them through its TopologyRoot.
+type Topology struct {
+	MinerList     []election.NodeInfo `json:"MinerList"`
+	CommitteeList []election.NodeInfo `json:"CommitteeList"`
+	Both          []election.NodeInfo `json:"Both"`
+	OfflineList   []election.NodeInfo `json:"OfflineList"`
+}
+
+// Empty reports whether the topology lists no node at all.
+func (t *Topology) Empty() bool {
+	return t == nil || len(t.MinerList)+len(t.CommitteeList)+len(t.Both)+len(t.OfflineList) == 0
+}
+
+// Hash returns the root a header commits to for the topology. An empty topology
+// has the zero root, so blocks without node lists leave the header field unset.
+func (t *Topology) Hash() common.Hash {
+	if t.Empty() {
+		return common.Hash{}
+	}
+	return rlpHash(t)
+}
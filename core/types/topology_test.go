@@ -0,0 +1,74 @@
+package types
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/election"
+)
+
+// Tests that only topologies listing nodes have a non-zero root.
+func TestTopologyHash(t *testing.T) {
+	if root := (*Topology)(nil).Hash(); root != (common.Hash{}) {
+		t.Errorf("nil topology root mismatch: have %x, want zero", root)
+	}
+	if root := new(Topology).Hash(); root != (common.Hash{}) {
+		t.Errorf("empty topology root mismatch: have %x, want zero", root)
+	}
+	miner := &Topology{MinerList: []election.NodeInfo{{ID: "01", IP: "192.168.3.95"}}}
+	committee := &Topology{CommitteeList: []election.NodeInfo{{ID: "01", IP: "192.168.3.95"}}}
+	if miner.Hash() == (common.Hash{}) || miner.Hash() == committee.Hash() {
+		t.Errorf("topology roots not distinct: miner %x, committee %x", miner.Hash(), committee.Hash())
+	}
+}
+
+// Tests that headers decode from both the current JSON format and the legacy one
+// carrying the node lists inline, keeping their hashes.
+func TestHeaderJSONTopology(t *testing.T) {
+	topology := &Topology{
+		MinerList:     []election.NodeInfo{{ID: "01", IP: "192.168.3.95"}},
+		CommitteeList: []election.NodeInfo{{ID: "02", IP: "192.168.3.96"}},
+	}
+	header := &Header{
+		Difficulty:   big.NewInt(131072),
+		Number:       big.NewInt(10),
+		Time:         big.NewInt(1426516743),
+		Extra:        []byte{},
+		TopologyRoot: topology.Hash(),
+	}
+	enc, err := json.Marshal(header)
+	if err != nil {
+		t.Fatalf("failed to encode header: %v", err)
+	}
+	var dec HeaderJSON
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatalf("failed to decode header: %v", err)
+	}
+	if dec.Hash() != header.Hash() {
+		t.Errorf("header hash mismatch: have %x, want %x", dec.Hash(), header.Hash())
+	}
+	// Replace the root with the lists inline, as served for pre-fork headers
+	legacy := CopyHeader(header)
+	legacy.TopologyRoot, legacy.legacyLists = common.Hash{}, topology
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(enc, &fields); err != nil {
+		t.Fatalf("failed to decode header fields: %v", err)
+	}
+	delete(fields, "topologyRoot")
+	fields["MinerList"], fields["CommitteeList"] = topology.MinerList, topology.CommitteeList
+	fields["Both"], fields["OfflineList"] = []election.NodeInfo{}, []election.NodeInfo{}
+
+	if enc, err = json.Marshal(fields); err != nil {
+		t.Fatalf("failed to encode legacy header: %v", err)
+	}
+	dec = HeaderJSON{}
+	if err := json.Unmarshal(enc, &dec); err != nil {
+		t.Fatalf("failed to decode legacy header: %v", err)
+	}
+	if dec.TopologyRoot != (common.Hash{}) || dec.Hash() != legacy.Hash() {
+		t.Errorf("legacy header mismatch: have root %x hash %x, want hash %x", dec.TopologyRoot, dec.Hash(), legacy.Hash())
+	}
+}
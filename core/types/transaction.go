@@ -72,6 +72,11 @@ type Matrix_Extra struct{
 	TxType byte `json:"txType" gencodec:"required"`
 	LockHeight uint64 `json:"lockHeight" gencodec:"required"`
 	ExtraTo []Tx_to `json:"extra_to" gencodec:"required"`
+
+	// Version of the extension, empty for extensions signed by the legacy
+	// signers. The PTC signer sets it to ptcExtensionVersion and covers it by
+	// the signature hash along with the rest of the extension.
+	Version []uint64 `json:"version,omitempty" rlp:"tail"`
 }
 
 type txdata struct {
@@ -208,9 +213,18 @@ func newTransaction(nonce uint64, to *common.Address, amount *big.Int, gasLimit
 
 // ChainId returns which chain id this transaction was signed for (if at all)
 func (tx *Transaction) ChainId() *big.Int {
+	if tx.PTCSigned() {
+		return deriveChainId(extendedSignatureV(tx.data.V))
+	}
 	return deriveChainId(tx.data.V)
 }
 
+// PTCSigned returns whether the transaction carries an extension marked as
+// signed by the PTC signer, which commits to it.
+func (tx *Transaction) PTCSigned() bool {
+	return len(tx.data.Extra) > 0 && ptcExtension(tx.data.Extra[0])
+}
+
 // Protected returns whether the transaction is protected from replay protection.
 func (tx *Transaction) Protected() bool {
 	return isProtectedV(tx.data.V)
@@ -290,11 +304,6 @@ func (tx *Transaction) CostALL() *big.Int {
 func (tx *Transaction) GetTxV() *big.Int{return tx.data.V}
 //YY
 func (tx *Transaction) GetTxS() *big.Int{return tx.data.S}
-//YY used to act on Nonce when passing the transactions
-func (tx *Transaction) SetNonce(nc uint64){
-	tx.data.AccountNonce = nc
-}
-
 //hezi
 func (tx *Transaction) SetTxS(S *big.Int) {tx.data.S = S}
 //func (tx *Transaction) SetTxN(N uint32) {tx.data.N = N}
@@ -369,7 +378,11 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 	cpy.data.R, cpy.data.S, cpy.data.V = r, s, v
 	//YY
 	if len(cpy.data.Extra) >0 {
-		cpy.data.V.Add(cpy.data.V,big.NewInt(128))
+		cpy.data.V.Add(cpy.data.V,big.NewInt(extendedSigOffset))
+	}
+	// The signature of the PTC signer covers the extension as marked by it
+	if _, ok := signer.(PTCSigner); ok && len(cpy.data.Extra) > 0 {
+		cpy.data.Extra = markPTCExtension(cpy.data.Extra)
 	}
 	return cpy, nil
 }
@@ -449,11 +462,18 @@ func (s *TxByPrice) Pop() interface{} {
 
 // TransactionsByPriceAndNonce represents a set of transactions that can return
 // transactions in a profit-maximizing sorted order, while supporting removing
-// entire batches of transactions for non-executable accounts.
+// entire batches of transactions for non-executable accounts. The nonce lanes
+// of an account are ordered independently of each other.
 type TransactionsByPriceAndNonce struct {
-	txs    map[common.Address]Transactions // Per account nonce-sorted list of transactions
-	heads  TxByPrice                       // Next transaction for each unique account (price heap)
-	signer Signer                          // Signer for the set of transactions
+	txs    map[senderLane]Transactions // Per account lane nonce-sorted list of transactions
+	heads  TxByPrice                   // Next transaction for each unique account lane (price heap)
+	signer Signer                      // Signer for the set of transactions
+}
+
+// senderLane identifies one of the parallel nonce lanes of a sender.
+type senderLane struct {
+	from common.Address
+	lane uint64
 }
 
 // NewTransactionsByPriceAndNonce creates a transaction set that can retrieve
@@ -462,22 +482,27 @@ type TransactionsByPriceAndNonce struct {
 // Note, the input map is reowned so the caller should not interact any more with
 // if after providing it to the constructor.
 func NewTransactionsByPriceAndNonce(signer Signer, txs map[common.Address]Transactions) *TransactionsByPriceAndNonce {
-	// Initialize a price based heap with the head transactions
+	// Split the accounts into their lanes and initialize a price based heap with the head transactions
+	lanes := make(map[senderLane]Transactions, len(txs))
 	heads := make(TxByPrice, 0, len(txs))
-	for from, accTxs := range txs {
-		heads = append(heads, accTxs[0])
+	for _, accTxs := range txs {
 		// Ensure the sender address is from the signer
 		acc, _ := Sender(signer, accTxs[0])
-		txs[acc] = accTxs[1:]
-		if from != acc {
-			delete(txs, from)
+		for start := 0; start < len(accTxs); {
+			end := start + 1
+			for end < len(accTxs) && accTxs[end].Lane() == accTxs[start].Lane() {
+				end++
+			}
+			heads = append(heads, accTxs[start])
+			lanes[senderLane{acc, accTxs[start].Lane()}] = accTxs[start+1 : end]
+			start = end
 		}
 	}
 	heap.Init(&heads)
 
 	// Assemble and return the transaction set
 	return &TransactionsByPriceAndNonce{
-		txs:    txs,
+		txs:    lanes,
 		heads:  heads,
 		signer: signer,
 	}
@@ -491,11 +516,12 @@ func (t *TransactionsByPriceAndNonce) Peek() *Transaction {
 	return t.heads[0]
 }
 
-// Shift replaces the current best head with the next one from the same account.
+// Shift replaces the current best head with the next one from the same account lane.
 func (t *TransactionsByPriceAndNonce) Shift() {
 	acc, _ := Sender(t.signer, t.heads[0])
-	if txs, ok := t.txs[acc]; ok && len(txs) > 0 {
-		t.heads[0], t.txs[acc] = txs[0], txs[1:]
+	key := senderLane{acc, t.heads[0].Lane()}
+	if txs, ok := t.txs[key]; ok && len(txs) > 0 {
+		t.heads[0], t.txs[key] = txs[0], txs[1:]
 		heap.Fix(&t.heads, 0)
 	} else {
 		heap.Pop(&t.heads)
@@ -503,8 +529,8 @@ func (t *TransactionsByPriceAndNonce) Shift() {
 }
 
 // Pop removes the best transaction, *not* replacing it with the next one from
-// the same account. This should be used when a transaction cannot be executed
-// and hence all subsequent ones should be discarded from the same account.
+// the same account lane. This should be used when a transaction cannot be executed
+// and hence all subsequent ones should be discarded from the same account lane.
 func (t *TransactionsByPriceAndNonce) Pop() {
 	heap.Pop(&t.heads)
 }
@@ -29,6 +29,21 @@ import (
 
 var (
 	ErrInvalidChainId = errors.New("invalid chain id for signer")
+
+	// ErrLegacyExtendedSig is returned by the PTC signer for extended
+	// transactions whose signature does not commit to the extension.
+	ErrLegacyExtendedSig = errors.New("extended transaction signed by legacy signer")
+)
+
+const (
+	// extendedSigOffset is added to V of every transaction carrying a
+	// Matrix_Extra extension when the signature is attached.
+	extendedSigOffset = 128
+
+	// ptcExtensionVersion marks the extensions signed by the PTC signer. The
+	// mark is part of the extension, not of V, so no legacy signature of any
+	// chain can pass for a PTC one.
+	ptcExtensionVersion = 1
 )
 
 // sigCache is used to cache the derived sender and contains
@@ -42,6 +57,8 @@ type sigCache struct {
 func MakeSigner(config *params.ChainConfig, blockNumber *big.Int) Signer {
 	var signer Signer
 	switch {
+	case config.IsPTCSigner(blockNumber):
+		signer = NewPTCSigner(config.ChainID)
 	case config.IsEIP155(blockNumber):
 		signer = NewEIP155Signer(config.ChainID)
 	case config.IsHomestead(blockNumber):
@@ -164,6 +181,88 @@ func (s EIP155Signer) Hash(tx *Transaction) common.Hash {
 	})
 }
 
+// PTCSigner implements Signer using the EIP155 rules, extended to commit to the
+// Matrix_Extra extension of a transaction: its one-to-many recipients, lock
+// height and type. Transactions without an extension hash and sign exactly as
+// under EIP155, so that standard wallets keep working.
+type PTCSigner struct {
+	EIP155Signer
+}
+
+func NewPTCSigner(chainId *big.Int) PTCSigner {
+	return PTCSigner{NewEIP155Signer(chainId)}
+}
+
+func (s PTCSigner) Equal(s2 Signer) bool {
+	ptc, ok := s2.(PTCSigner)
+	return ok && ptc.chainId.Cmp(s.chainId) == 0
+}
+
+func (s PTCSigner) Sender(tx *Transaction) (common.Address, error) {
+	if len(tx.data.Extra) == 0 {
+		return s.EIP155Signer.Sender(tx)
+	}
+	if !tx.PTCSigned() {
+		return common.Address{}, ErrLegacyExtendedSig
+	}
+	V := extendedSignatureV(tx.data.V)
+	if !isProtectedV(V) {
+		return recoverPlain(s.Hash(tx), tx.data.R, tx.data.S, V, true)
+	}
+	if deriveChainId(V).Cmp(s.chainId) != 0 {
+		return common.Address{}, ErrInvalidChainId
+	}
+	V.Sub(V, s.chainIdMul)
+	V.Sub(V, big8)
+	return recoverPlain(s.Hash(tx), tx.data.R, tx.data.S, V, true)
+}
+
+// SignatureValues returns the EIP155 signature values. Transaction.WithSignature
+// marks the extension of an extended transaction as signed by the PTC signer.
+func (s PTCSigner) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	return s.EIP155Signer.SignatureValues(tx, sig)
+}
+
+// Hash returns the hash to be signed by the sender. The hash of an extended
+// transaction covers its extension, marked as signed by the PTC signer.
+// It does not uniquely identify the transaction.
+func (s PTCSigner) Hash(tx *Transaction) common.Hash {
+	if len(tx.data.Extra) == 0 {
+		return s.EIP155Signer.Hash(tx)
+	}
+	return rlpHash([]interface{}{
+		tx.data.AccountNonce,
+		tx.data.Price,
+		tx.data.GasLimit,
+		tx.data.Recipient,
+		tx.data.Amount,
+		tx.data.Payload,
+		markPTCExtension(tx.data.Extra),
+		s.chainId, uint(0), uint(0),
+	})
+}
+
+// ptcExtension reports whether an extension is marked as signed by the PTC
+// signer.
+func ptcExtension(extra Matrix_Extra) bool {
+	return len(extra.Version) == 1 && extra.Version[0] == ptcExtensionVersion
+}
+
+// markPTCExtension returns a copy of an extension marked as signed by the PTC
+// signer.
+func markPTCExtension(extra []Matrix_Extra) []Matrix_Extra {
+	marked := make([]Matrix_Extra, len(extra))
+	copy(marked, extra)
+	marked[0].Version = []uint64{ptcExtensionVersion}
+	return marked
+}
+
+// extendedSignatureV strips the extension offset off the V value of an extended
+// transaction.
+func extendedSignatureV(V *big.Int) *big.Int {
+	return new(big.Int).Sub(V, big.NewInt(extendedSigOffset))
+}
+
 // HomesteadTransaction implements TransactionInterface using the
 // homestead rules.
 type HomesteadSigner struct{ FrontierSigner }
@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/rlp"
 )
@@ -76,6 +77,76 @@ func TestEIP155ChainId(t *testing.T) {
 	}
 }
 
+// Tests that the PTC signer commits to the extension of extended transactions,
+// rejects legacy signatures of them and signs plain transactions like EIP155.
+func TestPTCSigning(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+
+	signer := NewPTCSigner(big.NewInt(18))
+	legs := []*ExtraTo_tr{
+		{To_tr: &common.Address{0x01}, Value_tr: (*hexutil.Big)(big.NewInt(10))},
+		{To_tr: &common.Address{0x02}, Value_tr: (*hexutil.Big)(big.NewInt(20))},
+	}
+	tx, err := SignTx(NewTransactions(0, addr, new(big.Int), 0, new(big.Int), nil, legs, 100, 0), signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, err := Sender(signer, tx); err != nil || from != addr {
+		t.Fatalf("sender mismatch: have %x (%v), want %x", from, err, addr)
+	}
+	if !tx.PTCSigned() || tx.ChainId().Cmp(big.NewInt(18)) != 0 {
+		t.Errorf("signature mismatch: ptc %v, chain id %v", tx.PTCSigned(), tx.ChainId())
+	}
+	// Redirecting a leg has to invalidate the signature
+	tampered := &Transaction{data: tx.data}
+	tampered.data.Extra = []Matrix_Extra{tx.data.Extra[0]}
+	tampered.data.Extra[0].ExtraTo = append([]Tx_to{}, tx.data.Extra[0].ExtraTo...)
+	tampered.data.Extra[0].ExtraTo[1].Recipient = &common.Address{0x03}
+	if from, err := Sender(signer, tampered); err == nil && from == addr {
+		t.Errorf("redirected leg kept the sender")
+	}
+	// Legacy signatures of extended transactions are rejected
+	legacy, err := SignTx(NewTransactions(0, addr, new(big.Int), 0, new(big.Int), nil, legs, 100, 0), NewEIP155Signer(big.NewInt(18)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy.PTCSigned() {
+		t.Errorf("legacy signature reported as ptc signed")
+	}
+	if _, err := Sender(signer, legacy); err != ErrLegacyExtendedSig {
+		t.Errorf("legacy signature: error mismatch: have %v, want %v", err, ErrLegacyExtendedSig)
+	}
+	// Stripping the mark of the PTC signer has to be caught as well
+	stripped := &Transaction{data: tx.data}
+	stripped.data.Extra = []Matrix_Extra{tx.data.Extra[0]}
+	stripped.data.Extra[0].Version = nil
+	if _, err := Sender(signer, stripped); err != ErrLegacyExtendedSig {
+		t.Errorf("stripped mark: error mismatch: have %v, want %v", err, ErrLegacyExtendedSig)
+	}
+	// Legacy signatures of chains with large IDs must not pass for PTC ones
+	for _, chainID := range []int64{300, 1337} {
+		legacy, err := SignTx(NewTransactions(0, addr, new(big.Int), 0, new(big.Int), nil, legs, 100, 0), NewEIP155Signer(big.NewInt(chainID)), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if legacy.PTCSigned() {
+			t.Errorf("chain %d: legacy signature reported as ptc signed", chainID)
+		}
+		if _, err := Sender(NewPTCSigner(big.NewInt(chainID-128)), legacy); err != ErrLegacyExtendedSig {
+			t.Errorf("chain %d: legacy signature: error mismatch: have %v, want %v", chainID, err, ErrLegacyExtendedSig)
+		}
+	}
+	// Plain transactions sign the same as under EIP155
+	plain, err := SignTx(NewTransaction(0, addr, new(big.Int), 0, new(big.Int), nil), NewEIP155Signer(big.NewInt(18)), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from, err := Sender(signer, plain); err != nil || from != addr {
+		t.Errorf("plain sender mismatch: have %x (%v), want %x", from, err, addr)
+	}
+}
+
 func TestEIP155SigningVitalik(t *testing.T) {
 	// Test vectors come from http://vitalik.ca/files/eip155_testvec.txt
 	for i, test := range []struct {
@@ -0,0 +1,57 @@
+package types
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+//go:generate gencodec -type TransferReceipt -field-override transferReceiptMarshaling -out gen_transfer_json.go
+
+var (
+	// TransferLogAddress is the address synthetic transfer logs are emitted from.
+	TransferLogAddress = common.HexToAddress(params.TransferLogAccount)
+
+	// TransferLogTopic is the first topic of synthetic transfer logs, followed
+	// by the sender and the recipient. The log data is the amount transferred.
+	TransferLogTopic = crypto.Keccak256Hash([]byte("Transfer(address,address,uint256)"))
+)
+
+// TransferReceipt is the outcome of one of the one-to-many transfers of an
+// extended transaction.
+type TransferReceipt struct {
+	To      common.Address `json:"to"      gencodec:"required"`
+	Value   *big.Int       `json:"value"   gencodec:"required"`
+	Status  uint64         `json:"status"`
+	GasUsed uint64         `json:"gasUsed" gencodec:"required"`
+}
+
+type transferReceiptMarshaling struct {
+	Value   *hexutil.Big
+	Status  hexutil.Uint64
+	GasUsed hexutil.Uint64
+}
+
+// NewTransferLog creates the synthetic log recording a successful transfer of
+// an extended transaction, indexed by sender and recipient.
+func NewTransferLog(from, to common.Address, value *big.Int, number uint64) *Log {
+	return &Log{
+		Address:     TransferLogAddress,
+		Topics:      []common.Hash{TransferLogTopic, from.Hash(), to.Hash()},
+		Data:        common.LeftPadBytes(value.Bytes(), 32),
+		BlockNumber: number,
+	}
+}
+
+// ParseTransferLog returns the sender, recipient and amount of a synthetic
+// transfer log, or false if the log is not one.
+func ParseTransferLog(log *Log) (common.Address, common.Address, *big.Int, bool) {
+	if log.Address != TransferLogAddress || len(log.Topics) != 3 || log.Topics[0] != TransferLogTopic || len(log.Data) != 32 {
+		return common.Address{}, common.Address{}, nil, false
+	}
+	from, to := common.BytesToAddress(log.Topics[1].Bytes()), common.BytesToAddress(log.Topics[2].Bytes())
+	return from, to, new(big.Int).SetBytes(log.Data), true
+}
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that synthetic transfer logs round trip and other logs are rejected.
+func TestTransferLog(t *testing.T) {
+	from, to := common.Address{0x01}, common.Address{0x02}
+
+	log := NewTransferLog(from, to, big.NewInt(12345), 7)
+	sender, recipient, value, ok := ParseTransferLog(log)
+	if !ok || sender != from || recipient != to || value.Int64() != 12345 {
+		t.Fatalf("transfer log mismatch: have %x -> %x %v (%v)", sender, recipient, value, ok)
+	}
+	log.Address = common.Address{0xff}
+	if _, _, _, ok := ParseTransferLog(log); ok {
+		t.Errorf("log of another address parsed as a transfer")
+	}
+}
+
+// Tests that transfer receipts are stored along with the receipt and stored
+// receipts without them still decode.
+func TestTransferReceiptStorage(t *testing.T) {
+	receipt := &Receipt{
+		Status:            ReceiptStatusSuccessful,
+		CumulativeGasUsed: 63000,
+		TxHash:            common.Hash{0x11},
+		GasUsed:           63000,
+		Transfers: []*TransferReceipt{
+			{To: common.Address{0x02}, Value: big.NewInt(100), Status: ReceiptStatusSuccessful, GasUsed: 21000},
+			{To: common.Address{0x03}, Value: big.NewInt(300), Status: ReceiptStatusFailed, GasUsed: 21000},
+		},
+	}
+	blob, err := rlp.EncodeToBytes((*ReceiptForStorage)(receipt))
+	if err != nil {
+		t.Fatalf("failed to encode receipt: %v", err)
+	}
+	var dec ReceiptForStorage
+	if err := rlp.DecodeBytes(blob, &dec); err != nil {
+		t.Fatalf("failed to decode receipt: %v", err)
+	}
+	if len(dec.Transfers) != 2 {
+		t.Fatalf("transfer count mismatch: have %d, want 2", len(dec.Transfers))
+	}
+	for i, want := range receipt.Transfers {
+		if have := dec.Transfers[i]; have.To != want.To || have.Value.Cmp(want.Value) != 0 || have.Status != want.Status || have.GasUsed != want.GasUsed {
+			t.Errorf("transfer %d mismatch: have %+v, want %+v", i, have, want)
+		}
+	}
+	// Receipts stored before the transfers were tracked have none
+	receipt.Transfers = nil
+	if blob, err = rlp.EncodeToBytes((*ReceiptForStorage)(receipt)); err != nil {
+		t.Fatalf("failed to encode plain receipt: %v", err)
+	}
+	dec = ReceiptForStorage{}
+	if err := rlp.DecodeBytes(blob, &dec); err != nil {
+		t.Fatalf("failed to decode plain receipt: %v", err)
+	}
+	if len(dec.Transfers) != 0 {
+		t.Errorf("plain receipt decoded with transfers: %v", dec.Transfers)
+	}
+}
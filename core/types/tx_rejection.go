@@ -0,0 +1,65 @@
+package types
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	ErrRejectionUnsigned = errors.New("transaction rejection not signed")
+	ErrRejectionNodeSig  = errors.New("transaction rejection signature does not match node ID")
+)
+
+// TxRejection is the statement of a verifier node that a transaction failed its
+// verification, signed by the node key so it can be counted against the stake
+// the node holds in the committee.
+type TxRejection struct {
+	TxHash common.Hash // Hash of the rejected transaction
+	Number uint64      // Height the transaction was verified for
+	Reason string      // Verification error of the transaction
+	NodeID string      // Hex encoded node ID, the uncompressed node public key
+
+	Signature []byte // Node key signature over SigHash
+}
+
+// SigHash returns the hash the node key signs.
+func (r *TxRejection) SigHash() common.Hash {
+	return rlpHash([]interface{}{
+		r.TxHash,
+		r.Number,
+		r.Reason,
+		strings.ToLower(r.NodeID),
+	})
+}
+
+// Sign sets the node ID to the public key of prv and signs the rejection.
+func (r *TxRejection) Sign(prv *ecdsa.PrivateKey) error {
+	r.NodeID = hex.EncodeToString(crypto.FromECDSAPub(&prv.PublicKey)[1:])
+
+	sig, err := crypto.Sign(r.SigHash().Bytes(), prv)
+	if err != nil {
+		return err
+	}
+	r.Signature = sig
+	return nil
+}
+
+// Validate checks that the rejection is signed by the key of the node it claims.
+func (r *TxRejection) Validate() error {
+	if len(r.Signature) == 0 {
+		return ErrRejectionUnsigned
+	}
+	pub, err := crypto.Ecrecover(r.SigHash().Bytes(), r.Signature)
+	if err != nil {
+		return ErrRejectionNodeSig
+	}
+	if hex.EncodeToString(pub[1:]) != strings.ToLower(strings.TrimPrefix(r.NodeID, "0x")) {
+		return ErrRejectionNodeSig
+	}
+	return nil
+}
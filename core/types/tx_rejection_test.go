@@ -0,0 +1,38 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that signed rejections validate and that tampered ones do not.
+func TestTxRejectionSignature(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	other, _ := crypto.GenerateKey()
+
+	rejection := &TxRejection{TxHash: common.HexToHash("0x01"), Number: 10, Reason: "nonce too low"}
+	if err := rejection.Validate(); err != ErrRejectionUnsigned {
+		t.Errorf("unsigned rejection error mismatch: have %v, want %v", err, ErrRejectionUnsigned)
+	}
+	if err := rejection.Sign(key); err != nil {
+		t.Fatalf("failed to sign rejection: %v", err)
+	}
+	if err := rejection.Validate(); err != nil {
+		t.Errorf("failed to validate rejection: %v", err)
+	}
+	// Changing any signed field invalidates the signature
+	tampered := *rejection
+	tampered.Number++
+	if err := tampered.Validate(); err != ErrRejectionNodeSig {
+		t.Errorf("tampered rejection error mismatch: have %v, want %v", err, ErrRejectionNodeSig)
+	}
+	// Claiming the rejection for another node does too
+	forged := *rejection
+	forged.Sign(other)
+	forged.NodeID = rejection.NodeID
+	if err := forged.Validate(); err != ErrRejectionNodeSig {
+		t.Errorf("forged rejection error mismatch: have %v, want %v", err, ErrRejectionNodeSig)
+	}
+}
@@ -51,6 +51,8 @@ func (NoopStateDB) AddBalance(common.Address, *big.Int)
 func (NoopStateDB) GetBalance(common.Address) *big.Int                                 { return nil }
 func (NoopStateDB) GetNonce(common.Address) uint64                                     { return 0 }
 func (NoopStateDB) SetNonce(common.Address, uint64)                                    {}
+func (NoopStateDB) GetLaneNonce(common.Address, uint64) uint64                         { return 0 }
+func (NoopStateDB) SetLaneNonce(common.Address, uint64)                                {}
 func (NoopStateDB) GetCodeHash(common.Address) common.Hash                             { return common.Hash{} }
 func (NoopStateDB) GetCode(common.Address) []byte                                      { return nil }
 func (NoopStateDB) SetCode(common.Address, []byte)                                     {}
@@ -55,6 +55,8 @@ type Elector struct {
 	M         int //验证主节点个数
 	P         int //备份主节点个数
 	N         int //矿工主节点个数
+	Records   RecordWriter // Optional store of the elections held
+	Stakes    StakeReader  // Optional source of the stake delegated to candidates
 }
 
 type ElectMMSub struct {
@@ -66,7 +68,7 @@ type ElectMVSub struct {
 	MasterValidatorReElectionReqMsgSub event.Subscription
 }
 
-func NewEle() *Elector {
+func NewEle(records RecordWriter, stakes StakeReader) *Elector {
 	var ele Elector
 
 	ele.MaxSample = 1000
@@ -74,6 +76,8 @@ func NewEle() *Elector {
 	ele.M = 11
 	ele.P = 5
 	ele.N = 21
+	ele.Records = records
+	ele.Stakes = stakes
 	ele.EleServer()
 	ele.EleMMRs = make(chan mc.MasterMinerReElectionRsp, 10)
 	ele.EleMVRs = make(chan mc.MasterValidatorReElectionRsq, 10)
@@ -173,7 +177,7 @@ type strallyint struct {
 
 func Normalize(probVal []stf) []pnormalized {
 
-	fmt.Println(probVal)
+	log.Trace("Normalizing election values", "values", probVal)
 	var total float32
 	//	var mlen int
 	for _, item := range probVal {
@@ -280,8 +284,6 @@ func (Ele *Elector) SampleMPlusPNodes(probnormalized []pnormalized, seed int64)
 	for i := 0; i < Ele.MaxSample; i++ {
 		node := Sample1NodesInValNodes(probnormalized, float32(rand.Uniform(0.0, 1.0)))
 
-		//		WriteWithFileWrite("D:/testdata/Sample1NodesInValNodes.txt", hex.EncodeToString([]byte(node)))
-		//		WriteWithFileWrite("D:/testdata/Sample1NodesInValNodes.txt", "\n")
 		_, ok := dict[node]
 
 		if ok == true {
@@ -296,7 +298,6 @@ func (Ele *Elector) SampleMPlusPNodes(probnormalized []pnormalized, seed int64)
 			for k, v := range dict {
 				temp := strallyint{Value: v, Nodeid: k}
 				FirstMMinusJNodes = append(FirstMMinusJNodes, temp) //todo: 直接转成map
-				//				WriteWithFileWrite("D:/testdata/FirstMMinusJNodes.txt", hex.EncodeToString([]byte(temp.Nodeid)))
 				//FirstMMinusJNodes := list(dict.keys())
 			}
 		}
@@ -442,7 +443,7 @@ func CalcRemainingNodesVotes(RemainingProbNormalizedNodes []strallyint) []strall
 func (Ele *Elector) ValNodesSelected(probVal []stf, seed int64) ([]strallyint, []strallyint, []strallyint) {
 
 	probnormalized := Normalize(probVal)
-	fmt.Println(probnormalized)
+	log.Trace("Selecting validators", "probabilities", probnormalized)
 	PricipalValNodes, BakValNodes, RemainingProbNormalizedNodes := Ele.SampleMPlusPNodes(probnormalized, seed)
 
 	// 计算所有剩余节点的股权
@@ -470,7 +471,7 @@ func (Ele *Elector) ValNodesSelected(probVal []stf, seed int64) ([]strallyint, [
 func (Ele *Elector) MinerNodesSelected(probVal []stf, seed int64, Ms int) ([]strallyint, []strallyint) {
 	probnormalized := Normalize(probVal)
 
-	fmt.Println(probnormalized)
+	log.Trace("Selecting miners", "probabilities", probnormalized)
 	PricipalMinerNodes, BakMinerNodes := Ele.SampleMinerNodes(probnormalized, seed, Ms)
 
 	//计算所有剩余节点的股权
@@ -546,37 +547,30 @@ func (Ele *Elector) Listen() {
 				}
 			}
 
-			value := CalcAllValueFunction(mmrerm.MinerList)
+			rec := Ele.elect(RoleMiner, mmrerm.SeqNum, mmrerm.RandSeed, Ele.withDelegatedStake(mmrerm.SeqNum, mmrerm.MinerList))
+			Ele.record(rec)
 
-			a, b := Ele.MinerNodesSelected(value, mmrerm.RandSeed.Int64(), 21) //Ele.Engine(value, mmrerm.RandSeed.Int64()) //0x12217)
-			for index, item := range a {
-				fmt.Println(index, item)
-			}
-			for index, item := range b {
-				fmt.Println(index, item)
-			}
 			var MinerEleRs mc.MasterMinerReElectionRsp
 			MinerEleRs.SeqNum = mmrerm.SeqNum
 
-			for index, item := range a {
-				fmt.Println(item.Nodeid, []byte(item.Nodeid))
-				tmp := MinerElectMap[item.Nodeid]
+			for index, item := range rec.Principal {
+				tmp := MinerElectMap[string(item.NodeID[:])]
 				var ToG mc.TopologyNodeInfo
 				ToG.Account = tmp.Address
 				ToG.Position = uint16(index)
 				ToG.Type = common.RoleMiner
-				ToG.Stock = uint16(item.Value)
+				ToG.Stock = uint16(item.Stock)
 				MinerEleRs.MasterMiner = append(MinerEleRs.MasterMiner, ToG)
 			}
 
-			for index, item := range b {
-				tmp := MinerElectMap[item.Nodeid]
+			for index, item := range rec.Backup {
+				tmp := MinerElectMap[string(item.NodeID[:])]
 				var ToG mc.TopologyNodeInfo
 				ToG.Account = tmp.Address
 				//				ToG.OnlineState = true
 				ToG.Position = uint16(index)
 				ToG.Type = common.RoleMiner
-				ToG.Stock = uint16(item.Value)
+				ToG.Stock = uint16(item.Stock)
 				MinerEleRs.BackUpMiner = append(MinerEleRs.BackUpMiner, ToG)
 			}
 
@@ -601,38 +595,39 @@ func (Ele *Elector) Listen() {
 				}
 			}
 
-			value := CalcAllValueFunction(mvrerm.ValidatorList)
-			a, b, c := Ele.Engine(value, mvrerm.RandSeed.Int64()) //0x12217)
+			rec := Ele.elect(RoleValidator, mvrerm.SeqNum, mvrerm.RandSeed, Ele.withDelegatedStake(mvrerm.SeqNum, mvrerm.ValidatorList))
+			Ele.record(rec)
+
 			var ValidatorEleRs mc.MasterValidatorReElectionRsq
 			ValidatorEleRs.SeqNum = mvrerm.SeqNum
-			for index, item := range a {
-				tmp := ValidatorElectMap[item.Nodeid]
+			for index, item := range rec.Principal {
+				tmp := ValidatorElectMap[string(item.NodeID[:])]
 				var ToG mc.TopologyNodeInfo
 				ToG.Account = tmp.Address
 				ToG.Position = uint16(index)
 				ToG.Type = common.RoleValidator
-				ToG.Stock = uint16(item.Value)
+				ToG.Stock = uint16(item.Stock)
 				ValidatorEleRs.MasterValidator = append(ValidatorEleRs.MasterValidator, ToG)
 			}
 
-			for index, item := range b {
-				tmp := ValidatorElectMap[item.Nodeid]
+			for index, item := range rec.Backup {
+				tmp := ValidatorElectMap[string(item.NodeID[:])]
 				var ToG mc.TopologyNodeInfo
 				ToG.Account = tmp.Address
 				ToG.Position = uint16(index)
 				ToG.Type = common.RoleValidator
-				ToG.Stock = uint16(item.Value)
+				ToG.Stock = uint16(item.Stock)
 				ValidatorEleRs.BackUpValidator = append(ValidatorEleRs.BackUpValidator, ToG)
 			}
 
-			for index, item := range c {
-				tmp := ValidatorElectMap[item.Nodeid]
+			for index, item := range rec.Candidates {
+				tmp := ValidatorElectMap[string(item.NodeID[:])]
 				var ToG mc.TopologyNodeInfo
 				ToG.Account = tmp.Address
 
 				ToG.Position = uint16(index)
 				ToG.Type = common.RoleValidator
-				ToG.Stock = uint16(item.Value)
+				ToG.Stock = uint16(item.Stock)
 				ValidatorEleRs.CandidateValidator = append(ValidatorEleRs.CandidateValidator, ToG)
 			}
 			Ele.EleMVRs <- ValidatorEleRs
@@ -0,0 +1,188 @@
+package election
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// Roles of the elections held by the elector.
+const (
+	RoleMiner     = "miner"
+	RoleValidator = "validator"
+)
+
+// EngineVersion identifies the value function and sampling of the elector. It
+// has to be bumped whenever either of them changes the outcome of an election,
+// so that replays of older records can be told apart from regressions.
+const EngineVersion = "1"
+
+// Weight is the normalized election weight of a candidate.
+type Weight struct {
+	NodeID discover.NodeID `json:"nodeId"`
+	Value  float32         `json:"value"`
+}
+
+// Seat is a candidate elected into a tier, along with its stock.
+type Seat struct {
+	NodeID discover.NodeID `json:"nodeId"`
+	Stock  int             `json:"stock"`
+}
+
+// Record holds the inputs and the outcome of a single election, enough to hold
+// it again and compare the results.
+type Record struct {
+	Period uint64 `json:"period"` // Candidate period the election was held for, set when stored
+	Number uint64 `json:"number"` // Height the election was requested for
+	Role   string `json:"role"`
+	Engine string `json:"engine"`
+
+	MaxSample int `json:"maxSample"`
+	J         int `json:"j"`
+	M         int `json:"m"`
+	P         int `json:"p"`
+	N         int `json:"n"`
+
+	Seed     *big.Int           `json:"seed"`
+	Deposits []vm.DepositDetail `json:"deposits"`
+	Weights  []Weight           `json:"weights"` // Normalized values of the deposits
+
+	Principal  []Seat `json:"principal"`
+	Backup     []Seat `json:"backup"`
+	Candidates []Seat `json:"candidates"`
+}
+
+// RecordWriter persists the records of the elections held by the elector.
+type RecordWriter interface {
+	WriteElectionRecord(rec *Record)
+}
+
+// EncodeRecord encodes an election record for storage. Records are kept as
+// JSON, as the engine weights are floating point.
+func EncodeRecord(rec *Record) ([]byte, error) {
+	return json.Marshal(rec)
+}
+
+// DecodeRecords decodes stored election records.
+func DecodeRecords(blobs [][]byte) ([]*Record, error) {
+	records := make([]*Record, len(blobs))
+	for i, blob := range blobs {
+		records[i] = new(Record)
+		if err := json.Unmarshal(blob, records[i]); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+func newSeats(nodes []strallyint) []Seat {
+	seats := make([]Seat, 0, len(nodes))
+	for _, node := range nodes {
+		seats = append(seats, Seat{NodeID: nodeID(node.Nodeid), Stock: node.Value})
+	}
+	return seats
+}
+
+// nodeID converts a node ID keyed by the engine back into its original form.
+func nodeID(nodeid string) discover.NodeID {
+	var id discover.NodeID
+	copy(id[:], nodeid)
+	return id
+}
+
+// elect holds an election of the given role over the deposit list, recording
+// its inputs and its outcome.
+func (Ele *Elector) elect(role string, number uint64, seed *big.Int, deposits []vm.DepositDetail) *Record {
+	rec := &Record{
+		Number:    number,
+		Role:      role,
+		Engine:    EngineVersion,
+		MaxSample: Ele.MaxSample,
+		J:         Ele.J,
+		M:         Ele.M,
+		P:         Ele.P,
+		N:         Ele.N,
+		Seed:      new(big.Int).Set(seed),
+		Deposits:  deposits,
+	}
+	value := CalcAllValueFunction(deposits)
+	for _, item := range Normalize(value) {
+		rec.Weights = append(rec.Weights, Weight{NodeID: nodeID(item.Nodeid), Value: item.Value})
+	}
+	switch role {
+	case RoleMiner:
+		principal, backup := Ele.MinerNodesSelected(value, seed.Int64(), Ele.N)
+		rec.Principal, rec.Backup = newSeats(principal), newSeats(backup)
+
+	case RoleValidator:
+		engine := Ele.Engine
+		if engine == nil {
+			engine = Ele.ValNodesSelected
+		}
+		principal, backup, candidates := engine(value, seed.Int64())
+		rec.Principal, rec.Backup, rec.Candidates = newSeats(principal), newSeats(backup), newSeats(candidates)
+	}
+	return rec
+}
+
+// record hands the record of an election over to the configured writer.
+func (Ele *Elector) record(rec *Record) {
+	if Ele.Records != nil {
+		Ele.Records.WriteElectionRecord(rec)
+	}
+}
+
+// Replay holds a recorded election again with the same inputs and parameters.
+func Replay(rec *Record) (*Record, error) {
+	if rec.Role != RoleMiner && rec.Role != RoleValidator {
+		return nil, fmt.Errorf("unknown election role %q", rec.Role)
+	}
+	if rec.Seed == nil {
+		return nil, fmt.Errorf("%s election of period %d without seed", rec.Role, rec.Period)
+	}
+	ele := &Elector{MaxSample: rec.MaxSample, J: rec.J, M: rec.M, P: rec.P, N: rec.N}
+
+	replayed := ele.elect(rec.Role, rec.Number, rec.Seed, rec.Deposits)
+	replayed.Period = rec.Period
+	return replayed, nil
+}
+
+// Diff lists the differences in the weights and the outcome of two elections,
+// reporting the values of rec as have and the ones of other as want.
+func (rec *Record) Diff(other *Record) []string {
+	var diffs []string
+	for i := 0; i < len(rec.Weights) || i < len(other.Weights); i++ {
+		switch {
+		case i >= len(rec.Weights):
+			diffs = append(diffs, fmt.Sprintf("weight %d: missing, want %s=%v", i, other.Weights[i].NodeID.TerminalString(), other.Weights[i].Value))
+		case i >= len(other.Weights):
+			diffs = append(diffs, fmt.Sprintf("weight %d: have %s=%v, want none", i, rec.Weights[i].NodeID.TerminalString(), rec.Weights[i].Value))
+		case rec.Weights[i] != other.Weights[i]:
+			diffs = append(diffs, fmt.Sprintf("weight %d: have %s=%v, want %s=%v", i,
+				rec.Weights[i].NodeID.TerminalString(), rec.Weights[i].Value, other.Weights[i].NodeID.TerminalString(), other.Weights[i].Value))
+		}
+	}
+	diffs = append(diffs, diffSeats("principal", rec.Principal, other.Principal)...)
+	diffs = append(diffs, diffSeats("backup", rec.Backup, other.Backup)...)
+	diffs = append(diffs, diffSeats("candidate", rec.Candidates, other.Candidates)...)
+	return diffs
+}
+
+func diffSeats(tier string, have, want []Seat) []string {
+	var diffs []string
+	for i := 0; i < len(have) || i < len(want); i++ {
+		switch {
+		case i >= len(have):
+			diffs = append(diffs, fmt.Sprintf("%s %d: missing, want %s (stock %d)", tier, i, want[i].NodeID.TerminalString(), want[i].Stock))
+		case i >= len(want):
+			diffs = append(diffs, fmt.Sprintf("%s %d: have %s (stock %d), want none", tier, i, have[i].NodeID.TerminalString(), have[i].Stock))
+		case have[i] != want[i]:
+			diffs = append(diffs, fmt.Sprintf("%s %d: have %s (stock %d), want %s (stock %d)", tier, i,
+				have[i].NodeID.TerminalString(), have[i].Stock, want[i].NodeID.TerminalString(), want[i].Stock))
+		}
+	}
+	return diffs
+}
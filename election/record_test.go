@@ -0,0 +1,94 @@
+package election
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// testDeposits creates deposit details for n candidates with varying deposits
+// and online times.
+func testDeposits(n int) []vm.DepositDetail {
+	deposits := make([]vm.DepositDetail, n)
+	for i := range deposits {
+		deposits[i] = vm.DepositDetail{
+			NodeID:     discover.NodeID{byte(i + 1)},
+			Deposit:    big.NewInt(int64(10000+10000*(i%4)) * 1000000),
+			WithdrawH:  new(big.Int),
+			OnlineTime: big.NewInt(int64(64 << uint(i%5))),
+		}
+	}
+	return deposits
+}
+
+// Tests that replaying a recorded election reproduces it and that differing
+// outcomes are reported.
+func TestReplay(t *testing.T) {
+	ele := &Elector{MaxSample: 1000, M: 11, P: 5, N: 21}
+
+	for _, role := range []string{RoleMiner, RoleValidator} {
+		rec := ele.elect(role, 8, big.NewInt(0x12217), testDeposits(30))
+		rec.Period = 1
+
+		if len(rec.Weights) != 30 || len(rec.Principal) == 0 {
+			t.Fatalf("%s: incomplete record: %d weights, %d principal", role, len(rec.Weights), len(rec.Principal))
+		}
+		replayed, err := Replay(rec)
+		if err != nil {
+			t.Fatalf("%s: failed to replay: %v", role, err)
+		}
+		if replayed.Period != rec.Period || replayed.Engine != EngineVersion {
+			t.Errorf("%s: replay metadata mismatch: %+v", role, replayed)
+		}
+		if diffs := replayed.Diff(rec); len(diffs) != 0 {
+			t.Errorf("%s: replay differs: %v", role, diffs)
+		}
+		// Tamper with the recorded outcome and check it is caught
+		rec.Principal[0].Stock++
+		rec.Backup = append(rec.Backup, Seat{NodeID: discover.NodeID{0xff}})
+		if diffs := replayed.Diff(rec); len(diffs) != 2 {
+			t.Errorf("%s: diff mismatch: have %v, want 2 entries", role, diffs)
+		}
+	}
+	if _, err := Replay(&Record{Role: "observer", Seed: new(big.Int)}); err == nil {
+		t.Errorf("replay of unknown role succeeded")
+	}
+}
+
+// Tests that stored election records decode to the records held.
+func TestRecordEncoding(t *testing.T) {
+	ele := &Elector{MaxSample: 1000, M: 11, P: 5, N: 21}
+
+	var (
+		records []*Record
+		blobs   [][]byte
+	)
+	for _, number := range []uint64{8, 9} {
+		rec := ele.elect(RoleMiner, number, big.NewInt(int64(number)), testDeposits(10))
+		blob, err := EncodeRecord(rec)
+		if err != nil {
+			t.Fatalf("failed to encode record: %v", err)
+		}
+		records, blobs = append(records, rec), append(blobs, blob)
+	}
+	have, err := DecodeRecords(blobs)
+	if err != nil {
+		t.Fatalf("failed to decode records: %v", err)
+	}
+	if len(have) != len(records) {
+		t.Fatalf("record count mismatch: have %d, want %d", len(have), len(records))
+	}
+	for i, rec := range records {
+		if have[i].Number != rec.Number || have[i].Seed.Cmp(rec.Seed) != 0 || len(have[i].Deposits) != len(rec.Deposits) {
+			t.Errorf("record %d: inputs mismatch: have %+v, want %+v", i, have[i], rec)
+		}
+		if diffs := have[i].Diff(rec); len(diffs) != 0 {
+			t.Errorf("record %d: outcome mismatch: %v", i, diffs)
+		}
+	}
+	if _, err := DecodeRecords([][]byte{[]byte("{")}); err == nil {
+		t.Errorf("invalid record decoded")
+	}
+}
@@ -0,0 +1,33 @@
+package election
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// StakeReader retrieves the stake delegated to candidate nodes, counted into
+// their election weight on top of their own deposit.
+type StakeReader interface {
+	// DelegatedStake returns the stake delegated to the node as of the given
+	// height, in the unit of the deposits, or nil if there is none.
+	DelegatedStake(id discover.NodeID, number uint64) *big.Int
+}
+
+// withDelegatedStake returns a copy of the deposit list with the stake delegated
+// to each node added to its deposit. The request's list is left untouched, the
+// election is recorded with the combined deposits so it can be replayed as is.
+func (Ele *Elector) withDelegatedStake(number uint64, deposits []vm.DepositDetail) []vm.DepositDetail {
+	if Ele.Stakes == nil {
+		return deposits
+	}
+	combined := make([]vm.DepositDetail, len(deposits))
+	for i, detail := range deposits {
+		combined[i] = detail
+		if stake := Ele.Stakes.DelegatedStake(detail.NodeID, number); stake != nil && stake.Sign() > 0 {
+			combined[i].Deposit = new(big.Int).Add(detail.Deposit, stake)
+		}
+	}
+	return combined
+}
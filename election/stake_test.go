@@ -0,0 +1,49 @@
+package election
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// testStakes delegates fixed stakes to nodes.
+type testStakes map[discover.NodeID]int64
+
+func (s testStakes) DelegatedStake(id discover.NodeID, number uint64) *big.Int {
+	if stake, ok := s[id]; ok {
+		return big.NewInt(stake)
+	}
+	return nil
+}
+
+// Tests that delegated stake is added to copies of the deposits and weighs in
+// on the recorded election.
+func TestDelegatedStake(t *testing.T) {
+	deposits := testDeposits(4)
+	ele := &Elector{MaxSample: 1000, M: 11, P: 5, N: 21, Stakes: testStakes{deposits[1].NodeID: 40000 * 1000000}}
+
+	combined := ele.withDelegatedStake(8, deposits)
+	if deposits[1].Deposit.Int64() != 20000*1000000 {
+		t.Fatalf("request deposits modified: %v", deposits[1].Deposit)
+	}
+	if have := combined[1].Deposit.Int64(); have != 60000*1000000 {
+		t.Errorf("combined deposit mismatch: have %d, want %d", have, 60000*1000000)
+	}
+	if combined[0].Deposit != deposits[0].Deposit {
+		t.Errorf("deposit without delegations replaced")
+	}
+	plain := ele.elect(RoleMiner, 8, big.NewInt(0x12217), deposits)
+	delegated := ele.elect(RoleMiner, 8, big.NewInt(0x12217), combined)
+	if !(delegated.Weights[1].Value > plain.Weights[1].Value) {
+		t.Errorf("delegated stake did not raise the weight: have %v, plain %v", delegated.Weights[1].Value, plain.Weights[1].Value)
+	}
+	// Records keep the combined deposits, so replays hold the same election
+	replayed, err := Replay(delegated)
+	if err != nil {
+		t.Fatalf("failed to replay: %v", err)
+	}
+	if diffs := replayed.Diff(delegated); len(diffs) != 0 {
+		t.Errorf("replay differs: %v", diffs)
+	}
+}
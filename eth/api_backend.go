@@ -108,6 +108,13 @@ func (b *EthAPIBackend) GetReceipts(ctx context.Context, hash common.Hash) (type
 	return nil, nil
 }
 
+func (b *EthAPIBackend) GetTopology(ctx context.Context, hash common.Hash) (*types.Topology, error) {
+	if number := rawdb.ReadHeaderNumber(b.eth.chainDb, hash); number != nil {
+		return b.eth.blockchain.GetTopology(hash, *number), nil
+	}
+	return nil, nil
+}
+
 func (b *EthAPIBackend) GetLogs(ctx context.Context, hash common.Hash) ([][]*types.Log, error) {
 	number := rawdb.ReadHeaderNumber(b.eth.chainDb, hash)
 	if number == nil {
@@ -176,8 +183,8 @@ func (b *EthAPIBackend) GetPoolTransaction(hash common.Hash) *types.Transaction
 	return b.eth.txPool.Get(hash)
 }
 
-func (b *EthAPIBackend) GetPoolNonce(ctx context.Context, addr common.Address) (uint64, error) {
-	return b.eth.txPool.State().GetNonce(addr), nil
+func (b *EthAPIBackend) GetPoolNonce(ctx context.Context, addr common.Address, lane uint64) (uint64, error) {
+	return b.eth.txPool.State().GetLaneNonce(addr, lane), nil
 }
 
 func (b *EthAPIBackend) Stats() (pending int, queued int) {
@@ -192,6 +199,10 @@ func (b *EthAPIBackend) SubscribeNewTxsEvent(ch chan<- core.NewTxsEvent) event.S
 	return b.eth.TxPool().SubscribeNewTxsEvent(ch)
 }
 
+func (b *EthAPIBackend) SubscribeTxStatusEvent(ch chan<- core.TxStatusEvent) event.Subscription {
+	return b.eth.TxPool().SubscribeTxStatusEvent(ch)
+}
+
 func (b *EthAPIBackend) Downloader() *downloader.Downloader {
 	return b.eth.Downloader()
 }
@@ -0,0 +1,517 @@
+package eth
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/eth/filters"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// maxPtcQueryRange is the maximum number of blocks a single ptc range query
+// is allowed to span.
+const maxPtcQueryRange = 10000
+
+var errRewardDisabled = errors.New("reward distribution not configured")
+
+// PublicPtcAPI provides an API to access the PTC hierarchy related information
+// of the full node: rewards, candidates, delegations, elections and roles.
+type PublicPtcAPI struct {
+	e *Ethereum
+}
+
+// NewPublicPtcAPI creates a new PTC protocol API for full nodes.
+func NewPublicPtcAPI(e *Ethereum) *PublicPtcAPI {
+	return &PublicPtcAPI{e}
+}
+
+// RPCReward is a single block reward credit as returned by ptc_getRewards.
+type RPCReward struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Node        common.Address `json:"node"`
+	Role        string         `json:"role"`
+	Amount      *hexutil.Big   `json:"amount"`
+}
+
+// resolveRange converts a block number range into absolute heights, capping
+// it to the current head and to the maximum query span.
+func (api *PublicPtcAPI) resolveRange(fromBlock, toBlock rpc.BlockNumber) (uint64, uint64, error) {
+	head := api.e.blockchain.CurrentBlock().NumberU64()
+
+	resolve := func(number rpc.BlockNumber) uint64 {
+		if number < 0 || uint64(number) > head {
+			return head
+		}
+		return uint64(number)
+	}
+	from, to := resolve(fromBlock), resolve(toBlock)
+	if from > to {
+		return 0, 0, fmt.Errorf("invalid block range %d..%d", from, to)
+	}
+	if to-from >= maxPtcQueryRange {
+		return 0, 0, fmt.Errorf("block range %d..%d exceeds %d blocks", from, to, maxPtcQueryRange)
+	}
+	return from, to, nil
+}
+
+// GetRewards returns every reward credited to the given account in the blocks
+// of the requested range, either as a node of the topology or as a delegator.
+// The credits are recomputed from the canonical blocks and their receipts.
+func (api *PublicPtcAPI) GetRewards(address common.Address, fromBlock, toBlock rpc.BlockNumber) ([]*RPCReward, error) {
+	if api.e.reward == nil {
+		return nil, errRewardDisabled
+	}
+	from, to, err := api.resolveRange(fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	rewards := make([]*RPCReward, 0)
+	for number := from; number <= to; number++ {
+		block := api.e.blockchain.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		receipts := rawdb.ReadReceipts(api.e.chainDb, block.Hash(), number)
+		entries, err := api.e.reward.Calculate(block.Header(), block.Transactions(), receipts)
+		if err != nil {
+			return nil, fmt.Errorf("block #%d: %v", number, err)
+		}
+		for _, entry := range entries {
+			if entry.Account != address {
+				continue
+			}
+			rewards = append(rewards, &RPCReward{
+				BlockNumber: hexutil.Uint64(number),
+				BlockHash:   block.Hash(),
+				Node:        entry.Node,
+				Role:        string(entry.Role),
+				Amount:      (*hexutil.Big)(entry.Amount),
+			})
+		}
+	}
+	return rewards, nil
+}
+
+// electTypeNames maps the election transaction types to their RPC names.
+var electTypeNames = map[uint32]string{
+	types.ElectMiner:     "miner",
+	types.ElectCommittee: "committee",
+	types.ElectBoth:      "both",
+	types.ElectExit:      "exit",
+}
+
+// RPCCandidate is a single election candidate as returned by ptc_getCandidates.
+type RPCCandidate struct {
+	ID         string         `json:"id"`
+	IP         string         `json:"ip"`
+	Account    common.Address `json:"account"`
+	Type       string         `json:"type"`
+	TPS        hexutil.Uint64 `json:"tps"`
+	Wealth     hexutil.Uint64 `json:"wealth"`
+	OnlineTime hexutil.Uint64 `json:"onlineTime"`
+	Deposit    *hexutil.Big   `json:"deposit"` // Deposit made by the election transaction, in wei
+}
+
+// RPCCandidates is the candidate set of a period as returned by ptc_getCandidates.
+type RPCCandidates struct {
+	Period      hexutil.Uint64  `json:"period"`
+	BlockNumber hexutil.Uint64  `json:"blockNumber"` // Block the set is taken at, the head for an open period
+	BlockHash   common.Hash     `json:"blockHash"`
+	Closed      bool            `json:"closed"`
+	Candidates  []*RPCCandidate `json:"candidates"`
+}
+
+// GetCandidates returns the election candidate set of the given period, as fed
+// into the main node list of its broadcast block. For the period in progress
+// the set as of the current head is returned.
+func (api *PublicPtcAPI) GetCandidates(period hexutil.Uint64) (*RPCCandidates, error) {
+	index := api.e.blockchain.Candidates()
+	if period == 0 {
+		return nil, errors.New("candidate periods start at 1")
+	}
+	head := api.e.blockchain.CurrentBlock()
+	if uint64(period) > index.Period(head.NumberU64()) {
+		return nil, fmt.Errorf("period %d not started yet", period)
+	}
+	block, closed := head, false
+	if end := index.PeriodEnd(uint64(period)); end <= head.NumberU64() {
+		if block = api.e.blockchain.GetBlockByNumber(end); block == nil {
+			return nil, fmt.Errorf("block #%d not found", end)
+		}
+		closed = true
+	}
+	nodes, err := index.Candidates(block.Hash(), block.NumberU64())
+	if err != nil {
+		return nil, err
+	}
+	result := &RPCCandidates{
+		Period:      period,
+		BlockNumber: hexutil.Uint64(block.NumberU64()),
+		BlockHash:   block.Hash(),
+		Closed:      closed,
+		Candidates:  make([]*RPCCandidate, 0, len(nodes)),
+	}
+	for _, node := range nodes {
+		result.Candidates = append(result.Candidates, &RPCCandidate{
+			ID:         node.ID,
+			IP:         node.IP,
+			Account:    node.Account,
+			Type:       electTypeNames[node.ElectType],
+			TPS:        hexutil.Uint64(node.TPS),
+			Wealth:     hexutil.Uint64(node.Wealth),
+			OnlineTime: hexutil.Uint64(node.OnlineTime),
+			Deposit:    (*hexutil.Big)(new(big.Int).Mul(new(big.Int).SetUint64(node.Value), big.NewInt(params.Shannon))),
+		})
+	}
+	return result, nil
+}
+
+// RPCLeader is the verifier committee leader of a height as returned by
+// ptc_getLeader and ptc_getLeaderSchedule.
+type RPCLeader struct {
+	BlockNumber    hexutil.Uint64 `json:"blockNumber"`
+	ID             string         `json:"id"`
+	IP             string         `json:"ip"`
+	Account        common.Address `json:"account"`
+	CommitteeBlock hexutil.Uint64 `json:"committeeBlock"` // Broadcast block listing the committee and seeding its rotation
+}
+
+func newRPCLeader(number uint64, leader election.NodeInfo) *RPCLeader {
+	return &RPCLeader{
+		BlockNumber:    hexutil.Uint64(number),
+		ID:             leader.ID,
+		IP:             leader.IP,
+		Account:        leader.Account,
+		CommitteeBlock: hexutil.Uint64(core.CommitteeBlock(number)),
+	}
+}
+
+// GetLeader returns the verifier committee leader scheduled for the given
+// height. Heights are known as soon as the broadcast block listing their
+// committee is, so the leaders of upcoming blocks can be queried as well.
+func (api *PublicPtcAPI) GetLeader(height hexutil.Uint64) (*RPCLeader, error) {
+	leader, err := api.e.blockchain.Leader(uint64(height))
+	if err != nil {
+		return nil, err
+	}
+	return newRPCLeader(uint64(height), leader), nil
+}
+
+// GetLeaderSchedule returns the verifier committee leaders scheduled for count
+// heights starting at fromHeight. The schedule ends at the first height whose
+// committee is not known yet.
+func (api *PublicPtcAPI) GetLeaderSchedule(fromHeight, count hexutil.Uint64) ([]*RPCLeader, error) {
+	if count > maxPtcQueryRange {
+		return nil, fmt.Errorf("schedule of %d heights exceeds %d", count, maxPtcQueryRange)
+	}
+	leaders, err := api.e.blockchain.LeaderSchedule(uint64(fromHeight), uint64(count))
+	if err != nil {
+		return nil, err
+	}
+	schedule := make([]*RPCLeader, len(leaders))
+	for i, leader := range leaders {
+		schedule[i] = newRPCLeader(uint64(fromHeight)+uint64(i), leader)
+	}
+	return schedule, nil
+}
+
+// RPCElectionRecord is the record of the elections held for a candidate period
+// as returned by ptc_getElectionRecord. Elections held again within the period
+// follow the earlier ones.
+type RPCElectionRecord struct {
+	Period    hexutil.Uint64     `json:"period"`
+	Miner     []*election.Record `json:"miner"`
+	Validator []*election.Record `json:"validator"`
+}
+
+// GetElectionRecord returns the inputs, engine version and outcomes of the miner
+// and validator elections the local elector held for the given candidate period.
+func (api *PublicPtcAPI) GetElectionRecord(period hexutil.Uint64) (*RPCElectionRecord, error) {
+	miner, err := election.DecodeRecords(rawdb.ReadElectionRecords(api.e.chainDb, uint64(period), election.RoleMiner))
+	if err != nil {
+		return nil, err
+	}
+	validator, err := election.DecodeRecords(rawdb.ReadElectionRecords(api.e.chainDb, uint64(period), election.RoleValidator))
+	if err != nil {
+		return nil, err
+	}
+	if len(miner) == 0 && len(validator) == 0 {
+		return nil, fmt.Errorf("no election recorded for period %d", period)
+	}
+	return &RPCElectionRecord{Period: period, Miner: miner, Validator: validator}, nil
+}
+
+// RPCBond is stake bonded to a candidate node.
+type RPCBond struct {
+	Delegator common.Address `json:"delegator"`
+	NodeID    string         `json:"nodeId"`
+	Amount    *hexutil.Big   `json:"amount"`
+}
+
+// RPCUnbonding is stake unbonding from a candidate node, paid back by the
+// broadcast block at Release.
+type RPCUnbonding struct {
+	Delegator common.Address `json:"delegator"`
+	NodeID    string         `json:"nodeId"`
+	Amount    *hexutil.Big   `json:"amount"`
+	Release   hexutil.Uint64 `json:"release"`
+}
+
+// RPCDelegations is the stake of a delegator as returned by ptc_getDelegations.
+type RPCDelegations struct {
+	BlockNumber hexutil.Uint64  `json:"blockNumber"`
+	BlockHash   common.Hash     `json:"blockHash"`
+	Bonds       []*RPCBond      `json:"bonds"`
+	Unbondings  []*RPCUnbonding `json:"unbondings"`
+}
+
+// RPCNodeDelegations is the stake delegated to a node as returned by
+// ptc_getNodeDelegations.
+type RPCNodeDelegations struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	NodeID      string         `json:"nodeId"`
+	Total       *hexutil.Big   `json:"total"`
+	Bonds       []*RPCBond     `json:"bonds"`
+}
+
+// delegations returns the delegated stake as of the given canonical block,
+// along with the block itself.
+func (api *PublicPtcAPI) delegations(blockNr rpc.BlockNumber) (*types.Block, *types.Delegations, error) {
+	block := api.e.blockchain.CurrentBlock()
+	if blockNr >= 0 && uint64(blockNr) < block.NumberU64() {
+		if block = api.e.blockchain.GetBlockByNumber(uint64(blockNr)); block == nil {
+			return nil, nil, fmt.Errorf("block #%d not found", blockNr)
+		}
+	}
+	delegations, err := api.e.blockchain.Delegations().Delegations(block.Hash(), block.NumberU64())
+	if err != nil {
+		return nil, nil, err
+	}
+	return block, delegations, nil
+}
+
+// GetDelegations returns the stake the given account has bonded to candidate
+// nodes and the stake it has unbonding, as of the requested block.
+func (api *PublicPtcAPI) GetDelegations(address common.Address, blockNr rpc.BlockNumber) (*RPCDelegations, error) {
+	block, delegations, err := api.delegations(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	result := &RPCDelegations{
+		BlockNumber: hexutil.Uint64(block.NumberU64()),
+		BlockHash:   block.Hash(),
+		Bonds:       make([]*RPCBond, 0),
+		Unbondings:  make([]*RPCUnbonding, 0),
+	}
+	for _, bond := range delegations.Bonds {
+		if bond.Delegator == address {
+			result.Bonds = append(result.Bonds, &RPCBond{Delegator: bond.Delegator, NodeID: bond.NodeID, Amount: (*hexutil.Big)(bond.Amount)})
+		}
+	}
+	for _, unbonding := range delegations.Unbondings {
+		if unbonding.Delegator == address {
+			result.Unbondings = append(result.Unbondings, &RPCUnbonding{
+				Delegator: unbonding.Delegator,
+				NodeID:    unbonding.NodeID,
+				Amount:    (*hexutil.Big)(unbonding.Amount),
+				Release:   hexutil.Uint64(unbonding.Release),
+			})
+		}
+	}
+	return result, nil
+}
+
+// GetNodeDelegations returns the total stake delegated to the given candidate
+// node and its delegators, as of the requested block.
+func (api *PublicPtcAPI) GetNodeDelegations(nodeID string, blockNr rpc.BlockNumber) (*RPCNodeDelegations, error) {
+	id, err := discover.HexID(nodeID)
+	if err != nil {
+		return nil, err
+	}
+	block, delegations, err := api.delegations(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	node := hex.EncodeToString(id[:])
+
+	total := new(big.Int)
+	result := &RPCNodeDelegations{
+		BlockNumber: hexutil.Uint64(block.NumberU64()),
+		BlockHash:   block.Hash(),
+		NodeID:      node,
+		Total:       (*hexutil.Big)(total),
+		Bonds:       make([]*RPCBond, 0),
+	}
+	for _, bond := range delegations.Bonds {
+		if bond.NodeID == node {
+			total.Add(total, bond.Amount)
+			result.Bonds = append(result.Bonds, &RPCBond{Delegator: bond.Delegator, NodeID: bond.NodeID, Amount: (*hexutil.Big)(bond.Amount)})
+		}
+	}
+	return result, nil
+}
+
+// RPCTransfer is a transfer of an extended transaction as returned by
+// ptc_getTransfersByAddress.
+type RPCTransfer struct {
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint   `json:"transactionIndex"`
+	LogIndex         hexutil.Uint   `json:"logIndex"`
+	From             common.Address `json:"from"`
+	To               common.Address `json:"to"`
+	Value            *hexutil.Big   `json:"value"`
+}
+
+// GetTransfersByAddress returns the successful transfers of extended
+// transactions the given account sent or received in the blocks of the
+// requested range. The synthetic transfer logs are looked up through the bloom
+// bits index, once by sender and once by recipient.
+func (api *PublicPtcAPI) GetTransfersByAddress(ctx context.Context, address common.Address, fromBlock, toBlock rpc.BlockNumber) ([]*RPCTransfer, error) {
+	from, to, err := api.resolveRange(fromBlock, toBlock)
+	if err != nil {
+		return nil, err
+	}
+	var logs []*types.Log
+	for _, topics := range [][][]common.Hash{
+		{{types.TransferLogTopic}, {address.Hash()}},
+		{{types.TransferLogTopic}, nil, {address.Hash()}},
+	} {
+		filter := filters.New(api.e.APIBackend, int64(from), int64(to), []common.Address{types.TransferLogAddress}, topics)
+		found, err := filter.Logs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, found...)
+	}
+	// Merge both lookups in chain order, dropping transfers to self found twice
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].BlockNumber != logs[j].BlockNumber {
+			return logs[i].BlockNumber < logs[j].BlockNumber
+		}
+		return logs[i].Index < logs[j].Index
+	})
+	transfers := make([]*RPCTransfer, 0, len(logs))
+	for i, log := range logs {
+		if i > 0 && log.BlockHash == logs[i-1].BlockHash && log.Index == logs[i-1].Index {
+			continue
+		}
+		sender, recipient, value, ok := types.ParseTransferLog(log)
+		if !ok {
+			continue
+		}
+		transfers = append(transfers, &RPCTransfer{
+			BlockNumber:      hexutil.Uint64(log.BlockNumber),
+			BlockHash:        log.BlockHash,
+			TransactionHash:  log.TxHash,
+			TransactionIndex: hexutil.Uint(log.TxIndex),
+			LogIndex:         hexutil.Uint(log.Index),
+			From:             sender,
+			To:               recipient,
+			Value:            (*hexutil.Big)(value),
+		})
+	}
+	return transfers, nil
+}
+
+// RPCTxRejection is the tally of the committee rejections of a transaction as
+// returned by ptc_getTxRejection and the txRejections subscription.
+type RPCTxRejection struct {
+	TransactionHash common.Hash           `json:"transactionHash"`
+	BlockNumber     hexutil.Uint64        `json:"blockNumber"`
+	Rejected        bool                  `json:"rejected"`
+	Stake           *hexutil.Big          `json:"stake"`
+	TotalStake      *hexutil.Big          `json:"totalStake"`
+	Rejections      []*RPCTxRejectionVote `json:"rejections"`
+}
+
+// RPCTxRejectionVote is the signed rejection of a transaction by a committee
+// member.
+type RPCTxRejectionVote struct {
+	NodeID      string         `json:"nodeId"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	Reason      string         `json:"reason"`
+	Signature   hexutil.Bytes  `json:"signature"`
+}
+
+func newRPCTxRejection(status *core.TxRejectionStatus) *RPCTxRejection {
+	result := &RPCTxRejection{
+		TransactionHash: status.TxHash,
+		BlockNumber:     hexutil.Uint64(status.Number),
+		Rejected:        status.Rejected,
+		Stake:           (*hexutil.Big)(status.Stake),
+		TotalStake:      (*hexutil.Big)(status.Total),
+		Rejections:      make([]*RPCTxRejectionVote, 0, len(status.Rejections)),
+	}
+	for _, rejection := range status.Rejections {
+		result.Rejections = append(result.Rejections, &RPCTxRejectionVote{
+			NodeID:      rejection.NodeID,
+			BlockNumber: hexutil.Uint64(rejection.Number),
+			Reason:      rejection.Reason,
+			Signature:   rejection.Signature,
+		})
+	}
+	return result
+}
+
+// GetTxRejection returns the stake of the committee members that rejected the
+// given transaction, or nil if none of them did.
+func (api *PublicPtcAPI) GetTxRejection(hash common.Hash) *RPCTxRejection {
+	status := api.e.txPool.TxRejection(hash)
+	if status == nil {
+		return nil
+	}
+	return newRPCTxRejection(status)
+}
+
+// GetTransactionStatus returns the stages the given transaction reached, from
+// its admission into the pool to its inclusion or eviction, or nil if it is
+// not tracked.
+func (api *PublicPtcAPI) GetTransactionStatus(hash common.Hash) *core.TxLifecycle {
+	return api.e.txPool.Tracker().Lifecycle(hash)
+}
+
+// TxRejections creates a subscription that is notified of every transaction the
+// committee rejects.
+func (api *PublicPtcAPI) TxRejections(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		rejections := make(chan core.TxRejectedEvent, 128)
+		rejectionsSub := api.e.txPool.SubscribeTxRejectedEvent(rejections)
+		defer rejectionsSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-rejections:
+				notifier.Notify(rpcSub.ID, newRPCTxRejection(ev.Status))
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			case <-rejectionsSub.Err():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
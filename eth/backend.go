@@ -31,11 +31,14 @@ import (
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/consensus/clique"
 	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/consensus/ptcpos"
+	"github.com/ethereum/go-ethereum/consensus/reward"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/bloombits"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/election"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/eth/filters"
 	"github.com/ethereum/go-ethereum/eth/gasprice"
@@ -89,6 +92,8 @@ type Ethereum struct {
 	APIBackend *EthAPIBackend
 
 	miner     *miner.Miner
+	reward    *reward.Reward
+	elector   *election.Elector
 	Scheduler *scheduler.Scheduler
 	Verifier  *verifier.Verifier
 	gasPrice  *big.Int
@@ -156,6 +161,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if err != nil {
 		return nil, err
 	}
+	if engine, ok := eth.engine.(*ptcpos.Ptcpos); ok {
+		engine.SetChain(eth.blockchain)
+	}
 	// Rewind the chain in case of an incompatible config upgrade.
 	if compat, ok := genesisErr.(*params.ConfigCompatError); ok {
 		log.Warn("Rewinding chain to upgrade configuration", "err", compat)
@@ -164,6 +172,27 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	}
 	eth.bloomIndexer.Start(eth.blockchain)
 
+	// Distribute the block rewards among the PTC hierarchy if configured, passing
+	// them through to the stake delegated to the rewarded nodes
+	delegations := &delegationReader{chain: eth.blockchain}
+	if chainConfig.Reward != nil {
+		topology := &topologyReader{chain: eth.blockchain}
+		if eth.reward, err = reward.New(chainConfig.Reward, topology, delegations); err != nil {
+			return nil, err
+		}
+		switch engine := eth.engine.(type) {
+		case *ethash.Ethash:
+			engine.SetReward(eth.reward)
+		case *ptcpos.Ptcpos:
+			engine.SetReward(eth.reward)
+		}
+	}
+	// Hold the PTC elections locally, weighing in delegated stake and recording
+	// each of them for audits
+	if chainConfig.Ptcpos != nil {
+		eth.elector = election.NewEle(&electionRecorder{db: chainDb, candidates: eth.blockchain.Candidates()}, delegations)
+	}
+
 	if config.TxPool.Journal != "" {
 		config.TxPool.Journal = ctx.ResolvePath(config.TxPool.Journal)
 	}
@@ -185,6 +214,12 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	eth.Verifier = verifier.New(eth.blockchain, eth.txPool)
 	eth.Scheduler = scheduler.New(eth.blockchain, eth.miner, eth.chainConfig, eth.Verifier)
 	eth.protocolManager.udpHandler.AddVerifier(eth.Verifier)
+
+	// Sealed blocks may only pack the transaction batches approved by the committee
+	eth.protocolManager.udpHandler.AddMiner(eth.miner)
+	if engine, ok := eth.engine.(*ptcpos.Ptcpos); ok {
+		engine.SetBatches(eth.miner.Batches())
+	}
 	return eth, nil
 }
 
@@ -223,6 +258,10 @@ func CreateConsensusEngine(ctx *node.ServiceContext, config *ethash.Config, chai
 	if chainConfig.Clique != nil {
 		return clique.New(chainConfig.Clique, db)
 	}
+	// If the PTC proof-of-stake is requested, set it up
+	if chainConfig.Ptcpos != nil {
+		return ptcpos.New(chainConfig.Ptcpos)
+	}
 	// Otherwise assume proof-of-work
 	switch config.PowMode {
 	case ethash.ModeFake:
@@ -301,6 +340,11 @@ func (s *Ethereum) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   s.netRPCService,
 			Public:    true,
+		}, {
+			Namespace: "ptc",
+			Version:   "1.0",
+			Service:   NewPublicPtcAPI(s),
+			Public:    true,
 		},
 	}...)
 }
@@ -355,6 +399,14 @@ func (s *Ethereum) StartMining(local bool) error {
 		}
 		clique.Authorize(eb, wallet.SignHash)
 	}
+	if ptcpos, ok := s.engine.(*ptcpos.Ptcpos); ok {
+		wallet, err := s.accountManager.Find(accounts.Account{Address: eb})
+		if wallet == nil || err != nil {
+			log.Error("Etherbase account unavailable locally", "err", err)
+			return fmt.Errorf("signer missing: %v", err)
+		}
+		ptcpos.Authorize(eb, wallet.SignHash)
+	}
 	if local {
 		// If local (CPU) mining is started, we can disable the transaction rejection
 		// mechanism introduced to speed sync times. CPU mining on mainnet is ludicrous
@@ -465,6 +517,11 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 		}
 		maxPeers -= s.config.LightPeers
 	}
+	// Sign the rejections of invalid flooded transactions and the votes on the
+	// transaction batches with the node key
+	s.txPool.SetRejectionKey(srvr.PrivateKey)
+	s.Verifier.SetNodeKey(srvr.PrivateKey)
+
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(maxPeers)
 	if s.lesServer != nil {
@@ -497,6 +554,17 @@ func (s *Ethereum) SetSyncNodeID(nodeid discover.NodeID) {
 	s.protocolManager.setSyncNodeIDCh <- nodeid
 }
 
+// PeerHeads returns the chain heads announced by the connected eth peers.
+func (s *Ethereum) PeerHeads() []PeerHead {
+	return s.protocolManager.peers.Heads()
+}
+
+// SkipBlkSync ends the start up block sync without syncing, used if no peer is
+// ahead of the local chain.
+func (s *Ethereum) SkipBlkSync() {
+	s.protocolManager.finishedBlkSync <- struct{}{}
+}
+
 // add by hyk, waiting for block sync completed
 func (s *Ethereum) WaitingBlkSyncCompleted() error {
 	// TODO add fail handle
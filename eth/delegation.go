@@ -0,0 +1,85 @@
+package eth
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/reward"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// delegationReader feeds the stake delegated to candidate nodes into the reward
+// distribution and the elections.
+type delegationReader struct {
+	chain *core.BlockChain
+}
+
+// header returns the canonical header at the given height, or the head if the
+// chain did not reach it yet.
+func (r *delegationReader) header(number uint64) *types.Header {
+	if header := r.chain.GetHeaderByNumber(number); header != nil {
+		return header
+	}
+	return r.chain.CurrentHeader()
+}
+
+// Delegations implements reward.DelegationReader. The node's own stake is the
+// deposit of the candidate it registered, both are taken as of the parent of
+// the rewarded block, so side chains are rewarded along their own history.
+func (r *delegationReader) Delegations(header *types.Header, node common.Address) (*big.Int, []reward.Delegation, error) {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return nil, nil, nil
+	}
+	candidates, err := r.chain.Candidates().Candidates(header.ParentHash, number-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	var candidate *types.ElectionTxPayLoadInfo
+	for _, info := range candidates {
+		if info.Account == node {
+			candidate = info
+			break
+		}
+	}
+	if candidate == nil {
+		return nil, nil, nil
+	}
+	delegations, err := r.chain.Delegations().Delegations(header.ParentHash, number-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	var stakes []reward.Delegation
+	for _, bond := range delegations.Bonds {
+		if bond.NodeID == candidate.ID {
+			stakes = append(stakes, reward.Delegation{Delegator: bond.Delegator, Amount: bond.Amount})
+		}
+	}
+	own := new(big.Int).Mul(new(big.Int).SetUint64(candidate.Value), big.NewInt(params.Shannon))
+	return own, stakes, nil
+}
+
+// DelegatedStake implements election.StakeReader, reporting the stake in
+// Shannon like the deposits of the candidate set.
+func (r *delegationReader) DelegatedStake(id discover.NodeID, number uint64) *big.Int {
+	header := r.header(number)
+	delegations, err := r.chain.Delegations().Delegations(header.Hash(), header.Number.Uint64())
+	if err != nil {
+		log.Warn("Failed to retrieve delegations for election", "number", number, "err", err)
+		return nil
+	}
+	node := hex.EncodeToString(id[:])
+
+	stake := new(big.Int)
+	for _, bond := range delegations.Bonds {
+		if bond.NodeID == node {
+			stake.Add(stake, bond.Amount)
+		}
+	}
+	return stake.Div(stake, big.NewInt(params.Shannon))
+}
@@ -0,0 +1,37 @@
+package eth
+
+import (
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// electionRecorder stores the elections held by the local elector in the chain
+// database, keyed by the candidate period the election was requested in. The
+// elections held again within a period are appended to the earlier ones.
+type electionRecorder struct {
+	db         ethdb.Database
+	candidates *core.CandidateIndex
+	lock       sync.Mutex // Serializes appending to the records of a period
+}
+
+// WriteElectionRecord implements election.RecordWriter.
+func (r *electionRecorder) WriteElectionRecord(rec *election.Record) {
+	rec.Period = r.candidates.Period(rec.Number)
+	data, err := election.EncodeRecord(rec)
+	if err != nil {
+		log.Error("Failed to encode election record", "role", rec.Role, "period", rec.Period, "err", err)
+		return
+	}
+	r.lock.Lock()
+	records := append(rawdb.ReadElectionRecords(r.db, rec.Period, rec.Role), data)
+	rawdb.WriteElectionRecords(r.db, rec.Period, rec.Role, records)
+	r.lock.Unlock()
+
+	log.Info("Recorded election", "role", rec.Role, "period", rec.Period, "number", rec.Number, "held", len(records),
+		"candidates", len(rec.Deposits), "principal", len(rec.Principal), "backup", len(rec.Backup))
+}
@@ -168,6 +168,46 @@ func (api *PublicFilterAPI) NewPendingTransactions(ctx context.Context) (*rpc.Su
 	return rpcSub, nil
 }
 
+// TxStatus creates a subscription that is triggered each time a transaction
+// reaches a stage of its lifecycle: pool admission, flood, committee vote,
+// handoff to the miners, inclusion or eviction. If hashes are given, only the
+// stages of those transactions are reported.
+func (api *PublicFilterAPI) TxStatus(ctx context.Context, hashes *[]common.Hash) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	var watched map[common.Hash]bool
+	if hashes != nil && len(*hashes) > 0 {
+		watched = make(map[common.Hash]bool, len(*hashes))
+		for _, hash := range *hashes {
+			watched[hash] = true
+		}
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		statuses := make(chan core.TxStatusEvent, txChanSize)
+		statusSub := api.backend.SubscribeTxStatusEvent(statuses)
+		defer statusSub.Unsubscribe()
+
+		for {
+			select {
+			case ev := <-statuses:
+				if watched == nil || watched[ev.Hash] {
+					notifier.Notify(rpcSub.ID, ev)
+				}
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // NewBlockFilter creates a filter that fetches blocks that are imported into the chain.
 // It is part of the filter package since polling goes with eth_getFilterChanges.
 //
@@ -73,6 +73,7 @@ type ProtocolManager struct {
 	acceptTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
 	txpool      txPool
+	txRelay     *txRelay
 	blockchain  *core.BlockChain
 	chainconfig *params.ChainConfig
 	maxPeers    int
@@ -115,6 +116,7 @@ func NewProtocolManager(config *params.ChainConfig, mode downloader.SyncMode, ne
 		networkId:        networkId,
 		eventMux:         mux,
 		txpool:           txpool,
+		txRelay:          newTxRelay(txpool),
 		blockchain:       blockchain,
 		chainconfig:      config,
 		peers:            newPeerSet(),
@@ -710,8 +712,68 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			}
 			p.MarkTransaction(tx.Hash())
 		}
+		pm.txRelay.track(txs)
 		pm.txpool.AddRemotes(txs)
 
+	case p.version >= eth64 && msg.Code == TxAnnounceMsg:
+		// Transactions announced, fetch the unknown ones if we're accepting any
+		if atomic.LoadUint32(&pm.acceptTxs) == 0 {
+			break
+		}
+		var ids []uint64
+		if err := msg.Decode(&ids); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if len(ids) > maxTxAnnounce {
+			return errResp(ErrDecode, "too many announced transactions: %d > %d", len(ids), maxTxAnnounce)
+		}
+		p.MarkTransactionIDs(ids)
+		if missing := pm.txRelay.missing(p.id, ids); len(missing) > 0 {
+			return p.RequestTxs(missing)
+		}
+
+	case p.version >= eth64 && msg.Code == GetTxsMsg:
+		// Announced transactions requested, serve the ones still pooled
+		var ids []uint64
+		if err := msg.Decode(&ids); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p.SendTxs(pm.txRelay.lookup(ids))
+
+	case p.version >= eth64 && msg.Code == TxsMsg:
+		// Requested transactions arrived, deliver them to the pool
+		var txs []*types.Transaction
+		if err := msg.Decode(&txs); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		for i, tx := range txs {
+			if tx == nil {
+				return errResp(ErrDecode, "transaction %d is nil", i)
+			}
+			p.MarkTransaction(tx.Hash())
+		}
+		pm.txRelay.track(txs)
+		pm.txpool.AddRemotes(txs)
+
+	case p.version >= eth64 && msg.Code == GetNodeListsMsg:
+		// Main node lists requested, serve the ones in charge after the head
+		var head common.Hash
+		if err := msg.Decode(&head); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p.SendNodeLists(head, pm.nodeListsAfter(head))
+
+	case p.version >= eth64 && msg.Code == NodeListsMsg:
+		// Requested main node lists arrived, hand them to the waiting request
+		var data nodeListsData
+		if err := msg.Decode(&data); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		select {
+		case p.nodeLists <- &data:
+		default:
+		}
+
 	default:
 		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
 	}
@@ -756,6 +818,9 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 func (pm *ProtocolManager) BroadcastTxs(txs types.Transactions) {
 	var txset = make(map[*peer]types.Transactions)
 
+	// Make the transactions resolvable for the peers they get announced to
+	pm.txRelay.track(txs)
+
 	// Broadcast transactions to a batch of peers not knowing about it
 	for _, tx := range txs {
 		peers := pm.peers.PeersWithoutTx(tx.Hash())
@@ -799,11 +864,17 @@ type UDPHandler struct {
 	txpool          *txPool
 	broadcastInfoCh chan *miner.BroadcastInfo
 	verifier        *verifier.Verifier
+	miner           *miner.Miner
 }
 
 func (pm *UDPHandler) AddVerifier(v *verifier.Verifier) {
 	pm.verifier = v
 }
+
+// AddMiner sets the miner the approved transaction batches are queued at.
+func (pm *UDPHandler) AddMiner(m *miner.Miner) {
+	pm.miner = m
+}
 func (pm *UDPHandler) AddUDPMsg(b interface{}, data []byte) error {
 	//unmarshal the msg data
 	var verifierMsg verifier.MsgToMiner
@@ -826,6 +897,18 @@ func (pm *UDPHandler) AddUDPMsg(b interface{}, data []byte) error {
 			return errResp(ErrVerify, "msg blockNumber[%d] type[%d] message from verify is fake", verifierMsg.BlockNum, verifierMsg.MsgType)
 		}
 
+		// Queue the approved batch with its votes for the block at its height
+		batch := &types.VerifiedBatch{Number: verifierMsg.BlockNum, Txs: make(types.Transactions, len(txData.Txs))}
+		for i := range txData.Txs {
+			batch.Txs[i] = &txData.Txs[i]
+		}
+		batch.Votes = append(batch.Votes, txData.Result...)
+		if pm.miner != nil {
+			if err := pm.miner.AddVerifiedBatch(batch); err != nil {
+				log.Warn("p2p verifier msg: approved batch dropped", "blockNumber", verifierMsg.BlockNum, "err", err)
+			}
+		}
+
 		if len(txData.Txs) == 0 {
 			log.Info("p2p verifier msg: tx list is empty, no need to input to tx pool")
 			return nil
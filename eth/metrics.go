@@ -26,6 +26,10 @@ var (
 	propTxnInTrafficMeter     = metrics.NewRegisteredMeter("eth/prop/txns/in/traffic", nil)
 	propTxnOutPacketsMeter    = metrics.NewRegisteredMeter("eth/prop/txns/out/packets", nil)
 	propTxnOutTrafficMeter    = metrics.NewRegisteredMeter("eth/prop/txns/out/traffic", nil)
+	propTxIDInPacketsMeter    = metrics.NewRegisteredMeter("eth/prop/txids/in/packets", nil)
+	propTxIDInTrafficMeter    = metrics.NewRegisteredMeter("eth/prop/txids/in/traffic", nil)
+	propTxIDOutPacketsMeter   = metrics.NewRegisteredMeter("eth/prop/txids/out/packets", nil)
+	propTxIDOutTrafficMeter   = metrics.NewRegisteredMeter("eth/prop/txids/out/traffic", nil)
 	propHashInPacketsMeter    = metrics.NewRegisteredMeter("eth/prop/hashes/in/packets", nil)
 	propHashInTrafficMeter    = metrics.NewRegisteredMeter("eth/prop/hashes/in/traffic", nil)
 	propHashOutPacketsMeter   = metrics.NewRegisteredMeter("eth/prop/hashes/out/packets", nil)
@@ -42,6 +46,10 @@ var (
 	reqBodyInTrafficMeter     = metrics.NewRegisteredMeter("eth/req/bodies/in/traffic", nil)
 	reqBodyOutPacketsMeter    = metrics.NewRegisteredMeter("eth/req/bodies/out/packets", nil)
 	reqBodyOutTrafficMeter    = metrics.NewRegisteredMeter("eth/req/bodies/out/traffic", nil)
+	reqTxnInPacketsMeter      = metrics.NewRegisteredMeter("eth/req/txns/in/packets", nil)
+	reqTxnInTrafficMeter      = metrics.NewRegisteredMeter("eth/req/txns/in/traffic", nil)
+	reqTxnOutPacketsMeter     = metrics.NewRegisteredMeter("eth/req/txns/out/packets", nil)
+	reqTxnOutTrafficMeter     = metrics.NewRegisteredMeter("eth/req/txns/out/traffic", nil)
 	reqStateInPacketsMeter    = metrics.NewRegisteredMeter("eth/req/states/in/packets", nil)
 	reqStateInTrafficMeter    = metrics.NewRegisteredMeter("eth/req/states/in/traffic", nil)
 	reqStateOutPacketsMeter   = metrics.NewRegisteredMeter("eth/req/states/out/packets", nil)
@@ -96,6 +104,10 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 		packets, traffic = reqStateInPacketsMeter, reqStateInTrafficMeter
 	case rw.version >= eth63 && msg.Code == ReceiptsMsg:
 		packets, traffic = reqReceiptInPacketsMeter, reqReceiptInTrafficMeter
+	case rw.version >= eth64 && msg.Code == TxsMsg:
+		packets, traffic = reqTxnInPacketsMeter, reqTxnInTrafficMeter
+	case rw.version >= eth64 && msg.Code == TxAnnounceMsg:
+		packets, traffic = propTxIDInPacketsMeter, propTxIDInTrafficMeter
 
 	case msg.Code == NewBlockHashesMsg:
 		packets, traffic = propHashInPacketsMeter, propHashInTrafficMeter
@@ -123,6 +135,10 @@ func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 		packets, traffic = reqStateOutPacketsMeter, reqStateOutTrafficMeter
 	case rw.version >= eth63 && msg.Code == ReceiptsMsg:
 		packets, traffic = reqReceiptOutPacketsMeter, reqReceiptOutTrafficMeter
+	case rw.version >= eth64 && msg.Code == TxsMsg:
+		packets, traffic = reqTxnOutPacketsMeter, reqTxnOutTrafficMeter
+	case rw.version >= eth64 && msg.Code == TxAnnounceMsg:
+		packets, traffic = propTxIDOutPacketsMeter, propTxIDOutTrafficMeter
 
 	case msg.Code == NewBlockHashesMsg:
 		packets, traffic = propHashOutPacketsMeter, propHashOutTrafficMeter
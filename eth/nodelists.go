@@ -0,0 +1,66 @@
+package eth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+var (
+	errUnknownPeer      = errors.New("peer not connected")
+	errNodeListsTimeout = errors.New("main node lists request timed out")
+)
+
+// nodeListsAfter returns the main node lists in charge of the block after the
+// given head, or empty lists if the head is not known locally.
+func (pm *ProtocolManager) nodeListsAfter(head common.Hash) *types.Topology {
+	header := pm.blockchain.GetHeaderByHash(head)
+	if header == nil {
+		return new(types.Topology)
+	}
+	lists, _, err := pm.blockchain.NodeListsAt(header, header.Number.Uint64()+1)
+	if err != nil {
+		return new(types.Topology)
+	}
+	return &types.Topology{
+		MinerList:     lists.MinerList,
+		CommitteeList: lists.CommitteeList,
+		Both:          lists.Both,
+		OfflineList:   lists.OfflineList,
+	}
+}
+
+// RequestNodeLists asks a connected peer for the main node lists in charge of
+// the block after the head it announced, waiting at most timeout for them. The
+// lists are not verified, callers have to cross check the answers of several
+// peers.
+func (s *Ethereum) RequestNodeLists(id discover.NodeID, timeout time.Duration) (common.Hash, *types.Topology, error) {
+	p := s.protocolManager.peers.Peer(fmt.Sprintf("%x", id[:8]))
+	if p == nil {
+		return common.Hash{}, nil, errUnknownPeer
+	}
+	head, _ := p.Head()
+	if err := p.RequestNodeLists(head); err != nil {
+		return common.Hash{}, nil, err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case data := <-p.nodeLists:
+			if data.Head != head || data.Topology == nil {
+				continue
+			}
+			return head, data.Topology, nil
+		case <-timer.C:
+			return common.Hash{}, nil, errNodeListsTimeout
+		case <-p.term:
+			return common.Hash{}, nil, errUnknownPeer
+		}
+	}
+}
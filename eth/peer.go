@@ -20,12 +20,14 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"net"
 	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/rlp"
 	"gopkg.in/fatih/set.v0"
 )
@@ -66,6 +68,15 @@ type PeerInfo struct {
 	Head       string   `json:"head"`       // SHA3 hash of the peer's best owned block
 }
 
+// PeerHead is the chain head a connected peer announced in its eth handshake,
+// tracked as the peer propagates new blocks.
+type PeerHead struct {
+	ID   discover.NodeID
+	IP   net.IP
+	Head common.Hash
+	TD   *big.Int
+}
+
 // propEvent is a block propagation, waiting for its turn in the broadcast queue.
 type propEvent struct {
 	block *types.Block
@@ -86,10 +97,12 @@ type peer struct {
 	lock sync.RWMutex
 
 	knownTxs    *set.Set                  // Set of transaction hashes known to be known by this peer
+	knownTxIDs  *set.Set                  // Set of transaction short IDs announced by this peer
 	knownBlocks *set.Set                  // Set of block hashes known to be known by this peer
 	queuedTxs   chan []*types.Transaction // Queue of transactions to broadcast to the peer
 	queuedProps chan *propEvent           // Queue of blocks to broadcast to the peer
 	queuedAnns  chan *types.Block         // Queue of blocks to announce to the peer
+	nodeLists   chan *nodeListsData       // Main node lists delivered by the peer
 	term        chan struct{}             // Termination channel to stop the broadcaster
 }
 
@@ -100,10 +113,12 @@ func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 		version:     version,
 		id:          fmt.Sprintf("%x", p.ID().Bytes()[:8]),
 		knownTxs:    set.New(),
+		knownTxIDs:  set.New(),
 		knownBlocks: set.New(),
 		queuedTxs:   make(chan []*types.Transaction, maxQueuedTxs),
 		queuedProps: make(chan *propEvent, maxQueuedProps),
 		queuedAnns:  make(chan *types.Block, maxQueuedAnns),
+		nodeLists:   make(chan *nodeListsData, 1),
 		term:        make(chan struct{}),
 	}
 }
@@ -115,6 +130,14 @@ func (p *peer) broadcast() {
 	for {
 		select {
 		case txs := <-p.queuedTxs:
+			// Peers speaking the compact relay fetch what they miss themselves
+			if p.version >= eth64 {
+				if err := p.SendTxAnnounce(txs); err != nil {
+					return
+				}
+				p.Log().Trace("Announced transactions", "count", len(txs))
+				break
+			}
 			if err := p.SendTransactions(txs); err != nil {
 				return
 			}
@@ -193,6 +216,61 @@ func (p *peer) MarkTransaction(hash common.Hash) {
 	p.knownTxs.Add(hash)
 }
 
+// MarkTransactionIDs marks a batch of transaction short IDs as announced by the
+// peer, ensuring that the transactions will never be announced back to it.
+func (p *peer) MarkTransactionIDs(ids []uint64) {
+	for _, id := range ids {
+		// If we reached the memory allowance, drop a previously known short ID
+		for p.knownTxIDs.Size() >= maxKnownTxs {
+			p.knownTxIDs.Pop()
+		}
+		p.knownTxIDs.Add(id)
+	}
+}
+
+// knowsTransaction reports whether the peer is known to have a transaction,
+// either by hash or by the short ID it announced.
+func (p *peer) knowsTransaction(hash common.Hash) bool {
+	return p.knownTxs.Has(hash) || p.knownTxIDs.Has(txShortID(hash))
+}
+
+// SendTxAnnounce announces a batch of transactions to the peer by short ID and
+// includes the hashes in its transaction hash set for future reference.
+func (p *peer) SendTxAnnounce(txs types.Transactions) error {
+	ids := make([]uint64, len(txs))
+	for i, tx := range txs {
+		p.knownTxs.Add(tx.Hash())
+		ids[i] = txShortID(tx.Hash())
+	}
+	return p2p.Send(p.rw, TxAnnounceMsg, ids)
+}
+
+// RequestTxs fetches a batch of announced transactions from the peer by short ID.
+func (p *peer) RequestTxs(ids []uint64) error {
+	p.Log().Debug("Fetching batch of transactions", "count", len(ids))
+	return p2p.Send(p.rw, GetTxsMsg, ids)
+}
+
+// SendTxs sends a batch of transactions requested by short ID to the peer.
+func (p *peer) SendTxs(txs types.Transactions) error {
+	for _, tx := range txs {
+		p.knownTxs.Add(tx.Hash())
+	}
+	return p2p.Send(p.rw, TxsMsg, txs)
+}
+
+// RequestNodeLists asks the peer for the main node lists in charge of the block
+// after the given head.
+func (p *peer) RequestNodeLists(head common.Hash) error {
+	p.Log().Debug("Fetching main node lists", "head", head)
+	return p2p.Send(p.rw, GetNodeListsMsg, head)
+}
+
+// SendNodeLists sends the main node lists in charge after a head to the peer.
+func (p *peer) SendNodeLists(head common.Hash, topology *types.Topology) error {
+	return p2p.Send(p.rw, NodeListsMsg, &nodeListsData{Head: head, Topology: topology})
+}
+
 // SendTransactions sends transactions to the peer and includes the hashes
 // in its transaction hash set for future reference.
 func (p *peer) SendTransactions(txs types.Transactions) error {
@@ -485,7 +563,7 @@ func (ps *peerSet) PeersWithoutTx(hash common.Hash) []*peer {
 
 	list := make([]*peer, 0, len(ps.peers))
 	for _, p := range ps.peers {
-		if !p.knownTxs.Has(hash) {
+		if !p.knowsTransaction(hash) {
 			list = append(list, p)
 		}
 	}
@@ -509,6 +587,24 @@ func (ps *peerSet) BestPeer() *peer {
 	return bestPeer
 }
 
+// Heads retrieves the chain heads of all the registered peers. Peers are only
+// registered after a successful handshake on the same network and genesis.
+func (ps *peerSet) Heads() []PeerHead {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	heads := make([]PeerHead, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		head := PeerHead{ID: p.ID()}
+		if addr, ok := p.RemoteAddr().(*net.TCPAddr); ok {
+			head.IP = addr.IP
+		}
+		head.Head, head.TD = p.Head()
+		heads = append(heads, head)
+	}
+	return heads
+}
+
 // Close disconnects all peers.
 // No new peers can be registered after Close has returned.
 func (ps *peerSet) Close() {
@@ -32,16 +32,17 @@ import (
 const (
 	eth62 = 62
 	eth63 = 63
+	eth64 = 64
 )
 
 // ProtocolName is the official short name of the protocol used during capability negotiation.
 var ProtocolName = "eth"
 
 // ProtocolVersions are the upported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{eth63, eth62}
+var ProtocolVersions = []uint{eth64, eth63, eth62}
 
 // ProtocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{17, 8}
+var ProtocolLengths = []uint64{22, 17, 8}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -62,8 +63,22 @@ const (
 	NodeDataMsg    = 0x0e
 	GetReceiptsMsg = 0x0f
 	ReceiptsMsg    = 0x10
+
+	// Protocol messages belonging to eth/64
+	TxAnnounceMsg   = 0x11 // Short IDs of transactions available from the sender
+	GetTxsMsg       = 0x12 // Request of announced transactions by short ID
+	TxsMsg          = 0x13 // Transactions delivered for a request
+	GetNodeListsMsg = 0x14 // Request of the main node lists in charge after a head
+	NodeListsMsg    = 0x15 // Main node lists delivered for a request
 )
 
+// nodeListsData is the network packet of the main node lists in charge of the
+// block after the given head.
+type nodeListsData struct {
+	Head     common.Hash     // Head the lists are in charge after
+	Topology *types.Topology // Lists in charge, empty if the head is unknown
+}
+
 type errCode int
 
 const (
@@ -104,6 +119,9 @@ type txPool interface {
 	// The slice should be modifiable by the caller.
 	Pending() (map[common.Address]types.Transactions, error)
 
+	// Get should return the pooled transaction with the given hash, if any.
+	Get(hash common.Hash) *types.Transaction
+
 	// SubscribeNewTxsEvent should return an event subscription of
 	// NewTxsEvent and send events to the given channel.
 	SubscribeNewTxsEvent(chan<- core.NewTxsEvent) event.Subscription
@@ -0,0 +1,85 @@
+package eth
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/consensus/reward"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/election"
+)
+
+// topologyReader derives the reward topology of a block from the main node lists
+// committed by the chain it extends, so every node pays the same rewards whether
+// it followed the elections live, restarted or synced.
+type topologyReader struct {
+	chain *core.BlockChain
+}
+
+// Topology implements reward.TopologyReader. The master miner and validator of a
+// block are the ones the rotations of the miners and committee in charge pick for
+// its height, the other nodes in charge back them up and the candidates are the
+// nodes registered as of the parent that are not in charge yet.
+func (r *topologyReader) Topology(header *types.Header) (*reward.Topology, error) {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return new(reward.Topology), nil
+	}
+	parent := r.chain.GetHeader(header.ParentHash, number-1)
+	if parent == nil {
+		return nil, consensus.ErrUnknownAncestor
+	}
+	miners, seed, err := r.chain.MasterMinersAt(parent, number)
+	if err != nil {
+		return nil, err
+	}
+	committee, _, err := r.chain.CommitteeAt(parent, number)
+	if err != nil {
+		return nil, err
+	}
+	topology := new(reward.Topology)
+	topology.MasterMiners, topology.Backups = scheduledNode(miners, seed, number)
+
+	var backups []election.NodeInfo
+	topology.MasterValidators, backups = scheduledNode(committee, seed, number)
+	topology.Backups = append(topology.Backups, backups...)
+
+	candidates, err := r.chain.Candidates().Candidates(parent.Hash(), number-1)
+	if err != nil {
+		return nil, err
+	}
+	inCharge := make(map[string]bool, len(miners)+len(committee))
+	for _, node := range miners {
+		inCharge[node.ID] = true
+	}
+	for _, node := range committee {
+		inCharge[node.ID] = true
+	}
+	for _, candidate := range candidates {
+		if candidate.ElectType == types.ElectExit || inCharge[candidate.ID] {
+			continue
+		}
+		topology.Candidates = append(topology.Candidates, election.NodeInfo{
+			ID:      candidate.ID,
+			Value:   candidate.Value,
+			Account: candidate.Account,
+		})
+	}
+	return topology, nil
+}
+
+// scheduledNode splits nodes in charge into the one their rotation schedules for
+// the height and the others.
+func scheduledNode(nodes []election.NodeInfo, seed common.Hash, number uint64) ([]election.NodeInfo, []election.NodeInfo) {
+	scheduled, err := core.LeaderOf(nodes, seed, number)
+	if err != nil {
+		return nil, nil
+	}
+	var others []election.NodeInfo
+	for _, node := range nodes {
+		if node.ID != scheduled.ID {
+			others = append(others, node)
+		}
+	}
+	return []election.NodeInfo{scheduled}, others
+}
@@ -0,0 +1,152 @@
+package eth
+
+import (
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	maxTxRelayIDs     = 65536             // Maximum short IDs to keep resolvable in the relay index
+	maxTxAnnounce     = 4096              // Maximum short IDs accepted in a single announcement
+	maxTxRequest      = 256               // Maximum transactions served for a single request
+	txRequestTimeout  = 5 * time.Second   // Time after which an unanswered request may go to another peer
+	txRequestSweepCap = maxTxRelayIDs / 4 // Number of in flight requests triggering a sweep of expired ones
+)
+
+// txShortID returns the short ID a transaction is announced by in the compact
+// relay, the leading 8 bytes of its hash. Unlike the S value of the signature,
+// the hash prefix cannot be malleated by a relaying node.
+func txShortID(hash common.Hash) uint64 {
+	return binary.BigEndian.Uint64(hash[:8])
+}
+
+// txIDSet is a bounded mapping of short IDs to transaction hashes, evicting the
+// oldest entries first.
+type txIDSet struct {
+	hashes map[uint64]common.Hash
+	order  []uint64
+	limit  int
+}
+
+func newTxIDSet(limit int) *txIDSet {
+	return &txIDSet{hashes: make(map[uint64]common.Hash), limit: limit}
+}
+
+// add inserts the hash under its short ID.
+func (s *txIDSet) add(hash common.Hash) {
+	id := txShortID(hash)
+	if _, ok := s.hashes[id]; ok {
+		return
+	}
+	for len(s.order) >= s.limit {
+		delete(s.hashes, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.hashes[id] = hash
+	s.order = append(s.order, id)
+}
+
+// get resolves a short ID into the transaction hash it stands for.
+func (s *txIDSet) get(id uint64) (common.Hash, bool) {
+	hash, ok := s.hashes[id]
+	return hash, ok
+}
+
+// txRequest is a short ID requested from a peer and not delivered yet.
+type txRequest struct {
+	peer     string
+	deadline time.Time
+}
+
+// txRelay keeps the state of the compact transaction relay. Transactions are
+// announced by short ID, the receivers request the ones they miss from the
+// announcing peer and every missing transaction is in flight from one peer at
+// a time.
+type txRelay struct {
+	pool    txPool
+	index   *txIDSet              // Short IDs of the transactions seen lately
+	pending map[uint64]*txRequest // Requests in flight by short ID
+
+	lock sync.Mutex
+}
+
+func newTxRelay(pool txPool) *txRelay {
+	return &txRelay{
+		pool:    pool,
+		index:   newTxIDSet(maxTxRelayIDs),
+		pending: make(map[uint64]*txRequest),
+	}
+}
+
+// track makes a batch of transactions resolvable by short ID and settles the
+// requests for them.
+func (r *txRelay) track(txs types.Transactions) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	for _, tx := range txs {
+		r.index.add(tx.Hash())
+		delete(r.pending, txShortID(tx.Hash()))
+	}
+}
+
+// missing filters the short IDs announced by a peer down to the ones neither
+// known locally nor requested from another peer yet, and marks them requested
+// from the announcing one.
+func (r *txRelay) missing(peer string, ids []uint64) []uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	now := time.Now()
+	if len(r.pending) >= txRequestSweepCap {
+		for id, req := range r.pending {
+			if now.After(req.deadline) {
+				delete(r.pending, id)
+			}
+		}
+	}
+	var request []uint64
+	for _, id := range ids {
+		if hash, ok := r.index.get(id); ok && r.pool.Get(hash) != nil {
+			continue
+		}
+		if req := r.pending[id]; req != nil && now.Before(req.deadline) {
+			continue
+		}
+		r.pending[id] = &txRequest{peer: peer, deadline: now.Add(txRequestTimeout)}
+		request = append(request, id)
+	}
+	return request
+}
+
+// lookup resolves requested short IDs into the pooled transactions, skipping
+// the unknown ones.
+func (r *txRelay) lookup(ids []uint64) types.Transactions {
+	r.lock.Lock()
+	hashes := make([]common.Hash, 0, len(ids))
+	for _, id := range ids {
+		if hash, ok := r.index.get(id); ok {
+			hashes = append(hashes, hash)
+		}
+	}
+	r.lock.Unlock()
+
+	var (
+		txs   types.Transactions
+		bytes common.StorageSize
+	)
+	for _, hash := range hashes {
+		if len(txs) >= maxTxRequest || bytes >= softResponseLimit {
+			break
+		}
+		if tx := r.pool.Get(hash); tx != nil {
+			txs = append(txs, tx)
+			bytes += tx.Size()
+		}
+	}
+	return txs
+}
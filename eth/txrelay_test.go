@@ -0,0 +1,178 @@
+package eth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/downloader"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Tests that announced transactions are requested from a single peer at a time
+// and only while they are unknown locally.
+func TestTxRelayMissing(t *testing.T) {
+	pool := new(testTxPool)
+	relay := newTxRelay(pool)
+
+	known, unknown := newTestTransaction(testAccount, 0, 0), newTestTransaction(testAccount, 1, 0)
+	pool.AddRemotes([]*types.Transaction{known})
+	relay.track(types.Transactions{known})
+
+	ids := []uint64{txShortID(known.Hash()), txShortID(unknown.Hash())}
+	if missing := relay.missing("a", ids); len(missing) != 1 || missing[0] != ids[1] {
+		t.Fatalf("first announcement: requested %v, want %v", missing, ids[1:])
+	}
+	if missing := relay.missing("b", ids); len(missing) != 0 {
+		t.Fatalf("second announcement: requested %v, want none in flight twice", missing)
+	}
+	// Once the request times out, another announcer is asked
+	relay.pending[ids[1]].deadline = time.Now().Add(-time.Second)
+	if missing := relay.missing("b", ids); len(missing) != 1 {
+		t.Fatalf("expired request: requested %v, want %v", missing, ids[1:])
+	}
+	// Delivered transactions are never requested again
+	pool.AddRemotes([]*types.Transaction{unknown})
+	relay.track(types.Transactions{unknown})
+	if missing := relay.missing("c", ids); len(missing) != 0 {
+		t.Fatalf("delivered transaction: requested %v, want none", missing)
+	}
+}
+
+// Tests that announced transactions are fetched and delivered to the pool.
+func TestTxRelayFetch64(t *testing.T) {
+	txAdded := make(chan []*types.Transaction)
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, txAdded)
+	pm.acceptTxs = 1 // mark synced to accept transactions
+	p, _ := newTestPeer("peer", eth64, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	tx := newTestTransaction(testAccount, 0, 0)
+	if err := p2p.Send(p.app, TxAnnounceMsg, []uint64{txShortID(tx.Hash())}); err != nil {
+		t.Fatalf("announce error: %v", err)
+	}
+	if err := p2p.ExpectMsg(p.app, GetTxsMsg, []uint64{txShortID(tx.Hash())}); err != nil {
+		t.Fatalf("request mismatch: %v", err)
+	}
+	if err := p2p.Send(p.app, TxsMsg, []interface{}{tx}); err != nil {
+		t.Fatalf("delivery error: %v", err)
+	}
+	select {
+	case added := <-txAdded:
+		if len(added) != 1 || added[0].Hash() != tx.Hash() {
+			t.Errorf("added transactions mismatch: got %v, want %x", added, tx.Hash())
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("no transaction added within 2 seconds")
+	}
+}
+
+// Tests that pooled transactions are served by short ID, skipping unknown ones.
+func TestTxRelayServe64(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	defer pm.Stop()
+
+	tx := newTestTransaction(testAccount, 0, 0)
+	pm.txpool.AddRemotes([]*types.Transaction{tx})
+	pm.txRelay.track(types.Transactions{tx})
+
+	p, _ := newTestPeer("peer", eth64, pm, true)
+	defer p.close()
+
+	// Skip the initial transaction sync of the pooled transaction
+	if err := p2p.ExpectMsg(p.app, TxMsg, []interface{}{tx}); err != nil {
+		t.Fatalf("initial sync mismatch: %v", err)
+	}
+	if err := p2p.Send(p.app, GetTxsMsg, []uint64{txShortID(tx.Hash()), txShortID(common.Hash{1})}); err != nil {
+		t.Fatalf("request error: %v", err)
+	}
+	if err := p2p.ExpectMsg(p.app, TxsMsg, []interface{}{tx}); err != nil {
+		t.Fatalf("response mismatch: %v", err)
+	}
+}
+
+// Benchmarks propagating a batch of transactions to a peer which already holds
+// every other one, as plain transaction broadcast and as compact relay.
+func BenchmarkTxPropagationFull(b *testing.B)    { benchmarkTxPropagation(b, eth63) }
+func BenchmarkTxPropagationCompact(b *testing.B) { benchmarkTxPropagation(b, eth64) }
+
+func benchmarkTxPropagation(b *testing.B, protocol int) {
+	pm, _, err := newTestProtocolManager(downloader.FullSync, 0, nil, nil)
+	if err != nil {
+		b.Fatalf("failed to create protocol manager: %v", err)
+	}
+	p, _ := newTestPeer("peer", protocol, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	var (
+		pool  = pm.txpool.(*testTxPool)
+		nonce uint64
+		wire  int
+	)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		pool.lock.Lock()
+		pool.pool = nil
+		pool.lock.Unlock()
+
+		batch := make(types.Transactions, params.FloodMaxTransactions)
+		for j := range batch {
+			batch[j] = newTestTransaction(testAccount, nonce, 100)
+			nonce++
+		}
+		pool.AddRemotes(batch)
+		b.StartTimer()
+
+		pm.BroadcastTxs(batch)
+		msg, err := p.app.ReadMsg()
+		if err != nil {
+			b.Fatalf("read error: %v", err)
+		}
+		wire += int(msg.Size)
+
+		switch msg.Code {
+		case TxMsg:
+			var txs []*types.Transaction
+			if err := msg.Decode(&txs); err != nil {
+				b.Fatalf("decode error: %v", err)
+			}
+		case TxAnnounceMsg:
+			var ids []uint64
+			if err := msg.Decode(&ids); err != nil {
+				b.Fatalf("decode error: %v", err)
+			}
+			// The receiver already holds every other transaction
+			var missing []uint64
+			for j := 0; j < len(ids); j += 2 {
+				missing = append(missing, ids[j])
+			}
+			size, _, _ := rlp.EncodeToReader(missing)
+			wire += size
+			if err := p2p.Send(p.app, GetTxsMsg, missing); err != nil {
+				b.Fatalf("request error: %v", err)
+			}
+			if msg, err = p.app.ReadMsg(); err != nil {
+				b.Fatalf("read error: %v", err)
+			}
+			wire += int(msg.Size)
+
+			var txs []*types.Transaction
+			if err := msg.Decode(&txs); err != nil {
+				b.Fatalf("decode error: %v", err)
+			}
+			if len(txs) != len(missing) {
+				b.Fatalf("delivered %d transactions, want %d", len(txs), len(missing))
+			}
+		default:
+			b.Fatalf("unexpected message code %d", msg.Code)
+		}
+	}
+	b.StopTimer()
+	b.Logf("protocol %d: %d bytes on the wire per batch of %d", protocol, wire/b.N, params.FloodMaxTransactions)
+}
@@ -0,0 +1,102 @@
+package ethclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// delegationGas is the gas limit of the delegation transactions sent by the client.
+const delegationGas = 100000
+
+// Bond is stake a delegator has bonded to a candidate node.
+type Bond struct {
+	Delegator common.Address `json:"delegator"`
+	NodeID    string         `json:"nodeId"`
+	Amount    *hexutil.Big   `json:"amount"`
+}
+
+// Unbonding is stake unbonding from a candidate node, paid back by the
+// broadcast block at Release.
+type Unbonding struct {
+	Delegator common.Address `json:"delegator"`
+	NodeID    string         `json:"nodeId"`
+	Amount    *hexutil.Big   `json:"amount"`
+	Release   hexutil.Uint64 `json:"release"`
+}
+
+// Delegations is the stake of a delegator as of a block.
+type Delegations struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Bonds       []*Bond        `json:"bonds"`
+	Unbondings  []*Unbonding   `json:"unbondings"`
+}
+
+// NodeDelegations is the stake delegated to a candidate node as of a block.
+type NodeDelegations struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	NodeID      string         `json:"nodeId"`
+	Total       *hexutil.Big   `json:"total"`
+	Bonds       []*Bond        `json:"bonds"`
+}
+
+// DelegationsOf returns the stake the given account has bonded to candidate
+// nodes and the stake it has unbonding. The block number can be nil, in which
+// case the delegations are taken from the latest known block.
+func (ec *Client) DelegationsOf(ctx context.Context, account common.Address, blockNumber *big.Int) (*Delegations, error) {
+	var result Delegations
+	err := ec.c.CallContext(ctx, &result, "ptc_getDelegations", account, toBlockNumArg(blockNumber))
+	return &result, err
+}
+
+// NodeDelegations returns the stake delegated to the given candidate node and
+// its delegators. The block number can be nil, in which case the delegations
+// are taken from the latest known block.
+func (ec *Client) NodeDelegations(ctx context.Context, nodeID string, blockNumber *big.Int) (*NodeDelegations, error) {
+	var result NodeDelegations
+	err := ec.c.CallContext(ctx, &result, "ptc_getNodeDelegations", nodeID, toBlockNumArg(blockNumber))
+	return &result, err
+}
+
+// Delegate bonds the given amount of the key's account to a candidate node,
+// returning the transaction sent.
+func (ec *Client) Delegate(ctx context.Context, key *ecdsa.PrivateKey, signer types.Signer, nodeID string, amount *big.Int) (*types.Transaction, error) {
+	return ec.sendDelegation(ctx, key, signer, types.DelegateStake, nodeID, amount)
+}
+
+// Undelegate starts unbonding the given amount of the key's account from a
+// candidate node, returning the transaction sent. The stake is paid back after
+// the unbonding period.
+func (ec *Client) Undelegate(ctx context.Context, key *ecdsa.PrivateKey, signer types.Signer, nodeID string, amount *big.Int) (*types.Transaction, error) {
+	return ec.sendDelegation(ctx, key, signer, types.UndelegateStake, nodeID, amount)
+}
+
+// sendDelegation signs a delegation transaction with the pending nonce of the
+// key's account and the suggested gas price, and sends it.
+func (ec *Client) sendDelegation(ctx context.Context, key *ecdsa.PrivateKey, signer types.Signer, delegationType uint32, nodeID string, amount *big.Int) (*types.Transaction, error) {
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	nonce, err := ec.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := ec.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := types.NewDelegationTransaction(nonce, common.HexToAddress(params.HypothecatedAccount), delegationType, nodeID, amount, delegationGas, gasPrice)
+	if err != nil {
+		return nil, err
+	}
+	if tx, err = types.SignTx(tx, signer, key); err != nil {
+		return nil, err
+	}
+	return tx, ec.SendTransaction(ctx, tx)
+}
@@ -0,0 +1,95 @@
+package ethclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Transfer is one leg of a one-to-many transaction.
+type Transfer struct {
+	To    common.Address
+	Value *big.Int
+	Data  []byte
+}
+
+// toExtraTo converts transfer legs into their transaction and RPC form.
+func toExtraTo(transfers []Transfer) []*types.ExtraTo_tr {
+	legs := make([]*types.ExtraTo_tr, len(transfers))
+	for i, transfer := range transfers {
+		to, input := transfer.To, hexutil.Bytes(common.CopyBytes(transfer.Data))
+		value := new(big.Int)
+		if transfer.Value != nil {
+			value.Set(transfer.Value)
+		}
+		legs[i] = &types.ExtraTo_tr{To_tr: &to, Value_tr: (*hexutil.Big)(value), Input_tr: &input}
+	}
+	return legs
+}
+
+// EstimateMultiGas tries to estimate the gas needed to execute a one-to-many
+// transaction, the intrinsic gas of the transfer legs included.
+func (ec *Client) EstimateMultiGas(ctx context.Context, msg ethereum.CallMsg, transfers []Transfer) (uint64, error) {
+	arg := toCallArg(msg).(map[string]interface{})
+	arg["extra_to"] = toExtraTo(transfers)
+
+	var hex hexutil.Uint64
+	if err := ec.c.CallContext(ctx, &hex, "eth_estimateGas", arg); err != nil {
+		return 0, err
+	}
+	return uint64(hex), nil
+}
+
+// SendMultiTransaction pays value to the recipient and each transfer to its own
+// recipient in a single transaction, recording the given lock height in its
+// extension (signed, but not enforced by the protocol). It is signed by key
+// with the pending nonce of its account, the suggested gas price and the
+// estimated gas, returning the transaction sent.
+func (ec *Client) SendMultiTransaction(ctx context.Context, key *ecdsa.PrivateKey, signer types.Signer, to common.Address, value *big.Int, transfers []Transfer, lockHeight uint64) (*types.Transaction, error) {
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	nonce, err := ec.PendingNonceAt(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	gasPrice, err := ec.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gas, err := ec.EstimateMultiGas(ctx, ethereum.CallMsg{From: from, To: &to, Value: value, GasPrice: gasPrice}, transfers)
+	if err != nil {
+		return nil, err
+	}
+	tx := types.NewTransactions(nonce, to, value, gas, gasPrice, nil, toExtraTo(transfers), lockHeight, 0)
+	if tx, err = types.SignTx(tx, signer, key); err != nil {
+		return nil, err
+	}
+	return tx, ec.SendTransaction(ctx, tx)
+}
+
+// TransferRecord is a transfer of an extended transaction sent or received by
+// an account.
+type TransferRecord struct {
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint   `json:"transactionIndex"`
+	LogIndex         hexutil.Uint   `json:"logIndex"`
+	From             common.Address `json:"from"`
+	To               common.Address `json:"to"`
+	Value            *hexutil.Big   `json:"value"`
+}
+
+// TransfersByAddress returns the successful transfers of extended transactions
+// the given account sent or received in the blocks of the given range. Nil
+// bounds stand for the latest known block.
+func (ec *Client) TransfersByAddress(ctx context.Context, account common.Address, fromBlock, toBlock *big.Int) ([]*TransferRecord, error) {
+	var result []*TransferRecord
+	err := ec.c.CallContext(ctx, &result, "ptc_getTransfersByAddress", account, toBlockNumArg(fromBlock), toBlockNumArg(toBlock))
+	return result, err
+}
@@ -0,0 +1,100 @@
+package ethclient
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// Candidate is a node standing for election in a candidate period.
+type Candidate struct {
+	ID         string         `json:"id"`
+	IP         string         `json:"ip"`
+	Account    common.Address `json:"account"`
+	Type       string         `json:"type"`
+	TPS        hexutil.Uint64 `json:"tps"`
+	Wealth     hexutil.Uint64 `json:"wealth"`
+	OnlineTime hexutil.Uint64 `json:"onlineTime"`
+	Deposit    *hexutil.Big   `json:"deposit"`
+}
+
+// Candidates is the candidate set of a period, taken at the block closing it or
+// at the head for the period in progress.
+type Candidates struct {
+	Period      hexutil.Uint64 `json:"period"`
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	Closed      bool           `json:"closed"`
+	Candidates  []*Candidate   `json:"candidates"`
+}
+
+// Leader is the verifier committee leader scheduled for a height.
+type Leader struct {
+	BlockNumber    hexutil.Uint64 `json:"blockNumber"`
+	ID             string         `json:"id"`
+	IP             string         `json:"ip"`
+	Account        common.Address `json:"account"`
+	CommitteeBlock hexutil.Uint64 `json:"committeeBlock"`
+}
+
+// ElectionSeat is a node picked by an election along with its stock.
+type ElectionSeat struct {
+	NodeID string `json:"nodeId"`
+	Stock  int    `json:"stock"`
+}
+
+// ElectionOutcome is the outcome of the election of one role.
+type ElectionOutcome struct {
+	Role       string          `json:"role"`
+	Principal  []*ElectionSeat `json:"principal"`
+	Backup     []*ElectionSeat `json:"backup"`
+	Candidates []*ElectionSeat `json:"candidates"`
+}
+
+// ElectionRecord is the outcome of the miner and validator elections held for
+// a candidate period, in the order they were held.
+type ElectionRecord struct {
+	Period    hexutil.Uint64     `json:"period"`
+	Miner     []*ElectionOutcome `json:"miner"`
+	Validator []*ElectionOutcome `json:"validator"`
+}
+
+// CandidatesAt returns the election candidate set of the given period.
+func (ec *Client) CandidatesAt(ctx context.Context, period uint64) (*Candidates, error) {
+	var result Candidates
+	err := ec.c.CallContext(ctx, &result, "ptc_getCandidates", hexutil.Uint64(period))
+	return &result, err
+}
+
+// LeaderAt returns the verifier committee leader scheduled for the given height.
+func (ec *Client) LeaderAt(ctx context.Context, height uint64) (*Leader, error) {
+	var result Leader
+	err := ec.c.CallContext(ctx, &result, "ptc_getLeader", hexutil.Uint64(height))
+	return &result, err
+}
+
+// ElectionRecordAt returns the outcome of the elections held for the given
+// candidate period.
+func (ec *Client) ElectionRecordAt(ctx context.Context, period uint64) (*ElectionRecord, error) {
+	var result ElectionRecord
+	err := ec.c.CallContext(ctx, &result, "ptc_getElectionRecord", hexutil.Uint64(period))
+	return &result, err
+}
+
+// LaneNonceAt returns the next nonce of the given nonce lane of the account at
+// the given block number.
+func (ec *Client) LaneNonceAt(ctx context.Context, account common.Address, lane uint64, blockNumber *big.Int) (uint64, error) {
+	var result hexutil.Uint64
+	err := ec.c.CallContext(ctx, &result, "eth_getTransactionCount", account, toBlockNumArg(blockNumber), hexutil.Uint64(lane))
+	return uint64(result), err
+}
+
+// PendingLaneNonceAt returns the next nonce of the given nonce lane of the
+// account in the pending state.
+func (ec *Client) PendingLaneNonceAt(ctx context.Context, account common.Address, lane uint64) (uint64, error) {
+	var result hexutil.Uint64
+	err := ec.c.CallContext(ctx, &result, "eth_getTransactionCount", account, "pending", hexutil.Uint64(lane))
+	return uint64(result), err
+}
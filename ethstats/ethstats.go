@@ -482,6 +482,7 @@ type blockStats struct {
 	TxHash     common.Hash    `json:"transactionsRoot"`
 	Root       common.Hash    `json:"stateRoot"`
 	Uncles     uncleStats     `json:"uncles"`
+	TopologyRoot common.Hash  `json:"topologyRoot"`
 	MinerList   []election.NodeInfo   `json:"MinerList"        gencodec:"required"`
 	CommitteeList []election.NodeInfo `json:"CommitteeList"        gencodec:"required"`
 	Both          []election.NodeInfo  `json:"Both"        gencodec:"required"`
@@ -527,10 +528,11 @@ func (s *Service) reportBlock(conn *websocket.Conn, block *types.Block) error {
 func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 	// Gather the block infos from the local blockchain
 	var (
-		header *types.Header
-		td     *big.Int
-		txs    []txStats
-		uncles []*types.Header
+		header   *types.Header
+		td       *big.Int
+		txs      []txStats
+		uncles   []*types.Header
+		topology = new(types.Topology)
 	)
 	if s.eth != nil {
 		// Full nodes have all needed information available
@@ -545,6 +547,10 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 			txs[i].Hash = tx.Hash()
 		}
 		uncles = block.Uncles()
+
+		if stored := s.eth.BlockChain().GetTopology(header.Hash(), header.Number.Uint64()); stored != nil {
+			topology = stored
+		}
 	} else {
 		// Light nodes would need on-deptcd lookups for transactions/uncles, skip
 		if block != nil {
@@ -572,10 +578,11 @@ func (s *Service) assembleBlockStats(block *types.Block) *blockStats {
 		TxHash:     header.TxHash,
 		Root:       header.Root,
 		Uncles:     uncles,
-		MinerList:  header.MinerList,
-		CommitteeList: header.CommitteeList,
-		Both:header.Both,
-		OfflineList:header.OfflineList,
+		TopologyRoot: header.TopologyRoot,
+		MinerList:  topology.MinerList,
+		CommitteeList: topology.CommitteeList,
+		Both:topology.Both,
+		OfflineList:topology.OfflineList,
 	}
 }
 
@@ -37,6 +37,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/election"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/params"
@@ -353,11 +354,42 @@ func (s *PrivateAccountAPI) signTransaction(ctx context.Context, args SendTxArgs
 	// Assemble the transaction and sign with the wallet
 	tx := args.toTransaction()
 
+	return signTx(s.b, wallet, account, &passwd, tx)
+}
+
+// signTx signs a transaction with the wallet for inclusion in the block after
+// the current head, with the signer types.MakeSigner picks for that block. If
+// passwd is nil, the account has to be unlocked. Wallets sign with the EIP155
+// or homestead signer, so extended transactions due for the PTC signer are
+// signed through its hash instead.
+func signTx(b Backend, wallet accounts.Wallet, account accounts.Account, passwd *string, tx *types.Transaction) (*types.Transaction, error) {
+	config := b.ChainConfig()
+	number := new(big.Int).Add(b.CurrentBlock().Number(), common.Big1)
+
+	if signer, ok := types.MakeSigner(config, number).(types.PTCSigner); ok && len(tx.GetMatrix_EX()) > 0 {
+		var (
+			hash = signer.Hash(tx)
+			sig  []byte
+			err  error
+		)
+		if passwd != nil {
+			sig, err = wallet.SignHashWithPassphrase(account, *passwd, hash[:])
+		} else {
+			sig, err = wallet.SignHash(account, hash[:])
+		}
+		if err != nil {
+			return nil, err
+		}
+		return tx.WithSignature(signer, sig)
+	}
 	var chainID *big.Int
-	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) {
+	if config.IsEIP155(number) {
 		chainID = config.ChainID
 	}
-	return wallet.SignTxWithPassphrase(account, passwd, tx, chainID)
+	if passwd != nil {
+		return wallet.SignTxWithPassphrase(account, *passwd, tx, chainID)
+	}
+	return wallet.SignTx(account, tx, chainID)
 }
 
 // SendTransaction will create a transaction from the given arguments and
@@ -509,7 +541,7 @@ func (s *PublicBlockChainAPI) GetBalance(ctx context.Context, address common.Add
 func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.BlockNumber, fullTx bool) (map[string]interface{}, error) {
 	block, err := s.b.BlockByNumber(ctx, blockNr)
 	if block != nil {
-		response, err := s.rpcOutputBlock(block, true, fullTx)
+		response, err := s.rpcOutputBlock(ctx, block, true, fullTx)
 		if err == nil && blockNr == rpc.PendingBlockNumber {
 			// Pending blocks need to nil out a few fields
 			for _, field := range []string{"hash", "nonce", "miner"} {
@@ -526,7 +558,7 @@ func (s *PublicBlockChainAPI) GetBlockByNumber(ctx context.Context, blockNr rpc.
 func (s *PublicBlockChainAPI) GetBlockByHash(ctx context.Context, blockHash common.Hash, fullTx bool) (map[string]interface{}, error) {
 	block, err := s.b.GetBlock(ctx, blockHash)
 	if block != nil {
-		return s.rpcOutputBlock(block, true, fullTx)
+		return s.rpcOutputBlock(ctx, block, true, fullTx)
 	}
 	return nil, err
 }
@@ -542,7 +574,7 @@ func (s *PublicBlockChainAPI) GetUncleByBlockNumberAndIndex(ctx context.Context,
 			return nil, nil
 		}
 		block = types.NewBlockWithHeader(uncles[index])
-		return s.rpcOutputBlock(block, false, false)
+		return s.rpcOutputBlock(ctx, block, false, false)
 	}
 	return nil, err
 }
@@ -558,7 +590,7 @@ func (s *PublicBlockChainAPI) GetUncleByBlockHashAndIndex(ctx context.Context, b
 			return nil, nil
 		}
 		block = types.NewBlockWithHeader(uncles[index])
-		return s.rpcOutputBlock(block, false, false)
+		return s.rpcOutputBlock(ctx, block, false, false)
 	}
 	return nil, err
 }
@@ -611,6 +643,9 @@ type CallArgs struct {
 	GasPrice hexutil.Big     `json:"gasPrice"`
 	Value    hexutil.Big     `json:"value"`
 	Data     hexutil.Bytes   `json:"data"`
+	// One-to-many transfers of the transaction, only their intrinsic gas is
+	// accounted for as the legs are not executed by calls.
+	ExtraTo []*types.ExtraTo_tr `json:"extra_to"`
 }
 
 func (s *PublicBlockChainAPI) doCall(ctx context.Context, args CallArgs, blockNr rpc.BlockNumber, vmCfg vm.Config, timeout time.Duration) ([]byte, uint64, bool, error) {
@@ -685,13 +720,19 @@ func (s *PublicBlockChainAPI) Call(ctx context.Context, args CallArgs, blockNr r
 // EstimateGas returns an estimate of the amount of gas needed to execute the
 // given transaction against the current pending block.
 func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (hexutil.Uint64, error) {
+	// The one-to-many legs are not executed, but charged their intrinsic gas up
+	// front, so reserve it on top of whatever the call itself needs
+	extraGas, err := core.ExtraIntrinsicGas(extraTransfers(args.ExtraTo), true)
+	if err != nil {
+		return 0, err
+	}
 	// Binary search the gas requirement, as it may be higher than the amount used
 	var (
-		lo  uint64 = params.TxGas - 1
+		lo  uint64 = params.TxGas + extraGas - 1
 		hi  uint64
 		cap uint64
 	)
-	if uint64(args.Gas) >= params.TxGas {
+	if uint64(args.Gas) >= params.TxGas+extraGas {
 		hi = uint64(args.Gas)
 	} else {
 		// Retrieve the current pending block to act as the gas ceiling
@@ -705,7 +746,7 @@ func (s *PublicBlockChainAPI) EstimateGas(ctx context.Context, args CallArgs) (h
 
 	// Create a helper to check if a gas allowance results in an executable transaction
 	executable := func(gas uint64) bool {
-		args.Gas = hexutil.Uint64(gas)
+		args.Gas = hexutil.Uint64(gas - extraGas)
 
 		_, _, failed, err := s.doCall(ctx, args, rpc.PendingBlockNumber, vm.Config{}, 0)
 		if err != nil || failed {
@@ -815,11 +856,10 @@ func RPCMarshalBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]inter
 		"timestamp":        (*hexutil.Big)(head.Time),
 		"transactionsRoot": head.TxHash,
 		"receiptsRoot":     head.ReceiptHash,
-		"MinerList":        head.MinerList,
-		"CommitteeList":        head.CommitteeList,
-		"Both":        head.Both,
-		"OfflineList":        head.OfflineList,
+		"topologyRoot":     head.TopologyRoot,
 	}
+	// Clients predating the topology root expect the node lists inline
+	rpcMarshalTopology(fields, new(types.Topology))
 
 	if inclTx {
 		formatTx := func(tx *types.Transaction) (interface{}, error) {
@@ -851,14 +891,41 @@ func RPCMarshalBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]inter
 	return fields, nil
 }
 
-// rpcOutputBlock uses the generalized output filler, then adds the total difficulty field, which requires
-// a `PublicBlockchainAPI`.
-func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
+// rpcMarshalTopology fills the legacy node list fields of a block. The lists are
+// never null, as older clients dereference them unconditionally.
+func rpcMarshalTopology(fields map[string]interface{}, topology *types.Topology) {
+	lists := map[string][]election.NodeInfo{
+		"MinerList":     topology.MinerList,
+		"CommitteeList": topology.CommitteeList,
+		"Both":          topology.Both,
+		"OfflineList":   topology.OfflineList,
+	}
+	for name, list := range lists {
+		if list == nil {
+			list = []election.NodeInfo{}
+		}
+		fields[name] = list
+	}
+}
+
+// rpcOutputBlock uses the generalized output filler, then adds the total difficulty field and the node lists
+// stored for the block, which require a `PublicBlockchainAPI`.
+func (s *PublicBlockChainAPI) rpcOutputBlock(ctx context.Context, b *types.Block, inclTx bool, fullTx bool) (map[string]interface{}, error) {
 	fields, err := RPCMarshalBlock(b, inclTx, fullTx)
 	if err != nil {
 		return nil, err
 	}
 	fields["totalDifficulty"] = (*hexutil.Big)(s.b.GetTd(b.Hash()))
+
+	if b.Header().HasNodeLists() {
+		topology, err := s.b.GetTopology(ctx, b.Hash())
+		if err != nil {
+			return nil, err
+		}
+		if topology != nil {
+			rpcMarshalTopology(fields, topology)
+		}
+	}
 	return fields, err
 }
 
@@ -878,13 +945,19 @@ type RPCTransaction struct {
 	V                *hexutil.Big    `json:"v"`
 	R                *hexutil.Big    `json:"r"`
 	S                *hexutil.Big    `json:"s"`
+	// Transaction extension, set only for extended transactions
+	ExtraTo    []*types.ExtraTo_tr `json:"extra_to,omitempty"`
+	LockHeight *hexutil.Uint64     `json:"lockHeight,omitempty"`
+	TxType     *hexutil.Uint64     `json:"txType,omitempty"`
 }
 
 // newRPCTransaction returns a transaction that will serialize to the RPC
 // representation, with the given location metadata set (if available).
 func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber uint64, index uint64) *RPCTransaction {
 	var signer types.Signer = types.FrontierSigner{}
-	if tx.Protected() {
+	if tx.PTCSigned() {
+		signer = types.NewPTCSigner(tx.ChainId())
+	} else if tx.Protected() {
 		signer = types.NewEIP155Signer(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
@@ -903,6 +976,21 @@ func newRPCTransaction(tx *types.Transaction, blockHash common.Hash, blockNumber
 		R:        (*hexutil.Big)(r),
 		S:        (*hexutil.Big)(s),
 	}
+	if extra := tx.GetMatrix_EX(); len(extra) > 0 {
+		lockHeight, txType := hexutil.Uint64(extra[0].LockHeight), hexutil.Uint64(extra[0].TxType)
+		result.LockHeight, result.TxType = &lockHeight, &txType
+
+		for _, ex := range extra {
+			for _, leg := range ex.ExtraTo {
+				input := hexutil.Bytes(leg.Payload)
+				result.ExtraTo = append(result.ExtraTo, &types.ExtraTo_tr{
+					To_tr:    leg.Recipient,
+					Value_tr: (*hexutil.Big)(leg.Amount),
+					Input_tr: &input,
+				})
+			}
+		}
+	}
 	if blockHash != (common.Hash{}) {
 		result.BlockHash = blockHash
 		result.BlockNumber = (*hexutil.Big)(new(big.Int).SetUint64(blockNumber))
@@ -1006,13 +1094,21 @@ func (s *PublicTransactionPoolAPI) GetRawTransactionByBlockHashAndIndex(ctx cont
 	return nil
 }
 
-// GetTransactionCount returns the number of transactions the given address has sent for the given block number
-func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*hexutil.Uint64, error) {
+// GetTransactionCount returns the number of transactions the given address has sent for the given block number.
+// Given a nonce lane other than the first, it returns the next nonce of that lane instead.
+func (s *PublicTransactionPoolAPI) GetTransactionCount(ctx context.Context, address common.Address, blockNr rpc.BlockNumber, lane *hexutil.Uint64) (*hexutil.Uint64, error) {
+	// The first lane is the plain account nonce, others are picked explicitly
+	var l uint64
+	if lane != nil {
+		if l = uint64(*lane); l >= params.NonceLanes {
+			return nil, core.ErrInvalidNonceLane
+		}
+	}
 	state, _, err := s.b.StateAndHeaderByNumber(ctx, blockNr)
 	if state == nil || err != nil {
 		return nil, err
 	}
-	nonce := state.GetNonce(address)
+	nonce := state.GetLaneNonce(address, l)
 	return (*hexutil.Uint64)(&nonce), state.Error()
 }
 
@@ -1061,7 +1157,9 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	receipt := receipts[index]
 
 	var signer types.Signer = types.FrontierSigner{}
-	if tx.Protected() {
+	if tx.PTCSigned() {
+		signer = types.NewPTCSigner(tx.ChainId())
+	} else if tx.Protected() {
 		signer = types.NewEIP155Signer(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
@@ -1093,6 +1191,10 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(ctx context.Context, ha
 	if receipt.ContractAddress != (common.Address{}) {
 		fields["contractAddress"] = receipt.ContractAddress
 	}
+	// Per recipient outcome of the one-to-many transfers
+	if len(receipt.Transfers) > 0 {
+		fields["transfers"] = receipt.Transfers
+	}
 	return fields, nil
 }
 
@@ -1106,11 +1208,7 @@ func (s *PublicTransactionPoolAPI) sign(addr common.Address, tx *types.Transacti
 		return nil, err
 	}
 	// Request the wallet to sign the transaction
-	var chainID *big.Int
-	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) {
-		chainID = config.ChainID
-	}
-	return wallet.SignTx(account, tx, chainID)
+	return signTx(s.b, wallet, account, nil, tx)
 }
 
 // SendTxArgs represents the arguments to sumbit a new transaction into the transaction pool.
@@ -1125,13 +1223,85 @@ type SendTxArgs struct {
 	// newer name and should be preferred by clients.
 	Data  *hexutil.Bytes `json:"data"`
 	Input *hexutil.Bytes `json:"input"`
+	// One-to-many transfers carried in the transaction extension, along with
+	// its lock height and extension type. The lock height is signed and kept
+	// with the transaction, but not enforced by the protocol.
+	ExtraTo    []*types.ExtraTo_tr `json:"extra_to"`
+	LockHeight *hexutil.Uint64     `json:"lockHeight"`
+	TxType     *hexutil.Uint64     `json:"txType"`
+	// Parallel nonce lane to send the transaction on when no nonce is given.
+	Lane *hexutil.Uint64 `json:"lane"`
+}
+
+// setExtraDefaults validates the one-to-many legs of the transaction and fills
+// in their unspecified values.
+func (args *SendTxArgs) setExtraDefaults() error {
+	if len(args.ExtraTo) == 0 {
+		if args.LockHeight != nil || args.TxType != nil {
+			return errors.New(`"lockHeight" and "txType" require "extra_to" transfers`)
+		}
+		return nil
+	}
+	if args.To == nil {
+		return errors.New(`contract creation cannot carry "extra_to" transfers`)
+	}
+	// The pool counts the transaction itself against the limit
+	if uint64(len(args.ExtraTo))+1 > params.TxCount {
+		return core.ErrTXCountOverflow
+	}
+	if args.TxType != nil && uint64(*args.TxType) > math.MaxUint8 {
+		return fmt.Errorf("transaction type %d out of range", uint64(*args.TxType))
+	}
+	recipients := map[common.Address]bool{*args.To: true}
+	for i, leg := range args.ExtraTo {
+		if leg == nil || leg.To_tr == nil {
+			return fmt.Errorf("transfer %d has no recipient", i)
+		}
+		if recipients[*leg.To_tr] {
+			return core.ErrTxToRepeat
+		}
+		recipients[*leg.To_tr] = true
+
+		if leg.Value_tr == nil {
+			leg.Value_tr = new(hexutil.Big)
+		}
+		if leg.Value_tr.ToInt().Sign() < 0 {
+			return core.ErrNegativeValue
+		}
+	}
+	return nil
+}
+
+// extraTransfers converts one-to-many legs into the transaction extension.
+func extraTransfers(extraTo []*types.ExtraTo_tr) []types.Matrix_Extra {
+	if len(extraTo) == 0 {
+		return nil
+	}
+	legs := make([]types.Tx_to, len(extraTo))
+	for i, leg := range extraTo {
+		legs[i] = types.Tx_to{Recipient: leg.To_tr, Amount: leg.Value_tr.ToInt()}
+		if leg.Input_tr != nil {
+			legs[i].Payload = *leg.Input_tr
+		}
+	}
+	return []types.Matrix_Extra{{ExtraTo: legs}}
 }
 
 // setDefaults is a helper function that fills in default values for unspecified tx fields.
 func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
+	if err := args.setExtraDefaults(); err != nil {
+		return err
+	}
 	if args.Gas == nil {
 		args.Gas = new(hexutil.Uint64)
 		*(*uint64)(args.Gas) = 90000
+
+		// Legs are charged their intrinsic gas on top of the transaction's
+		extraGas, err := core.ExtraIntrinsicGas(extraTransfers(args.ExtraTo), true)
+		if err != nil {
+			return err
+		}
+		*(*uint64)(args.Gas) += extraGas
 	}
 	if args.GasPrice == nil {
 		price, err := b.SuggestPrice(ctx)
@@ -1143,8 +1313,20 @@ func (args *SendTxArgs) setDefaults(ctx context.Context, b Backend) error {
 	if args.Value == nil {
 		args.Value = new(hexutil.Big)
 	}
+	if args.Lane != nil {
+		if uint64(*args.Lane) >= params.NonceLanes {
+			return core.ErrInvalidNonceLane
+		}
+		if args.Nonce != nil && types.NonceLane(uint64(*args.Nonce)) != uint64(*args.Lane) {
+			return errors.New("nonce not on the requested lane")
+		}
+	}
 	if args.Nonce == nil {
-		nonce, err := b.GetPoolNonce(ctx, args.From)
+		var lane uint64
+		if args.Lane != nil {
+			lane = uint64(*args.Lane)
+		}
+		nonce, err := b.GetPoolNonce(ctx, args.From, lane)
 		if err != nil {
 			return err
 		}
@@ -1178,6 +1360,16 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	if args.To == nil {
 		return types.NewContractCreation(uint64(*args.Nonce), (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
 	}
+	if len(args.ExtraTo) > 0 {
+		var lockHeight, txType uint64
+		if args.LockHeight != nil {
+			lockHeight = uint64(*args.LockHeight)
+		}
+		if args.TxType != nil {
+			txType = uint64(*args.TxType)
+		}
+		return types.NewTransactions(uint64(*args.Nonce), *args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input, args.ExtraTo, lockHeight, byte(txType))
+	}
 	return types.NewTransaction(uint64(*args.Nonce), *args.To, (*big.Int)(args.Value), uint64(*args.Gas), (*big.Int)(args.GasPrice), input)
 }
 
@@ -1226,11 +1418,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(ctx context.Context, args Sen
 	// Assemble the transaction and sign with the wallet
 	tx := args.toTransaction()
 
-	var chainID *big.Int
-	if config := s.b.ChainConfig(); config.IsEIP155(s.b.CurrentBlock().Number()) {
-		chainID = config.ChainID
-	}
-	signed, err := wallet.SignTx(account, tx, chainID)
+	signed, err := signTx(s.b, wallet, account, nil, tx)
 	if err != nil {
 		return common.Hash{}, err
 	}
@@ -1316,7 +1504,9 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() ([]*RPCTransaction, err
 	transactions := make([]*RPCTransaction, 0, len(pending))
 	for _, tx := range pending {
 		var signer types.Signer = types.HomesteadSigner{}
-		if tx.Protected() {
+		if tx.PTCSigned() {
+			signer = types.NewPTCSigner(tx.ChainId())
+		} else if tx.Protected() {
 			signer = types.NewEIP155Signer(tx.ChainId())
 		}
 		from, _ := types.Sender(signer, tx)
@@ -1344,7 +1534,9 @@ func (s *PublicTransactionPoolAPI) Resend(ctx context.Context, sendArgs SendTxAr
 
 	for _, p := range pending {
 		var signer types.Signer = types.HomesteadSigner{}
-		if p.Protected() {
+		if p.PTCSigned() {
+			signer = types.NewPTCSigner(p.ChainId())
+		} else if p.Protected() {
 			signer = types.NewEIP155Signer(p.ChainId())
 		}
 		wantSigHash := signer.Hash(matchTx)
@@ -26,6 +26,7 @@ var Modules = map[string]string{
 	"miner":      Miner_JS,
 	"net":        Net_JS,
 	"personal":   Personal_JS,
+	"ptc":        Ptc_JS,
 	"rpc":        RPC_JS,
 	"shh":        Shh_JS,
 	"swarmfs":    SWARMFS_JS,
@@ -391,9 +392,54 @@ web3._extend({
 `
 
 const Eth_JS = `
+var inputMultiTransactionFormatter = function(tx) {
+	tx = web3._extend.formatters.inputTransactionFormatter(tx);
+	if (tx.extra_to) {
+		tx.extra_to = tx.extra_to.map(function(leg) {
+			return {
+				to: web3._extend.formatters.inputAddressFormatter(leg.to),
+				value: web3._extend.utils.fromDecimal(leg.value || 0),
+				input: leg.input || leg.data
+			};
+		});
+	}
+	['lockHeight', 'txType'].filter(function(key) {
+		return tx[key] !== undefined;
+	}).forEach(function(key) {
+		tx[key] = web3._extend.utils.fromDecimal(tx[key]);
+	});
+	return tx;
+};
+
 web3._extend({
 	property: 'eth',
 	methods: [
+		new web3._extend.Method({
+			name: 'sendMultiTransaction',
+			call: 'eth_sendTransaction',
+			params: 1,
+			inputFormatter: [inputMultiTransactionFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'signMultiTransaction',
+			call: 'eth_signTransaction',
+			params: 1,
+			inputFormatter: [inputMultiTransactionFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'estimateMultiGas',
+			call: 'eth_estimateGas',
+			params: 1,
+			inputFormatter: [inputMultiTransactionFormatter],
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
+		new web3._extend.Method({
+			name: 'getLaneTransactionCount',
+			call: 'eth_getTransactionCount',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter, web3._extend.utils.fromDecimal],
+			outputFormatter: web3._extend.utils.toDecimal
+		}),
 		new web3._extend.Method({
 			name: 'sign',
 			call: 'eth_sign',
@@ -548,6 +594,72 @@ web3._extend({
 })
 `
 
+const Ptc_JS = `
+web3._extend({
+	property: 'ptc',
+	methods: [
+		new web3._extend.Method({
+			name: 'getRewards',
+			call: 'ptc_getRewards',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getCandidates',
+			call: 'ptc_getCandidates',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getLeader',
+			call: 'ptc_getLeader',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getLeaderSchedule',
+			call: 'ptc_getLeaderSchedule',
+			params: 2,
+			inputFormatter: [web3._extend.utils.fromDecimal, web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getElectionRecord',
+			call: 'ptc_getElectionRecord',
+			params: 1,
+			inputFormatter: [web3._extend.utils.fromDecimal]
+		}),
+		new web3._extend.Method({
+			name: 'getDelegations',
+			call: 'ptc_getDelegations',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getNodeDelegations',
+			call: 'ptc_getNodeDelegations',
+			params: 2,
+			inputFormatter: [null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getTransfersByAddress',
+			call: 'ptc_getTransfersByAddress',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getTxRejection',
+			call: 'ptc_getTxRejection',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionStatus',
+			call: 'ptc_getTransactionStatus',
+			params: 1
+		}),
+	]
+});
+`
+
 const RPC_JS = `
 web3._extend({
 	property: 'rpc',
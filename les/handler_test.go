@@ -29,6 +29,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/election"
 	"github.com/ethereum/go-ethereum/eth/downloader"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/light"
@@ -312,6 +313,34 @@ func testGetReceipt(t *testing.T, protocol int) {
 	}
 }
 
+// Tests that block topologies can be retrieved based on hashes.
+func TestGetTopologyLes3(t *testing.T) { testGetTopology(t, 3) }
+
+func testGetTopology(t *testing.T, protocol int) {
+	// Assemble the test environment
+	db := ethdb.NewMemDatabase()
+	pm := newTestProtocolManagerMust(t, false, 4, testChainGen, nil, nil, db)
+	bc := pm.blockchain.(*core.BlockChain)
+	peer, _ := newTestPeer(t, "peer", protocol, pm, true)
+	defer peer.close()
+
+	// Store the lists of a single block, the others are skipped
+	block := bc.GetBlockByNumber(2)
+	topology := &types.Topology{MinerList: []election.NodeInfo{{ID: "01", IP: "192.168.3.95"}}}
+	rawdb.WriteTopology(db, block.Hash(), block.NumberU64(), topology)
+
+	hashes := []common.Hash{}
+	for i := uint64(0); i <= bc.CurrentBlock().NumberU64(); i++ {
+		hashes = append(hashes, bc.GetBlockByNumber(i).Hash())
+	}
+	// Send the hash request and verify the response
+	cost := peer.GetRequestCost(GetTopologyMsg, len(hashes))
+	sendRequest(peer.app, GetTopologyMsg, 42, cost, hashes)
+	if err := expectResponse(peer.app, TopologyMsg, 42, testBufLimit, []*types.Topology{topology}); err != nil {
+		t.Errorf("topologies mismatch: %v", err)
+	}
+}
+
 // Tests that trie merkle proofs can be retrieved
 func TestGetProofsLes1(t *testing.T) { testGetProofs(t, 1) }
 func TestGetProofsLes2(t *testing.T) { testGetProofs(t, 2) }
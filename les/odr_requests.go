@@ -41,6 +41,7 @@ var (
 	errTxHashMismatch      = errors.New("transaction hash mismatch")
 	errUncleHashMismatch   = errors.New("uncle hash mismatch")
 	errReceiptHashMismatch = errors.New("receipt hash mismatch")
+	errTopologyMismatch    = errors.New("topology root mismatch")
 	errDataHashMismatch    = errors.New("data hash mismatch")
 	errCHTHashMismatch     = errors.New("cht hash mismatch")
 	errCHTNumberMismatch   = errors.New("cht number mismatch")
@@ -60,6 +61,8 @@ func LesRequest(req light.OdrRequest) LesOdrRequest {
 		return (*BlockRequest)(r)
 	case *light.ReceiptsRequest:
 		return (*ReceiptsRequest)(r)
+	case *light.TopologyRequest:
+		return (*TopologyRequest)(r)
 	case *light.TrieRequest:
 		return (*TrieRequest)(r)
 	case *light.CodeRequest:
@@ -178,6 +181,58 @@ func (r *ReceiptsRequest) Validate(db ethdb.Database, msg *Msg) error {
 	return nil
 }
 
+// TopologyRequest is the ODR request type for the main node lists of a block
+type TopologyRequest light.TopologyRequest
+
+// GetCost returns the cost of the given ODR request according to the serving
+// peer's cost table (implementation of LesOdrRequest)
+func (r *TopologyRequest) GetCost(peer *peer) uint64 {
+	return peer.GetRequestCost(GetTopologyMsg, 1)
+}
+
+// CanSend tells if a certain peer is suitable for serving the given request
+func (r *TopologyRequest) CanSend(peer *peer) bool {
+	if peer.version < lpv3 {
+		return false
+	}
+	return peer.HasBlock(r.Hash, r.Number)
+}
+
+// Request sends an ODR request to the LES network (implementation of LesOdrRequest)
+func (r *TopologyRequest) Request(reqID uint64, peer *peer) error {
+	peer.Log().Debug("Requesting block topology", "hash", r.Hash)
+	return peer.RequestTopology(reqID, r.GetCost(peer), []common.Hash{r.Hash})
+}
+
+// Valid processes an ODR request reply message from the LES network
+// returns true and stores results in memory if the message was a valid reply
+// to the request (implementation of LesOdrRequest)
+func (r *TopologyRequest) Validate(db ethdb.Database, msg *Msg) error {
+	log.Debug("Validating block topology", "hash", r.Hash)
+
+	// Ensure we have a correct message with a single topology
+	if msg.MsgType != MsgTopology {
+		return errInvalidMessageType
+	}
+	topologies := msg.Obj.([]*types.Topology)
+	if len(topologies) != 1 {
+		return errInvalidEntryCount
+	}
+	topology := topologies[0]
+
+	// Retrieve our stored header and validate the lists against its root
+	header := rawdb.ReadHeader(db, r.Hash, r.Number)
+	if header == nil {
+		return errHeaderUnavailable
+	}
+	if header.TopologyRoot != topology.Hash() {
+		return errTopologyMismatch
+	}
+	// Validations passed, store and return
+	r.Topology = topology
+	return nil
+}
+
 type ProofReq struct {
 	BHash       common.Hash
 	AccKey, Key []byte
@@ -193,7 +248,7 @@ func (r *TrieRequest) GetCost(peer *peer) uint64 {
 	switch peer.version {
 	case lpv1:
 		return peer.GetRequestCost(GetProofsV1Msg, 1)
-	case lpv2:
+	case lpv2, lpv3:
 		return peer.GetRequestCost(GetProofsV2Msg, 1)
 	default:
 		panic(nil)
@@ -353,7 +408,7 @@ func (r *ChtRequest) GetCost(peer *peer) uint64 {
 	switch peer.version {
 	case lpv1:
 		return peer.GetRequestCost(GetHeaderProofsMsg, 1)
-	case lpv2:
+	case lpv2, lpv3:
 		return peer.GetRequestCost(GetHelperTrieProofsMsg, 1)
 	default:
 		panic(nil)
@@ -211,6 +211,12 @@ func (p *peer) SendReceiptsRLP(reqID, bv uint64, receipts []rlp.RawValue) error
 	return sendResponse(p.rw, ReceiptsMsg, reqID, bv, receipts)
 }
 
+// SendTopologyRLP sends a batch of block topologies, corresponding to the ones
+// requested from an already RLP encoded format.
+func (p *peer) SendTopologyRLP(reqID, bv uint64, topologies []rlp.RawValue) error {
+	return sendResponse(p.rw, TopologyMsg, reqID, bv, topologies)
+}
+
 // SendProofs sends a batch of legacy LES/1 merkle proofs, corresponding to the ones requested.
 func (p *peer) SendProofs(reqID, bv uint64, proofs proofsData) error {
 	return sendResponse(p.rw, ProofsV1Msg, reqID, bv, proofs)
@@ -270,13 +276,19 @@ func (p *peer) RequestReceipts(reqID, cost uint64, hashes []common.Hash) error {
 	return sendRequest(p.rw, GetReceiptsMsg, reqID, cost, hashes)
 }
 
+// RequestTopology fetches a batch of block topologies from a remote node.
+func (p *peer) RequestTopology(reqID, cost uint64, hashes []common.Hash) error {
+	p.Log().Debug("Fetching batch of topologies", "count", len(hashes))
+	return sendRequest(p.rw, GetTopologyMsg, reqID, cost, hashes)
+}
+
 // RequestProofs fetches a batch of merkle proofs from a remote node.
 func (p *peer) RequestProofs(reqID, cost uint64, reqs []ProofReq) error {
 	p.Log().Debug("Fetching batch of proofs", "count", len(reqs))
 	switch p.version {
 	case lpv1:
 		return sendRequest(p.rw, GetProofsV1Msg, reqID, cost, reqs)
-	case lpv2:
+	case lpv2, lpv3:
 		return sendRequest(p.rw, GetProofsV2Msg, reqID, cost, reqs)
 	default:
 		panic(nil)
@@ -298,7 +310,7 @@ func (p *peer) RequestHelperTrieProofs(reqID, cost uint64, reqs []HelperTrieReq)
 			reqsV1[i] = ChtReq{ChtNum: (req.TrieIdx + 1) * (light.CHTFrequencyClient / light.CHTFrequencyServer), BlockNum: blockNum, FromLevel: req.FromLevel}
 		}
 		return sendRequest(p.rw, GetHeaderProofsMsg, reqID, cost, reqsV1)
-	case lpv2:
+	case lpv2, lpv3:
 		return sendRequest(p.rw, GetHelperTrieProofsMsg, reqID, cost, reqs)
 	default:
 		panic(nil)
@@ -317,7 +329,7 @@ func (p *peer) SendTxs(reqID, cost uint64, txs types.Transactions) error {
 	switch p.version {
 	case lpv1:
 		return p2p.Send(p.rw, SendTxMsg, txs) // old message format does not include reqID
-	case lpv2:
+	case lpv2, lpv3:
 		return sendRequest(p.rw, SendTxV2Msg, reqID, cost, txs)
 	default:
 		panic(nil)
@@ -129,6 +129,20 @@ func (req *ReceiptsRequest) StoreResult(db ethdb.Database) {
 	rawdb.WriteReceipts(db, req.Hash, req.Number, req.Receipts)
 }
 
+// TopologyRequest is the ODR request type for retrieving the main node lists
+// published by a broadcast block
+type TopologyRequest struct {
+	OdrRequest
+	Hash     common.Hash
+	Number   uint64
+	Topology *types.Topology
+}
+
+// StoreResult stores the retrieved data in local database
+func (req *TopologyRequest) StoreResult(db ethdb.Database) {
+	rawdb.WriteTopology(db, req.Hash, req.Number, req.Topology)
+}
+
 // ChtRequest is the ODR request type for state/storage trie entries
 type ChtRequest struct {
 	OdrRequest
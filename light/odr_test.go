@@ -80,6 +80,8 @@ func (odr *testOdr) Retrieve(ctx context.Context, req OdrRequest) error {
 		if number != nil {
 			req.Receipts = rawdb.ReadReceipts(odr.sdb, req.Hash, *number)
 		}
+	case *TopologyRequest:
+		req.Topology = rawdb.ReadTopology(odr.sdb, req.Hash, req.Number)
 	case *TrieRequest:
 		t, _ := trie.New(req.Id.Root, trie.NewDatabase(odr.sdb))
 		nodes := NewNodeSet()
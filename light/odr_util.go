@@ -153,6 +153,26 @@ func GetBlockReceipts(ctx context.Context, odr OdrBackend, hash common.Hash, num
 	return receipts, nil
 }
 
+// GetTopology retrieves the main node lists published by a block given by its
+// hash. Blocks not committing to any lists have an empty topology.
+func GetTopology(ctx context.Context, odr OdrBackend, hash common.Hash, number uint64) (*types.Topology, error) {
+	if topology := rawdb.ReadTopology(odr.Database(), hash, number); topology != nil {
+		return topology, nil
+	}
+	header := rawdb.ReadHeader(odr.Database(), hash, number)
+	if header == nil {
+		return nil, ErrNoHeader
+	}
+	if !header.HasNodeLists() {
+		return new(types.Topology), nil
+	}
+	r := &TopologyRequest{Hash: hash, Number: number}
+	if err := odr.Retrieve(ctx, r); err != nil {
+		return nil, err
+	}
+	return r.Topology, nil
+}
+
 // GetBlockLogs retrieves the logs generated by the transactions included in a
 // block given by its hash.
 func GetBlockLogs(ctx context.Context, odr OdrBackend, hash common.Hash, number uint64) ([][]*types.Log, error) {
@@ -62,7 +62,7 @@ type TxPool struct {
 	chainDb      ethdb.Database
 	relay        TxRelayBackend
 	head         common.Hash
-	nonce        map[common.Address]uint64            // "pending" nonce
+	nonce        map[accountLane]uint64               // "pending" nonce of each lane of an account
 	pending      map[common.Hash]*types.Transaction   // pending transactions by tx hash
 	mined        map[common.Hash][]*types.Transaction // mined transactions by block hash
 	clearIdx     uint64                               // earliest block nr that can contain mined tx info
@@ -90,7 +90,7 @@ func NewTxPool(config *params.ChainConfig, chain *LightChain, relay TxRelayBacke
 	pool := &TxPool{
 		config:      config,
 		signer:      types.NewEIP155Signer(config.ChainID),
-		nonce:       make(map[common.Address]uint64),
+		nonce:       make(map[accountLane]uint64),
 		pending:     make(map[common.Hash]*types.Transaction),
 		mined:       make(map[common.Hash][]*types.Transaction),
 		quit:        make(chan bool),
@@ -114,21 +114,28 @@ func (pool *TxPool) currentState(ctx context.Context) *state.StateDB {
 	return NewState(ctx, pool.chain.CurrentHeader(), pool.odr)
 }
 
-// GetNonce returns the "pending" nonce of a given address. It always queries
-// the nonce belonging to the latest header too in order to detect if another
-// client using the same key sent a transaction.
-func (pool *TxPool) GetNonce(ctx context.Context, addr common.Address) (uint64, error) {
+// accountLane identifies one of the parallel nonce lanes of an account.
+type accountLane struct {
+	addr common.Address
+	lane uint64
+}
+
+// GetNonce returns the "pending" nonce of a given address on the given lane. It
+// always queries the nonce belonging to the latest header too in order to detect
+// if another client using the same key sent a transaction.
+func (pool *TxPool) GetNonce(ctx context.Context, addr common.Address, lane uint64) (uint64, error) {
 	state := pool.currentState(ctx)
-	nonce := state.GetNonce(addr)
+	nonce := state.GetLaneNonce(addr, lane)
 	if state.Error() != nil {
 		return 0, state.Error()
 	}
-	sn, ok := pool.nonce[addr]
+	key := accountLane{addr, lane}
+	sn, ok := pool.nonce[key]
 	if ok && sn > nonce {
 		nonce = sn
 	}
 	if !ok || sn < nonce {
-		pool.nonce[addr] = nonce
+		pool.nonce[key] = nonce
 	}
 	return nonce, nil
 }
@@ -351,7 +358,7 @@ func (pool *TxPool) validateTx(ctx context.Context, tx *types.Transaction) error
 	}
 	// Last but not least check for nonce errors
 	currentState := pool.currentState(ctx)
-	if n := currentState.GetNonce(from); n > tx.Nonce() {
+	if n := currentState.GetLaneNonce(from, tx.Lane()); n > tx.Nonce() {
 		return core.ErrNonceTooLow
 	}
 
@@ -405,8 +412,8 @@ func (self *TxPool) add(ctx context.Context, tx *types.Transaction) error {
 		nonce := tx.Nonce() + 1
 
 		addr, _ := types.Sender(self.signer, tx)
-		if nonce > self.nonce[addr] {
-			self.nonce[addr] = nonce
+		if key := (accountLane{addr, tx.Lane()}); nonce > self.nonce[key] {
+			self.nonce[key] = nonce
 		}
 
 		// Notify the subscribers. This event is posted in a goroutine
@@ -0,0 +1,100 @@
+package miner
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	maxBatchHeights = 16 // Number of heights beyond the chain head batches are queued for
+	batchKeepDepth  = 16 // Number of heights below the chain head batches are kept for reorgs
+)
+
+var (
+	errStaleBatch       = errors.New("batch for already mined height")
+	errFutureBatch      = errors.New("batch too far ahead of chain head")
+	errUncertifiedBatch = errors.New("batch without vote certificate")
+	errNotLeaderBatch   = errors.New("batch not approved by scheduled leader")
+)
+
+// batchQueue holds the transaction batches approved by the verifier committee
+// until they get packaged into a block at their height.
+type batchQueue struct {
+	batches map[uint64][]*types.VerifiedBatch
+	fed     bool // Whether any batch arrived, i.e. the miner is served by a committee
+
+	mu sync.RWMutex
+}
+
+func newBatchQueue() *batchQueue {
+	return &batchQueue{batches: make(map[uint64][]*types.VerifiedBatch)}
+}
+
+// add queues an approved batch for its height given the current chain head,
+// dropping duplicates of batches already queued.
+func (q *batchQueue) add(batch *types.VerifiedBatch, head uint64) error {
+	if len(batch.Votes) == 0 {
+		return errUncertifiedBatch
+	}
+	if batch.Number <= head {
+		return errStaleBatch
+	}
+	if batch.Number > head+maxBatchHeights {
+		return errFutureBatch
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.fed = true
+	hash := batch.Hash()
+	for _, queued := range q.batches[batch.Number] {
+		if queued.Hash() == hash {
+			return nil
+		}
+	}
+	q.batches[batch.Number] = append(q.batches[batch.Number], batch)
+	return nil
+}
+
+// pending returns the batches approved for a height, and whether the queue is
+// fed by a committee at all.
+func (q *batchQueue) pending(number uint64) ([]*types.VerifiedBatch, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	return q.batches[number], q.fed
+}
+
+// ApprovedTxs implements core.BatchReader, returning the hashes of the
+// transactions approved for a height.
+func (q *batchQueue) ApprovedTxs(number uint64) (map[common.Hash]struct{}, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	batches, ok := q.batches[number]
+	if !ok {
+		return nil, false
+	}
+	approved := make(map[common.Hash]struct{})
+	for _, batch := range batches {
+		for _, tx := range batch.Txs {
+			approved[tx.Hash()] = struct{}{}
+		}
+	}
+	return approved, true
+}
+
+// prune drops the batches too deep below the chain head to be sealed again.
+func (q *batchQueue) prune(head uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for number := range q.batches {
+		if number+batchKeepDepth < head {
+			delete(q.batches, number)
+		}
+	}
+}
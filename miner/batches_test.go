@@ -0,0 +1,51 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Tests that approved batches are only queued for upcoming heights, once each,
+// and pruned as the chain progresses.
+func TestBatchQueue(t *testing.T) {
+	var (
+		queue = newBatchQueue()
+		tx1   = types.NewTransaction(0, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		tx2   = types.NewTransaction(1, common.Address{0x01}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		votes = []types.BatchVote{{Result: true, NodeId: "01"}}
+	)
+	if _, fed := queue.pending(11); fed {
+		t.Fatalf("empty queue reported as fed")
+	}
+	if err := queue.add(&types.VerifiedBatch{Number: 11, Txs: types.Transactions{tx1}}, 10); err != errUncertifiedBatch {
+		t.Fatalf("uncertified batch: error mismatch: have %v, want %v", err, errUncertifiedBatch)
+	}
+	if err := queue.add(&types.VerifiedBatch{Number: 10, Txs: types.Transactions{tx1}, Votes: votes}, 10); err != errStaleBatch {
+		t.Fatalf("stale batch: error mismatch: have %v, want %v", err, errStaleBatch)
+	}
+	if err := queue.add(&types.VerifiedBatch{Number: 11 + maxBatchHeights, Txs: types.Transactions{tx1}, Votes: votes}, 10); err != errFutureBatch {
+		t.Fatalf("future batch: error mismatch: have %v, want %v", err, errFutureBatch)
+	}
+	for _, txs := range []types.Transactions{{tx1}, {tx1}, {tx2}} {
+		if err := queue.add(&types.VerifiedBatch{Number: 11, Txs: txs, Votes: votes}, 10); err != nil {
+			t.Fatalf("failed to queue batch: %v", err)
+		}
+	}
+	if batches, fed := queue.pending(11); !fed || len(batches) != 2 {
+		t.Fatalf("pending batches mismatch: have %d (fed %v), want 2", len(batches), fed)
+	}
+	approved, ok := queue.ApprovedTxs(11)
+	if _, has := approved[tx2.Hash()]; !ok || len(approved) != 2 || !has {
+		t.Fatalf("approved transactions mismatch: have %v", approved)
+	}
+	if _, ok := queue.ApprovedTxs(12); ok {
+		t.Fatalf("transactions approved for height without batch")
+	}
+	queue.prune(11 + batchKeepDepth + 1)
+	if _, ok := queue.ApprovedTxs(11); ok {
+		t.Fatalf("batch not pruned below keep depth")
+	}
+}
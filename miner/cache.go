@@ -6,7 +6,6 @@ import (
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/log"
-	"github.com/ethereum/go-ethereum/core/types"
 )
 
 type broadcastCache struct {
@@ -70,34 +69,3 @@ func (cache *broadcastCache) update(info *BroadcastInfo)  {
 
 	return
 }
-
-func  (cache *broadcastCache) writeCacheToHeader(h *types.Header) {
-	cache.mu.Lock()
-	defer cache.mu.Unlock()
-
-	headNumber := h.Number.Uint64()
-	if headNumber == cache.BlockNum {
-		// write broadcast block with main node list info
-		cache.copyNodeListToHeader(h)
-	} else {
-		log.Warn("broadcastCache log: broadcastCache is incorrect", "curNumber", headNumber, "cacheNumber", cache.BlockNum)
-		//todo 异常情况处理,暂时使用旧的的广播信息组建广播区块
-		cache.copyNodeListToHeader(h)
-	}
-
-	return
-}
-
-func (cache *broadcastCache) copyNodeListToHeader(h *types.Header) {
-	h.MinerList = make([]election.NodeInfo, 0, len(cache.NodeList.MinerList))
-	h.MinerList = append(h.MinerList, cache.NodeList.MinerList...)
-
-	h.CommitteeList = make([]election.NodeInfo, 0, len(cache.NodeList.CommitteeList))
-	h.CommitteeList = append(h.CommitteeList, cache.NodeList.CommitteeList...)
-
-	h.Both = make([]election.NodeInfo, 0, len(cache.NodeList.Both))
-	h.Both = append(h.Both, cache.NodeList.Both...)
-
-	h.OfflineList = make([]election.NodeInfo, 0, len(cache.NodeList.OfflineList))
-	h.OfflineList = append(h.OfflineList, cache.NodeList.OfflineList...)
-}
\ No newline at end of file
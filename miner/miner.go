@@ -162,6 +162,35 @@ func (self *Miner) SetExtra(extra []byte) error {
 	return nil
 }
 
+// AddVerifiedBatch queues a transaction batch approved by the verifier committee
+// to be packaged into the block at its height. The batch has to be approved by
+// the leader scheduled for the height and carry the signed votes certifying it
+// to the chain.
+func (self *Miner) AddVerifiedBatch(batch *types.VerifiedBatch) error {
+	chain := self.eth.BlockChain()
+	leader, err := chain.Leader(batch.Number)
+	if err != nil {
+		return err
+	}
+	if !batch.ApprovedBy(leader.ID) {
+		return errNotLeaderBatch
+	}
+	committee, _, err := chain.Committee(batch.Number)
+	if err != nil {
+		return err
+	}
+	if err := core.VerifyBatchVotes(committee, batch.Number, batch.Hash(), batch.Votes); err != nil {
+		return err
+	}
+	return self.worker.batches.add(batch, chain.CurrentBlock().NumberU64())
+}
+
+// Batches returns the approved transaction batches the local blocks are sealed
+// from.
+func (self *Miner) Batches() core.BatchReader {
+	return self.worker.batches
+}
+
 // Pending returns the currently pending block and associated state.
 func (self *Miner) Pending() (*types.Block, *state.StateDB) {
 	return self.worker.pending()
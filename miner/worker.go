@@ -127,6 +127,7 @@ type worker struct {
 	possibleUncles map[common.Hash]*types.Block
 
 	unconfirmed *unconfirmedBlocks // set of locally mined blocks pending canonicalness confirmations
+	batches     *batchQueue        // transaction batches approved by the verifier committee
 
 	// atomic status counters
 	mining int32
@@ -150,6 +151,7 @@ func newWorker(config *params.ChainConfig, engine consensus.Engine, coinbase com
 		coinbase:       coinbase,
 		agents:         make(map[Agent]struct{}),
 		unconfirmed:    newUnconfirmedBlocks(eth.BlockChain(), miningLogAtDepth),
+		batches:        newBatchQueue(),
 	}
 	// Subscribe NewTxsEvent for tx pool
 	worker.txsSub = eth.TxPool().SubscribeNewTxsEvent(worker.txsCh)
@@ -251,7 +253,8 @@ func (self *worker) update() {
 		// A real event arrived, process interesting content
 		select {
 		// Handle ChainHeadEvent
-		case <-self.chainHeadCh:
+		case ev := <-self.chainHeadCh:
+			self.batches.prune(ev.Block.NumberU64())
 			self.commitNewWork()
 
 		// Handle ChainSideEvent
@@ -369,9 +372,14 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 	if err != nil {
 		return err
 	}
+	// Extended transactions have to be signed by the PTC signer past its fork
+	var signer types.Signer = types.NewEIP155Signer(self.config.ChainId)
+	if self.config.IsPTCSigner(header.Number) {
+		signer = types.NewPTCSigner(self.config.ChainId)
+	}
 	work := &Work{
 		config:    self.config,
-		signer:    types.NewEIP155Signer(self.config.ChainId),
+		signer:    signer,
 		state:     state,
 		ancestors: set.New(),
 		family:    set.New(),
@@ -433,6 +441,11 @@ func (self *worker) commitNewWork() {
 		log.Error("Failed to prepare header for mining", "err", err)
 		return
 	}
+	// Broadcast blocks list the main nodes derived from the candidate set
+	if err := self.chain.SetNodeLists(header); err != nil {
+		log.Error("Failed to derive main node lists", "number", header.Number, "err", err)
+		return
+	}
 	// If we are care about TheDAO hard-fork check whether to override the extra-data or not
 	if daoBlock := self.config.DAOForkBlock; daoBlock != nil {
 		// Check whether the block is among the fork extra-override range
@@ -466,9 +479,21 @@ func (self *worker) commitNewWork() {
 	//listN := work.commitTransactions(self.mux, txs, self.chain, self.coinbase)
 	//log.Info("====hezi=====","processTransactions listN",listN)
 
-	txpool := self.eth.TxPool()
-	listN := work.processTransactions(self.mux, txpool, self.chain, self.coinbase)
-	log.Info("====hezi=====","processTransactions listN",listN)
+	// Miners served by a verifier committee package exactly an approved batch,
+	// certified to the chain by the committee votes
+	if batches, fed := self.batches.pending(header.Number.Uint64()); fed {
+		batch := work.commitBatches(self.mux, batches, self.chain, self.coinbase)
+		if engine, ok := self.engine.(consensus.Certified); ok && batch != nil && len(batch.Txs) > 0 && !params.IsBroadcastNumber(header.Number.Uint64()) {
+			if err := engine.Certify(header, batch.Votes); err != nil {
+				log.Error("Failed to certify approved batch", "number", header.Number, "err", err)
+				return
+			}
+		}
+	} else {
+		txpool := self.eth.TxPool()
+		listN := work.processTransactions(self.mux, txpool, self.chain, self.coinbase)
+		log.Info("====hezi=====","processTransactions listN",listN)
+	}
 
 	// compute uncles for the new block.
 	var (
@@ -638,6 +663,52 @@ func (env *Work) commitTransaction(tx *types.Transaction, bc *core.BlockChain, c
 
 	return nil, receipt.Logs
 }
+// commitBatches packs the first of the batches approved for the block whose
+// transactions all apply, so the transaction root of the block is the root the
+// committee votes sign. It returns the packed batch, nil if none applies and the
+// block is left empty.
+func (env *Work) commitBatches(mux *event.TypeMux, batches []*types.VerifiedBatch, bc *core.BlockChain, coinbase common.Address) *types.VerifiedBatch {
+	if env.gasPool == nil {
+		env.gasPool = new(core.GasPool).AddGas(env.header.GasLimit)
+	}
+	for _, batch := range batches {
+		if env.commitBatch(batch, bc, coinbase) {
+			if env.tcount > 0 {
+				go mux.Post(core.PendingStateEvent{})
+			}
+			return batch
+		}
+	}
+	return nil
+}
+
+// commitBatch applies all transactions of a batch, reverting the work to its
+// previous state if any of them fails.
+func (env *Work) commitBatch(batch *types.VerifiedBatch, bc *core.BlockChain, coinbase common.Address) bool {
+	var (
+		snap     = env.state.Snapshot()
+		gas      = env.gasPool.Gas()
+		gasUsed  = env.header.GasUsed
+		txs      = len(env.txs)
+		receipts = len(env.receipts)
+		tcount   = env.tcount
+	)
+	for _, tx := range batch.Txs {
+		env.state.Prepare(tx.Hash(), common.Hash{}, env.tcount)
+		if err, _ := env.commitTransaction(tx, bc, coinbase, env.gasPool); err != nil {
+			log.Debug("Approved batch failed to apply", "number", batch.Number, "batch", batch.Hash(), "hash", tx.Hash(), "err", err)
+
+			env.state.RevertToSnapshot(snap)
+			*env.gasPool = core.GasPool(gas)
+			env.header.GasUsed = gasUsed
+			env.txs, env.receipts, env.tcount = env.txs[:txs], env.receipts[:receipts], tcount
+			return false
+		}
+		env.tcount++
+	}
+	return true
+}
+
 //==============================================================================//
 //Leader
 func (self *Work) processTransactions(mux *event.TypeMux, tp *core.TxPool, bc *core.BlockChain, coinbase common.Address) []uint32{
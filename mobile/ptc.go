@@ -0,0 +1,362 @@
+// Contains the wrappers of the PTC extended, election and delegation
+// transactions, and of the PTC RPC namespace.
+
+package geth
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Transfer is one of the one-to-many transfers of an extended transaction.
+type Transfer struct {
+	transfer ethclient.Transfer
+}
+
+// NewTransfer creates a transfer paying amount to the given recipient.
+func NewTransfer(to *Address, amount *BigInt, data []byte) *Transfer {
+	return &Transfer{ethclient.Transfer{To: to.address, Value: new(big.Int).Set(amount.bigint), Data: common.CopyBytes(data)}}
+}
+
+func (t *Transfer) GetTo() *Address   { return &Address{t.transfer.To} }
+func (t *Transfer) GetValue() *BigInt { return &BigInt{t.transfer.Value} }
+func (t *Transfer) GetData() []byte   { return t.transfer.Data }
+
+// Transfers represents a slice of transfers.
+type Transfers struct{ transfers []ethclient.Transfer }
+
+// NewTransfers creates a slice of uninitialized transfers.
+func NewTransfers(size int) *Transfers {
+	return &Transfers{
+		transfers: make([]ethclient.Transfer, size),
+	}
+}
+
+// NewTransfersEmpty creates an empty slice of Transfer values.
+func NewTransfersEmpty() *Transfers {
+	return NewTransfers(0)
+}
+
+// Size returns the number of transfers in the slice.
+func (t *Transfers) Size() int {
+	return len(t.transfers)
+}
+
+// Get returns the transfer at the given index from the slice.
+func (t *Transfers) Get(index int) (transfer *Transfer, _ error) {
+	if index < 0 || index >= len(t.transfers) {
+		return nil, errors.New("index out of bounds")
+	}
+	return &Transfer{t.transfers[index]}, nil
+}
+
+// Set sets the transfer at the given index in the slice.
+func (t *Transfers) Set(index int, transfer *Transfer) error {
+	if index < 0 || index >= len(t.transfers) {
+		return errors.New("index out of bounds")
+	}
+	t.transfers[index] = transfer.transfer
+	return nil
+}
+
+// Append adds a new transfer element to the end of the slice.
+func (t *Transfers) Append(transfer *Transfer) {
+	t.transfers = append(t.transfers, transfer.transfer)
+}
+
+// NewExtendedTransaction creates a transaction paying amount to the given
+// recipient and every transfer to its own recipient, recording lockHeight in
+// its extension.
+func NewExtendedTransaction(nonce int64, to *Address, amount *BigInt, gasLimit int64, gasPrice *BigInt, data []byte, transfers *Transfers, lockHeight int64) *Transaction {
+	legs := make([]*types.ExtraTo_tr, len(transfers.transfers))
+	for i, transfer := range transfers.transfers {
+		to, input := transfer.To, hexutil.Bytes(common.CopyBytes(transfer.Data))
+		legs[i] = &types.ExtraTo_tr{To_tr: &to, Value_tr: (*hexutil.Big)(new(big.Int).Set(transfer.Value)), Input_tr: &input}
+	}
+	return &Transaction{types.NewTransactions(uint64(nonce), to.address, amount.bigint, uint64(gasLimit), gasPrice.bigint, common.CopyBytes(data), legs, uint64(lockHeight), 0)}
+}
+
+// IsExtended reports whether the transaction carries a PTC transaction extension.
+func (tx *Transaction) IsExtended() bool { return len(tx.tx.GetMatrix_EX()) > 0 }
+
+// GetTransfers returns the one-to-many transfers of an extended transaction.
+func (tx *Transaction) GetTransfers() *Transfers {
+	transfers := NewTransfersEmpty()
+	for _, ex := range tx.tx.GetMatrix_EX() {
+		for _, leg := range ex.ExtraTo {
+			transfer := ethclient.Transfer{Value: leg.Amount, Data: leg.Payload}
+			if leg.Recipient != nil {
+				transfer.To = *leg.Recipient
+			}
+			transfers.transfers = append(transfers.transfers, transfer)
+		}
+	}
+	return transfers
+}
+
+// GetLockHeight returns the lock height recorded in the extension of an extended
+// transaction. It is signed, but not enforced by the protocol.
+func (tx *Transaction) GetLockHeight() int64 {
+	if extra := tx.tx.GetMatrix_EX(); len(extra) > 0 {
+		return int64(extra[0].LockHeight)
+	}
+	return 0
+}
+
+// GetPTCSigHash returns the hash the PTC signer of the given chain signs, which
+// also covers the extension of an extended transaction. Chains past their PTC
+// signer fork only accept extended transactions signed over this hash.
+func (tx *Transaction) GetPTCSigHash(chainID *BigInt) *Hash {
+	return &Hash{types.NewPTCSigner(chainID.bigint).Hash(tx.tx)}
+}
+
+// WithPTCSignature attaches a signature over GetPTCSigHash to the transaction.
+func (tx *Transaction) WithPTCSignature(sig []byte, chainID *BigInt) (signedTx *Transaction, _ error) {
+	rawTx, err := tx.tx.WithSignature(types.NewPTCSigner(chainID.bigint), common.CopyBytes(sig))
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{rawTx}, nil
+}
+
+// electTypes maps the election type names to the election transaction types.
+var electTypes = map[string]uint32{
+	"miner":     types.ElectMiner,
+	"committee": types.ElectCommittee,
+	"both":      types.ElectBoth,
+	"exit":      types.ElectExit,
+}
+
+// ElectionPayload is the node information of an election transaction, standing
+// for election or withdrawing from it.
+type ElectionPayload struct {
+	info types.ElectionTxPayLoadInfo
+}
+
+// NewElectionPayload creates the payload of an election transaction. The type
+// is one of "miner", "committee", "both" or "exit".
+func NewElectionPayload(electType string, ip string, tps int, wealth int64, onlineTime int64) (*ElectionPayload, error) {
+	marker, ok := electTypes[electType]
+	if !ok {
+		return nil, fmt.Errorf("unknown election type %q", electType)
+	}
+	return &ElectionPayload{types.ElectionTxPayLoadInfo{
+		ElectType:  marker,
+		IP:         ip,
+		TPS:        uint32(tps),
+		Wealth:     uint64(wealth),
+		OnlineTime: uint64(onlineTime),
+	}}, nil
+}
+
+// Sign signs the payload with the raw private key of the node, on behalf of
+// the account sending the election transaction.
+func (p *ElectionPayload) Sign(from *Address, nodeKey []byte) error {
+	key, err := crypto.ToECDSA(nodeKey)
+	if err != nil {
+		return err
+	}
+	return p.info.Sign(from.address, key)
+}
+
+// GetNodeID returns the hex encoded ID of the node the payload was signed by.
+func (p *ElectionPayload) GetNodeID() string { return p.info.ID }
+
+// Encode encodes the payload into the data of an election transaction.
+func (p *ElectionPayload) Encode() ([]byte, error) {
+	return types.EncodeElectionTxPayLoad(&p.info)
+}
+
+// NewElectionTransaction creates an election transaction depositing the given
+// amount with the deposit account. Exits deposit nothing.
+func NewElectionTransaction(nonce int64, deposit *BigInt, payload []byte, gasLimit int64, gasPrice *BigInt) *Transaction {
+	return &Transaction{types.NewTransaction(uint64(nonce), common.HexToAddress(params.HypothecatedAccount), deposit.bigint, uint64(gasLimit), gasPrice.bigint, common.CopyBytes(payload))}
+}
+
+// NewDelegationTransaction creates a transaction bonding amount to the given
+// candidate node.
+func NewDelegationTransaction(nonce int64, nodeID string, amount *BigInt, gasLimit int64, gasPrice *BigInt) (*Transaction, error) {
+	tx, err := types.NewDelegationTransaction(uint64(nonce), common.HexToAddress(params.HypothecatedAccount), types.DelegateStake, nodeID, amount.bigint, uint64(gasLimit), gasPrice.bigint)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{tx}, nil
+}
+
+// NewUndelegationTransaction creates a transaction unbonding amount from the
+// given candidate node.
+func NewUndelegationTransaction(nonce int64, nodeID string, amount *BigInt, gasLimit int64, gasPrice *BigInt) (*Transaction, error) {
+	tx, err := types.NewDelegationTransaction(uint64(nonce), common.HexToAddress(params.HypothecatedAccount), types.UndelegateStake, nodeID, amount.bigint, uint64(gasLimit), gasPrice.bigint)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{tx}, nil
+}
+
+// Candidate is a node standing for election in a candidate period.
+type Candidate struct {
+	candidate *ethclient.Candidate
+}
+
+func (c *Candidate) GetID() string        { return c.candidate.ID }
+func (c *Candidate) GetIP() string        { return c.candidate.IP }
+func (c *Candidate) GetAccount() *Address { return &Address{c.candidate.Account} }
+func (c *Candidate) GetType() string      { return c.candidate.Type }
+func (c *Candidate) GetTPS() int64        { return int64(c.candidate.TPS) }
+func (c *Candidate) GetWealth() int64     { return int64(c.candidate.Wealth) }
+func (c *Candidate) GetOnlineTime() int64 { return int64(c.candidate.OnlineTime) }
+
+// GetDeposit returns the deposit made by the election transaction, in wei.
+func (c *Candidate) GetDeposit() *BigInt {
+	if c.candidate.Deposit == nil {
+		return &BigInt{new(big.Int)}
+	}
+	return &BigInt{c.candidate.Deposit.ToInt()}
+}
+
+// Candidates is the candidate set of a period.
+type Candidates struct {
+	candidates *ethclient.Candidates
+}
+
+func (c *Candidates) GetPeriod() int64      { return int64(c.candidates.Period) }
+func (c *Candidates) GetBlockNumber() int64 { return int64(c.candidates.BlockNumber) }
+func (c *Candidates) IsClosed() bool        { return c.candidates.Closed }
+
+// Size returns the number of candidates in the set.
+func (c *Candidates) Size() int {
+	return len(c.candidates.Candidates)
+}
+
+// Get returns the candidate at the given index from the set.
+func (c *Candidates) Get(index int) (candidate *Candidate, _ error) {
+	if index < 0 || index >= len(c.candidates.Candidates) {
+		return nil, errors.New("index out of bounds")
+	}
+	return &Candidate{c.candidates.Candidates[index]}, nil
+}
+
+// Find returns the candidate registered by the given account, or nil if the
+// account does not stand for election.
+func (c *Candidates) Find(account *Address) *Candidate {
+	for _, candidate := range c.candidates.Candidates {
+		if candidate.Account == account.address {
+			return &Candidate{candidate}
+		}
+	}
+	return nil
+}
+
+// Leader is the verifier committee leader scheduled for a height.
+type Leader struct {
+	leader *ethclient.Leader
+}
+
+func (l *Leader) GetBlockNumber() int64    { return int64(l.leader.BlockNumber) }
+func (l *Leader) GetID() string            { return l.leader.ID }
+func (l *Leader) GetIP() string            { return l.leader.IP }
+func (l *Leader) GetAccount() *Address     { return &Address{l.leader.Account} }
+func (l *Leader) GetCommitteeBlock() int64 { return int64(l.leader.CommitteeBlock) }
+
+// ElectionRecord is the outcome of the elections held for a candidate period.
+type ElectionRecord struct {
+	record *ethclient.ElectionRecord
+}
+
+func (r *ElectionRecord) GetPeriod() int64 { return int64(r.record.Period) }
+
+// seat returns the seat a node got in the last of the elections held for a
+// period: "principal", "backup", "candidate" or an empty string if it was not
+// elected.
+func seat(outcomes []*ethclient.ElectionOutcome, nodeID string) string {
+	if len(outcomes) == 0 {
+		return ""
+	}
+	outcome := outcomes[len(outcomes)-1]
+	names := []string{"principal", "backup", "candidate"}
+	for i, seats := range [][]*ethclient.ElectionSeat{outcome.Principal, outcome.Backup, outcome.Candidates} {
+		for _, seat := range seats {
+			if seat.NodeID == nodeID {
+				return names[i]
+			}
+		}
+	}
+	return ""
+}
+
+// GetMinerSeat returns the seat the given node got in the last miner election:
+// "principal", "backup", "candidate" or an empty string if it was not elected.
+func (r *ElectionRecord) GetMinerSeat(nodeID string) string { return seat(r.record.Miner, nodeID) }
+
+// GetValidatorSeat returns the seat the given node got in the last validator
+// election: "principal", "backup", "candidate" or an empty string.
+func (r *ElectionRecord) GetValidatorSeat(nodeID string) string {
+	return seat(r.record.Validator, nodeID)
+}
+
+// Delegations is the stake an account bonded to candidate nodes.
+type Delegations struct {
+	delegations *ethclient.Delegations
+}
+
+func (d *Delegations) GetBlockNumber() int64 { return int64(d.delegations.BlockNumber) }
+
+// GetBonded returns the stake bonded to the given node, or to all nodes if the
+// node ID is empty.
+func (d *Delegations) GetBonded(nodeID string) *BigInt {
+	total := new(big.Int)
+	for _, bond := range d.delegations.Bonds {
+		if nodeID == "" || bond.NodeID == nodeID {
+			total.Add(total, bond.Amount.ToInt())
+		}
+	}
+	return &BigInt{total}
+}
+
+// GetUnbonding returns the stake unbonding from all nodes, paid back once the
+// unbonding period is over.
+func (d *Delegations) GetUnbonding() *BigInt {
+	total := new(big.Int)
+	for _, unbonding := range d.delegations.Unbondings {
+		total.Add(total, unbonding.Amount.ToInt())
+	}
+	return &BigInt{total}
+}
+
+// GetCandidates returns the election candidate set of the given period.
+func (ec *EthereumClient) GetCandidates(ctx *Context, period int64) (candidates *Candidates, _ error) {
+	rawCandidates, err := ec.client.CandidatesAt(ctx.context, uint64(period))
+	return &Candidates{rawCandidates}, err
+}
+
+// GetLeader returns the verifier committee leader scheduled for the given height.
+func (ec *EthereumClient) GetLeader(ctx *Context, height int64) (leader *Leader, _ error) {
+	rawLeader, err := ec.client.LeaderAt(ctx.context, uint64(height))
+	return &Leader{rawLeader}, err
+}
+
+// GetElectionRecord returns the outcome of the elections held for the given
+// candidate period.
+func (ec *EthereumClient) GetElectionRecord(ctx *Context, period int64) (record *ElectionRecord, _ error) {
+	rawRecord, err := ec.client.ElectionRecordAt(ctx.context, uint64(period))
+	return &ElectionRecord{rawRecord}, err
+}
+
+// GetDelegations returns the stake the given account bonded to candidate nodes.
+// If number is <0, the delegations of the latest known block are returned.
+func (ec *EthereumClient) GetDelegations(ctx *Context, account *Address, number int64) (delegations *Delegations, _ error) {
+	if number < 0 {
+		rawDelegations, err := ec.client.DelegationsOf(ctx.context, account.address, nil)
+		return &Delegations{rawDelegations}, err
+	}
+	rawDelegations, err := ec.client.DelegationsOf(ctx.context, account.address, big.NewInt(number))
+	return &Delegations{rawDelegations}, err
+}
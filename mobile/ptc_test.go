@@ -0,0 +1,67 @@
+package geth
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Tests that extended transactions signed through the PTC signature hash
+// survive an RLP round trip with their sender, transfers and lock height, and
+// that plain transactions keep being signed and recovered with EIP155.
+func TestExtendedTransactionRoundTrip(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	chainID := NewBigInt(300)
+
+	to, payee := &Address{common.Address{0x01}}, &Address{common.Address{0x02}}
+	transfers := NewTransfersEmpty()
+	transfers.Append(NewTransfer(payee, NewBigInt(100), []byte{0xca, 0xfe}))
+
+	tx := NewExtendedTransaction(1, to, NewBigInt(50), 100000, NewBigInt(1), nil, transfers, 42)
+	sig, err := crypto.Sign(tx.GetPTCSigHash(chainID).GetBytes(), key)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if tx, err = tx.WithPTCSignature(sig, chainID); err != nil {
+		t.Fatalf("failed to attach signature: %v", err)
+	}
+	blob, err := tx.EncodeRLP()
+	if err != nil {
+		t.Fatalf("failed to encode transaction: %v", err)
+	}
+	dec, err := NewTransactionFromRLP(blob)
+	if err != nil {
+		t.Fatalf("failed to decode transaction: %v", err)
+	}
+	if sender, err := dec.GetFrom(chainID); err != nil || sender.address != from {
+		t.Errorf("sender mismatch: have %x (%v), want %x", sender.address, err, from)
+	}
+	if !dec.IsExtended() {
+		t.Errorf("decoded transaction not extended")
+	}
+	if have := dec.GetLockHeight(); have != 42 {
+		t.Errorf("lock height mismatch: have %d, want 42", have)
+	}
+	legs := dec.GetTransfers()
+	if legs.Size() != 1 {
+		t.Fatalf("transfer count mismatch: have %d, want 1", legs.Size())
+	}
+	leg, _ := legs.Get(0)
+	if leg.GetTo().address != payee.address || leg.GetValue().GetInt64() != 100 || common.Bytes2Hex(leg.GetData()) != "cafe" {
+		t.Errorf("transfer mismatch: %+v", leg.transfer)
+	}
+	// Plain transactions are signed and recovered with EIP155
+	plain := NewTransaction(2, to, NewBigInt(50), 21000, NewBigInt(1), nil)
+	if sig, err = crypto.Sign(types.NewEIP155Signer(chainID.bigint).Hash(plain.tx).Bytes(), key); err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	if plain, err = plain.WithSignature(sig, chainID); err != nil {
+		t.Fatalf("failed to attach signature: %v", err)
+	}
+	if sender, err := plain.GetFrom(chainID); err != nil || sender.address != from {
+		t.Errorf("plain sender mismatch: have %x (%v), want %x", sender.address, err, from)
+	}
+}
@@ -254,6 +254,9 @@ func (tx *Transaction) GetFrom(chainID *BigInt) (address *Address, _ error) {
 	var signer types.Signer = types.HomesteadSigner{}
 	if chainID != nil {
 		signer = types.NewEIP155Signer(chainID.bigint)
+		if tx.tx.PTCSigned() {
+			signer = types.NewPTCSigner(chainID.bigint)
+		}
 	}
 	from, err := types.Sender(signer, tx.tx)
 	return &Address{from}, err
@@ -22,6 +22,7 @@ import (
 	"net"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/mclock"
@@ -107,6 +108,9 @@ type Peer struct {
 	closed   chan struct{}
 	disc     chan DiscReason
 
+	pingSent int64 // mclock time of the unanswered ping, zero if none (atomic)
+	latency  int64 // Smoothed ping round trip in nanoseconds (atomic)
+
 	// events receives message send / receive events if set
 	events *event.Feed
 }
@@ -160,6 +164,12 @@ func (p *Peer) String() string {
 	return fmt.Sprintf("Peer %x %v", p.rw.id[:8], p.RemoteAddr())
 }
 
+// Latency returns the smoothed round trip time of the base protocol pings, or
+// zero if no ping was answered yet.
+func (p *Peer) Latency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&p.latency))
+}
+
 // Inbound returns true if the peer is an inbound connection
 func (p *Peer) Inbound() bool {
 	return p.rw.flags&inboundConn != 0
@@ -240,6 +250,9 @@ func (p *Peer) pingLoop() {
 	for {
 		select {
 		case <-ping.C:
+			// A ping still unanswered after a full interval is taken as lost,
+			// so the measurement restarts with every ping
+			atomic.StoreInt64(&p.pingSent, int64(mclock.Now()))
 			if err := SendItems(p.rw, pingMsg); err != nil {
 				p.protoErr <- err
 				return
@@ -272,6 +285,11 @@ func (p *Peer) handle(msg Msg) error {
 	case msg.Code == pingMsg:
 		msg.Discard()
 		go SendItems(p.rw, pongMsg)
+	case msg.Code == pongMsg:
+		msg.Discard()
+		if sent := atomic.SwapInt64(&p.pingSent, 0); sent != 0 {
+			p.measured(time.Duration(int64(mclock.Now()) - sent))
+		}
 	case msg.Code == discMsg:
 		var reason [1]DiscReason
 		// This is the last message. We don't need to discard or
@@ -297,6 +315,14 @@ func (p *Peer) handle(msg Msg) error {
 	return nil
 }
 
+// measured folds a ping round trip into the smoothed latency of the peer.
+func (p *Peer) measured(rtt time.Duration) {
+	if old := atomic.LoadInt64(&p.latency); old != 0 {
+		rtt = (7*time.Duration(old) + rtt) / 8
+	}
+	atomic.StoreInt64(&p.latency, int64(rtt))
+}
+
 func countMatchingProtocols(protocols []Protocol, caps []Cap) int {
 	n := 0
 	for _, cap := range caps {
@@ -424,7 +450,9 @@ type PeerInfo struct {
 		Trusted       bool   `json:"trusted"`
 		Static        bool   `json:"static"`
 	} `json:"network"`
-	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+	Role      string                 `json:"role,omitempty"`    // Role of the peer in the network topology
+	Latency   string                 `json:"latency,omitempty"` // Smoothed ping round trip, if measured
+	Protocols map[string]interface{} `json:"protocols"`         // Sub-protocol specific metadata fields
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -446,6 +474,9 @@ func (p *Peer) Info() *PeerInfo {
 	info.Network.Inbound = p.rw.is(inboundConn)
 	info.Network.Trusted = p.rw.is(trustedConn)
 	info.Network.Static = p.rw.is(staticDialedConn)
+	if latency := p.Latency(); latency != 0 {
+		info.Latency = latency.String()
+	}
 
 	// Gather all the running protocol infos
 	for _, proto := range p.running {
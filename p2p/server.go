@@ -141,6 +141,10 @@ type Config struct {
 
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:",omitempty"`
+
+	// TopologyPolicy decides on the links maintained within the network
+	// topology. If nil, DefaultTopologyPolicy is used.
+	TopologyPolicy TopologyPolicy `toml:"-"`
 }
 
 // Server ptcages all peer connections.
@@ -172,8 +176,9 @@ type Server struct {
 	posthandshake chan *conn
 	addpeer       chan *conn
 	delpeer       chan peerDrop
-	loopWG        sync.WaitGroup // loop, listenLoop
+	loopWG        sync.WaitGroup // loop, listenLoop, topology
 	peerFeed      event.Feed
+	topology      *topology
 	log           log.Logger
 }
 
@@ -300,6 +305,18 @@ func (srv *Server) RemovePeer(node *discover.Node) {
 	}
 }
 
+// UpdateTopology hands a new role assignment of the network topology to the
+// topology policy, which adjusts the peer links accordingly.
+func (srv *Server) UpdateTopology(update TopologyUpdate) {
+	if srv.topology == nil {
+		return
+	}
+	select {
+	case srv.topology.update <- update:
+	case <-srv.quit:
+	}
+}
+
 // SubscribePeers subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -558,9 +575,12 @@ func (srv *Server) Start() (err error) {
 		srv.log.Warn("P2P server will be useless, neither dialing nor listening")
 	}
 
-	srv.loopWG.Add(1)
+	srv.topology = newTopology(srv, srv.TopologyPolicy)
+
+	srv.loopWG.Add(2)
 	go receiveudp()
 	go srv.run(dialer)
+	go srv.topology.loop()
 	srv.running = true
 	Custsrv = srv
 	return nil
@@ -1021,7 +1041,9 @@ func (srv *Server) PeersInfo() []*PeerInfo {
 	infos := make([]*PeerInfo, 0, srv.PeerCount())
 	for _, peer := range srv.Peers() {
 		if peer != nil {
-			infos = append(infos, peer.Info())
+			info := peer.Info()
+			info.Role = roleName(srv.topology.peerRole(peer.ID()))
+			infos = append(infos, info)
 		}
 	}
 	// Sort the result array alphabetically by node identifier
@@ -0,0 +1,547 @@
+// Package ptcsim runs networks of in-memory PTC nodes for tests.
+//
+// The nodes run on the simulation adapter with generated keys and a custom
+// genesis listing the first of them as boot nodes, so no real host, address or
+// boot node is involved. Every node runs the real chain, ptcpos engine and
+// transaction pool: elections go through the candidate index, node lists and
+// master miner schedules through the broadcast blocks, and committee leaders
+// through the leader rotation of the chain.
+//
+// The harness covers the chain side of the protocol only: elections, node
+// lists, master miner schedules, leader rotation and the certified batch votes
+// the chain checks. It does not run the verify, scheduler, random and ca
+// services. Scheduler, random and ca talk through the message center, which is
+// not part of this tree, and all of them share process-wide globals such as the
+// identity of the node in ca and the UDP registry of p2p, so they cannot run as
+// several nodes of one process. The verify module keeps its session state per
+// instance, but still sends through that registry. Running the real services is
+// out of scope until they keep their state per node.
+//
+// The network therefore plays the verifier committee vote itself, with custom
+// messages between the nodes: the leader the chain schedules for a height
+// packages its pending transactions with the packaging of the verify module,
+// the members check them with its validation and sign their votes, and the
+// batch the votes approve by the quorum of the chain goes to the master miner.
+// The miner seals it with the votes like the miner module does, and the chain
+// refuses anything else.
+//
+// Blocks are sealed one height at a time, which keeps scenarios deterministic,
+// and nodes can be killed, restarted and partitioned in between.
+package ptcsim
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/simulations"
+	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+const (
+	serviceName = "ptcsim"
+
+	electionGas = 200000 // Gas limit of the election transactions built by Elect
+	transferGas = 21000  // Gas limit of the transfers built by Transfer
+
+	linkTimeout = 5 * time.Second // Time to wait for a link to come up
+	syncTimeout = 5 * time.Second // Time to wait for a block to reach the linked nodes
+)
+
+var (
+	// ErrSealerDown is returned if the master miner scheduled for the next
+	// height is not running.
+	ErrSealerDown = errors.New("scheduled sealer down")
+
+	// ErrLeaderDown is returned if the committee leader scheduled for a height
+	// is not running.
+	ErrLeaderDown = errors.New("scheduled committee leader down")
+
+	// errNetworkDown is returned if no node of the network is running.
+	errNetworkDown = errors.New("no node running")
+)
+
+// Config is the layout of a simulated network.
+type Config struct {
+	BootNodes int      // Nodes listed in the genesis hierarchy, the first verifies and the others mine
+	Nodes     int      // Further nodes, joining the hierarchy by election only
+	Balance   *big.Int // Genesis balance of every node account (nil = 1M ether)
+}
+
+// Network is a simulated network of PTC nodes. Every node runs a chain of its
+// own and links to every other node unless partitioned or killed.
+type Network struct {
+	config  *params.ChainConfig
+	genesis *core.Genesis
+	sim     *simulations.Network
+	nodes   []*Node
+
+	cuts map[[2]discover.NodeID]bool // Links severed by a partition
+	lock sync.Mutex
+}
+
+// NewNetwork creates a network of stopped nodes with generated keys. The boot
+// nodes are the boot masternodes of its genesis: the first one sits in the
+// committee, the others are the boot miners.
+func NewNetwork(conf Config) (*Network, error) {
+	if conf.BootNodes < 2 {
+		return nil, fmt.Errorf("need at least 2 boot nodes, have %d", conf.BootNodes)
+	}
+	balance := conf.Balance
+	if balance == nil {
+		balance = new(big.Int).Mul(big.NewInt(1000000), big.NewInt(params.Ether))
+	}
+	keys := make([]*ecdsa.PrivateKey, conf.BootNodes+conf.Nodes)
+	for i := range keys {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+	config := *params.TestChainConfig
+	config.Ethash, config.NodeListBlock = nil, big.NewInt(0)
+	config.Ptcpos = new(params.PtcposConfig)
+
+	net := &Network{
+		config: &config,
+		genesis: &core.Genesis{
+			Config:     &config,
+			GasLimit:   8000000,
+			Difficulty: big.NewInt(1),
+			Alloc:      make(core.GenesisAlloc),
+		},
+		cuts: make(map[[2]discover.NodeID]bool),
+	}
+	for i, key := range keys {
+		account := crypto.PubkeyToAddress(key.PublicKey)
+		net.genesis.Alloc[account] = core.GenesisAccount{Balance: balance}
+
+		if i < conf.BootNodes {
+			config.Ptcpos.Bootnodes = append(config.Ptcpos.Bootnodes, discover.NewNode(discover.PubkeyID(&key.PublicKey), nodeIP(i), 30303, 30303).String())
+			if i > 0 {
+				config.Ptcpos.Miners = append(config.Ptcpos.Miners, account)
+			}
+		}
+	}
+	for i, key := range keys {
+		net.nodes = append(net.nodes, newNode(net, i, key))
+	}
+	adapter := adapters.NewSimAdapter(adapters.Services{
+		serviceName: func(ctx *adapters.ServiceContext) (node.Service, error) {
+			n := net.nodeByID(ctx.Config.ID)
+			if n == nil {
+				return nil, fmt.Errorf("unknown node %s", ctx.Config.ID)
+			}
+			return &service{node: n}, nil
+		},
+	})
+	net.sim = simulations.NewNetwork(adapter, &simulations.NetworkConfig{DefaultService: serviceName})
+	for _, n := range net.nodes {
+		_, err := net.sim.NewNodeWithConfig(&adapters.NodeConfig{
+			ID:         n.ID,
+			PrivateKey: n.Key,
+			Name:       n.String(),
+			Services:   []string{serviceName},
+		})
+		if err != nil {
+			net.sim.Shutdown()
+			return nil, err
+		}
+	}
+	return net, nil
+}
+
+// Config returns the chain config of the network.
+func (net *Network) Config() *params.ChainConfig {
+	return net.config
+}
+
+// Nodes returns all nodes of the network, running or not.
+func (net *Network) Nodes() []*Node {
+	return net.nodes
+}
+
+// Node returns the node at the given index.
+func (net *Network) Node(index int) *Node {
+	return net.nodes[index]
+}
+
+// Start starts all nodes and links them with each other.
+func (net *Network) Start() error {
+	for _, n := range net.nodes {
+		if err := net.sim.Start(n.ID); err != nil {
+			return err
+		}
+	}
+	return net.linkAll()
+}
+
+// Shutdown stops all nodes of the network.
+func (net *Network) Shutdown() {
+	net.sim.Shutdown()
+}
+
+// Kill stops a node and waits until all its links are dropped. Its database is
+// kept.
+func (net *Network) Kill(n *Node) error {
+	if err := net.sim.Stop(n.ID); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(linkTimeout)
+	for _, other := range net.nodes {
+		for other.linked(n.ID) {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("%s still linked to killed %s", other, n)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// Restart brings a killed node back up and links it to the running nodes it is
+// not partitioned from, catching up with their chains.
+func (net *Network) Restart(n *Node) error {
+	if err := net.sim.Start(n.ID); err != nil {
+		return err
+	}
+	return net.linkAll()
+}
+
+// Partition severs all links between nodes of different groups. Nodes not in
+// any group keep their links.
+func (net *Network) Partition(groups ...[]*Node) error {
+	for i, group := range groups {
+		for _, other := range groups[i+1:] {
+			for _, one := range group {
+				for _, two := range other {
+					if err := net.cut(one, two); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Heal restores all links severed by partitions.
+func (net *Network) Heal() error {
+	net.lock.Lock()
+	net.cuts = make(map[[2]discover.NodeID]bool)
+	net.lock.Unlock()
+
+	return net.linkAll()
+}
+
+// SendTransaction adds a transaction to the pool of every running node, as if
+// it had been gossiped. Nodes down at the time miss it.
+func (net *Network) SendTransaction(tx *types.Transaction) {
+	for _, n := range net.nodes {
+		if !n.Running() {
+			continue
+		}
+		if err := n.AddTx(tx); err != nil {
+			log.Debug("Simulated transaction rejected", "node", n, "hash", tx.Hash(), "err", err)
+		}
+	}
+}
+
+// Transfer has a node send the given amount from its account.
+func (net *Network) Transfer(n *Node, to common.Address, amount *big.Int) (*types.Transaction, error) {
+	return net.send(n, to, amount, transferGas, nil)
+}
+
+// Elect has a node stand for election of the given type, or exit, by sending a
+// signed election transaction with the given deposit from its account.
+func (net *Network) Elect(n *Node, electType uint32, deposit *big.Int) (*types.Transaction, error) {
+	info := &types.ElectionTxPayLoadInfo{
+		TPS:       1000,
+		IP:        n.IP.String(),
+		ElectType: electType,
+	}
+	if electType != types.ElectExit {
+		info.Wealth = new(big.Int).Div(deposit, big.NewInt(params.Ether)).Uint64()
+	}
+	if err := info.Sign(n.Account, n.Key); err != nil {
+		return nil, err
+	}
+	data, err := types.EncodeElectionTxPayLoad(info)
+	if err != nil {
+		return nil, err
+	}
+	return net.send(n, common.HexToAddress(params.HypothecatedAccount), deposit, electionGas, data)
+}
+
+// send signs a transaction from the account of a node with its next nonce and
+// sends it at the lowest gas price the pools accept.
+func (net *Network) send(n *Node, to common.Address, amount *big.Int, gas uint64, data []byte) (*types.Transaction, error) {
+	n.lock.Lock()
+	nonce := n.nonce
+	n.nonce++
+	n.lock.Unlock()
+
+	tx := types.NewTransaction(nonce, to, amount, gas, new(big.Int).SetUint64(core.DefaultTxPoolConfig.PriceLimit), data)
+	signed, err := types.SignTx(tx, types.NewEIP155Signer(net.config.ChainID), n.Key)
+	if err != nil {
+		return nil, err
+	}
+	net.SendTransaction(signed)
+	return signed, nil
+}
+
+// Step has the committee leader scheduled for the height above the highest head
+// run the vote on a batch for it and the master miner scheduled for the height
+// seal it, then waits until every node linked to the sealer imported the block.
+// If the leader is down, cut off from the sealer or not approved, the sealer
+// goes without a batch.
+func (net *Network) Step() (*types.Block, error) {
+	var head *Node
+	for _, n := range net.nodes {
+		if block := n.Head(); block != nil && (head == nil || block.NumberU64() > head.Head().NumberU64()) {
+			head = n
+		}
+	}
+	if head == nil {
+		return nil, errNetworkDown
+	}
+	number := head.Head().NumberU64() + 1
+
+	account, err := head.Sealer(number)
+	if err != nil {
+		return nil, err
+	}
+	sealer := net.nodeByAccount(account)
+	if sealer == nil || !sealer.Running() {
+		return nil, ErrSealerDown
+	}
+	if err := net.certify(head, sealer, number); err != nil {
+		log.Debug("Simulated committee vote failed", "number", number, "err", err)
+	}
+	block, err := sealer.seal()
+	if err != nil {
+		return nil, err
+	}
+	return block, net.waitBlock(sealer, block)
+}
+
+// certify has the committee leader the given node schedules for a height run
+// the vote on a batch and waits until the sealer queued the approved batch.
+func (net *Network) certify(head, sealer *Node, number uint64) error {
+	chain := head.Chain()
+	if chain == nil {
+		return ErrNodeDown
+	}
+	info, err := chain.Leader(number)
+	if err != nil {
+		return err
+	}
+	leader := net.nodeByNodeID(info.ID)
+	if leader == nil || !leader.Running() {
+		return ErrLeaderDown
+	}
+	if err := leader.lead(number, sealer); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(syncTimeout)
+	for !sealer.hasBatch(number) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("batch %d not queued by %s", number, sealer)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
+// Mine steps the network count heights ahead.
+func (net *Network) Mine(count int) error {
+	for i := 0; i < count; i++ {
+		if _, err := net.Step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MineTo steps the network until the highest head reaches the given height.
+func (net *Network) MineTo(number uint64) error {
+	for {
+		var head uint64
+		for _, n := range net.nodes {
+			if block := n.Head(); block != nil && block.NumberU64() > head {
+				head = block.NumberU64()
+			}
+		}
+		if head >= number {
+			return nil
+		}
+		if _, err := net.Step(); err != nil {
+			return err
+		}
+	}
+}
+
+// Sync waits until every running node reached the highest head, e.g. after a
+// node was restarted or a partition healed.
+func (net *Network) Sync() error {
+	deadline := time.Now().Add(syncTimeout)
+	for {
+		var head *types.Block
+		for _, n := range net.nodes {
+			if block := n.Head(); block != nil && (head == nil || block.NumberU64() > head.NumberU64()) {
+				head = block
+			}
+		}
+		if head == nil {
+			return errNetworkDown
+		}
+		synced := true
+		for _, n := range net.nodes {
+			if block := n.Head(); block != nil && block.Hash() != head.Hash() {
+				synced = false
+			}
+		}
+		if synced {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("nodes not synced to block %d", head.NumberU64())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitBlock waits until every running node the sealer is linked to imported a
+// block.
+func (net *Network) waitBlock(sealer *Node, block *types.Block) error {
+	deadline := time.Now().Add(syncTimeout)
+	for _, n := range net.nodes {
+		if n != sealer && !sealer.linked(n.ID) {
+			continue
+		}
+		for {
+			chain := n.Chain()
+			if chain == nil {
+				break
+			}
+			if imported := chain.GetBlockByNumber(block.NumberU64()); imported != nil && imported.Hash() == block.Hash() {
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("block %d not imported by %s", block.NumberU64(), n)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+// linkAll links every pair of running nodes not partitioned from each other.
+func (net *Network) linkAll() error {
+	for i, one := range net.nodes {
+		for _, two := range net.nodes[i+1:] {
+			if !one.Running() || !two.Running() || net.severed(one, two) {
+				continue
+			}
+			if err := net.link(one, two); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// link connects two nodes and waits until both run the link protocol.
+func (net *Network) link(one, two *Node) error {
+	deadline := time.Now().Add(linkTimeout)
+	for !one.linked(two.ID) || !two.linked(one.ID) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("failed to link %s and %s", one, two)
+		}
+		// Connecting fails while the last attempt is recent or not yet torn
+		// down, the loop retries until the link is up.
+		net.sim.Connect(one.ID, two.ID)
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil
+}
+
+// cut severs the link between two nodes and keeps it severed.
+func (net *Network) cut(one, two *Node) error {
+	net.lock.Lock()
+	net.cuts[linkKey(one.ID, two.ID)] = true
+	net.lock.Unlock()
+
+	deadline := time.Now().Add(linkTimeout)
+	for one.linked(two.ID) || two.linked(one.ID) {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("failed to cut %s and %s", one, two)
+		}
+		net.sim.Disconnect(one.ID, two.ID)
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil
+}
+
+// severed reports whether the link between two nodes is severed.
+func (net *Network) severed(one, two *Node) bool {
+	net.lock.Lock()
+	defer net.lock.Unlock()
+	return net.cuts[linkKey(one.ID, two.ID)]
+}
+
+// linkKey returns the key of the link between two nodes, whatever their order.
+func linkKey(one, two discover.NodeID) [2]discover.NodeID {
+	if one.String() > two.String() {
+		one, two = two, one
+	}
+	return [2]discover.NodeID{one, two}
+}
+
+func (net *Network) nodeByID(id discover.NodeID) *Node {
+	for _, n := range net.nodes {
+		if n.ID == id {
+			return n
+		}
+	}
+	return nil
+}
+
+func (net *Network) nodeByNodeID(id string) *Node {
+	for _, n := range net.nodes {
+		if n.ID.String() == id {
+			return n
+		}
+	}
+	return nil
+}
+
+func (net *Network) nodeByIP(ip string) *Node {
+	for _, n := range net.nodes {
+		if n.IP.String() == ip {
+			return n
+		}
+	}
+	return nil
+}
+
+func (net *Network) nodeByAccount(account common.Address) *Node {
+	for _, n := range net.nodes {
+		if n.Account == account {
+			return n
+		}
+	}
+	return nil
+}
@@ -0,0 +1,298 @@
+package ptcsim
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/election"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// newTestNetwork creates and starts a simulated network.
+func newTestNetwork(t *testing.T, conf Config) *Network {
+	net, err := NewNetwork(conf)
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	if err := net.Start(); err != nil {
+		net.Shutdown()
+		t.Fatalf("failed to start network: %v", err)
+	}
+	return net
+}
+
+// author returns the account that sealed a block.
+func author(t *testing.T, n *Node, block *types.Block) common.Address {
+	account, err := n.Chain().Engine().Author(block.Header())
+	if err != nil {
+		t.Fatalf("failed to recover sealer of block %d: %v", block.NumberU64(), err)
+	}
+	return account
+}
+
+// listed reports whether a node is listed among the given ones.
+func listed(nodes []election.NodeInfo, n *Node) bool {
+	for _, node := range nodes {
+		if node.ID == n.ID.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// Tests a full re-election: candidates elected in the first period are listed
+// by the first broadcast block on every node, take over the committee and the
+// sealing once their lists take effect.
+func TestReElection(t *testing.T) {
+	net := newTestNetwork(t, Config{BootNodes: 3, Nodes: 3})
+	defer net.Shutdown()
+
+	committee, miner, both := net.Node(3), net.Node(4), net.Node(5)
+	for n, electType := range map[*Node]uint32{committee: types.ElectCommittee, miner: types.ElectMiner, both: types.ElectBoth} {
+		if _, err := net.Elect(n, electType, params.MinElectionDeposit); err != nil {
+			t.Fatalf("%s: failed to stand for election: %v", n, err)
+		}
+	}
+	if err := net.MineTo(params.BroadcastInterval); err != nil {
+		t.Fatalf("failed to mine first period: %v", err)
+	}
+	for _, n := range net.Nodes() {
+		block := n.Chain().GetBlockByNumber(params.BroadcastInterval)
+		topology := n.Chain().GetTopology(block.Hash(), block.NumberU64())
+		if topology == nil {
+			t.Fatalf("%s: broadcast block without node lists", n)
+		}
+		if !listed(topology.CommitteeList, committee) || !listed(topology.MinerList, miner) || !listed(topology.Both, both) {
+			t.Errorf("%s: elected nodes not listed: %+v", n, topology)
+		}
+		if !listed(topology.CommitteeList, net.Node(0)) || !listed(topology.MinerList, net.Node(1)) {
+			t.Errorf("%s: boot nodes not listed: %+v", n, topology)
+		}
+	}
+	effect := params.BroadcastInterval + core.CommitteeEffectDelay + 1
+	if err := net.MineTo(effect - 1); err != nil {
+		t.Fatalf("failed to mine until lists take effect: %v", err)
+	}
+	want, _ := net.Node(0).Chain().Leader(effect)
+	for _, n := range net.Nodes() {
+		members, _, err := n.Chain().Committee(effect)
+		if err != nil {
+			t.Fatalf("%s: failed to retrieve committee: %v", n, err)
+		}
+		if len(members) != 3 || !listed(members, committee) || !listed(members, both) || !listed(members, net.Node(0)) {
+			t.Errorf("%s: committee mismatch: %+v", n, members)
+		}
+		if leader, _ := n.Chain().Leader(effect); leader.ID != want.ID {
+			t.Errorf("%s: leader mismatch: have %s, want %s", n, leader.ID, want.ID)
+		}
+	}
+	// Only the elected miners hold accounts, so they seal from now on
+	for i := 0; i < 4; i++ {
+		block, err := net.Step()
+		if err != nil {
+			t.Fatalf("failed to seal block: %v", err)
+		}
+		if sealer := author(t, net.Node(0), block); sealer != miner.Account && sealer != both.Account {
+			t.Errorf("block %d: sealed by %x, want an elected miner", block.NumberU64(), sealer)
+		}
+	}
+}
+
+// Tests that the failure of the master miner scheduled for a height stalls the
+// chain without diverging the remaining nodes, and that the miner picks up its
+// height again once it is back.
+func TestSealerFailure(t *testing.T) {
+	net := newTestNetwork(t, Config{BootNodes: 4})
+	defer net.Shutdown()
+
+	if err := net.Mine(3); err != nil {
+		t.Fatalf("failed to mine: %v", err)
+	}
+	number := net.Node(0).Head().NumberU64() + 1
+	account, err := net.Node(0).Sealer(number)
+	if err != nil {
+		t.Fatalf("failed to schedule sealer: %v", err)
+	}
+	sealer := net.nodeByAccount(account)
+	if err := net.Kill(sealer); err != nil {
+		t.Fatalf("failed to kill sealer: %v", err)
+	}
+	if _, err := net.Step(); err != ErrSealerDown {
+		t.Fatalf("sealing without scheduled sealer: error mismatch: have %v, want %v", err, ErrSealerDown)
+	}
+	if err := net.Node(0).Send(p2p.Custsend{ToIp: sealer.IP.String()}); err != ErrUnreachable {
+		t.Errorf("message to failed sealer: error mismatch: have %v, want %v", err, ErrUnreachable)
+	}
+	for _, n := range net.Nodes() {
+		if head := n.Head(); head != nil && head.NumberU64() != number-1 {
+			t.Errorf("%s: head moved without sealer: have %d, want %d", n, head.NumberU64(), number-1)
+		}
+	}
+	if err := net.Restart(sealer); err != nil {
+		t.Fatalf("failed to restart sealer: %v", err)
+	}
+	block, err := net.Step()
+	if err != nil {
+		t.Fatalf("failed to seal after restart: %v", err)
+	}
+	if block.NumberU64() != number || author(t, net.Node(0), block) != sealer.Account {
+		t.Errorf("resumed block mismatch: number %d, sealer %x", block.NumberU64(), author(t, net.Node(0), block))
+	}
+	if err := net.Sync(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+}
+
+// Tests that the failure of the committee leader scheduled for a height keeps
+// transactions out of the chain without stalling it, as the master miner goes
+// without an approved batch, and that they are approved and sealed again once
+// the leader is back.
+func TestLeaderFailure(t *testing.T) {
+	net := newTestNetwork(t, Config{BootNodes: 4})
+	defer net.Shutdown()
+
+	if err := net.Mine(3); err != nil {
+		t.Fatalf("failed to mine: %v", err)
+	}
+	number := net.Node(1).Head().NumberU64() + 1
+	info, err := net.Node(1).Chain().Leader(number)
+	if err != nil {
+		t.Fatalf("failed to schedule leader: %v", err)
+	}
+	leader := net.nodeByNodeID(info.ID)
+	if err := net.Kill(leader); err != nil {
+		t.Fatalf("failed to kill leader: %v", err)
+	}
+	tx, err := net.Transfer(net.Node(1), net.Node(2).Account, big.NewInt(1))
+	if err != nil {
+		t.Fatalf("failed to send transfer: %v", err)
+	}
+	block, err := net.Step()
+	if err != nil {
+		t.Fatalf("failed to seal without leader: %v", err)
+	}
+	if block.NumberU64() != number || len(block.Transactions()) != 0 {
+		t.Errorf("block %d sealed without leader: have %d txs, want none", block.NumberU64(), len(block.Transactions()))
+	}
+	if err := net.Node(1).Send(p2p.Custsend{ToIp: leader.IP.String()}); err != ErrUnreachable {
+		t.Errorf("message to failed leader: error mismatch: have %v, want %v", err, ErrUnreachable)
+	}
+	if err := net.Restart(leader); err != nil {
+		t.Fatalf("failed to restart leader: %v", err)
+	}
+	if err := net.Sync(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	// The restarted leader lost its pool, have the transaction gossiped again
+	net.SendTransaction(tx)
+
+	block, err = net.Step()
+	if err != nil {
+		t.Fatalf("failed to seal after restart: %v", err)
+	}
+	if txs := block.Transactions(); len(txs) != 1 || txs[0].Hash() != tx.Hash() {
+		t.Errorf("block %d sealed after restart: have %d txs, want the transfer", block.NumberU64(), len(txs))
+	}
+	if err := net.Sync(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+}
+
+// Tests offline substitution: a master miner exiting the hierarchy is listed as
+// offline by the next broadcast block, gets its deposit back and leaves the
+// sealing to the remaining miners.
+func TestOfflineSubstitution(t *testing.T) {
+	net := newTestNetwork(t, Config{BootNodes: 2, Nodes: 2})
+	defer net.Shutdown()
+
+	leaving, staying := net.Node(2), net.Node(3)
+	for _, n := range []*Node{leaving, staying} {
+		if _, err := net.Elect(n, types.ElectMiner, params.MinElectionDeposit); err != nil {
+			t.Fatalf("%s: failed to stand for election: %v", n, err)
+		}
+	}
+	if err := net.MineTo(params.BroadcastInterval + core.CommitteeEffectDelay + 1); err != nil {
+		t.Fatalf("failed to mine first period: %v", err)
+	}
+	if _, err := net.Elect(leaving, types.ElectExit, new(big.Int)); err != nil {
+		t.Fatalf("failed to exit: %v", err)
+	}
+	broadcast := 2 * params.BroadcastInterval
+	if err := net.MineTo(broadcast); err != nil {
+		t.Fatalf("failed to mine second period: %v", err)
+	}
+	for _, n := range net.Nodes() {
+		chain := n.Chain()
+		block := chain.GetBlockByNumber(broadcast)
+		if topology := chain.GetTopology(block.Hash(), broadcast); topology == nil || !listed(topology.OfflineList, leaving) || listed(topology.MinerList, leaving) {
+			t.Errorf("%s: exited miner not listed offline: %+v", n, topology)
+		}
+		before, _ := chain.StateAt(chain.GetBlockByNumber(broadcast - 1).Root())
+		after, _ := chain.StateAt(block.Root())
+		refund := new(big.Int).Sub(after.GetBalance(leaving.Account), before.GetBalance(leaving.Account))
+		if refund.Cmp(params.MinElectionDeposit) != 0 {
+			t.Errorf("%s: refund mismatch: have %v, want %v", n, refund, params.MinElectionDeposit)
+		}
+	}
+	if err := net.MineTo(broadcast + core.CommitteeEffectDelay); err != nil {
+		t.Fatalf("failed to mine until lists take effect: %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		block, err := net.Step()
+		if err != nil {
+			t.Fatalf("failed to seal block: %v", err)
+		}
+		if sealer := author(t, net.Node(0), block); sealer != staying.Account {
+			t.Errorf("block %d: sealed by %x, want remaining miner %x", block.NumberU64(), sealer, staying.Account)
+		}
+	}
+}
+
+// Tests that partitioned nodes neither receive blocks nor custom messages from
+// the other side, and catch up once the partition heals.
+func TestPartition(t *testing.T) {
+	net := newTestNetwork(t, Config{BootNodes: 4})
+	defer net.Shutdown()
+
+	isolated := net.Node(0)
+	rest := []*Node{net.Node(1), net.Node(2), net.Node(3)}
+	if err := net.Partition([]*Node{isolated}, rest); err != nil {
+		t.Fatalf("failed to partition: %v", err)
+	}
+	msg := p2p.Custsend{ToIp: net.Node(1).IP.String(), Code: 1}
+	if err := isolated.Send(msg); err != ErrUnreachable {
+		t.Errorf("message across partition: error mismatch: have %v, want %v", err, ErrUnreachable)
+	}
+	if err := net.Mine(3); err != nil {
+		t.Fatalf("failed to mine: %v", err)
+	}
+	if head := isolated.Head().NumberU64(); head != 0 {
+		t.Errorf("isolated node imported blocks: head %d", head)
+	}
+	if err := net.Heal(); err != nil {
+		t.Fatalf("failed to heal: %v", err)
+	}
+	if err := net.Sync(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+	received := make(chan p2p.Custsend, 1)
+	sub := net.Node(1).SubscribeCustom(received)
+	defer sub.Unsubscribe()
+
+	if err := isolated.Send(msg); err != nil {
+		t.Fatalf("failed to send message: %v", err)
+	}
+	select {
+	case got := <-received:
+		if got.NodeId != isolated.ID.String() || got.FromIp != isolated.IP.String() || got.Code != msg.Code {
+			t.Errorf("message mismatch: %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("message not delivered")
+	}
+}
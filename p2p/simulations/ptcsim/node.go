@@ -0,0 +1,733 @@
+package ptcsim
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ptcpos"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Messages of the protocol the simulated nodes speak over their links.
+const (
+	statusMsg    = 0x00 // Head announcement on connect
+	customMsg    = 0x01 // Custom message of the PTC modules
+	blocksMsg    = 0x02 // Consecutive blocks, a newly sealed one or a sync batch
+	getBlocksMsg = 0x03 // Request for the blocks following a height
+
+	protocolLength = 4
+)
+
+// Codes of the custom messages the simulated verifier committee exchanges. The
+// payload is RLP encoded into the data of the message.
+const (
+	voteRequestCode = 0x100 // Batch proposed by the leader, asking a member to vote
+	voteCode        = 0x101 // Vote of a member on a proposed batch
+	batchCode       = 0x102 // Batch approved by the committee, handed to the sealer
+)
+
+const (
+	maxBlocksPerMsg = 64 // Maximum number of blocks served in one sync batch
+
+	voteTimeout = time.Second // Time the leader waits for the votes on a batch
+)
+
+var (
+	// ErrNodeDown is returned if a killed node is asked to do something.
+	ErrNodeDown = errors.New("node down")
+
+	// ErrUnreachable is returned if a custom message is sent to a node the
+	// sender has no link to.
+	ErrUnreachable = errors.New("destination unreachable")
+
+	// errNotScheduled is returned if a node is asked to seal a height it is not
+	// scheduled for.
+	errNotScheduled = errors.New("node not scheduled to seal")
+
+	// errNotApproved is returned if the committee did not approve a batch.
+	errNotApproved = errors.New("batch not approved by the committee")
+)
+
+// statusData is the network packet of the head announcement.
+type statusData struct {
+	Number uint64
+	Hash   common.Hash
+}
+
+// getBlocksData is the network packet of a block request.
+type getBlocksData struct {
+	From uint64
+}
+
+// voteData is the custom message payload of a vote on a batch.
+type voteData struct {
+	Number    uint64
+	Hash      common.Hash
+	Result    bool
+	Signature []byte // Node key signature of the vote, certifying the batch
+	NodeId    string `rlp:"-"` // Voting member, filled in from the message sender
+}
+
+// peer is a link of a node to another node of the network.
+type peer struct {
+	id discover.NodeID
+	rw p2p.MsgReadWriter
+}
+
+// send writes a message to the peer in the background, so the handler of a
+// link never waits on the handler of another one.
+func (p *peer) send(code uint64, data interface{}) {
+	go func() {
+		if err := p2p.Send(p.rw, code, data); err != nil {
+			log.Trace("Failed to send simulated message", "peer", p.id, "code", code, "err", err)
+		}
+	}()
+}
+
+// Node is a PTC node of a simulated network. Its key and database survive a
+// kill, its chain and transaction pool only run while the node is up.
+type Node struct {
+	Index   int               // Position of the node in the network
+	Key     *ecdsa.PrivateKey // Node key, also signing as the node account
+	ID      discover.NodeID   // Node ID listed in the main node lists
+	IP      net.IP            // Simulated address custom messages are routed by
+	Account common.Address    // Account sealing blocks and holding deposits
+
+	network *Network
+	db      ethdb.Database
+
+	chain   *core.BlockChain // Chain of the running node, nil while down
+	engine  *ptcpos.Ptcpos   // Consensus engine of the running node
+	pool    *core.TxPool     // Transaction pool of the running node
+	peers   map[discover.NodeID]*peer
+	batches map[uint64]*types.VerifiedBatch // Batches approved for the heights the node seals
+	fed     bool                            // Whether the committee handed the node a batch since it started
+	nonce   uint64                          // Next nonce of the transactions built for the account
+	lock    sync.RWMutex
+
+	votes      chan *voteData // Votes on the batches the node leads
+	customFeed event.Feed
+}
+
+// newNode creates a node with a fresh database holding the genesis block.
+func newNode(network *Network, index int, key *ecdsa.PrivateKey) *Node {
+	n := &Node{
+		Index:   index,
+		Key:     key,
+		ID:      discover.PubkeyID(&key.PublicKey),
+		IP:      nodeIP(index),
+		Account: crypto.PubkeyToAddress(key.PublicKey),
+		network: network,
+		db:      ethdb.NewMemDatabase(),
+		votes:   make(chan *voteData, 16),
+	}
+	network.genesis.MustCommit(n.db)
+	return n
+}
+
+// nodeIP returns the simulated address of the node at the given index.
+func nodeIP(index int) net.IP {
+	return net.IPv4(10, 0, byte((index+1)>>8), byte(index+1))
+}
+
+// String implements fmt.Stringer.
+func (n *Node) String() string {
+	return fmt.Sprintf("ptc%02d", n.Index)
+}
+
+// URL returns the enode URL of the node.
+func (n *Node) URL() string {
+	return discover.NewNode(n.ID, n.IP, 30303, 30303).String()
+}
+
+// Chain returns the chain of the node, nil while it is down.
+func (n *Node) Chain() *core.BlockChain {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	return n.chain
+}
+
+// Pool returns the transaction pool of the node, nil while it is down.
+func (n *Node) Pool() *core.TxPool {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	return n.pool
+}
+
+// Running reports whether the node is up.
+func (n *Node) Running() bool {
+	return n.Chain() != nil
+}
+
+// Head returns the head block of the node, nil while it is down.
+func (n *Node) Head() *types.Block {
+	if chain := n.Chain(); chain != nil {
+		return chain.CurrentBlock()
+	}
+	return nil
+}
+
+// Sealer returns the account the node schedules to seal the given height.
+func (n *Node) Sealer(number uint64) (common.Address, error) {
+	n.lock.RLock()
+	engine := n.engine
+	n.lock.RUnlock()
+
+	if engine == nil {
+		return common.Address{}, ErrNodeDown
+	}
+	return engine.Sealer(number)
+}
+
+// linked reports whether the node has a link to the given one.
+func (n *Node) linked(id discover.NodeID) bool {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	_, ok := n.peers[id]
+	return ok
+}
+
+// AddTx adds a transaction to the pool of the node, as if it had been gossiped.
+func (n *Node) AddTx(tx *types.Transaction) error {
+	pool := n.Pool()
+	if pool == nil {
+		return ErrNodeDown
+	}
+	return pool.AddRemote(tx)
+}
+
+// Send delivers a custom message to the node listening on its destination IP.
+// The sender has to be linked to the destination, messages are not relayed.
+func (n *Node) Send(msg p2p.Custsend) error {
+	dest := n.network.nodeByIP(msg.ToIp)
+	if dest == nil {
+		return ErrUnreachable
+	}
+	n.lock.RLock()
+	peer, up := n.peers[dest.ID], n.chain != nil
+	n.lock.RUnlock()
+
+	if !up {
+		return ErrNodeDown
+	}
+	if peer == nil {
+		return ErrUnreachable
+	}
+	msg.FromIp, msg.NodeId = n.IP.String(), n.ID.String()
+	return p2p.Send(peer.rw, customMsg, &msg)
+}
+
+// sendCustom sends a custom message with an RLP encoded payload.
+func (n *Node) sendCustom(ip string, code uint64, data interface{}) error {
+	payload, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		return err
+	}
+	return n.Send(p2p.Custsend{ToIp: ip, Code: code, Data: p2p.Data_Format{Data_struct: payload}})
+}
+
+// SubscribeCustom subscribes to the custom messages delivered to the node, the
+// ones of the committee vote included. The link delivering a message waits
+// until it is received, so the channel should be buffered.
+func (n *Node) SubscribeCustom(ch chan<- p2p.Custsend) event.Subscription {
+	return n.customFeed.Subscribe(ch)
+}
+
+// start brings up the chain and transaction pool of the node on top of its
+// database.
+func (n *Node) start() error {
+	config := n.network.config
+
+	engine := ptcpos.New(config.Ptcpos)
+	chain, err := core.NewBlockChain(n.db, nil, config, engine, vm.Config{})
+	if err != nil {
+		return err
+	}
+	engine.SetChain(chain)
+	engine.SetBatches(n)
+	engine.Authorize(n.Account, func(account accounts.Account, hash []byte) ([]byte, error) {
+		return crypto.Sign(hash, n.Key)
+	})
+	poolConfig := core.DefaultTxPoolConfig
+	poolConfig.Journal = ""
+	pool := core.NewTxPool(poolConfig, config, chain)
+
+	n.lock.Lock()
+	n.chain, n.engine, n.pool = chain, engine, pool
+	n.peers = make(map[discover.NodeID]*peer)
+	n.batches, n.fed = make(map[uint64]*types.VerifiedBatch), false
+	n.lock.Unlock()
+	return nil
+}
+
+// stop tears the chain and transaction pool of the node down, keeping its
+// database.
+func (n *Node) stop() {
+	n.lock.Lock()
+	chain, pool := n.chain, n.pool
+	n.chain, n.engine, n.pool, n.peers, n.batches = nil, nil, nil, nil, nil
+	n.lock.Unlock()
+
+	if pool != nil {
+		pool.Stop()
+	}
+	if chain != nil {
+		chain.Stop()
+	}
+}
+
+// runPeer handles a link of the node until it is dropped.
+func (n *Node) runPeer(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+	chain := n.Chain()
+	if chain == nil {
+		return ErrNodeDown
+	}
+	peer := &peer{id: p.ID(), rw: rw}
+
+	head := chain.CurrentBlock()
+	peer.send(statusMsg, &statusData{Number: head.NumberU64(), Hash: head.Hash()})
+
+	n.lock.Lock()
+	if n.peers == nil {
+		n.lock.Unlock()
+		return ErrNodeDown
+	}
+	n.peers[peer.id] = peer
+	n.lock.Unlock()
+
+	defer func() {
+		n.lock.Lock()
+		if n.peers != nil && n.peers[peer.id] == peer {
+			delete(n.peers, peer.id)
+		}
+		n.lock.Unlock()
+	}()
+	for {
+		msg, err := rw.ReadMsg()
+		if err != nil {
+			return err
+		}
+		err = n.handleMsg(chain, peer, msg)
+		msg.Discard()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handleMsg handles a message received over a link.
+func (n *Node) handleMsg(chain *core.BlockChain, p *peer, msg p2p.Msg) error {
+	switch msg.Code {
+	case statusMsg:
+		var status statusData
+		if err := msg.Decode(&status); err != nil {
+			return err
+		}
+		if head := chain.CurrentBlock().NumberU64(); status.Number > head {
+			p.send(getBlocksMsg, &getBlocksData{From: head + 1})
+		}
+
+	case getBlocksMsg:
+		var req getBlocksData
+		if err := msg.Decode(&req); err != nil {
+			return err
+		}
+		var blocks []*types.Block
+		for number := req.From; number < req.From+maxBlocksPerMsg; number++ {
+			block := chain.GetBlockByNumber(number)
+			if block == nil {
+				break
+			}
+			blocks = append(blocks, block)
+		}
+		if len(blocks) > 0 {
+			p.send(blocksMsg, blocks)
+		}
+
+	case blocksMsg:
+		var blocks []*types.Block
+		if err := msg.Decode(&blocks); err != nil {
+			return err
+		}
+		if len(blocks) > 0 {
+			n.importBlocks(chain, p, blocks)
+		}
+
+	case customMsg:
+		var data p2p.Custsend
+		if err := msg.Decode(&data); err != nil {
+			return err
+		}
+		n.handleCustom(chain, data)
+		n.customFeed.Send(data)
+
+	default:
+		return fmt.Errorf("unknown message code %d", msg.Code)
+	}
+	return nil
+}
+
+// handleCustom consumes the custom messages of the committee vote. Others are
+// only handed to the subscribers.
+func (n *Node) handleCustom(chain *core.BlockChain, msg p2p.Custsend) {
+	switch msg.Code {
+	case voteRequestCode:
+		batch := new(types.VerifiedBatch)
+		if err := rlp.DecodeBytes(msg.Data.Data_struct, batch); err != nil {
+			log.Debug("Invalid simulated vote request", "node", n, "from", msg.FromIp, "err", err)
+			return
+		}
+		vote := n.vote(chain, msg.NodeId, batch)
+		if err := n.sendCustom(msg.FromIp, voteCode, vote); err != nil {
+			log.Debug("Failed to send simulated vote", "node", n, "to", msg.FromIp, "err", err)
+		}
+
+	case voteCode:
+		vote := new(voteData)
+		if err := rlp.DecodeBytes(msg.Data.Data_struct, vote); err != nil {
+			log.Debug("Invalid simulated vote", "node", n, "from", msg.FromIp, "err", err)
+			return
+		}
+		vote.NodeId = msg.NodeId
+		select {
+		case n.votes <- vote:
+		default:
+			log.Debug("Dropping simulated vote", "node", n, "from", msg.FromIp)
+		}
+
+	case batchCode:
+		batch := new(types.VerifiedBatch)
+		if err := rlp.DecodeBytes(msg.Data.Data_struct, batch); err != nil {
+			log.Debug("Invalid simulated batch", "node", n, "from", msg.FromIp, "err", err)
+			return
+		}
+		n.addBatch(chain, batch)
+	}
+}
+
+// vote has the node, as a committee member, check a batch proposed by a leader
+// and returns its signed vote. Only batches of the leader its chain schedules
+// for the height whose transactions all pass the pool validation of the verifier
+// are approved.
+func (n *Node) vote(chain *core.BlockChain, leader string, batch *types.VerifiedBatch) *voteData {
+	vote := &voteData{Number: batch.Number, Hash: batch.Hash()}
+	defer n.signVote(vote)
+
+	scheduled, err := chain.Leader(batch.Number)
+	pool := n.Pool()
+	if err != nil || scheduled.ID != leader || pool == nil {
+		return vote
+	}
+	for _, tx := range batch.Txs {
+		if err := core.ValidateLocalTx(pool, tx); err != nil {
+			log.Debug("Rejecting simulated batch", "node", n, "number", batch.Number, "tx", tx.Hash(), "err", err)
+			return vote
+		}
+	}
+	vote.Result = true
+	return vote
+}
+
+// signVote signs a vote with the node key, as the verifier does.
+func (n *Node) signVote(vote *voteData) {
+	signed := types.BatchVote{Result: vote.Result}
+	if err := signed.Sign(vote.Number, vote.Hash, n.Key); err != nil {
+		log.Debug("Failed to sign simulated vote", "node", n, "err", err)
+		return
+	}
+	vote.Signature = signed.Signature
+}
+
+// lead has the node, as the committee leader of a height, propose its pending
+// transactions to the other committee members and hand the batch a majority of
+// the committee approved to the sealer.
+func (n *Node) lead(number uint64, sealer *Node) error {
+	n.lock.RLock()
+	chain, pool := n.chain, n.pool
+	n.lock.RUnlock()
+
+	if chain == nil {
+		return ErrNodeDown
+	}
+	members, _, err := chain.Committee(number)
+	if err != nil {
+		return err
+	}
+	batch := &types.VerifiedBatch{Number: number}
+	for _, tx := range core.PackageTxInPool(pool) {
+		tx := tx
+		if hash, _, _ := rawdb.ReadTxLookupEntry(n.db, tx.Hash()); hash == (common.Hash{}) {
+			batch.Txs = append(batch.Txs, &tx)
+		}
+	}
+	// Drop votes left over from earlier heights, then collect the ones on the
+	// batch from every member reached
+	for len(n.votes) > 0 {
+		<-n.votes
+	}
+	var asked int
+	for _, member := range members {
+		if member.ID == n.ID.String() {
+			continue
+		}
+		if err := n.sendCustom(member.IP, voteRequestCode, batch); err != nil {
+			log.Debug("Failed to request simulated vote", "node", n, "member", member.IP, "err", err)
+			continue
+		}
+		asked++
+	}
+	hash := batch.Hash()
+	own := &voteData{Number: number, Hash: hash, Result: true}
+	n.signVote(own)
+	batch.Votes = []types.BatchVote{{Result: true, NodeId: n.ID.String(), Signature: own.Signature}}
+
+	timeout := time.NewTimer(voteTimeout)
+	defer timeout.Stop()
+	for answered := 0; answered < asked; {
+		select {
+		case vote := <-n.votes:
+			if vote.Number == number && vote.Hash == hash {
+				batch.Votes = append(batch.Votes, types.BatchVote{Result: vote.Result, NodeId: vote.NodeId, Signature: vote.Signature})
+				answered++
+			}
+		case <-timeout.C:
+			answered = asked
+		}
+	}
+	// Only batches the chain accepts the votes of are handed on
+	if err := core.VerifyBatchVotes(members, number, hash, batch.Votes); err != nil {
+		return errNotApproved
+	}
+	if sealer == n {
+		n.addBatch(chain, batch)
+		return nil
+	}
+	return n.sendCustom(sealer.IP.String(), batchCode, batch)
+}
+
+// addBatch queues a batch handed over by the committee for sealing. Like the
+// miner module, the node only admits batches approved by the leader its chain
+// schedules for their height.
+func (n *Node) addBatch(chain *core.BlockChain, batch *types.VerifiedBatch) {
+	leader, err := chain.Leader(batch.Number)
+	if err != nil || !batch.ApprovedBy(leader.ID) {
+		log.Debug("Dropping simulated batch without leader approval", "node", n, "number", batch.Number, "err", err)
+		return
+	}
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	if n.batches != nil {
+		n.batches[batch.Number], n.fed = batch, true
+	}
+}
+
+// hasBatch reports whether a batch was queued for the given height.
+func (n *Node) hasBatch(number uint64) bool {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	return n.batches[number] != nil
+}
+
+// ApprovedTxs implements core.BatchReader, restricting the blocks the engine of
+// the node seals to the batch approved for their height.
+func (n *Node) ApprovedTxs(number uint64) (map[common.Hash]struct{}, bool) {
+	n.lock.RLock()
+	batch := n.batches[number]
+	n.lock.RUnlock()
+
+	if batch == nil {
+		return nil, false
+	}
+	approved := make(map[common.Hash]struct{}, len(batch.Txs))
+	for _, tx := range batch.Txs {
+		approved[tx.Hash()] = struct{}{}
+	}
+	return approved, true
+}
+
+// importBlocks inserts blocks received from a peer. Blocks not connecting to
+// the local head trigger a sync from the peer, blocks becoming the new head are
+// relayed to the other peers.
+func (n *Node) importBlocks(chain *core.BlockChain, from *peer, blocks []*types.Block) {
+	head := chain.CurrentBlock().NumberU64()
+	if blocks[0].NumberU64() > head+1 {
+		from.send(getBlocksMsg, &getBlocksData{From: head + 1})
+		return
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		log.Debug("Failed to import simulated blocks", "node", n, "number", blocks[0].Number(), "err", err)
+		return
+	}
+	last := blocks[len(blocks)-1]
+	if chain.CurrentBlock().Hash() != last.Hash() || last.NumberU64() <= head {
+		return
+	}
+	if len(blocks) == maxBlocksPerMsg {
+		from.send(getBlocksMsg, &getBlocksData{From: last.NumberU64() + 1})
+	}
+	n.broadcast(last, from.id)
+}
+
+// broadcast sends a new head to every peer but the one it came from.
+func (n *Node) broadcast(block *types.Block, origin discover.NodeID) {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+
+	for id, peer := range n.peers {
+		if id != origin {
+			peer.send(blocksMsg, []*types.Block{block})
+		}
+	}
+}
+
+// seal assembles a block on top of the local head, seals it and broadcasts it.
+// The node has to be the master miner scheduled for the height.
+func (n *Node) seal() (*types.Block, error) {
+	n.lock.RLock()
+	chain, engine := n.chain, n.engine
+	n.lock.RUnlock()
+
+	if chain == nil {
+		return nil, ErrNodeDown
+	}
+	parent := chain.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   core.CalcGasLimit(parent),
+		Coinbase:   n.Account,
+	}
+	if sealer, err := engine.Sealer(header.Number.Uint64()); err != nil {
+		return nil, err
+	} else if sealer != n.Account {
+		return nil, errNotScheduled
+	}
+	if err := engine.Prepare(chain, header); err != nil {
+		return nil, err
+	}
+	statedb, err := chain.StateAt(parent.Root())
+	if err != nil {
+		return nil, err
+	}
+	var (
+		gp       = new(core.GasPool).AddGas(header.GasLimit)
+		txs      []*types.Transaction
+		receipts []*types.Receipt
+	)
+	sealable, batch := n.sealable(chain, header.Number)
+	for _, tx := range sealable {
+		snap := statedb.Snapshot()
+		statedb.Prepare(tx.Hash(), common.Hash{}, len(txs))
+
+		receipt, _, err := core.ApplyTransaction(chain.Config(), chain, &header.Coinbase, gp, statedb, header, tx, &header.GasUsed, vm.Config{})
+		if err != nil {
+			log.Trace("Skipping simulated transaction", "node", n, "hash", tx.Hash(), "err", err)
+			statedb.RevertToSnapshot(snap)
+			if batch != nil {
+				// The votes certify the whole batch, a partial one is invalid
+				return nil, err
+			}
+			continue
+		}
+		txs, receipts = append(txs, tx), append(receipts, receipt)
+	}
+	if batch != nil && len(txs) > 0 && !params.IsBroadcastNumber(header.Number.Uint64()) {
+		if err := engine.Certify(header, batch.Votes); err != nil {
+			return nil, err
+		}
+	}
+	block, err := engine.Finalize(chain, header, statedb, txs, nil, receipts)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := engine.Seal(chain, block, make(chan struct{}))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := chain.InsertChain(types.Blocks{sealed}); err != nil {
+		return nil, err
+	}
+	n.broadcast(sealed, discover.NodeID{})
+	return sealed, nil
+}
+
+// sealable returns the transactions to seal at a height and the batch they
+// are approved by. Like the miner module, a node served by the committee seals
+// exactly the batch approved for the height, nothing if there is none, and one
+// never served seals its pending transactions.
+func (n *Node) sealable(chain *core.BlockChain, number *big.Int) ([]*types.Transaction, *types.VerifiedBatch) {
+	n.lock.RLock()
+	batch, fed, pool := n.batches[number.Uint64()], n.fed, n.pool
+	n.lock.RUnlock()
+
+	if fed {
+		if batch == nil {
+			return nil, nil
+		}
+		return batch.Txs, batch
+	}
+	pending, err := pool.Pending()
+	if err != nil {
+		return nil, nil
+	}
+	var (
+		txs    []*types.Transaction
+		sorted = types.NewTransactionsByPriceAndNonce(types.MakeSigner(chain.Config(), number), pending)
+	)
+	for tx := sorted.Peek(); tx != nil; tx = sorted.Peek() {
+		txs = append(txs, tx)
+		sorted.Shift()
+	}
+	return txs, nil
+}
+
+// service runs a node within the simulation adapter.
+type service struct {
+	node *Node
+}
+
+// Protocols implements node.Service, returning the link protocol of the node.
+func (s *service) Protocols() []p2p.Protocol {
+	return []p2p.Protocol{{
+		Name:    "ptcsim",
+		Version: 1,
+		Length:  protocolLength,
+		Run:     s.node.runPeer,
+	}}
+}
+
+// APIs implements node.Service.
+func (s *service) APIs() []rpc.API {
+	return nil
+}
+
+// Start implements node.Service, bringing up the chain of the node.
+func (s *service) Start(server *p2p.Server) error {
+	return s.node.start()
+}
+
+// Stop implements node.Service, tearing the chain of the node down.
+func (s *service) Stop() error {
+	s.node.stop()
+	return nil
+}
@@ -0,0 +1,114 @@
+// Copyright 2018 The MATRIX Authors
+// This file is part of the MATRIX library.
+//
+// The MATRIX library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MATRIX library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MATRIX library. If not, see <http://www.gnu.org/licenses/>.
+
+package simulations
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/simulations/adapters"
+)
+
+// TestTopologyPolicy hands a role assignment to every node of a simulation
+// network and checks that the default topology policy meshes the validators
+// and links every miner to the configured number of validators.
+func TestTopologyPolicy(t *testing.T) {
+	adapter := adapters.NewSimAdapter(adapters.Services{
+		"test": newTestService,
+	})
+	network := NewNetwork(adapter, &NetworkConfig{
+		DefaultService: "test",
+	})
+	defer network.Shutdown()
+
+	roles := map[discover.NodeID]common.RoleType{}
+	update := p2p.TopologyUpdate{Number: 1, Nodes: make(map[common.RoleType][]*discover.Node)}
+	for i := 0; i < 6; i++ {
+		node, err := network.NewNode()
+		if err != nil {
+			t.Fatalf("error creating node: %s", err)
+		}
+		if err := network.Start(node.ID()); err != nil {
+			t.Fatalf("error starting node: %s", err)
+		}
+		role := common.RoleValidator
+		if i >= 4 {
+			role = common.RoleMiner
+		}
+		roles[node.ID()] = role
+		update.Nodes[role] = append(update.Nodes[role], node.Node.(*adapters.SimNode).Node())
+	}
+	var ids []discover.NodeID
+	for id := range roles {
+		ids = append(ids, id)
+	}
+	action := func(_ context.Context) error {
+		for id, role := range roles {
+			update := update
+			update.Role = role
+			network.GetNode(id).Node.(*adapters.SimNode).Server().UpdateTopology(update)
+		}
+		return nil
+	}
+	check := func(ctx context.Context, id discover.NodeID) (bool, error) {
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		default:
+		}
+		links := 0
+		for other, role := range roles {
+			if other == id || role != common.RoleValidator {
+				continue
+			}
+			if conn := network.GetConn(id, other); conn != nil && conn.Up {
+				links++
+			}
+		}
+		switch roles[id] {
+		case common.RoleValidator:
+			return links == len(update.Nodes[common.RoleValidator])-1, nil
+		case common.RoleMiner:
+			return links >= p2p.DefaultTopologyPolicy.MinerLinks, nil
+		default:
+			return false, fmt.Errorf("unexpected role of node %s", id)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	trigger := make(chan discover.NodeID)
+	go triggerChecks(ctx, ids, trigger, 100*time.Millisecond)
+
+	result := NewSimulation(network).Run(ctx, &Step{
+		Action:  action,
+		Trigger: trigger,
+		Expect: &Expectation{
+			Nodes: ids,
+			Check: check,
+		},
+	})
+	if result.Error != nil {
+		t.Fatalf("simulation failed: %s", result.Error)
+	}
+}
@@ -0,0 +1,506 @@
+// Copyright 2018 The MATRIX Authors
+// This file is part of the MATRIX library.
+//
+// The MATRIX library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MATRIX library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MATRIX library. If not, see <http://www.gnu.org/licenses/>.
+package p2p
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+// topologyRefreshInterval is the interval the topology policy is re-run at in
+// between topology updates, picking up latency changes and lost links.
+const topologyRefreshInterval = 30 * time.Second
+
+// TopologyUpdate is the role assignment of the network topology, as published
+// by the identity module whenever a block changes it.
+type TopologyUpdate struct {
+	Number uint64                               // Block the assignment is valid from
+	Role   common.RoleType                      // Role of the local node
+	Nodes  map[common.RoleType][]*discover.Node // Nodes of the topology by role, endpoints may be incomplete
+}
+
+// TopologyPeer is a connected peer as seen by a topology policy.
+type TopologyPeer struct {
+	ID      discover.NodeID
+	Role    common.RoleType // Role of the peer, RoleNil if not part of the topology
+	Latency time.Duration   // Smoothed ping round trip, zero if not measured yet
+	Inbound bool            // Whether the link was dialed by the remote side
+}
+
+// TopologyView is the state a topology policy plans the links of the local
+// node on.
+type TopologyView struct {
+	Self    discover.NodeID
+	Role    common.RoleType
+	Nodes   map[common.RoleType][]discover.NodeID // Topology nodes by role, excluding the local node
+	Latency map[discover.NodeID]time.Duration     // Last latency measured to topology nodes
+	Peers   []TopologyPeer                        // Currently connected peers
+}
+
+// TopologyPolicy decides on the links the local node maintains within the
+// network topology. Plan is run on every topology update and periodically in
+// between; the nodes to dial are connected and kept connected until they leave
+// the topology, the peers to drop are disconnected.
+type TopologyPolicy interface {
+	Plan(view *TopologyView) (dial, drop []discover.NodeID)
+}
+
+// RolePolicy is the default, role-aware topology policy. Validators are fully
+// meshed with each other and miners keep redundant links to the validators.
+// Bucket nodes spread their links over latency tiers, so blocks travel along
+// fast paths as well as across the network, and a few of them link up to the
+// miners. Nodes outside of the buckets give up links to miners.
+//
+// Only links dialed by the local node are ever dropped, inbound links are left
+// to the policy of the remote side.
+type RolePolicy struct {
+	MinerLinks  int             // Validators every miner stays connected to
+	OuterLinks  int             // Miners every bucket node stays connected to
+	BucketLinks int             // Bucket nodes kept connected per latency tier
+	Tiers       []time.Duration // Upper latency bounds of all but the last tier
+}
+
+// DefaultTopologyPolicy is the topology policy used if none is configured.
+var DefaultTopologyPolicy = &RolePolicy{
+	MinerLinks:  3,
+	OuterLinks:  1,
+	BucketLinks: 3,
+	Tiers:       []time.Duration{50 * time.Millisecond, 150 * time.Millisecond, 400 * time.Millisecond},
+}
+
+// Plan implements TopologyPolicy.
+func (p *RolePolicy) Plan(view *TopologyView) (dial, drop []discover.NodeID) {
+	switch view.Role {
+	case common.RoleValidator:
+		for _, id := range view.Nodes[common.RoleValidator] {
+			if !view.connected(id) {
+				dial = append(dial, id)
+			}
+		}
+	case common.RoleMiner:
+		dial = p.link(view, common.RoleValidator, p.MinerLinks)
+	case common.RoleBucket:
+		dial = append(p.link(view, common.RoleMiner, p.OuterLinks), p.spread(view)...)
+		drop = append(p.trim(view, common.RoleMiner, p.OuterLinks), p.trimTiers(view)...)
+	default:
+		drop = p.trim(view, common.RoleMiner, 0)
+	}
+	return dial, drop
+}
+
+// link returns the nodes of a role to dial to keep n links to them. Nodes with
+// the lowest measured latency are preferred, unmeasured ones are ranked by
+// their distance to the local node to spread the load among the role.
+func (p *RolePolicy) link(view *TopologyView, role common.RoleType, n int) []discover.NodeID {
+	var candidates []discover.NodeID
+	for _, id := range view.Nodes[role] {
+		if view.connected(id) {
+			n--
+		} else {
+			candidates = append(candidates, id)
+		}
+	}
+	if n <= 0 {
+		return nil
+	}
+	view.rank(candidates)
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+	return candidates
+}
+
+// trim returns the dialed peers of a role beyond the n lowest latency ones.
+func (p *RolePolicy) trim(view *TopologyView, role common.RoleType, n int) []discover.NodeID {
+	var dialed []discover.NodeID
+	for _, peer := range view.Peers {
+		if peer.Role != role {
+			continue
+		}
+		if peer.Inbound {
+			n--
+		} else {
+			dialed = append(dialed, peer.ID)
+		}
+	}
+	if n < 0 {
+		n = 0
+	}
+	if len(dialed) <= n {
+		return nil
+	}
+	view.rank(dialed)
+	return dialed[n:]
+}
+
+// tier returns the latency tier of a measured latency.
+func (p *RolePolicy) tier(latency time.Duration) int {
+	for i, bound := range p.Tiers {
+		if latency < bound {
+			return i
+		}
+	}
+	return len(p.Tiers)
+}
+
+// spread returns the bucket nodes to dial to fill every latency tier up to
+// BucketLinks. Tiers nobody known falls into are made up for by nodes whose
+// latency is yet to be measured.
+func (p *RolePolicy) spread(view *TopologyView) []discover.NodeID {
+	var (
+		tiers   = len(p.Tiers) + 1
+		links   = make([]int, tiers)
+		pending int
+	)
+	for _, peer := range view.Peers {
+		if peer.Role != common.RoleBucket {
+			continue
+		}
+		if peer.Latency == 0 {
+			pending++
+		} else {
+			links[p.tier(peer.Latency)]++
+		}
+	}
+	var (
+		measured   = make([][]discover.NodeID, tiers)
+		unmeasured []discover.NodeID
+	)
+	for _, id := range view.Nodes[common.RoleBucket] {
+		if view.connected(id) {
+			continue
+		}
+		if latency := view.Latency[id]; latency != 0 {
+			measured[p.tier(latency)] = append(measured[p.tier(latency)], id)
+		} else {
+			unmeasured = append(unmeasured, id)
+		}
+	}
+	var dial []discover.NodeID
+	missing := -pending
+	for i := 0; i < tiers; i++ {
+		want := p.BucketLinks - links[i]
+		if want <= 0 {
+			continue
+		}
+		view.rank(measured[i])
+		if len(measured[i]) > want {
+			measured[i] = measured[i][:want]
+		}
+		dial = append(dial, measured[i]...)
+		missing += want - len(measured[i])
+	}
+	if missing > 0 {
+		view.rank(unmeasured)
+		if len(unmeasured) > missing {
+			unmeasured = unmeasured[:missing]
+		}
+		dial = append(dial, unmeasured...)
+	}
+	return dial
+}
+
+// trimTiers returns the dialed bucket peers overfilling their latency tier,
+// the highest latency ones first.
+func (p *RolePolicy) trimTiers(view *TopologyView) []discover.NodeID {
+	tiers := make([][]TopologyPeer, len(p.Tiers)+1)
+	for _, peer := range view.Peers {
+		if peer.Role == common.RoleBucket && peer.Latency != 0 {
+			tier := p.tier(peer.Latency)
+			tiers[tier] = append(tiers[tier], peer)
+		}
+	}
+	var drop []discover.NodeID
+	for _, peers := range tiers {
+		if len(peers) <= p.BucketLinks {
+			continue
+		}
+		sort.SliceStable(peers, func(i, j int) bool { return peers[i].Latency < peers[j].Latency })
+
+		excess := len(peers) - p.BucketLinks
+		for i := len(peers) - 1; i >= 0 && excess > 0; i-- {
+			if !peers[i].Inbound {
+				drop = append(drop, peers[i].ID)
+				excess--
+			}
+		}
+	}
+	return drop
+}
+
+// connected reports whether the node is a connected peer.
+func (view *TopologyView) connected(id discover.NodeID) bool {
+	for _, peer := range view.Peers {
+		if peer.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// rank sorts nodes by their measured latency, unmeasured ones last, and
+// otherwise by their XOR distance to the local node.
+func (view *TopologyView) rank(ids []discover.NodeID) {
+	latency := func(id discover.NodeID) time.Duration {
+		for _, peer := range view.Peers {
+			if peer.ID == id && peer.Latency != 0 {
+				return peer.Latency
+			}
+		}
+		return view.Latency[id]
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		li, lj := latency(ids[i]), latency(ids[j])
+		switch {
+		case li != lj && li != 0 && lj != 0:
+			return li < lj
+		case li != lj:
+			return li != 0
+		}
+		var di, dj discover.NodeID
+		for k := range view.Self {
+			di[k] = ids[i][k] ^ view.Self[k]
+			dj[k] = ids[j][k] ^ view.Self[k]
+		}
+		return bytes.Compare(di[:], dj[:]) < 0
+	})
+}
+
+// topology runs the topology policy of a server, dialing and dropping peers as
+// the role assignment of the network and the measured latencies change.
+type topology struct {
+	srv    *Server
+	self   discover.NodeID
+	policy TopologyPolicy
+	update chan TopologyUpdate
+
+	role    common.RoleType
+	nodes   map[discover.NodeID]*discover.Node  // Nodes of the topology, excluding self
+	roles   map[discover.NodeID]common.RoleType // Roles of the topology nodes
+	latency map[discover.NodeID]time.Duration   // Last latency measured to topology nodes
+	dialed  map[discover.NodeID]*discover.Node  // Nodes kept connected on behalf of the policy
+	lock    sync.RWMutex
+}
+
+// newTopology creates the topology manager of a server.
+func newTopology(srv *Server, policy TopologyPolicy) *topology {
+	if policy == nil {
+		policy = DefaultTopologyPolicy
+	}
+	return &topology{
+		srv:     srv,
+		self:    discover.PubkeyID(&srv.PrivateKey.PublicKey),
+		policy:  policy,
+		update:  make(chan TopologyUpdate),
+		role:    common.RoleNil,
+		nodes:   make(map[discover.NodeID]*discover.Node),
+		roles:   make(map[discover.NodeID]common.RoleType),
+		latency: make(map[discover.NodeID]time.Duration),
+		dialed:  make(map[discover.NodeID]*discover.Node),
+	}
+}
+
+// loop applies topology updates and re-runs the policy until the server quits.
+func (t *topology) loop() {
+	defer t.srv.loopWG.Done()
+
+	refresh := time.NewTicker(topologyRefreshInterval)
+	defer refresh.Stop()
+
+	for {
+		select {
+		case update := <-t.update:
+			t.apply(update)
+			t.plan()
+		case <-refresh.C:
+			t.plan()
+		case <-t.srv.quit:
+			return
+		}
+	}
+}
+
+// apply replaces the role assignment by an update and releases the nodes kept
+// connected that left the topology.
+func (t *topology) apply(update TopologyUpdate) {
+	t.lock.Lock()
+	t.role = update.Role
+	t.nodes = make(map[discover.NodeID]*discover.Node)
+	t.roles = make(map[discover.NodeID]common.RoleType)
+	for role, nodes := range update.Nodes {
+		for _, node := range nodes {
+			if node.ID == t.self {
+				continue
+			}
+			t.nodes[node.ID] = node
+			t.roles[node.ID] = role
+		}
+	}
+	for id := range t.latency {
+		if _, ok := t.roles[id]; !ok {
+			delete(t.latency, id)
+		}
+	}
+	var released []*discover.Node
+	for id, node := range t.dialed {
+		if _, ok := t.roles[id]; !ok {
+			released = append(released, node)
+			delete(t.dialed, id)
+		}
+	}
+	t.lock.Unlock()
+
+	for _, node := range released {
+		t.srv.RemovePeer(node)
+	}
+	t.srv.log.Debug("Updated peer topology", "number", update.Number, "role", roleName(update.Role), "nodes", len(t.roles), "released", len(released))
+}
+
+// plan runs the policy on the current peers and executes its decision.
+func (t *topology) plan() {
+	peers := t.srv.Peers()
+
+	t.lock.Lock()
+	if len(t.roles) == 0 {
+		t.lock.Unlock()
+		return
+	}
+	view := &TopologyView{
+		Self:    t.self,
+		Role:    t.role,
+		Nodes:   make(map[common.RoleType][]discover.NodeID),
+		Latency: make(map[discover.NodeID]time.Duration),
+	}
+	for _, peer := range peers {
+		id, role := peer.ID(), common.RoleNil
+		if r, ok := t.roles[id]; ok {
+			role = r
+			if latency := peer.Latency(); latency != 0 {
+				t.latency[id] = latency
+			}
+		}
+		view.Peers = append(view.Peers, TopologyPeer{ID: id, Role: role, Latency: peer.Latency(), Inbound: peer.Inbound()})
+	}
+	for id, role := range t.roles {
+		view.Nodes[role] = append(view.Nodes[role], id)
+	}
+	for role := range view.Nodes {
+		nodes := view.Nodes[role]
+		sort.Slice(nodes, func(i, j int) bool { return bytes.Compare(nodes[i][:], nodes[j][:]) < 0 })
+	}
+	for id, latency := range t.latency {
+		view.Latency[id] = latency
+	}
+	dial, drop := t.policy.Plan(view)
+
+	var (
+		add, remove []*discover.Node
+		unresolved  []discover.NodeID
+	)
+	for _, id := range dial {
+		node := t.nodes[id]
+		switch {
+		case node == nil:
+		case node.Incomplete():
+			unresolved = append(unresolved, id)
+		default:
+			t.dialed[id] = node
+			add = append(add, node)
+		}
+	}
+	for _, id := range drop {
+		node := t.dialed[id]
+		if node == nil {
+			node = &discover.Node{ID: id}
+		}
+		delete(t.dialed, id)
+		remove = append(remove, node)
+	}
+	t.lock.Unlock()
+
+	if len(unresolved) > 0 {
+		add = append(add, t.resolve(unresolved)...)
+	}
+	for _, node := range add {
+		t.srv.AddPeer(node)
+	}
+	for _, node := range remove {
+		t.srv.RemovePeer(node)
+	}
+	if len(add) > 0 || len(remove) > 0 {
+		t.srv.log.Debug("Adjusted peer topology", "role", roleName(view.Role), "dial", len(add), "drop", len(remove))
+	}
+}
+
+// resolve looks up the endpoints of topology nodes to dial. The lookups take
+// network round trips, so they run without holding the lock and only the nodes
+// still part of the topology afterwards are kept connected.
+func (t *topology) resolve(ids []discover.NodeID) []*discover.Node {
+	if t.srv.ntab == nil {
+		t.srv.log.Debug("Skipping unresolvable topology nodes", "count", len(ids))
+		return nil
+	}
+	resolved := make([]*discover.Node, 0, len(ids))
+	for _, id := range ids {
+		if node := t.srv.ntab.Resolve(id); node != nil {
+			resolved = append(resolved, node)
+		}
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var add []*discover.Node
+	for _, node := range resolved {
+		if _, ok := t.roles[node.ID]; !ok {
+			continue
+		}
+		t.nodes[node.ID] = node
+		t.dialed[node.ID] = node
+		add = append(add, node)
+	}
+	return add
+}
+
+// peerRole returns the topology role of a node.
+func (t *topology) peerRole(id discover.NodeID) common.RoleType {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if role, ok := t.roles[id]; ok {
+		return role
+	}
+	return common.RoleNil
+}
+
+// roleName returns the name of a topology role as reported in peer infos.
+func roleName(role common.RoleType) string {
+	switch role {
+	case common.RoleValidator:
+		return "validator"
+	case common.RoleMiner:
+		return "miner"
+	case common.RoleBucket:
+		return "bucket"
+	case common.RoleDefault:
+		return "default"
+	}
+	return ""
+}
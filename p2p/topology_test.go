@@ -0,0 +1,128 @@
+// Copyright 2018 The MATRIX Authors
+// This file is part of the MATRIX library.
+//
+// The MATRIX library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The MATRIX library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the MATRIX library. If not, see <http://www.gnu.org/licenses/>.
+package p2p
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+)
+
+func topologyID(b byte) discover.NodeID {
+	var id discover.NodeID
+	id[0] = b
+	return id
+}
+
+func topologyIDs(bs ...byte) []discover.NodeID {
+	ids := make([]discover.NodeID, len(bs))
+	for i, b := range bs {
+		ids[i] = topologyID(b)
+	}
+	return ids
+}
+
+var rolePolicyTests = []struct {
+	name       string
+	view       *TopologyView
+	dial, drop []discover.NodeID
+}{
+	{
+		name: "validators are fully meshed",
+		view: &TopologyView{
+			Role: common.RoleValidator,
+			Nodes: map[common.RoleType][]discover.NodeID{
+				common.RoleValidator: topologyIDs(1, 2, 3),
+				common.RoleMiner:     topologyIDs(4, 5),
+			},
+			Peers: []TopologyPeer{{ID: topologyID(2), Role: common.RoleValidator}, {ID: topologyID(4), Role: common.RoleMiner, Inbound: true}},
+		},
+		dial: topologyIDs(1, 3),
+	},
+	{
+		name: "miners keep redundant links to the fastest validators",
+		view: &TopologyView{
+			Role: common.RoleMiner,
+			Nodes: map[common.RoleType][]discover.NodeID{
+				common.RoleValidator: topologyIDs(1, 2, 3, 4, 5),
+			},
+			Latency: map[discover.NodeID]time.Duration{topologyID(4): 20 * time.Millisecond, topologyID(5): 10 * time.Millisecond},
+			Peers:   []TopologyPeer{{ID: topologyID(1), Role: common.RoleValidator}},
+		},
+		dial: topologyIDs(5, 4),
+	},
+	{
+		name: "miners with enough validator links stay put",
+		view: &TopologyView{
+			Role: common.RoleMiner,
+			Nodes: map[common.RoleType][]discover.NodeID{
+				common.RoleValidator: topologyIDs(1, 2, 3, 4),
+			},
+			Peers: []TopologyPeer{{ID: topologyID(1), Role: common.RoleValidator}, {ID: topologyID(2), Role: common.RoleValidator}, {ID: topologyID(3), Role: common.RoleValidator}},
+		},
+	},
+	{
+		name: "bucket nodes fill latency tiers and trim overfull ones",
+		view: &TopologyView{
+			Role: common.RoleBucket,
+			Nodes: map[common.RoleType][]discover.NodeID{
+				common.RoleMiner:  topologyIDs(1),
+				common.RoleBucket: topologyIDs(10, 11, 12, 13, 14),
+			},
+			Latency: map[discover.NodeID]time.Duration{topologyID(13): 500 * time.Millisecond},
+			Peers: []TopologyPeer{
+				{ID: topologyID(1), Role: common.RoleMiner},
+				{ID: topologyID(10), Role: common.RoleBucket, Latency: 10 * time.Millisecond},
+				{ID: topologyID(11), Role: common.RoleBucket, Latency: 30 * time.Millisecond},
+				{ID: topologyID(12), Role: common.RoleBucket, Latency: 20 * time.Millisecond, Inbound: true},
+			},
+		},
+		dial: topologyIDs(13, 14),
+		drop: topologyIDs(11),
+	},
+	{
+		name: "ordinary nodes give up dialed miner links",
+		view: &TopologyView{
+			Role: common.RoleDefault,
+			Nodes: map[common.RoleType][]discover.NodeID{
+				common.RoleMiner: topologyIDs(1, 2),
+			},
+			Peers: []TopologyPeer{{ID: topologyID(1), Role: common.RoleMiner}, {ID: topologyID(2), Role: common.RoleMiner, Inbound: true}},
+		},
+		drop: topologyIDs(1),
+	},
+}
+
+func TestRolePolicy(t *testing.T) {
+	policy := &RolePolicy{
+		MinerLinks:  3,
+		OuterLinks:  1,
+		BucketLinks: 2,
+		Tiers:       []time.Duration{50 * time.Millisecond},
+	}
+	for _, tt := range rolePolicyTests {
+		dial, drop := policy.Plan(tt.view)
+		if !reflect.DeepEqual(dial, tt.dial) {
+			t.Errorf("%s: dial mismatch:\nhave %v\nwant %v", tt.name, dial, tt.dial)
+		}
+		if !reflect.DeepEqual(drop, tt.drop) {
+			t.Errorf("%s: drop mismatch:\nhave %v\nwant %v", tt.name, drop, tt.drop)
+		}
+	}
+}
@@ -28,6 +28,26 @@ const (
 	HypothecatedAccount = "0x0ead6cdb8d214389909a535d4ccc21a393dddba9"		// 抵押账户
 )
 
+// TransferLogAccount is the address the synthetic logs of the transfers of
+// extended transactions are emitted from. No contract lives there.
+const TransferLogAccount = "0x00000000000000000000000000000000000000fe"
+
+// UnbondingPeriods is the number of candidate periods undelegated stake keeps
+// unbonding before the deposit account pays it back.
+const UnbondingPeriods = 3
+
+// UnbondingRelease returns the broadcast block paying back the stake unbonded
+// by a transaction in the given block.
+func UnbondingRelease(number uint64) uint64 {
+	return (number/BroadcastInterval + UnbondingPeriods) * BroadcastInterval
+}
+
+// IsBroadcastNumber reports whether the given block is a broadcast block, the
+// only blocks carrying main node lists.
+func IsBroadcastNumber(number uint64) bool {
+	return number > 0 && number%BroadcastInterval == 0
+}
+
 // Genesis hashes to enforce below configs on.
 var (
 	MainnetGenesisHash = common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
@@ -88,16 +108,16 @@ var (
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	AllEthashProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil, nil, nil, nil, nil, nil}
 
 	// AllCliqueProtocolChanges contains every protocol change (EIPs) introduced
 	// and accepted by the Ethereum core developers into the Clique consensus.
 	//
 	// This configuration is intentionally not using keyed fields to force anyone
 	// adding flags to the config to also have to set these fields.
-	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}}
+	AllCliqueProtocolChanges = &ChainConfig{big.NewInt(1337), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, nil, &CliqueConfig{Period: 0, Epoch: 30000}, nil, nil, nil, nil, nil, nil}
 
-	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil}
+	TestChainConfig = &ChainConfig{big.NewInt(1), big.NewInt(0), nil, false, big.NewInt(0), common.Hash{}, big.NewInt(0), big.NewInt(0), big.NewInt(0), nil, new(EthashConfig), nil, nil, nil, nil, nil, nil, nil}
 	TestRules       = TestChainConfig.Rules(new(big.Int))
 )
 
@@ -127,6 +147,15 @@ type ChainConfig struct {
 	// Various consensus engines
 	Ethash *EthashConfig `json:"ethash,omitempty"`
 	Clique *CliqueConfig `json:"clique,omitempty"`
+	Ptcpos *PtcposConfig `json:"ptcpos,omitempty"`
+
+	// PTC hierarchy reward distribution (nil = coinbase-only ethash rewards)
+	Reward *RewardConfig `json:"reward,omitempty"`
+
+	NodeListBlock  *big.Int `json:"nodeListBlock,omitempty"`  // Block header node lists are validated from (nil = not validated)
+	PTCSignerBlock *big.Int `json:"ptcSignerBlock,omitempty"` // Block transaction signatures commit to the Matrix_Extra extension from (nil = no fork)
+	NonceLaneBlock *big.Int `json:"nonceLaneBlock,omitempty"` // Block accounts may send transactions on parallel nonce lanes from (nil = no fork)
+	ExtraToBlock   *big.Int `json:"extraToBlock,omitempty"`   // Block the one-to-many transfers of extended transactions are executed from (nil = no fork)
 }
 
 // EthashConfig is the consensus engine configs for proof-of-work based sealing.
@@ -148,6 +177,41 @@ func (c *CliqueConfig) String() string {
 	return "clique"
 }
 
+// PtcposConfig is the consensus engine configs for the PTC hierarchy, sealing
+// blocks by signature of the scheduled master miners.
+type PtcposConfig struct {
+	Period    uint64           `json:"period"`              // Number of seconds between blocks to enforce
+	Miners    []common.Address `json:"miners"`              // Boot miners sealing while the topology lists no miner accounts
+	Bootnodes []string         `json:"bootnodes,omitempty"` // Enode URLs of the boot nodes (empty = mainnet boot nodes)
+}
+
+// String implements the stringer interface, returning the consensus engine details.
+func (c *PtcposConfig) String() string {
+	return "ptcpos"
+}
+
+// RewardConfig is the block reward distribution config for the PTC hierarchy.
+// All rates are expressed in per mille of the block's total payout (static
+// reward plus transaction fees); whatever is left after paying the groups is
+// credited to the block author.
+type RewardConfig struct {
+	BlockReward    *big.Int `json:"blockReward"`    // Static reward minted in every block
+	MinerRate      uint64   `json:"minerRate"`      // Share paid to the master miners
+	ValidatorRate  uint64   `json:"validatorRate"`  // Share paid to the master validators
+	BackupRate     uint64   `json:"backupRate"`     // Share paid to the backup miners and validators
+	CandidateRate  uint64   `json:"candidateRate"`  // Share paid to the candidate nodes
+	CommissionRate uint64   `json:"commissionRate"` // Part of a node's share kept before passing the rest to its delegators
+}
+
+// RewardRateBase is the denominator of every rate in RewardConfig.
+const RewardRateBase = 1000
+
+// String implements the stringer interface, returning the reward split.
+func (c *RewardConfig) String() string {
+	return fmt.Sprintf("{BlockReward: %v Miner: %d Validator: %d Backup: %d Candidate: %d Commission: %d}",
+		c.BlockReward, c.MinerRate, c.ValidatorRate, c.BackupRate, c.CandidateRate, c.CommissionRate)
+}
+
 // String implements the fmt.Stringer interface.
 func (c *ChainConfig) String() string {
 	var engine interface{}
@@ -156,10 +220,12 @@ func (c *ChainConfig) String() string {
 		engine = c.Ethash
 	case c.Clique != nil:
 		engine = c.Clique
+	case c.Ptcpos != nil:
+		engine = c.Ptcpos
 	default:
 		engine = "unknown"
 	}
-	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v Engine: %v}",
+	return fmt.Sprintf("{ChainID: %v Homestead: %v DAO: %v DAOSupport: %v EIP150: %v EIP155: %v EIP158: %v Byzantium: %v Constantinople: %v PTCSigner: %v NonceLane: %v ExtraTo: %v Engine: %v}",
 		c.ChainID,
 		c.HomesteadBlock,
 		c.DAOForkBlock,
@@ -169,6 +235,9 @@ func (c *ChainConfig) String() string {
 		c.EIP158Block,
 		c.ByzantiumBlock,
 		c.ConstantinopleBlock,
+		c.PTCSignerBlock,
+		c.NonceLaneBlock,
+		c.ExtraToBlock,
 		engine,
 	)
 }
@@ -208,6 +277,39 @@ func (c *ChainConfig) IsConstantinople(num *big.Int) bool {
 	return isForked(c.ConstantinopleBlock, num)
 }
 
+// BootNodes returns the enode URLs of the boot nodes, which are listed in the
+// main node lists since the genesis block.
+func (c *ChainConfig) BootNodes() []string {
+	if c.Ptcpos != nil && len(c.Ptcpos.Bootnodes) > 0 {
+		return c.Ptcpos.Bootnodes
+	}
+	return MainnetBootnodes
+}
+
+// IsNodeList returns whether num is either equal to the block header node lists
+// are validated from or greater.
+func (c *ChainConfig) IsNodeList(num *big.Int) bool {
+	return isForked(c.NodeListBlock, num)
+}
+
+// IsPTCSigner returns whether num is either equal to the PTC signer fork block
+// or greater.
+func (c *ChainConfig) IsPTCSigner(num *big.Int) bool {
+	return isForked(c.PTCSignerBlock, num)
+}
+
+// IsNonceLane returns whether num is either equal to the nonce lane fork block
+// or greater.
+func (c *ChainConfig) IsNonceLane(num *big.Int) bool {
+	return isForked(c.NonceLaneBlock, num)
+}
+
+// IsExtraTo returns whether num is either equal to the one-to-many transfer
+// fork block or greater.
+func (c *ChainConfig) IsExtraTo(num *big.Int) bool {
+	return isForked(c.ExtraToBlock, num)
+}
+
 // GasTable returns the gas table corresponding to the current phase (homestead or homestead reprice).
 //
 // The returned GasTable's fields shouldn't, under any circumstances, be changed.
@@ -271,6 +373,18 @@ func (c *ChainConfig) checkCompatible(newcfg *ChainConfig, head *big.Int) *Confi
 	if isForkIncompatible(c.ConstantinopleBlock, newcfg.ConstantinopleBlock, head) {
 		return newCompatError("Constantinople fork block", c.ConstantinopleBlock, newcfg.ConstantinopleBlock)
 	}
+	if isForkIncompatible(c.NodeListBlock, newcfg.NodeListBlock, head) {
+		return newCompatError("Node list block", c.NodeListBlock, newcfg.NodeListBlock)
+	}
+	if isForkIncompatible(c.PTCSignerBlock, newcfg.PTCSignerBlock, head) {
+		return newCompatError("PTC signer fork block", c.PTCSignerBlock, newcfg.PTCSignerBlock)
+	}
+	if isForkIncompatible(c.NonceLaneBlock, newcfg.NonceLaneBlock, head) {
+		return newCompatError("Nonce lane fork block", c.NonceLaneBlock, newcfg.NonceLaneBlock)
+	}
+	if isForkIncompatible(c.ExtraToBlock, newcfg.ExtraToBlock, head) {
+		return newCompatError("Extra transfer fork block", c.ExtraToBlock, newcfg.ExtraToBlock)
+	}
 	return nil
 }
 
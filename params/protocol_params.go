@@ -82,11 +82,11 @@ const (
 	Bn256PairingPerPointGas uint64 = 80000  // Per-point price for an elliptic curve pairing check
 
 	//YY
-	TxCount                 uint64 = 3   //A maximum of 1000 one-ptcy transactions can be supported, including the extented one
-	ErrTxConsensus          uint64 = 6   //The number of nodes to consider a transaction as error upon consensus (eg. if over 6 nodes consider a transaction as error, then it can be removed)
+	TxCount                 uint64 = 3   //A maximum of 3 transfers per one-to-many transaction can be supported, including the main one
+	TxRejectionQuorum       uint64 = 50  //Percentage of the committee stake that has to be exceeded by the verifiers rejecting a transaction for it to be dropped
 	SubBlockNum             uint64 = 20  //Something will be deleted if the SubBlockNum blockheight is exceeded (unpacked transactions will be removed if there are more than 20 blocks)
-	NonceAddOne             uint64 = 0x0010000000000000  //Nonce's top digit plus 1
-	NonceSubOne             uint64 = 0x0001FFFFFFFFFFFF  //Nonce's top digit minus 1
+	NonceLaneBits           uint64 = 8   //Top bits of a nonce selecting the nonce lane of the transaction, the rest being its sequence number in the lane
+	NonceLanes              uint64 = 16  //Number of parallel nonce lanes of an account, the first one being the plain account nonce
 	MaxTxN					uint32 = 0x1FFFF	//Max Transaction Numbering
 	FloodMaxTransactions	int = 200	//Maximum Flood Transactions
 )
@@ -97,4 +97,7 @@ var (
 	MinimumDifficulty      = big.NewInt(131072) // The minimum that the difficulty may ever be.
 	DurationLimit          = big.NewInt(13)     // The decision boundary on the blocktime duration used to determine whether difficulty should go up or not.
 	FloodTime			   = 1* time.Second			//Flood Time Threshold
+
+	MinElectionDeposit = new(big.Int).Mul(big.NewInt(10000), big.NewInt(Ether)) // Minimum deposit an election transaction has to carry
+	MinDelegation      = big.NewInt(Ether)                                       // Minimum stake a delegation transaction has to bond
 )
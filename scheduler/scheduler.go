@@ -36,7 +36,7 @@ import (
 )
 
 const (
-	electionNetEffterTime = 6
+	electionNetEffterTime = core.CommitteeEffectDelay // the committee of a broadcast block takes over, see core.Committee
 	blockEffectDelay      = 4
 )
 
@@ -83,13 +83,13 @@ func (self *Scheduler) getnodelistfrombootnodes() (err error) {
 	self.nodeList[self.eletempIndex].MinerList = make([]election.NodeInfo, 2)
 	self.nodeList[self.eletempIndex].CommitteeList = make([]election.NodeInfo, 1)
 
-	node, _ := discover.ParseNode(params.MainnetBootnodes[0])
+	node, _ := discover.ParseNode(self.chainConfig.BootNodes()[0])
 
 	self.nodeList[self.eletempIndex].CommitteeList[0].ID = node.ID.String()
 	self.nodeList[self.eletempIndex].CommitteeList[0].IP = node.IP.String()
 	self.nodeList[self.eletempIndex].CommitteeList[0].Wealth = 10000
 	for i := 0; i < 2; i++ {
-		node, _ := discover.ParseNode(params.MainnetBootnodes[i+1])
+		node, _ := discover.ParseNode(self.chainConfig.BootNodes()[i+1])
 
 		self.nodeList[self.eletempIndex].MinerList[i].ID = node.ID.String()
 		self.nodeList[self.eletempIndex].MinerList[i].IP = node.IP.String()
@@ -173,26 +173,23 @@ func New(bc *core.BlockChain, miner *miner.Miner, chainConfig *params.ChainConfi
 }
 
 func (bc *Scheduler) copyblockNodeList(block *types.Block, to *election.NodeList) (e *election.NodeList) {
-
-	to.MinerList = make([]election.NodeInfo, len(block.Header().MinerList))
-	if 0 != len(block.Header().MinerList) {
-		copy(to.MinerList, block.Header().MinerList)
+	// The header only commits to the lists, they are stored next to the block
+	topology := bc.bc.GetTopology(block.Hash(), block.NumberU64())
+	if topology == nil {
+		topology = new(types.Topology)
 	}
 
-	to.CommitteeList = make([]election.NodeInfo, len(block.Header().CommitteeList))
-	if 0 != len(block.Header().CommitteeList) {
-		copy(to.CommitteeList, block.Header().CommitteeList)
-	}
+	to.MinerList = make([]election.NodeInfo, len(topology.MinerList))
+	copy(to.MinerList, topology.MinerList)
 
-	to.Both = make([]election.NodeInfo, len(block.Header().Both))
-	if 0 != len(block.Header().Both) {
-		copy(to.Both, block.Header().Both)
-	}
+	to.CommitteeList = make([]election.NodeInfo, len(topology.CommitteeList))
+	copy(to.CommitteeList, topology.CommitteeList)
 
-	to.OfflineList = make([]election.NodeInfo, len(block.Header().OfflineList))
-	if 0 != len(block.Header().OfflineList) {
-		copy(to.OfflineList, block.Header().OfflineList)
-	}
+	to.Both = make([]election.NodeInfo, len(topology.Both))
+	copy(to.Both, topology.Both)
+
+	to.OfflineList = make([]election.NodeInfo, len(topology.OfflineList))
+	copy(to.OfflineList, topology.OfflineList)
 
 	return to
 }
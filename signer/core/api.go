@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/usbwallet"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/internal/ethapi"
 	"github.com/ethereum/go-ethereum/log"
@@ -128,6 +129,8 @@ type (
 		Transaction SendTxArgs       `json:"transaction"`
 		Callinfo    []ValidationInfo `json:"call_info"`
 		Meta        Metadata         `json:"meta"`
+		// Value paid to all recipients plus the gas allowance
+		Cost *hexutil.Big `json:"cost"`
 	}
 	// SignTxResponse result from SignTxRequest
 	SignTxResponse struct {
@@ -317,6 +320,18 @@ func logDiff(original *SignTxRequest, new *SignTxResponse) bool {
 		modified = true
 		log.Info("Nonce changed by UI", "was", n0, "is", n1)
 	}
+	if e0, e1 := original.Transaction.ExtraTo, new.Transaction.ExtraTo; !reflect.DeepEqual(e0, e1) {
+		modified = true
+		log.Info("Extra transfers changed by UI", "was", len(e0), "is", len(e1))
+	}
+	if l0, l1 := original.Transaction.LockHeight, new.Transaction.LockHeight; !reflect.DeepEqual(l0, l1) {
+		modified = true
+		log.Info("Lock height changed by UI", "was", l0, "is", l1)
+	}
+	if t0, t1 := original.Transaction.TxType, new.Transaction.TxType; !reflect.DeepEqual(t0, t1) {
+		modified = true
+		log.Info("Tx type changed by UI", "was", t0, "is", t1)
+	}
 	return modified
 }
 
@@ -335,6 +350,7 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 		Transaction: args,
 		Meta:        MetadataFromContext(ctx),
 		Callinfo:    msgs.Messages,
+		Cost:        (*hexutil.Big)(args.Cost()),
 	}
 	// Process approval
 	result, err = api.UI.ApproveTx(&req)
@@ -358,8 +374,22 @@ func (api *SignerAPI) SignTransaction(ctx context.Context, args SendTxArgs, meth
 	// Convert fields into a real transaction
 	var unsignedTx = result.Transaction.toTransaction()
 
-	// The one to sign is the one that was returned from the UI
-	signedTx, err := wallet.SignTxWithPassphrase(acc, result.Password, unsignedTx, api.chainID)
+	// The one to sign is the one that was returned from the UI. Wallets sign
+	// with the EIP155 signer, so transactions paying extra recipients are signed
+	// through the hash of the PTC signer instead.
+	var signedTx *types.Transaction
+	if len(result.Transaction.ExtraTo) > 0 {
+		var (
+			signer = types.NewPTCSigner(api.chainID)
+			hash   = signer.Hash(unsignedTx)
+			sig    []byte
+		)
+		if sig, err = wallet.SignHashWithPassphrase(acc, result.Password, hash[:]); err == nil {
+			signedTx, err = unsignedTx.WithSignature(signer, sig)
+		}
+	} else {
+		signedTx, err = wallet.SignTxWithPassphrase(acc, result.Password, unsignedTx, api.chainID)
+	}
 	if err != nil {
 		api.UI.ShowError(err.Error())
 		return nil, err
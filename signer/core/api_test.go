@@ -357,6 +357,41 @@ func TestSignTx(t *testing.T) {
 
 }
 
+// Tests that transactions paying extra recipients are signed through the PTC
+// signer, as the signature has to commit to the transfers.
+func TestSignExtendedTx(t *testing.T) {
+	api, control := setup(t)
+	createAccount(control, api, t)
+	control <- "A"
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	methodSig := "test(uint)"
+	tx := mkTestTx(common.NewMixedcaseAddress(list[0].Address))
+	tx.ExtraTo = []*ExtraTransfer{{
+		To:    common.NewMixedcaseAddress(common.HexToAddress("0x1338")),
+		Value: (hexutil.Big)(*big.NewInt(1e17)),
+	}}
+	control <- "Y"
+	control <- "apassword"
+	res, err := api.SignTransaction(context.Background(), tx, &methodSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedTx := new(types.Transaction)
+	if err := rlp.Decode(bytes.NewReader(res.Raw), parsedTx); err != nil {
+		t.Fatal(err)
+	}
+	from, err := types.Sender(types.NewPTCSigner(big.NewInt(1)), parsedTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from != list[0].Address {
+		t.Errorf("sender mismatch: have %x, want %x", from, list[0].Address)
+	}
+}
+
 /*
 func TestAsyncronousResponses(t *testing.T){
 
@@ -53,6 +53,8 @@ func (l *AuditLogger) SignTransaction(ctx context.Context, args SendTxArgs, meth
 	}
 	l.log.Info("SignTransaction", "type", "request", "metadata", MetadataFromContext(ctx).String(),
 		"tx", args.String(),
+		"recipients", len(args.ExtraTo)+1,
+		"cost", args.Cost(),
 		"methodSelector", sel)
 
 	res, e := l.api.SignTransaction(ctx, args, methodSelector)
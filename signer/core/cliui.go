@@ -119,6 +119,26 @@ func (ui *ComptcdlineUI) ApproveTx(request *SignTxRequest) (SignTxResponse, erro
 			fmt.Printf("data:  %v\n", common.Bytes2Hex(d))
 		}
 	}
+	for i, leg := range request.Transaction.ExtraTo {
+		fmt.Printf("extra transfer %d:\n", i)
+		fmt.Printf("  to:    %v\n", leg.To.Original())
+		if !leg.To.ValidChecksum() {
+			fmt.Printf("\n  WARNING: Invalid checksum on to-address!\n\n")
+		}
+		fmt.Printf("  value: %v wei\n", leg.Value.ToInt())
+		if leg.Input != nil && len(*leg.Input) > 0 {
+			fmt.Printf("  data:  %v\n", common.Bytes2Hex(*leg.Input))
+		}
+	}
+	if lock := request.Transaction.LockHeight; lock != nil && *lock > 0 {
+		fmt.Printf("lock height:  %d (not enforced)\n", uint64(*lock))
+	}
+	if len(request.Transaction.ExtraTo) > 0 {
+		fmt.Printf("total value: %v wei\n", request.Transaction.TotalValue())
+	}
+	if request.Cost != nil {
+		fmt.Printf("max cost: %v wei\n", request.Cost.ToInt())
+	}
 	if request.Callinfo != nil {
 		fmt.Printf("\nTransaction validation:\n")
 		for _, m := range request.Callinfo {
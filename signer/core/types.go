@@ -71,6 +71,34 @@ type SendTxArgs struct {
 	// We accept "data" and "input" for backwards-compatibility reasons.
 	Data  *hexutil.Bytes `json:"data"`
 	Input *hexutil.Bytes `json:"input"`
+	// One-to-many transfers of PTC extended transactions, along with their
+	// lock height, which is not enforced by the protocol, and extension type.
+	ExtraTo    []*ExtraTransfer `json:"extra_to,omitempty"`
+	LockHeight *hexutil.Uint64  `json:"lockHeight,omitempty"`
+	TxType     *hexutil.Uint64  `json:"txType,omitempty"`
+}
+
+// ExtraTransfer is one of the one-to-many transfers of an extended transaction.
+type ExtraTransfer struct {
+	To    common.MixedcaseAddress `json:"to"`
+	Value hexutil.Big             `json:"value"`
+	Input *hexutil.Bytes          `json:"input"`
+}
+
+// TotalValue returns the value paid to all recipients of the transaction.
+func (args *SendTxArgs) TotalValue() *big.Int {
+	total := new(big.Int).Set((*big.Int)(&args.Value))
+	for _, leg := range args.ExtraTo {
+		total.Add(total, (*big.Int)(&leg.Value))
+	}
+	return total
+}
+
+// Cost returns the most the transaction can cost the sender: the value paid to
+// all recipients plus the gas allowance at the requested price.
+func (args *SendTxArgs) Cost() *big.Int {
+	fee := new(big.Int).Mul((*big.Int)(&args.GasPrice), new(big.Int).SetUint64(uint64(args.Gas)))
+	return fee.Add(fee, args.TotalValue())
 }
 
 func (args SendTxArgs) String() string {
@@ -91,5 +119,20 @@ func (args *SendTxArgs) toTransaction() *types.Transaction {
 	if args.To == nil {
 		return types.NewContractCreation(uint64(args.Nonce), (*big.Int)(&args.Value), uint64(args.Gas), (*big.Int)(&args.GasPrice), input)
 	}
+	if len(args.ExtraTo) > 0 {
+		legs := make([]*types.ExtraTo_tr, len(args.ExtraTo))
+		for i, leg := range args.ExtraTo {
+			to, value := leg.To.Address(), leg.Value
+			legs[i] = &types.ExtraTo_tr{To_tr: &to, Value_tr: &value, Input_tr: leg.Input}
+		}
+		var lockHeight, txType uint64
+		if args.LockHeight != nil {
+			lockHeight = uint64(*args.LockHeight)
+		}
+		if args.TxType != nil {
+			txType = uint64(*args.TxType)
+		}
+		return types.NewTransactions(uint64(args.Nonce), args.To.Address(), (*big.Int)(&args.Value), uint64(args.Gas), (*big.Int)(&args.GasPrice), input, legs, lockHeight, byte(txType))
+	}
 	return types.NewTransaction(uint64(args.Nonce), args.To.Address(), (*big.Int)(&args.Value), (uint64)(args.Gas), (*big.Int)(&args.GasPrice), input)
 }
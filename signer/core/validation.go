@@ -23,6 +23,7 @@ import (
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/params"
 )
 
 // The validation package contains validation checks for transactions
@@ -152,6 +153,56 @@ func (v *Validator) validate(msgs *ValidationMessages, txargs *SendTxArgs, metho
 		// Validate calldata
 		v.validateCallData(msgs, data, methodSelector)
 	}
+	return v.validateExtra(msgs, txargs)
+}
+
+// validateExtra checks the one-to-many transfers and the lock height of a PTC
+// extended transaction, rejecting the ones the transaction pool would.
+func (v *Validator) validateExtra(msgs *ValidationMessages, txargs *SendTxArgs) error {
+	if len(txargs.ExtraTo) == 0 {
+		if txargs.LockHeight != nil || txargs.TxType != nil {
+			return errors.New(`"lockHeight" and "txType" require "extra_to" transfers`)
+		}
+		return nil
+	}
+	if txargs.To == nil {
+		return errors.New("Tx creates a contract, it cannot carry extra transfers")
+	}
+	// The transaction itself counts against the limit
+	if uint64(len(txargs.ExtraTo))+1 > params.TxCount {
+		return fmt.Errorf("Tx carries %d extra transfers, at most %d allowed", len(txargs.ExtraTo), params.TxCount-1)
+	}
+	if txargs.TxType != nil && uint64(*txargs.TxType) > 255 {
+		return fmt.Errorf("Tx type %d out of range", uint64(*txargs.TxType))
+	}
+	recipients := map[common.Address]bool{txargs.To.Address(): true}
+	for i, leg := range txargs.ExtraTo {
+		if leg == nil {
+			return fmt.Errorf("Extra transfer %d is empty", i)
+		}
+		to := leg.To.Address()
+		if recipients[to] {
+			return fmt.Errorf("Extra transfer %d pays %v, which is already a recipient", i, to.Hex())
+		}
+		recipients[to] = true
+
+		if leg.Value.ToInt().Sign() < 0 {
+			return fmt.Errorf("Extra transfer %d has a negative value", i)
+		}
+		if !leg.To.ValidChecksum() {
+			msgs.warn(fmt.Sprintf("Invalid checksum on extra transfer %d to-address", i))
+		}
+		if to == (common.Address{}) {
+			msgs.crit(fmt.Sprintf("Extra transfer %d destination is the zero address!", i))
+		}
+		if leg.Input != nil {
+			v.validateCallData(msgs, *leg.Input, nil)
+		}
+	}
+	msgs.info(fmt.Sprintf("Tx pays %d recipients a total of %v wei", len(txargs.ExtraTo)+1, txargs.TotalValue()))
+	if txargs.LockHeight != nil && *txargs.LockHeight > 0 {
+		msgs.info(fmt.Sprintf("Tx is locked until block %d", uint64(*txargs.LockHeight)))
+	}
 	return nil
 }
 
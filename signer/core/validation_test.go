@@ -137,3 +137,69 @@ func TestValidator(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateExtraTransfers(t *testing.T) {
+	var (
+		db, _ = NewEmptyAbiDB()
+		v     = NewValidator(db)
+	)
+	legTo := func(addrs ...string) []*ExtraTransfer {
+		var legs []*ExtraTransfer
+		for _, addr := range addrs {
+			to, _ := mixAddr(addr)
+			legs = append(legs, &ExtraTransfer{To: *to, Value: toHexBig("0x10")})
+		}
+		return legs
+	}
+	lock := hexutil.Uint64(100)
+	tests := []struct {
+		legs        []*ExtraTransfer
+		lockHeight  *hexutil.Uint64
+		expectErr   bool
+		numMessages int
+	}{
+		// A valid transfer, summarised by an info message
+		{legs: legTo("0x000000000000000000000000000000000000dEaD"), numMessages: 1},
+		// Lock height is reported
+		{legs: legTo("0x000000000000000000000000000000000000dEaD"), lockHeight: &lock, numMessages: 2},
+		// Invalid checksum and zero address are flagged
+		{legs: legTo("000000000000000000000000000000000000dead", "0x0000000000000000000000000000000000000000"), numMessages: 3},
+		// Repeated recipient, either the main one or another leg
+		{legs: legTo("0x0000000000000000000000000000000000001234"), expectErr: true},
+		{legs: legTo("0x000000000000000000000000000000000000dEaD", "0x000000000000000000000000000000000000dEaD"), expectErr: true},
+		// Lock height without transfers
+		{lockHeight: &lock, expectErr: true},
+	}
+	for i, test := range tests {
+		args := dummyTxArgs(txtestcase{from: "000000000000000000000000000000000000dead", to: "0x0000000000000000000000000000000000001234",
+			n: "0x01", g: "0x20", gp: "0x40", value: "0x01"})
+		args.ExtraTo, args.LockHeight = test.legs, test.lockHeight
+
+		msgs, err := v.ValidateTransaction(args, nil)
+		if (err != nil) != test.expectErr {
+			t.Errorf("test %d: error mismatch: have %v, want error %v", i, err, test.expectErr)
+			continue
+		}
+		if err == nil && len(msgs.Messages) != test.numMessages {
+			t.Errorf("test %d: message count mismatch: have %d, want %d: %v", i, len(msgs.Messages), test.numMessages, msgs.Messages)
+		}
+	}
+}
+
+func TestSendTxArgsCost(t *testing.T) {
+	args := dummyTxArgs(txtestcase{from: "000000000000000000000000000000000000dead", to: "0x0000000000000000000000000000000000001234",
+		n: "0x01", g: "0x20", gp: "0x40", value: "0x01"})
+	to, _ := mixAddr("0x000000000000000000000000000000000000dEaD")
+	args.ExtraTo = []*ExtraTransfer{{To: *to, Value: toHexBig("0x10")}}
+
+	if have := args.TotalValue(); have.Int64() != 0x11 {
+		t.Errorf("total value mismatch: have %v, want %d", have, 0x11)
+	}
+	if have := args.Cost(); have.Int64() != 0x11+0x20*0x40 {
+		t.Errorf("cost mismatch: have %v, want %d", have, 0x11+0x20*0x40)
+	}
+	tx := args.toTransaction()
+	if legs := tx.GetMatrix_EX(); len(legs) != 1 || len(legs[0].ExtraTo) != 1 || *legs[0].ExtraTo[0].Recipient != to.Address() {
+		t.Errorf("extra transfers not carried by the transaction: %v", legs)
+	}
+}
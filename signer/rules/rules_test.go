@@ -629,3 +629,53 @@ function ApproveSignData(r){
 		t.Fatalf("Expected approved")
 	}
 }
+
+func TestSignTxRequestExtraTransfers(t *testing.T) {
+	js := `
+	function ApproveTx(r){
+		var legs = r.transaction.extra_to || [];
+		if (legs.length != 2 || r.transaction.lockHeight != "0x64") { return "Reject" }
+		for (var i = 0; i < legs.length; i++) {
+			if (legs[i].to.toLowerCase() == "0x000000000000000000000000000000000000dead") { return "Reject" }
+		}
+		if (new BigNumber(r.cost.slice(2), 16).greaterThan(1000)) { return "Reject" }
+		return "Approve"
+	}`
+
+	r, err := initRuleEngine(js)
+	if err != nil {
+		t.Fatalf("Couldn't create evaluator %v", err)
+	}
+	from, _ := mixAddr("0000000000000000000000000000000000001337")
+	to, _ := mixAddr("0000000000000000000000000000000000001338")
+	leg := func(addr string, value int64) *core.ExtraTransfer {
+		to, _ := mixAddr(addr)
+		return &core.ExtraTransfer{To: *to, Value: hexutil.Big(*big.NewInt(value))}
+	}
+	lock := hexutil.Uint64(100)
+
+	tests := []struct {
+		legs    []*core.ExtraTransfer
+		approve bool
+	}{
+		{[]*core.ExtraTransfer{leg("0000000000000000000000000000000000001339", 100), leg("000000000000000000000000000000000000133a", 200)}, true},
+		// Policies see every recipient, not only the first one
+		{[]*core.ExtraTransfer{leg("0000000000000000000000000000000000001339", 100), leg("000000000000000000000000000000000000dead", 200)}, false},
+		// And the cost of all of them
+		{[]*core.ExtraTransfer{leg("0000000000000000000000000000000000001339", 100), leg("000000000000000000000000000000000000133a", 2000)}, false},
+	}
+	for i, test := range tests {
+		args := core.SendTxArgs{From: *from, To: to, ExtraTo: test.legs, LockHeight: &lock}
+		resp, err := r.ApproveTx(&core.SignTxRequest{
+			Transaction: args,
+			Cost:        (*hexutil.Big)(args.Cost()),
+			Meta:        core.Metadata{Remote: "remoteip", Local: "localip", Scheme: "inproc"},
+		})
+		if err != nil {
+			t.Errorf("test %d: unexpected error %v", i, err)
+		}
+		if resp.Approved != test.approve {
+			t.Errorf("test %d: approval mismatch: have %v, want %v", i, resp.Approved, test.approve)
+		}
+	}
+}
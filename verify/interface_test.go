@@ -165,9 +165,14 @@ func GenerateMainNodeListfoForTest(currentNumber uint64, lastBlkMList election.N
 	}
 
 	// 将上个广播区块中的主节点列表和本周期内出现的新参选退选合并，其中上个广播区块中退选列表不需要合并
-	mergeNodeInfo(newNodeMap, minerList, types.ElectMiner)
-	mergeNodeInfo(newNodeMap, committeeList, types.ElectCommittee)
-	mergeNodeInfo(newNodeMap, bothList, types.ElectBoth)
+	for electType, list := range map[uint32][]election.NodeInfo{types.ElectMiner: minerList, types.ElectCommittee: committeeList, types.ElectBoth: bothList} {
+		for _, info := range list {
+			if _, ok := newNodeMap[info.ID]; ok {
+				continue
+			}
+			newNodeMap[info.ID] = &types.ElectionTxPayLoadInfo{TPS: info.TPS, IP: info.IP, ID: info.ID, Wealth: info.Wealth, OnlineTime: info.OnlineTime, TxHash: info.TxHash, Value: info.Value, ElectType: electType, Account: info.Account}
+		}
+	}
 
 	// 由合并后的map生产本周期的主节点列表
 	for _, value := range newNodeMap {
@@ -17,12 +17,12 @@
 package verifier
 
 import (
+	"crypto/ecdsa"
 	"encoding/json"
-	"sort"
-	"strconv"
 	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/election"
@@ -59,7 +59,7 @@ type Verifier struct {
 	invalidTxChan  chan []bool              // channel of leader recv invalid tx list
 	voteResultChan chan VoteResult
 
-	followerVoteReqCh chan []uint16
+	followerVoteReqCh chan voteRequest
 	followerTxRecvCh  chan []types.Transaction // channel of follower recv tx
 	followerMsgCh     chan int                 // channel of follower recv msg
 
@@ -74,6 +74,9 @@ type Verifier struct {
 	invalidTxsList         []bool
 	leaderRemoveTxList     []uint16
 	followerInvalidTxsList []uint16
+	followerTxs            []types.Transaction // transactions of the session sent by the leader
+
+	nodeKey *ecdsa.PrivateKey // node key the votes on transaction batches are signed with
 }
 
 func New(chain *core.BlockChain, pool *core.TxPool) *Verifier {
@@ -90,7 +93,7 @@ func New(chain *core.BlockChain, pool *core.TxPool) *Verifier {
 		invalidTxChan:       make(chan []bool),
 		voteResultChan:      make(chan VoteResult),
 		followerTxRecvCh:    make(chan []types.Transaction),
-		followerVoteReqCh:   make(chan []uint16),
+		followerVoteReqCh:   make(chan voteRequest),
 		followerMsgCh:       make(chan int),
 		nodeState:           nodeIdle,
 		sessionPM:           sessionType{sessionState: sessionIdle},
@@ -105,6 +108,58 @@ func New(chain *core.BlockChain, pool *core.TxPool) *Verifier {
 	return verifier
 }
 
+// SetNodeKey sets the node key the votes on the transaction batches are signed
+// with, certifying the batches to the chain.
+func (v *Verifier) SetNodeKey(key *ecdsa.PrivateKey) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.nodeKey = key
+}
+
+// signVote signs a vote on the batch with the given root approved for the block
+// at number. Without a node key the vote is left unsigned and will not certify
+// the batch.
+func (v *Verifier) signVote(vote *VoteResult, number uint64, root common.Hash) {
+	v.lock.RLock()
+	key := v.nodeKey
+	v.lock.RUnlock()
+
+	if key == nil {
+		log.Warn(modulName, "no node key to sign vote", number)
+		return
+	}
+	if err := vote.Sign(number, root, key); err != nil {
+		log.Warn(modulName, "failed to sign vote", err)
+	}
+}
+
+// batchOf returns the transactions of a session left after removing the ones
+// at the given indices, the batch the committee votes on.
+func batchOf(txs []types.Transaction, removed []uint16) []types.Transaction {
+	drop := make(map[uint16]bool, len(removed))
+	for _, index := range removed {
+		drop[index] = true
+	}
+	batch := make([]types.Transaction, 0, len(txs))
+	for i := range txs {
+		if !drop[uint16(i)] {
+			batch = append(batch, txs[i])
+		}
+	}
+	return batch
+}
+
+// batchRoot returns the root of a batch, the transaction root of the block
+// packing it.
+func batchRoot(txs []types.Transaction) common.Hash {
+	list := make(types.Transactions, len(txs))
+	for i := range txs {
+		list[i] = &txs[i]
+	}
+	return types.DeriveSha(list)
+}
+
 func (v *Verifier) waitForScheduler() {
 	for {
 		select {
@@ -114,7 +169,7 @@ func (v *Verifier) waitForScheduler() {
 				log.Info(modulName, "ENTER VERIFIER", blockNum)
 
 				//Figure out whether the current validator is Leader or Follower, based on nodelist and nodeid
-				v.electionLeader(v.verifierList, blockNum)
+				v.electionLeader(blockNum)
 				log.Info(modulName, "verifierRole", v.role)
 				switch v.role {
 				case leader:
@@ -122,6 +177,7 @@ func (v *Verifier) waitForScheduler() {
 					log.Info(modulName, "leader", "")
 					if v.sessionPM.sessionState == sessionIdle {
 						v.sessionPM.reset()
+						v.sessionPM.number = blockNum + 1
 						v.sessionPM.sessionState = sessionTxmsg1
 						v.leaderWorkCh <- TIMEUNKNOW
 
@@ -142,19 +198,14 @@ func (v *Verifier) waitForScheduler() {
 	}
 }
 
-var txs []types.Transaction
-var validTx []types.Transaction = make([]types.Transaction, 0)
-var vrlist []VoteResult
-
 func (v *Verifier) sendMsg1() {
 
 	//clear vote list
-	vrlist = make([]VoteResult, 0)
+	v.sessionPM.votes = make([]VoteResult, 0)
 
 	//package leader's transactions
-	validTx = make([]types.Transaction, 0)
-	txs = core.PackageTxInPool(v.txPool)
-	validTx = txs
+	v.sessionPM.txs = core.PackageTxInPool(v.txPool)
+	v.sessionPM.batch = v.sessionPM.txs
 	//send transaction request to follower
 	v.sendTxRequestToFollowers()
 	v.sessionPM.updatestate(sessionRxmsg2)
@@ -164,17 +215,19 @@ func (v *Verifier) sendMsg3() {
 
 	log.Info(modulName, "Leader Session, Rx Msg2", len(v.sessionPM.rxMsg2List), "node", v.sessionPM.rxMsg2List)
 	//tx msg3, rcv msg4
-	txs = core.PackageTxInPool(v.txPool)
-	v.sendTxToFollower(txs)
+	v.sessionPM.txs = core.PackageTxInPool(v.txPool)
+	v.sendTxToFollower(v.sessionPM.txs)
 	v.sessionPM.updatestate(sessionRxmsg4)
-	log.Info(modulName, "Leader Session , Tx Msg3", len(txs))
+	log.Info(modulName, "Leader Session , Tx Msg3", len(v.sessionPM.txs))
 }
 
 func (v *Verifier) sendMsg5() {
 	log.Info(modulName, "Leader Session, Rx Msg4", len(v.sessionPM.rxMsg4List), "node", v.sessionPM.rxMsg4List)
-	log.Info(modulName, "Leader Session, Rx Msg4,  valid trans num", len(validTx))
-	log.Info(modulName, "Leader Session, Rx Msg4,  valid trans ", validTx)
-	//TODO:leader voteToTx()
+	log.Info(modulName, "Leader Session, Rx Msg4,  valid trans num", len(v.sessionPM.batch))
+	log.Info(modulName, "Leader Session, Rx Msg4,  valid trans ", v.sessionPM.batch)
+	// The batch voted on is the session transactions without the invalid ones
+	v.sessionPM.batch = batchOf(v.sessionPM.txs, v.leaderRemoveTxList)
+	v.sessionPM.root = batchRoot(v.sessionPM.batch)
 	//tx msg5, rcv msg6
 	v.sendVoteRequestToFollower()
 	v.sessionPM.updatestate(sessionRxmsg6)
@@ -185,34 +238,47 @@ func (v *Verifier) leaderVoteToTx(leaderInvalidTxList []uint16) {
 	var vr VoteResult
 	vr.NodeId = v.localNodeInfo.ID
 	vr.Result = true
-	vrlist = append(vrlist, vr)
-	log.Info(modulName, "leader session Vote to Transaction result", vrlist)
+	v.sessionPM.votes = append(v.sessionPM.votes, vr)
+	log.Info(modulName, "leader session Vote to Transaction result", v.sessionPM.votes)
 }
 
 func (v *Verifier) makeDPOS() {
 
 	log.Info(modulName, "Leader Session, Rx Msg6", len(v.sessionPM.rxMsg6List), "node", v.sessionPM.rxMsg6List)
-	voteLen := len(vrlist)
+	voteLen := len(v.sessionPM.votes)
 	log.Info(modulName, "Leader Session, Rx Msg6, vote num", voteLen)
-	log.Info(modulName, "Leader Session, Rx Msg6, vrlist", vrlist)
+	log.Info(modulName, "Leader Session, Rx Msg6, vrlist", v.sessionPM.votes)
+
+	if ret := v.DposTx(v.sessionPM.votes); ret {
+		v.recordTxStage(v.sessionPM.batch, core.TxStageVotePassed, "")
 
-	if ret := v.DposTx(vrlist); ret {
 		var msg ConsesusResult
-		msg.Txs = validTx
-		log.Info(modulName, "Leader Session, vot,  valid trans ", validTx)
-		msg.Result = vrlist
+		msg.Txs = v.sessionPM.batch
+		log.Info(modulName, "Leader Session, vot,  valid trans ", v.sessionPM.batch)
+		msg.Result = v.sessionPM.votes
 		data, err := json.MarshalIndent(msg, "", "   ")
 		if err != nil {
 			log.Info(modulName, "to miner", err)
 		}
-		v.sendMsgToMiner(data, Transaction, blockNum)
-		log.Info(modulName, "leader Session", "", "Tx Miner trans Num", len(vrlist))
+		v.sendMsgToMiner(data, Transaction, v.sessionPM.number)
+		v.recordTxStage(v.sessionPM.batch, core.TxStageHandedOff, "")
+		log.Info(modulName, "leader Session", "", "Tx Miner trans Num", len(v.sessionPM.votes))
 	} else {
 		log.Info(modulName, "Leader Session,  vote fail")
+		v.recordTxStage(v.sessionPM.batch, core.TxStageVoteFailed, "committee vote failed")
 	}
 	v.sessionPM.updatestate(sessionIdle)
 }
 
+// recordTxStage records the stage the transactions of the session reached in
+// the lifecycle tracker of the transaction pool.
+func (v *Verifier) recordTxStage(txs []types.Transaction, stage core.TxStage, reason string) {
+	tracker := v.txPool.Tracker()
+	for i := range txs {
+		tracker.Record(txs[i].Hash(), core.TxStageRecord{Stage: stage, Reason: reason})
+	}
+}
+
 func (v *Verifier) leaderNewRun() {
 	switch v.sessionPM.sessionState {
 	case sessionIdle:
@@ -227,8 +293,9 @@ func (v *Verifier) leaderNewRun() {
 	case sessionRxmsg6:
 
 	case sessionDpos:
-		leaderVote := VoteResult{true, v.localNodeInfo.ID}
-		vrlist = append(vrlist, leaderVote)
+		leaderVote := VoteResult{Result: true, NodeId: v.localNodeInfo.ID}
+		v.signVote(&leaderVote, v.sessionPM.number, batchRoot(v.sessionPM.batch))
+		v.sessionPM.votes = append(v.sessionPM.votes, leaderVote)
 		v.makeDPOS()
 		v.sessionPM.updatestate(sessionIdle)
 	}
@@ -296,6 +363,7 @@ func (v *Verifier) followerProcess() {
 			go v.sendTxToLeader(txs)
 
 		case leaderTx := <-v.followerTxRecvCh:
+			v.followerTxs = leaderTx
 			//validate the transactions
 			invalidTx := make([]uint16, 0)
 			for k, tx := range leaderTx {
@@ -308,10 +376,10 @@ func (v *Verifier) followerProcess() {
 			log.Info(modulName, "Follower Rcv msg3", "", "ack msg4", "")
 			go v.sendInvalidTxToLeader(invalidTx)
 
-		case remTxList := <-v.followerVoteReqCh:
+		case req := <-v.followerVoteReqCh:
 			//go v.voteToLeader(remTxList)
-			log.Info(modulName, "Follower Rcv msg5", remTxList, "ack msg6", "")
-			go v.voteToTx(remTxList)
+			log.Info(modulName, "Follower Rcv msg5", req.Removed, "ack msg6", "")
+			go v.voteToTx(req, v.followerTxs)
 
 		case <-v.processStopCh:
 			return
@@ -345,6 +413,11 @@ const (
 
 type sessionType struct {
 	sessionState uint8
+	number       uint64              // height of the block the transactions are verified for
+	root         common.Hash         // root of the batch the committee votes on
+	txs          []types.Transaction // transactions sent to the followers
+	batch        []types.Transaction // transactions voted on, without the invalid ones
+	votes        []VoteResult        // votes collected on the batch
 	rxMsgCount   int
 	rxMsg2List   []string
 	rxMsg4List   []string
@@ -353,6 +426,7 @@ type sessionType struct {
 
 func (s *sessionType) reset() {
 	s.sessionState = sessionIdle
+	s.txs, s.batch, s.votes = nil, nil, nil
 	s.rxMsg2List = make([]string, 0)
 	s.rxMsg4List = make([]string, 0)
 	s.rxMsg6List = make([]string, 0)
@@ -443,8 +517,12 @@ func (v *Verifier) leaderProcessMsg(temp interface{}, data p2p.Custsend) {
 				if !v.msgFromVeifierNodeId(vr.NodeId) {
 					log.Info(modulName, "vote nodeid invalid", vr)
 				}
-				vrlist = append(vrlist, vr)
-				log.Info(modulName, "Leader session  rx msg6", vrlist)
+				if err := vr.Validate(v.sessionPM.number, v.sessionPM.root); err != nil {
+					log.Info(modulName, "vote signature invalid", vr.NodeId, "err", err)
+				} else {
+					v.sessionPM.votes = append(v.sessionPM.votes, vr)
+				}
+				log.Info(modulName, "Leader session  rx msg6", v.sessionPM.votes)
 			}
 		} else {
 			log.Info(modulName, "Leader session, rx msg6 ,timeout", "node ip", data.FromIp)
@@ -482,7 +560,7 @@ func (v *Verifier) followerProcessMsg(temp interface{}, data p2p.Custsend) {
 		log.Info(modulName, "Followers Rcv Msg3 Trans", len(txs))
 		v.followerTxRecvCh <- txs
 	case msgSendVoteReqToFollower:
-		var msg []uint16
+		var msg voteRequest
 		if err := json.Unmarshal(data.Data.Data_struct, &msg); err != nil {
 			log.Info("Deserializing height information fails")
 		}
@@ -493,50 +571,24 @@ func (v *Verifier) followerProcessMsg(temp interface{}, data p2p.Custsend) {
 	}
 }
 
-type VoteResult struct {
-	//State
-	Result bool
-	NodeId string
-}
-type VoteList []VoteResult
-
-var blockNum uint64
+// VoteResult is the vote of a committee member on the batch of a session,
+// signed by the node key so the miners can certify the batch to the chain.
+type VoteResult = types.BatchVote
 
-func (v *Verifier) isFromVerifyList(nodeID string) (election.NodeInfo, bool) {
-	for _, node := range v.verifierList {
-		if node.ID == nodeID {
-			return node, true
-		}
-	}
-	return election.NodeInfo{}, false
+// voteRequest asks the followers to vote on the batch of a session, the
+// transactions sent to them without the ones the leader removed.
+type voteRequest struct {
+	Number  uint64   `json:"number"`  // height of the block the batch is voted for
+	Removed []uint16 `json:"removed"` // indices of the invalid transactions
 }
+type VoteList []VoteResult
 
+// DposTx reports whether the votes approve the batch by the quorum of the
+// verifier committee, the same rule the chain checks the votes by.
 func (v *Verifier) DposTx(results []VoteResult) bool {
-	validNum := 0
-	passNum := 0
-	wealthValid := uint64(0)
-	wealthTotal := uint64(0)
-	for _, res := range results {
-		if node, isIn := v.isFromVerifyList(res.NodeId); isIn {
-			validNum++
-			if res.Result {
-				passNum++
-				wealthValid += node.Wealth
-			}
-			wealthTotal += node.Wealth
-		}
-	}
-	log.Info(modulName, "leader session DposTx", "", "passNum", passNum, "validNum", validNum, "blocknum", blockNum)
-	//must over half
-	if passNum*2 <= validNum {
-		return false
-	}
-	log.Info(modulName, "leader session DposTx", "", "wealthValid", wealthValid, "wealthTotal", wealthTotal, "blocknum", blockNum)
-	//wealthValid must over 75% of wealthTotal
-	if wealthValid*4 <= 3*wealthTotal {
-		return false
-	}
-	return true
+	passed := core.BatchQuorum(v.verifierList, results)
+	log.Info(modulName, "leader session DposTx", "", "votes", len(results), "passed", passed)
+	return passed
 }
 
 type ConsesusResult struct {
@@ -661,7 +713,8 @@ func (v *Verifier) sendVoteRequestToFollower() {
 			continue
 		}
 		log.Info(modulName, "Tx verifier Node Success", v.verifierList[i].ID, "IP", v.verifierList[i].IP, "val", v.leaderRemoveTxList)
-		go sendMsg(verifierToVerifier, msgSendVoteReqToFollower, v.leaderRemoveTxList, v.verifierList[i].IP, false)
+		req := voteRequest{Number: v.sessionPM.number, Removed: v.leaderRemoveTxList}
+		go sendMsg(verifierToVerifier, msgSendVoteReqToFollower, req, v.verifierList[i].IP, false)
 
 	}
 	return
@@ -683,7 +736,8 @@ func (v *Verifier) sendVoteResultToLeader(vrList VoteResult) {
 	return
 }
 
-func (v *Verifier) voteToTx(leaderInvalidTxList []uint16) {
+func (v *Verifier) voteToTx(req voteRequest, txs []types.Transaction) {
+	leaderInvalidTxList := req.Removed
 
 	var srchCnt = 0
 
@@ -706,6 +760,7 @@ func (v *Verifier) voteToTx(leaderInvalidTxList []uint16) {
 		vr.Result = true
 	}
 	vr.NodeId = v.localNodeInfo.ID
+	v.signVote(&vr, req.Number, batchRoot(batchOf(txs, leaderInvalidTxList)))
 	log.Info(modulName, "Vote to Transaction result", vr)
 	v.sendVoteResultToLeader(vr)
 }
@@ -724,36 +779,18 @@ func readySendNodelist(v *Verifier, blockNum uint64) {
 	return
 }
 
-type verifierList []election.NodeInfo
-
-func (v verifierList) Len() int {
-	return len(v)
-}
-
-func (v verifierList) Swap(i, j int) {
-	v[i], v[j] = v[j], v[i]
-}
-
-func (v verifierList) Less(i, j int) bool {
-	if v[i].Wealth < v[j].Wealth {
-
-		return v[i].Wealth < v[j].Wealth
-	} else if v[i].Wealth == v[j].Wealth {
-		id1, _ := strconv.Atoi(v[i].ID)
-		id2, _ := strconv.Atoi(v[j].ID)
-		return id1 < id2
-	} else {
-		return v[i].Wealth < v[j].Wealth
+// electionLeader takes the role of the local node in the session verifying the
+// transactions of the block following blockHeight, following the leader
+// schedule of the chain.
+func (verifier *Verifier) electionLeader(blockHeight uint64) {
+	leader, err := verifier.chain.Leader(blockHeight + 1)
+	if err != nil {
+		log.Info(modulName, "No leader", blockHeight+1, "err", err)
+		verifier.leadNodeInfo = election.NodeInfo{}
+		verifier.role = follower
+		return
 	}
-}
-
-func (verifier *Verifier) electionLeader(nodeList []election.NodeInfo, blockHeight uint64) {
-
-	var sortNodeList = verifierList(nodeList)
-	sort.Sort(sortNodeList)
-	verifierNumber := uint64(len(nodeList))
-
-	verifier.leadNodeInfo = sortNodeList[blockHeight%verifierNumber]
+	verifier.leadNodeInfo = leader
 
 	if verifier.isVerifierLeaderByNodeId(verifier.localNodeInfo) {
 		verifier.role = leader
@@ -232,20 +232,20 @@ func TestDposTx(t *testing.T) {
 	var voteResult []VoteResult
 	voteResult = make([]VoteResult, 4)
 	for i := 0; i < 2; i++ {
-		voteResult[i] = VoteResult{result: true, nodeId: string(i)}
+		voteResult[i] = VoteResult{Result: true, NodeId: string(i)}
 	}
 	for i := 2; i < 4; i++ {
-		voteResult[i] = VoteResult{result: false, nodeId: string(i)}
+		voteResult[i] = VoteResult{Result: false, NodeId: string(i)}
 	}
 
 	if result := v.DposTx(voteResult); result {
 		t.Errorf("Expect false as this case only 2 of 4 passed")
 	}
-	voteResult[2].result = true
+	voteResult[2].Result = true
 	if result := v.DposTx(voteResult); result {
 		t.Error("Expect false as although 3 of 4 passed, but the value of valid node is only 75%")
 	}
-	voteResult[3].result = true
+	voteResult[3].Result = true
 	if result := v.DposTx(voteResult); !result {
 		t.Error("Expect true, as all node are valid and all results are true")
 	}